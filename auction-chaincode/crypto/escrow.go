@@ -0,0 +1,34 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dbogatov/dac-lib/dac"
+)
+
+// VerifyIdentityEscrow checks the proof that escrow is an encryption, for the auditor public key, of the public
+// key of the DAC credential behind the nym pkNym
+func VerifyIdentityEscrow(hBytes, auditorPkBytes, pkNymBytes, escrow, proof []byte) (err error) {
+	// the dac library panics on malformed encodings
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed identity escrow: %v", r)
+		}
+	}()
+
+	h, err := dac.PointFromBytes(hBytes)
+	if err != nil || h == nil {
+		return errors.New("invalid DAC parameter h")
+	}
+	auditorPk, err := dac.PointFromBytes(auditorPkBytes)
+	if err != nil || auditorPk == nil {
+		return errors.New("invalid auditor public key")
+	}
+	pkNym, err := dac.PointFromBytes(pkNymBytes)
+	if err != nil || pkNym == nil {
+		return errors.New("invalid nym")
+	}
+	encryption := dac.AuditingEncryptionFromBytes(escrow)
+	auditingProof := dac.AuditingProofFromBytes(proof)
+	return auditingProof.Verify(*encryption, pkNym, auditorPk, h)
+}
@@ -3,7 +3,16 @@ module github.com/hyperledger/fabric-samples/auction/chaincode-go
 go 1.15
 
 require (
-	github.com/consensys/gnark-crypto v0.4.0
+	github.com/ckiere/test-network/auction-circuit v0.0.0
+	github.com/consensys/gnark v0.4.0
+	github.com/consensys/gnark-crypto v0.4.1-0.20210428083642-6bd055b79906
+	github.com/dbogatov/dac-lib v1.0.0
+	github.com/dbogatov/fabric-amcl v0.0.0-20190731091901-c69f438d7884
+	github.com/golang/protobuf v1.5.2
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20200728190242-9b3ae92d8664
 	github.com/hyperledger/fabric-contract-api-go v1.1.0
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200424173316-dd554ba3746e
+	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
 )
+
+replace github.com/ckiere/test-network/auction-circuit => ../auction-circuit
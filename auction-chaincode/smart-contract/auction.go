@@ -8,8 +8,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	pedersen "github.com/ckiere/test-network/auction-circuit/commitment"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
-	"github.com/hyperledger/fabric-samples/auction/chaincode-go/crypto"
 )
 
 type SmartContract struct {
@@ -18,18 +18,60 @@ type SmartContract struct {
 
 const SellerPkSize = 32
 
+// Auction types, auctions created before the type was introduced are sealed-bid auctions
+const (
+	SealedBidAuction = "sealed"
+	DutchAuction     = "dutch"
+	EnglishAuction   = "english"
+)
+
+// AuctionVersion is the schema version of the auctions of this chaincode, version 1 is the schema of the
+// private data auction
+const AuctionVersion = 2
+
 // Auction data
 type Auction struct {
 	Type         string                    `json:"objectType"`
+	AuctionType  string                    `json:"auctionType"`
 	ItemSold     string                    `json:"item"`
 	Seller       string                    `json:"seller"`
 	SellerPk	 [SellerPkSize]byte        `json:"sellerPk"`
 	Commitments  map[string] []byte        `json:"commitments"`
+	CommitmentOrder []string               `json:"commitmentOrder"`
 	EncryptedBids map[string] EncryptedBid `json:"encryptedBids"`
 	InvalidSet   string                    `json:"invalidSet"`
 	WinningBid   string                    `json:"winningBid"`
 	Proof        []byte                    `json:"proof"`
 	Status       string                    `json:"status"`
+	// open-outcry auctions
+	StartTime    int64                     `json:"startTime,omitempty"`
+	StartPrice   int                       `json:"startPrice,omitempty"`
+	ReservePrice int                       `json:"reservePrice,omitempty"`
+	Decrement    int                       `json:"decrement,omitempty"`
+	MinIncrement int                       `json:"minIncrement,omitempty"`
+	Price        int                       `json:"price,omitempty"`
+	// one bid per credential, the scope tags are verified with the DAC configuration of the chaincode
+	OneBidPerCredential bool               `json:"oneBidPerCredential,omitempty"`
+	// identity escrow, every bid contains the bidder public key encrypted for the auditor of the DAC configuration
+	IdentityEscrow bool                    `json:"identityEscrow,omitempty"`
+	// deposits, Deposits maps the ID of a commitment to the hash of its deposit voucher
+	Deposit      int                       `json:"deposit,omitempty"`
+	PaymentWindow int64                    `json:"paymentWindow,omitempty"`
+	PaymentDeadline int64                  `json:"paymentDeadline,omitempty"`
+	// time the sealed-bid auction was ended, the owners of the deposits can reclaim them if the winner is not
+	// declared within the payment window after it
+	EndedAt      int64                     `json:"endedAt,omitempty"`
+	Deposits     map[string]string         `json:"deposits,omitempty"`
+	// proof of the winner, DeclareWinner verifies the proof against the key of the registry pinned by the
+	// auction, VerifyingKey holds the key of auctions created before the registry, some of which have no key
+	ProofSystem  string                    `json:"proofSystem,omitempty"`
+	MaxBids      int                       `json:"maxBids,omitempty"`
+	VerifyingKeyID string                  `json:"verifyingKeyID,omitempty"`
+	VerifyingKey []byte                    `json:"verifyingKey,omitempty"`
+	// schema version, auctions without a version were created before it was introduced and have the same
+	// schema. MigratedFrom is the chaincode of the private data auction the auction was migrated from
+	Version      int                       `json:"version,omitempty"`
+	MigratedFrom string                    `json:"migratedFrom,omitempty"`
 }
 
 // EncryptedBid contains the values needed to open a commitment to a bid, encrypted with the public key of the seller
@@ -39,9 +81,15 @@ type EncryptedBid struct {
 	Bidder   string `json:"bidder"`
 }
 
+// isSealedBid returns true if the auction uses sealed commitments to bids
+func (a *Auction) isSealedBid() bool {
+	return a.AuctionType == "" || a.AuctionType == SealedBidAuction
+}
+
 // CreateAuction creates on auction on the public channel. The identity that
-// submits the transaction becomes the seller of the auction
-func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID, itemsold, sellerPk string) error {
+// submits the transaction becomes the seller of the auction. The proof of the winner is verified
+// against the key keyID of the registry
+func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID, itemsold, sellerPk, keyID string) error {
 
 	// get ID of submitting client
 	clientID, err := ctx.GetClientIdentity().GetID()
@@ -69,13 +117,20 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 
 	auction := Auction{
 		Type:         "auction",
+		AuctionType:  SealedBidAuction,
 		ItemSold:     itemsold,
 		Seller:       clientID,
 		SellerPk:     sellerPkBytes,
 		Commitments:  coms,
+		CommitmentOrder: []string{},
 		EncryptedBids: revealedBids,
 		WinningBid:   "",
 		Status:       "open",
+		Version:      AuctionVersion,
+	}
+	err = pinVerifyingKey(ctx, &auction, keyID)
+	if err != nil {
+		return err
 	}
 
 	auctionBytes, err := json.Marshal(auction)
@@ -109,7 +164,7 @@ func (s *SmartContract) SendCommitment(ctx contractapi.TransactionContextInterfa
 	if err != nil {
 		return "", err
 	}
-	if !crypto.CheckCommitProofBytes(proofBytes, comBytes, nil) {
+	if !pedersen.CheckCommitProofBytes(proofBytes, comBytes, nil) {
 		return "", fmt.Errorf("invalid proof")
 	}
 	// get the auction from state
@@ -124,6 +179,14 @@ func (s *SmartContract) SendCommitment(ctx contractapi.TransactionContextInterfa
 		return "", fmt.Errorf("failed to create auction object JSON")
 	}
 
+	if !auctionJSON.isSealedBid() {
+		return "", fmt.Errorf("operation is only supported by sealed-bid auctions")
+	}
+
+	if auctionJSON.OneBidPerCredential {
+		return "", fmt.Errorf("auction allows one bid per credential, use SendUniqueCommitment")
+	}
+
 	// the auction needs to be open for users to add their bid
 	Status := auctionJSON.Status
 	if Status != "open" {
@@ -132,13 +195,29 @@ func (s *SmartContract) SendCommitment(ctx contractapi.TransactionContextInterfa
 
 	// use the transaction ID as a key for the commitment
 	txID := ctx.GetStub().GetTxID()
-	auctionJSON.Commitments[txID] = comBytes
+	err = addCommitment(&auctionJSON, txID, comBytes)
+	if err != nil {
+		return "", err
+	}
+
+	err = storeIdentityEscrow(ctx, auctionID, &auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = bindDeposit(ctx, auctionID, &auctionJSON)
+	if err != nil {
+		return "", err
+	}
 
 	newAuctionBytes, _ := json.Marshal(auctionJSON)
 	err = ctx.GetStub().PutState(auctionID, newAuctionBytes)
 	if err != nil {
 		return "", fmt.Errorf("failed to update auction")
 	}
+	err = setAuctionEvent(ctx, "CommitmentSent", AuctionEvent{AuctionID: auctionID, BidID: txID})
+	if err != nil {
+		return "", err
+	}
 	return txID, nil
 }
 
@@ -164,6 +243,10 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("failed to create auction object JSON: %v", err)
 	}
 
+	if !auctionJSON.isSealedBid() {
+		return fmt.Errorf("operation is only supported by sealed-bid auctions")
+	}
+
 	// check that the auction is closed
 	Status := auctionJSON.Status
 	if Status != "closed" {
@@ -181,7 +264,7 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 	if err != nil {
 		return err
 	}
-	if !crypto.CheckCommitProofBytes(proofBytes, comBytes, dataBytes) {
+	if !pedersen.CheckCommitProofBytes(proofBytes, comBytes, dataBytes) {
 		return fmt.Errorf("invalid proof")
 	}
 	// add the new revealed bid to the list
@@ -203,7 +286,7 @@ func (s *SmartContract) RevealBid(ctx contractapi.TransactionContextInterface, a
 		return fmt.Errorf("failed to update auction: %v", err)
 	}
 
-	return nil
+	return setAuctionEvent(ctx, "BidRevealed", AuctionEvent{AuctionID: auctionID, BidID: txID})
 }
 
 // CloseAuction can be used by the seller to close the auction. This prevents
@@ -238,6 +321,10 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("auction can only be closed by seller: %v", err)
 	}
 
+	if !auctionJSON.isSealedBid() {
+		return fmt.Errorf("operation is only supported by sealed-bid auctions")
+	}
+
 	Status := auctionJSON.Status
 	if Status != "open" {
 		return fmt.Errorf("cannot close auction that is not open")
@@ -252,7 +339,7 @@ func (s *SmartContract) CloseAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("failed to close auction: %v", err)
 	}
 
-	return nil
+	return setAuctionEvent(ctx, "AuctionClosed", AuctionEvent{AuctionID: auctionID})
 }
 
 // EndAuction changes the status to ended
@@ -286,6 +373,11 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("auction can only be ended by seller: %v", err)
 	}
 
+	// open-outcry auctions have no reveal phase
+	if !auctionJSON.isSealedBid() {
+		return endOpenAuction(ctx, auctionID, &auctionJSON)
+	}
+
 	Status := auctionJSON.Status
 	if Status != "closed" {
 		return fmt.Errorf("Can only end a closed auction")
@@ -296,6 +388,10 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("No bids have been revealed, cannot end auction: %v", err)
 	}
 	auctionJSON.Status = "ended"
+	auctionJSON.EndedAt, err = getTxTime(ctx)
+	if err != nil {
+		return err
+	}
 
 	endedAuction, _ := json.Marshal(auctionJSON)
 
@@ -303,10 +399,10 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 	if err != nil {
 		return fmt.Errorf("failed to end auction: %v", err)
 	}
-	return nil
+	return setAuctionEvent(ctx, "AuctionEnded", AuctionEvent{AuctionID: auctionID})
 }
 
-// DeclareWinner sets the winner of an auction
+// DeclareWinner sets the winner of an auction, the winner can only be declared once
 func (s *SmartContract) DeclareWinner(ctx contractapi.TransactionContextInterface, auctionID, winningBidId, proof, invalidSet string) error {
 	auctionBytes, err := ctx.GetStub().GetState(auctionID)
 	if err != nil {
@@ -336,23 +432,47 @@ func (s *SmartContract) DeclareWinner(ctx contractapi.TransactionContextInterfac
 		return fmt.Errorf("auction can only be ended by seller: %v", err)
 	}
 
+	if !auctionJSON.isSealedBid() {
+		return fmt.Errorf("operation is only supported by sealed-bid auctions")
+	}
+
 	Status := auctionJSON.Status
 	if Status != "ended" {
 		return fmt.Errorf("can only declare the winner of an ended auction")
 	}
+	// the invalid set of a declared auction is never empty, it is at least the empty JSON object
+	if auctionJSON.WinningBid != "" || auctionJSON.InvalidSet != "" {
+		return fmt.Errorf("the winner of the auction has already been declared")
+	}
+	if invalidSet == "" {
+		invalidSet = "{}"
+	}
 	// Set the winner
 	proofBytes, err := base64.StdEncoding.DecodeString(proof)
 	if err != nil {
 		return fmt.Errorf("invalid proof format")
 	}
+	// auctions created before the registry may have no key, their winner cannot be proven
+	if auctionJSON.VerifyingKeyID == "" && auctionJSON.VerifyingKey == nil {
+		return fmt.Errorf("the auction has no verifying key, its winner cannot be declared")
+	}
+	err = verifyWinner(ctx, &auctionJSON, winningBidId, proofBytes, invalidSet)
+	if err != nil {
+		return err
+	}
 	auctionJSON.Proof = proofBytes
 	auctionJSON.WinningBid = winningBidId
 	auctionJSON.InvalidSet = invalidSet
+	// release or forfeit the deposits of the bidders
+	err = settleDeposits(ctx, auctionID, &auctionJSON)
+	if err != nil {
+		return err
+	}
 	// Save auction
 	endedAuction, _ := json.Marshal(auctionJSON)
 	err = ctx.GetStub().PutState(auctionID, endedAuction)
 	if err != nil {
 		return fmt.Errorf("failed to set auction winner: %v", err)
 	}
-	return nil
+	return setAuctionEvent(ctx, "WinnerDeclared", AuctionEvent{AuctionID: auctionID, BidID: winningBidId})
 }
\ No newline at end of file
@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+func TestDeclareWinnerOnce(t *testing.T) {
+	l := depositAuction(t, "auction1", "alice", "bob")
+	l.lockDeposit("auction1", "alice", []byte("alice"))
+	l.lockDeposit("auction1", "bob", []byte("bob"))
+	aliceBid, err := l.sendBid("auction1", "alice", 50, []byte("alice"))
+	l.must(err)
+	bobBid, err := l.sendBid("auction1", "bob", 70, []byte("bob"))
+	l.must(err)
+	l.endAuction("auction1", aliceBid, bobBid)
+
+	if err := l.contract.DeclareWinner(l.as("alice", "Org1MSP"), "auction1", aliceBid.id, "", ""); err == nil {
+		t.Error("the winner was declared by another client than the seller")
+	}
+	l.must(l.declareWinner("auction1", bobBid.id))
+	declared, err := getAuction(l.as("reader", "Org2MSP"), "auction1")
+	l.must(err)
+	if declared.WinningBid != bobBid.id || declared.InvalidSet != "{}" {
+		t.Errorf("declared winner %v with invalid set %q", declared.WinningBid, declared.InvalidSet)
+	}
+
+	// a second declaration would settle the deposits again and move the payment deadline
+	if err := l.declareWinner("auction1", bobBid.id); err == nil {
+		t.Error("the winner was declared twice")
+	}
+	auction, err := getAuction(l.as("reader", "Org2MSP"), "auction1")
+	l.must(err)
+	if auction.WinningBid != bobBid.id || auction.PaymentDeadline != declared.PaymentDeadline {
+		t.Error("the second declaration changed the auction")
+	}
+	l.checkVoucher("auction1", []byte("alice"), DepositRefunded)
+	l.checkVoucher("auction1", []byte("bob"), DepositAwaitingPayment)
+	if l.balance("alice") != 100 || l.balance("bob") != 100-testDeposit {
+		t.Errorf("balances of alice and bob %v and %v", l.balance("alice"), l.balance("bob"))
+	}
+}
+
+func TestVerifyingKeyRequired(t *testing.T) {
+	l := newTestLedger(t)
+	sellerPk := base64.StdEncoding.EncodeToString(l.sellerPk[:])
+	if err := l.contract.CreateAuction(l.as("seller", "Org1MSP"), "auction1", "item", sellerPk, ""); err == nil {
+		t.Error("an auction without a verifying key was created")
+	}
+	if err := l.contract.CreateAuction(l.as("seller", "Org1MSP"), "auction1", "item", sellerPk, "unknown"); err == nil {
+		t.Error("an auction with an unregistered verifying key was created")
+	}
+
+	l.createAuction("auction1")
+	bid, err := l.sendBid("auction1", "alice", 50, nil)
+	l.must(err)
+	l.endAuction("auction1", bid)
+	if err := l.contract.DeclareWinner(l.as("seller", "Org1MSP"), "auction1", bid.id, "", ""); err == nil {
+		t.Error("the winner was declared without a proof")
+	}
+	l.must(l.declareWinner("auction1", bid.id))
+
+	// an auction created before the registry has no key and no version, its winner cannot be proven
+	legacy := Auction{Type: "auction", Seller: "seller", SellerPk: *l.sellerPk, Commitments: map[string][]byte{},
+		EncryptedBids: map[string]EncryptedBid{}, Status: "ended"}
+	l.must(putAuction(l.as("seller", "Org1MSP"), "auction2", &legacy))
+	if err := l.contract.DeclareWinner(l.as("seller", "Org1MSP"), "auction2", "bid", "", ""); err == nil {
+		t.Error("the winner of an auction without a verifying key was declared")
+	}
+}
+
+func TestCommitmentLimit(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	var bids []*testBid
+	for i := 0; i < testMaxBids; i++ {
+		bid, err := l.sendBid("auction1", fmt.Sprintf("bidder%v", i), 50+i, nil)
+		l.must(err)
+		bids = append(bids, bid)
+	}
+	// a flood of commitments would keep the winner from being declared
+	for i := 0; i < 3; i++ {
+		if _, err := l.sendBid("auction1", "flooder", 1, nil); err == nil {
+			t.Fatal("a commitment was accepted beyond the bids of the proof system")
+		}
+	}
+	auction, err := getAuction(l.as("reader", "Org2MSP"), "auction1")
+	l.must(err)
+	if len(auction.CommitmentOrder) != testMaxBids {
+		t.Errorf("%v commitments in the auction", len(auction.CommitmentOrder))
+	}
+	l.endAuction("auction1", bids...)
+	l.must(l.declareWinner("auction1", bids[len(bids)-1].id))
+}
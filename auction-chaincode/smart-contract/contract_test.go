@@ -0,0 +1,250 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	pedersen "github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// testMaxBids is the number of bids of the circuit of the test key
+const testMaxBids = 3
+
+const testKeyID = "testKey"
+
+// testKeys are the Groth16 keys of the auction circuit, set up once for the tests of the package
+var testKeys struct {
+	once sync.Once
+	r1cs frontend.CompiledConstraintSystem
+	pk   groth16.ProvingKey
+	vk   []byte
+	err  error
+}
+
+// setupTestKeys sets up the test keys, the tests using them are skipped with -short
+func setupTestKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the setup of the test key takes a minute")
+	}
+	testKeys.once.Do(func() {
+		testKeys.r1cs, testKeys.err = frontend.Compile(ecc.BLS12_381, backend.GROTH16, circuit.NewAuctionCircuit(testMaxBids))
+		if testKeys.err != nil {
+			return
+		}
+		var vk groth16.VerifyingKey
+		testKeys.pk, vk, testKeys.err = groth16.Setup(testKeys.r1cs)
+		if testKeys.err != nil {
+			return
+		}
+		var vkBuf bytes.Buffer
+		_, testKeys.err = vk.WriteTo(&vkBuf)
+		testKeys.vk = vkBuf.Bytes()
+	})
+	if testKeys.err != nil {
+		t.Fatal(testKeys.err)
+	}
+}
+
+// testIdentity is the identity of the client submitting a transaction to the mock stub, with the OUs of its
+// certificate and its attributes
+type testIdentity struct {
+	id    string
+	mspID string
+	ous   []string
+	attrs map[string]string
+}
+
+func (c *testIdentity) GetID() (string, error) {
+	return c.id, nil
+}
+
+func (c *testIdentity) GetMSPID() (string, error) {
+	return c.mspID, nil
+}
+
+func (c *testIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, ok := c.attrs[attrName]
+	return value, ok, nil
+}
+
+func (c *testIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	value, ok := c.attrs[attrName]
+	if !ok {
+		return fmt.Errorf("attribute %v not found", attrName)
+	}
+	if value != attrValue {
+		return fmt.Errorf("attribute %v is %v, not %v", attrName, value, attrValue)
+	}
+	return nil
+}
+
+func (c *testIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: c.id, OrganizationalUnit: c.ous}}, nil
+}
+
+// testLedger runs the transactions of the contract on a mock stub, one after the other
+type testLedger struct {
+	t        *testing.T
+	stub     *shimtest.MockStub
+	contract SmartContract
+	nbTx     int
+	sellerPk *[32]byte
+	bids     map[string]*testBid
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+	sellerPk, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testLedger{t: t, stub: shimtest.NewMockStub("auction", nil), sellerPk: sellerPk, bids: make(map[string]*testBid)}
+}
+
+// as starts a new transaction submitted by the client id of the organization mspID
+func (l *testLedger) as(id, mspID string) *contractapi.TransactionContext {
+	return l.asIdentity(&testIdentity{id: id, mspID: mspID, ous: []string{"client"}})
+}
+
+// asAdmin starts a new transaction submitted by an admin of adminMSPID
+func (l *testLedger) asAdmin() *contractapi.TransactionContext {
+	return l.asIdentity(&testIdentity{id: "admin", mspID: adminMSPID, ous: []string{adminOU}})
+}
+
+func (l *testLedger) asIdentity(identity *testIdentity) *contractapi.TransactionContext {
+	l.nbTx++
+	l.stub.MockTransactionStart(fmt.Sprintf("tx%v", l.nbTx))
+	l.stub.TransientMap = nil
+	l.stub.Creator = nil
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(l.stub)
+	ctx.SetClientIdentity(identity)
+	return ctx
+}
+
+// must fails the test if a transaction failed
+func (l *testLedger) must(err error) {
+	l.t.Helper()
+	if err != nil {
+		l.t.Fatal(err)
+	}
+}
+
+// createAuction creates a sealed-bid auction sold by seller, pinning the test key. The key is registered with
+// the first auction of the ledger
+func (l *testLedger) createAuction(auctionID string) {
+	l.t.Helper()
+	setupTestKeys(l.t)
+	entry, err := getVerifyingKey(l.asAdmin(), testKeyID)
+	l.must(err)
+	if entry == nil {
+		circuitHash := sha256.Sum256([]byte("circuit"))
+		l.must(l.contract.RegisterVerifyingKey(l.asAdmin(), testKeyID, wire.ProofSystemGroth16,
+			base64.StdEncoding.EncodeToString(testKeys.vk), hex.EncodeToString(circuitHash[:]), 0, 1<<40))
+	}
+	l.must(l.contract.CreateAuction(l.as("seller", "Org1MSP"), auctionID, "item",
+		base64.StdEncoding.EncodeToString(l.sellerPk[:]), testKeyID))
+}
+
+// testBid is a bid of a test, with the opening of its commitment
+type testBid struct {
+	id     string
+	bidder string
+	price  int
+	r      *big.Int
+	com    []byte
+	point  *twistededwards.PointAffine
+}
+
+// sendBid sends a commitment to price, with the deposit secret in the transient map if it is not nil
+func (l *testLedger) sendBid(auctionID, bidder string, price int, secret []byte) (*testBid, error) {
+	com, r, err := pedersen.Commit(price)
+	l.must(err)
+	comBytes := wire.MarshalCommitment(com)
+	proof, err := pedersen.ProveCommit(price, r, comBytes, nil)
+	l.must(err)
+	ctx := l.as(bidder, "DacMSP")
+	if secret != nil {
+		l.stub.TransientMap = map[string][]byte{"depositSecret": secret}
+	}
+	bidID, err := l.contract.SendCommitment(ctx, auctionID, base64.StdEncoding.EncodeToString(comBytes),
+		base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(proof)))
+	bid := &testBid{id: bidID, bidder: bidder, price: price, r: r, com: comBytes, point: com}
+	l.bids[bidID] = bid
+	return bid, err
+}
+
+// revealBid reveals a bid, encrypted for the seller
+func (l *testLedger) revealBid(auctionID string, bid *testBid) {
+	l.t.Helper()
+	data, err := pedersen.Encrypt(bid.price, bid.r, l.sellerPk)
+	l.must(err)
+	proof, err := pedersen.ProveCommit(bid.price, bid.r, bid.com, data)
+	l.must(err)
+	l.must(l.contract.RevealBid(l.as(bid.bidder, "DacMSP"), auctionID, bid.id, bid.bidder,
+		base64.StdEncoding.EncodeToString(data), base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(proof))))
+}
+
+// declareWinner declares the winner of an ended auction with the proof that it is the highest revealed bid,
+// the bids that were not revealed are excluded
+func (l *testLedger) declareWinner(auctionID, winningBidID string) error {
+	auctionJSON, err := getAuction(l.as("seller", "Org1MSP"), auctionID)
+	l.must(err)
+	var bids []circuit.Bid
+	winner := -1
+	for i, bidID := range auctionJSON.CommitmentOrder {
+		bid := l.bids[bidID]
+		_, revealed := auctionJSON.EncryptedBids[bidID]
+		bids = append(bids, circuit.Bid{Commitment: *bid.point, Value: bid.price, R: bid.r, Excluded: !revealed})
+		if bidID == winningBidID {
+			winner = i
+		}
+	}
+	witness, err := circuit.NewWitness(testMaxBids, bids, winner)
+	l.must(err)
+	proof, err := groth16.Prove(testKeys.r1cs, testKeys.pk, witness)
+	l.must(err)
+	var proofBuf bytes.Buffer
+	_, err = proof.WriteTo(&proofBuf)
+	l.must(err)
+	proofBytes, err := wire.MarshalWinnerProof(wire.ProofSystemGroth16, proofBuf.Bytes())
+	l.must(err)
+	return l.contract.DeclareWinner(l.as("seller", "Org1MSP"), auctionID, winningBidID,
+		base64.StdEncoding.EncodeToString(proofBytes), "")
+}
+
+// balance returns the balance of an account
+func (l *testLedger) balance(account string) int {
+	l.t.Helper()
+	balance, err := l.contract.BalanceOf(l.as("reader", "Org2MSP"), account)
+	l.must(err)
+	return balance
+}
+
+// voucherHash returns the hash of a deposit secret, as given to LockDeposit
+func voucherHash(secret []byte) string {
+	digest := sha256.Sum256(secret)
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,85 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ckiere/test-network/auction-circuit/scopetag"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const dacConfigType = "dacConfig"
+
+// DacConfig is the public DAC configuration of the anonymous bidders, the proofs of the bidders are verified
+// with it instead of parameters chosen by the seller. H is the h parameter of the DAC configuration, AuditorPk
+// the public key of the auditor the bidders escrow their identity for
+type DacConfig struct {
+	Type      string `json:"objectType"`
+	H         []byte `json:"h"`
+	AuditorPk []byte `json:"auditorPk,omitempty"`
+}
+
+// SetDacConfig sets the DAC configuration of the chaincode, only clients of the admin organization can set it.
+// dacH and auditorPk are base64 encoded, auditorPk is empty if there is no auditor
+func (s *SmartContract) SetDacConfig(ctx contractapi.TransactionContextInterface, dacH, auditorPk string) error {
+	err := checkAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	hBytes, err := base64.StdEncoding.DecodeString(dacH)
+	if err != nil || len(hBytes) != scopetag.PointSize || FP256BN.ECP2_fromBytes(hBytes).Is_infinity() {
+		return fmt.Errorf("invalid DAC parameter h")
+	}
+	auditorPkBytes, err := base64.StdEncoding.DecodeString(auditorPk)
+	if err != nil {
+		return fmt.Errorf("invalid auditor public key")
+	}
+
+	config := DacConfig{Type: dacConfigType, H: hBytes, AuditorPk: auditorPkBytes}
+	configKey, err := ctx.GetStub().CreateCompositeKey(dacConfigType, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(configKey, configBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store DAC configuration: %v", err)
+	}
+	return nil
+}
+
+// QueryDacConfig returns the DAC configuration of the chaincode
+func (s *SmartContract) QueryDacConfig(ctx contractapi.TransactionContextInterface) (*DacConfig, error) {
+	return getDacConfig(ctx)
+}
+
+// getDacConfig is an internal helper function to read the DAC configuration, it fails if the admin did not
+// set it
+func getDacConfig(ctx contractapi.TransactionContextInterface) (*DacConfig, error) {
+	configKey, err := ctx.GetStub().CreateCompositeKey(dacConfigType, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	configBytes, err := ctx.GetStub().GetState(configKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DAC configuration: %v", err)
+	}
+	if configBytes == nil {
+		return nil, fmt.Errorf("the DAC configuration is not set")
+	}
+	var config DacConfig
+	err = json.Unmarshal(configBytes, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DAC configuration object JSON: %v", err)
+	}
+	return &config, nil
+}
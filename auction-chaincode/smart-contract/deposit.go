@@ -0,0 +1,405 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	pedersen "github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const depositKeyType = "deposit"
+
+// Status of a deposit voucher
+const (
+	DepositLocked          = "locked"
+	DepositBound           = "bound"
+	DepositAwaitingPayment = "awaitingPayment"
+	DepositRefunded        = "refunded"
+	DepositForfeited       = "forfeited"
+)
+
+// DepositVoucher is a deposit locked for an auction. It is identified by the hash of a secret, and the
+// anonymous bidder who knows the secret binds it to their commitment. Owner is the account that locked the
+// deposit and gets it back. The ledger links the owner to the bid, so bidders who want to stay anonymous
+// get their secret from an account that locks vouchers for many bidders, e.g. an account of their organization
+type DepositVoucher struct {
+	Type      string `json:"objectType"`
+	AuctionID string `json:"auctionID"`
+	Amount    int    `json:"amount"`
+	Owner     string `json:"owner"`
+	BidID     string `json:"bidID"`
+	Status    string `json:"status"`
+}
+
+// RequireDeposit can be used by the seller of a sealed-bid auction, before any commitment is sent, to require
+// a deposit of amount tokens with every commitment. Deposits of bids that are not revealed are forfeited to the
+// seller, the deposit of the winner is released when the winning bid is paid within paymentWindow seconds
+func (s *SmartContract) RequireDeposit(ctx contractapi.TransactionContextInterface, auctionID string, amount int, paymentWindow int) error {
+	if amount <= 0 || paymentWindow <= 0 {
+		return fmt.Errorf("invalid deposit amount or payment window")
+	}
+
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	Seller := auctionJSON.Seller
+	if Seller != clientID {
+		return fmt.Errorf("rules of the auction can only be changed by seller")
+	}
+
+	if !auctionJSON.isSealedBid() {
+		return fmt.Errorf("operation is only supported by sealed-bid auctions")
+	}
+	if auctionJSON.Status != "open" || len(auctionJSON.Commitments) > 0 {
+		return fmt.Errorf("rules can only be changed before the first commitment")
+	}
+
+	auctionJSON.Deposit = amount
+	auctionJSON.PaymentWindow = int64(paymentWindow)
+	auctionJSON.Deposits = make(map[string]string)
+	return putAuction(ctx, auctionID, auctionJSON)
+}
+
+// LockDeposit locks the deposit of an auction from the account of the submitting client in a voucher.
+// voucherHash is the hex encoded SHA-256 hash of the voucher secret, that is given to the bidder
+func (s *SmartContract) LockDeposit(ctx contractapi.TransactionContextInterface, auctionID, voucherHash string) error {
+	hashBytes, err := hex.DecodeString(voucherHash)
+	if err != nil || len(hashBytes) != sha256.Size {
+		return fmt.Errorf("invalid voucher hash")
+	}
+
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	if auctionJSON.Deposit == 0 {
+		return fmt.Errorf("auction does not require a deposit")
+	}
+	if auctionJSON.Status != "open" {
+		return fmt.Errorf("cannot lock a deposit for a closed or ended auction")
+	}
+
+	voucher, err := getDepositVoucher(ctx, auctionID, voucherHash)
+	if err != nil {
+		return err
+	}
+	if voucher != nil {
+		return fmt.Errorf("voucher %v already exists", voucherHash)
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	err = addBalance(ctx, clientID, -auctionJSON.Deposit)
+	if err != nil {
+		return err
+	}
+
+	voucher = &DepositVoucher{
+		Type:      "deposit",
+		AuctionID: auctionID,
+		Amount:    auctionJSON.Deposit,
+		Owner:     clientID,
+		Status:    DepositLocked,
+	}
+	return putDepositVoucher(ctx, voucherHash, voucher)
+}
+
+// QueryDeposit returns the deposit voucher with the given hash
+func (s *SmartContract) QueryDeposit(ctx contractapi.TransactionContextInterface, auctionID, voucherHash string) (*DepositVoucher, error) {
+	voucher, err := getDepositVoucher(ctx, auctionID, voucherHash)
+	if err != nil {
+		return nil, err
+	}
+	if voucher == nil {
+		return nil, fmt.Errorf("voucher %v does not exist", voucherHash)
+	}
+	return voucher, nil
+}
+
+// PayWinningBid is used to pay the price of the winning bid to the seller, from the account of the submitting
+// client. The price is proven by opening the winning commitment, r is the base64 encoded randomness of the
+// commitment. The deposit of the winner is then released
+func (s *SmartContract) PayWinningBid(ctx contractapi.TransactionContextInterface, auctionID string, price int, r string) error {
+	rBytes, err := base64.StdEncoding.DecodeString(r)
+	if err != nil {
+		return fmt.Errorf("invalid commitment randomness")
+	}
+
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	voucherHash, exists := auctionJSON.Deposits[auctionJSON.WinningBid]
+	if auctionJSON.WinningBid == "" || !exists {
+		return fmt.Errorf("auction has no deposit awaiting payment")
+	}
+	voucher, err := getDepositVoucher(ctx, auctionID, voucherHash)
+	if err != nil {
+		return err
+	}
+	if voucher == nil || voucher.Status != DepositAwaitingPayment {
+		return fmt.Errorf("auction has no deposit awaiting payment")
+	}
+
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if txTime > auctionJSON.PaymentDeadline {
+		return fmt.Errorf("payment deadline has passed")
+	}
+
+	if !pedersen.CheckCommitBytes(price, rBytes, auctionJSON.Commitments[auctionJSON.WinningBid]) {
+		return fmt.Errorf("price does not open the winning commitment")
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	err = transferTokens(ctx, clientID, auctionJSON.Seller, price)
+	if err != nil {
+		return err
+	}
+	return releaseDeposit(ctx, voucherHash, voucher, voucher.Owner, DepositRefunded)
+}
+
+// ForfeitUnpaidDeposit can be used by the seller once the payment deadline has passed, to get the deposit
+// of a winner who did not pay
+func (s *SmartContract) ForfeitUnpaidDeposit(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	Seller := auctionJSON.Seller
+	if Seller != clientID {
+		return fmt.Errorf("deposit can only be claimed by seller")
+	}
+
+	voucherHash, exists := auctionJSON.Deposits[auctionJSON.WinningBid]
+	if auctionJSON.WinningBid == "" || !exists {
+		return fmt.Errorf("auction has no deposit awaiting payment")
+	}
+	voucher, err := getDepositVoucher(ctx, auctionID, voucherHash)
+	if err != nil {
+		return err
+	}
+	if voucher == nil || voucher.Status != DepositAwaitingPayment {
+		return fmt.Errorf("auction has no deposit awaiting payment")
+	}
+
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if txTime <= auctionJSON.PaymentDeadline {
+		return fmt.Errorf("payment deadline has not passed")
+	}
+	return releaseDeposit(ctx, voucherHash, voucher, auctionJSON.Seller, DepositForfeited)
+}
+
+// ReclaimDeposit can be used by the owner of a deposit when the seller did not declare the winner within the
+// payment window after the end of the auction, to get the deposit back
+func (s *SmartContract) ReclaimDeposit(ctx contractapi.TransactionContextInterface, auctionID, voucherHash string) error {
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+	if auctionJSON.Status != "ended" || auctionJSON.EndedAt == 0 || auctionJSON.WinningBid != "" {
+		return fmt.Errorf("deposits can only be reclaimed from an ended auction without a winner")
+	}
+
+	voucher, err := getDepositVoucher(ctx, auctionID, voucherHash)
+	if err != nil {
+		return err
+	}
+	if voucher == nil || (voucher.Status != DepositLocked && voucher.Status != DepositBound) {
+		return fmt.Errorf("no locked deposit for voucher %v", voucherHash)
+	}
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	if voucher.Owner != clientID {
+		return fmt.Errorf("deposit can only be reclaimed by its owner")
+	}
+
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if txTime <= auctionJSON.EndedAt+auctionJSON.PaymentWindow {
+		return fmt.Errorf("the seller can still declare the winner")
+	}
+	return releaseDeposit(ctx, voucherHash, voucher, voucher.Owner, DepositRefunded)
+}
+
+// bindDeposit is an internal function called by every transaction that sends a commitment. If the auction
+// requires a deposit, the voucher secret is read from the transient map and the voucher is bound to the
+// commitment, that is identified by the transaction ID
+func bindDeposit(ctx contractapi.TransactionContextInterface, auctionID string, auctionJSON *Auction) error {
+	if auctionJSON.Deposit == 0 {
+		return nil
+	}
+
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient: %v", err)
+	}
+	secret, ok := transientMap["depositSecret"]
+	if !ok {
+		return fmt.Errorf("auction requires a deposit secret in the transient map")
+	}
+	digest := sha256.Sum256(secret)
+	voucherHash := hex.EncodeToString(digest[:])
+
+	voucher, err := getDepositVoucher(ctx, auctionID, voucherHash)
+	if err != nil {
+		return err
+	}
+	if voucher == nil || voucher.Status != DepositLocked {
+		return fmt.Errorf("no unused deposit for the secret")
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	voucher.BidID = txID
+	voucher.Status = DepositBound
+	// the map is omitted from the state while it is empty
+	if auctionJSON.Deposits == nil {
+		auctionJSON.Deposits = make(map[string]string)
+	}
+	auctionJSON.Deposits[txID] = voucherHash
+	return putDepositVoucher(ctx, voucherHash, voucher)
+}
+
+// settleDeposits is an internal function called when the winner is declared. Unused deposits and deposits of
+// revealed bids are refunded, deposits of bids that were not revealed are forfeited to the seller, and the
+// deposit of the winner stays locked until the winning bid is paid
+func settleDeposits(ctx contractapi.TransactionContextInterface, auctionID string, auctionJSON *Auction) error {
+	if auctionJSON.Deposit == 0 {
+		return nil
+	}
+
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	auctionJSON.PaymentDeadline = txTime + auctionJSON.PaymentWindow
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(depositKeyType, []string{auctionID})
+	if err != nil {
+		return fmt.Errorf("failed to read deposits: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return err
+		}
+		var voucher DepositVoucher
+		err = json.Unmarshal(queryResponse.Value, &voucher)
+		if err != nil {
+			return fmt.Errorf("failed to create deposit object JSON: %v", err)
+		}
+		voucherHash := keyParts[1]
+
+		switch voucher.Status {
+		case DepositLocked:
+			err = releaseDeposit(ctx, voucherHash, &voucher, voucher.Owner, DepositRefunded)
+		case DepositBound:
+			_, revealed := auctionJSON.EncryptedBids[voucher.BidID]
+			if voucher.BidID == auctionJSON.WinningBid {
+				voucher.Status = DepositAwaitingPayment
+				err = putDepositVoucher(ctx, voucherHash, &voucher)
+			} else if revealed {
+				err = releaseDeposit(ctx, voucherHash, &voucher, voucher.Owner, DepositRefunded)
+			} else {
+				err = releaseDeposit(ctx, voucherHash, &voucher, auctionJSON.Seller, DepositForfeited)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseDeposit is an internal function that pays the amount of a voucher to an account
+func releaseDeposit(ctx contractapi.TransactionContextInterface, voucherHash string, voucher *DepositVoucher, account, status string) error {
+	err := addBalance(ctx, account, voucher.Amount)
+	if err != nil {
+		return err
+	}
+	voucher.Status = status
+	return putDepositVoucher(ctx, voucherHash, voucher)
+}
+
+// getDepositVoucher is an internal helper function to read a voucher, it returns nil if the voucher does not exist
+func getDepositVoucher(ctx contractapi.TransactionContextInterface, auctionID, voucherHash string) (*DepositVoucher, error) {
+	depositKey, err := ctx.GetStub().CreateCompositeKey(depositKeyType, []string{auctionID, voucherHash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	voucherBytes, err := ctx.GetStub().GetState(depositKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deposit: %v", err)
+	}
+	if voucherBytes == nil {
+		return nil, nil
+	}
+	var voucher DepositVoucher
+	err = json.Unmarshal(voucherBytes, &voucher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deposit object JSON: %v", err)
+	}
+	return &voucher, nil
+}
+
+// putDepositVoucher is an internal helper function to write a voucher
+func putDepositVoucher(ctx contractapi.TransactionContextInterface, voucherHash string, voucher *DepositVoucher) error {
+	depositKey, err := ctx.GetStub().CreateCompositeKey(depositKeyType, []string{voucher.AuctionID, voucherHash})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	voucherBytes, err := json.Marshal(voucher)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(depositKey, voucherBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store deposit: %v", err)
+	}
+	return nil
+}
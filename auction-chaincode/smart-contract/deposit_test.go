@@ -0,0 +1,213 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+const (
+	testDeposit       = 10
+	testPaymentWindow = 60
+)
+
+// depositAuction creates an auction requiring a deposit, and funds the accounts of the bidders
+func depositAuction(t *testing.T, auctionID string, bidders ...string) *testLedger {
+	l := newTestLedger(t)
+	l.createAuction(auctionID)
+	l.must(l.contract.RequireDeposit(l.as("seller", "Org1MSP"), auctionID, testDeposit, testPaymentWindow))
+	for _, bidder := range bidders {
+		l.must(l.contract.Mint(l.as(bidder, "Org1MSP"), 100))
+	}
+	return l
+}
+
+// lockDeposit locks a deposit from the account of owner, for the bidder who gets secret
+func (l *testLedger) lockDeposit(auctionID, owner string, secret []byte) {
+	l.t.Helper()
+	l.must(l.contract.LockDeposit(l.as(owner, "Org1MSP"), auctionID, voucherHash(secret)))
+}
+
+// checkVoucher checks the status of the voucher of a deposit secret
+func (l *testLedger) checkVoucher(auctionID string, secret []byte, status string) {
+	l.t.Helper()
+	voucher, err := l.contract.QueryDeposit(l.as("reader", "Org2MSP"), auctionID, voucherHash(secret))
+	l.must(err)
+	if voucher.Status != status {
+		l.t.Errorf("voucher of %v has status %v, expected %v", voucher.Owner, voucher.Status, status)
+	}
+}
+
+// endAuction closes the auction, reveals the bids and ends the auction
+func (l *testLedger) endAuction(auctionID string, revealed ...*testBid) {
+	l.t.Helper()
+	l.must(l.contract.CloseAuction(l.as("seller", "Org1MSP"), auctionID))
+	for _, bid := range revealed {
+		l.revealBid(auctionID, bid)
+	}
+	l.must(l.contract.EndAuction(l.as("seller", "Org1MSP"), auctionID))
+}
+
+func TestLockDeposit(t *testing.T) {
+	l := depositAuction(t, "auction1", "alice")
+	if err := l.contract.LockDeposit(l.as("alice", "Org1MSP"), "auction1", "00"); err == nil {
+		t.Error("a voucher hash that is not a SHA-256 hash was accepted")
+	}
+	l.lockDeposit("auction1", "alice", []byte("secret"))
+	if err := l.contract.LockDeposit(l.as("alice", "Org1MSP"), "auction1", voucherHash([]byte("secret"))); err == nil {
+		t.Error("a voucher was locked twice")
+	}
+	if err := l.contract.LockDeposit(l.as("bob", "Org1MSP"), "auction1", voucherHash([]byte("other"))); err == nil {
+		t.Error("a deposit was locked without funds")
+	}
+	l.checkVoucher("auction1", []byte("secret"), DepositLocked)
+	if l.balance("alice") != 100-testDeposit {
+		t.Errorf("balance %v after locking a deposit", l.balance("alice"))
+	}
+
+	if _, err := l.sendBid("auction1", "bidder1", 50, nil); err == nil {
+		t.Error("a bid without a deposit secret was accepted")
+	}
+	if _, err := l.sendBid("auction1", "bidder1", 50, []byte("unknown")); err == nil {
+		t.Error("a bid with the secret of no voucher was accepted")
+	}
+	_, err := l.sendBid("auction1", "bidder1", 50, []byte("secret"))
+	l.must(err)
+	l.checkVoucher("auction1", []byte("secret"), DepositBound)
+	if _, err := l.sendBid("auction1", "bidder2", 60, []byte("secret")); err == nil {
+		t.Error("a voucher was bound to two bids")
+	}
+}
+
+func TestSettleDeposits(t *testing.T) {
+	l := depositAuction(t, "auction1", "alice", "bob", "carol")
+	l.lockDeposit("auction1", "alice", []byte("alice"))
+	l.lockDeposit("auction1", "alice", []byte("unused"))
+	l.lockDeposit("auction1", "bob", []byte("bob"))
+	l.lockDeposit("auction1", "carol", []byte("carol"))
+	aliceBid, err := l.sendBid("auction1", "alice", 50, []byte("alice"))
+	l.must(err)
+	bobBid, err := l.sendBid("auction1", "bob", 70, []byte("bob"))
+	l.must(err)
+	_, err = l.sendBid("auction1", "carol", 30, []byte("carol"))
+	l.must(err)
+
+	// carol does not reveal her bid
+	l.endAuction("auction1", aliceBid, bobBid)
+	l.must(l.declareWinner("auction1", bobBid.id))
+
+	l.checkVoucher("auction1", []byte("alice"), DepositRefunded)
+	l.checkVoucher("auction1", []byte("unused"), DepositRefunded)
+	l.checkVoucher("auction1", []byte("bob"), DepositAwaitingPayment)
+	l.checkVoucher("auction1", []byte("carol"), DepositForfeited)
+	if l.balance("alice") != 100 || l.balance("bob") != 100-testDeposit || l.balance("carol") != 100-testDeposit ||
+		l.balance("seller") != testDeposit {
+		t.Errorf("balances of alice, bob, carol and the seller %v, %v, %v and %v after the settlement",
+			l.balance("alice"), l.balance("bob"), l.balance("carol"), l.balance("seller"))
+	}
+
+	rBytes := base64.StdEncoding.EncodeToString(bobBid.r.Bytes())
+	if err := l.contract.PayWinningBid(l.as("bob", "Org1MSP"), "auction1", 60, rBytes); err == nil {
+		t.Error("a price that does not open the winning commitment was accepted")
+	}
+	l.must(l.contract.PayWinningBid(l.as("bob", "Org1MSP"), "auction1", 70, rBytes))
+	l.checkVoucher("auction1", []byte("bob"), DepositRefunded)
+	if l.balance("bob") != 30 || l.balance("seller") != testDeposit+70 {
+		t.Errorf("balances of bob and the seller %v and %v after the payment", l.balance("bob"), l.balance("seller"))
+	}
+	if err := l.contract.PayWinningBid(l.as("bob", "Org1MSP"), "auction1", 70, rBytes); err == nil {
+		t.Error("the winning bid was paid twice")
+	}
+	if err := l.contract.ForfeitUnpaidDeposit(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("the deposit of a paid bid was forfeited")
+	}
+}
+
+func TestForfeitUnpaidDeposit(t *testing.T) {
+	l := depositAuction(t, "auction1", "alice")
+	l.lockDeposit("auction1", "alice", []byte("alice"))
+	bid, err := l.sendBid("auction1", "alice", 50, []byte("alice"))
+	l.must(err)
+	l.endAuction("auction1", bid)
+	l.must(l.declareWinner("auction1", bid.id))
+
+	if err := l.contract.ForfeitUnpaidDeposit(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("a deposit was forfeited before the payment deadline")
+	}
+	// the next transactions are after the payment deadline
+	ctx := l.as("alice", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	if err := l.contract.PayWinningBid(ctx, "auction1", 50, base64.StdEncoding.EncodeToString(bid.r.Bytes())); err == nil {
+		t.Error("the winning bid was paid after the payment deadline")
+	}
+	ctx = l.as("alice", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	if err := l.contract.ForfeitUnpaidDeposit(ctx, "auction1"); err == nil {
+		t.Error("a deposit was forfeited by another client than the seller")
+	}
+	ctx = l.as("seller", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	l.must(l.contract.ForfeitUnpaidDeposit(ctx, "auction1"))
+	l.checkVoucher("auction1", []byte("alice"), DepositForfeited)
+	if l.balance("alice") != 100-testDeposit || l.balance("seller") != testDeposit {
+		t.Errorf("balances of alice and the seller %v and %v after the forfeit", l.balance("alice"), l.balance("seller"))
+	}
+}
+
+func TestReclaimDeposit(t *testing.T) {
+	l := depositAuction(t, "auction1", "alice", "bob")
+	l.lockDeposit("auction1", "alice", []byte("alice"))
+	l.lockDeposit("auction1", "bob", []byte("bob"))
+	bid, err := l.sendBid("auction1", "alice", 50, []byte("alice"))
+	l.must(err)
+	if err := l.contract.ReclaimDeposit(l.as("alice", "Org1MSP"), "auction1", voucherHash([]byte("alice"))); err == nil {
+		t.Error("a deposit was reclaimed from an open auction")
+	}
+	l.endAuction("auction1", bid)
+
+	if err := l.contract.ReclaimDeposit(l.as("alice", "Org1MSP"), "auction1", voucherHash([]byte("alice"))); err == nil {
+		t.Error("a deposit was reclaimed before the end of the payment window")
+	}
+	// the next transactions are after the payment window, the seller did not declare the winner
+	ctx := l.as("bob", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	if err := l.contract.ReclaimDeposit(ctx, "auction1", voucherHash([]byte("alice"))); err == nil {
+		t.Error("a deposit was reclaimed by another client than its owner")
+	}
+	ctx = l.as("alice", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	l.must(l.contract.ReclaimDeposit(ctx, "auction1", voucherHash([]byte("alice"))))
+	l.checkVoucher("auction1", []byte("alice"), DepositRefunded)
+	ctx = l.as("alice", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	if err := l.contract.ReclaimDeposit(ctx, "auction1", voucherHash([]byte("alice"))); err == nil {
+		t.Error("a deposit was reclaimed twice")
+	}
+	// an unused deposit is reclaimed too
+	ctx = l.as("bob", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	l.must(l.contract.ReclaimDeposit(ctx, "auction1", voucherHash([]byte("bob"))))
+	if l.balance("alice") != 100 || l.balance("bob") != 100 || l.balance("seller") != 0 {
+		t.Errorf("balances of alice, bob and the seller %v, %v and %v after the reclaims",
+			l.balance("alice"), l.balance("bob"), l.balance("seller"))
+	}
+}
+
+func TestReclaimDepositDeclared(t *testing.T) {
+	l := depositAuction(t, "auction1", "alice")
+	l.lockDeposit("auction1", "alice", []byte("alice"))
+	bid, err := l.sendBid("auction1", "alice", 50, []byte("alice"))
+	l.must(err)
+	l.endAuction("auction1", bid)
+	l.must(l.declareWinner("auction1", bid.id))
+
+	ctx := l.as("alice", "Org1MSP")
+	l.stub.TxTimestamp.Seconds += testPaymentWindow + 1
+	if err := l.contract.ReclaimDeposit(ctx, "auction1", voucherHash([]byte("alice"))); err == nil {
+		t.Error("the deposit of the winner was reclaimed from a declared auction")
+	}
+	l.checkVoucher("auction1", []byte("alice"), DepositAwaitingPayment)
+}
@@ -0,0 +1,116 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/auction/chaincode-go/crypto"
+)
+
+const escrowKeyType = "escrow"
+
+// RequireIdentityEscrow can be used by the seller, before any bid is placed, to require every bidder to
+// escrow the public key of their DAC credential for the auditor of the DAC configuration set by the admin.
+// The auditor can de-anonymize the bidder of a transaction in case of dispute
+func (s *SmartContract) RequireIdentityEscrow(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	dacConfig, err := getDacConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if len(dacConfig.AuditorPk) == 0 {
+		return fmt.Errorf("the DAC configuration has no auditor")
+	}
+
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	Seller := auctionJSON.Seller
+	if Seller != clientID {
+		return fmt.Errorf("rules of the auction can only be changed by seller")
+	}
+
+	if auctionJSON.Status != "open" || len(auctionJSON.Commitments) > 0 || auctionJSON.WinningBid != "" {
+		return fmt.Errorf("rules can only be changed before the first bid")
+	}
+
+	auctionJSON.IdentityEscrow = true
+	return putAuction(ctx, auctionID, auctionJSON)
+}
+
+// QueryIdentityEscrow returns the escrowed identity of the bidder who submitted the transaction txID, it
+// can only be decrypted by the auditor of the auction
+func (s *SmartContract) QueryIdentityEscrow(ctx contractapi.TransactionContextInterface, auctionID, txID string) (string, error) {
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowKeyType, []string{auctionID, txID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+	escrow, err := ctx.GetStub().GetState(escrowKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read escrow: %v", err)
+	}
+	if escrow == nil {
+		return "", fmt.Errorf("no identity escrow for transaction %v", txID)
+	}
+	return base64.StdEncoding.EncodeToString(escrow), nil
+}
+
+// storeIdentityEscrow is an internal function called by every transaction that places a bid. If the auction
+// requires identity escrow, the escrow and its proof are read from the transient map, checked against the
+// nym of the submitting client and the DAC configuration, and stored in the public state under the transaction ID
+func storeIdentityEscrow(ctx contractapi.TransactionContextInterface, auctionID string, auctionJSON *Auction) error {
+	if !auctionJSON.IdentityEscrow {
+		return nil
+	}
+	dacConfig, err := getDacConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if len(dacConfig.AuditorPk) == 0 {
+		return fmt.Errorf("the DAC configuration has no auditor")
+	}
+
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient: %v", err)
+	}
+	escrow, ok := transientMap["escrow"]
+	if !ok {
+		return fmt.Errorf("auction requires an identity escrow in the transient map")
+	}
+	escrowProof, ok := transientMap["escrowProof"]
+	if !ok {
+		return fmt.Errorf("auction requires an identity escrow proof in the transient map")
+	}
+
+	nymBytes, err := getCreatorNym(ctx)
+	if err != nil {
+		return err
+	}
+	err = crypto.VerifyIdentityEscrow(dacConfig.H, dacConfig.AuditorPk, nymBytes, escrow, escrowProof)
+	if err != nil {
+		return fmt.Errorf("invalid identity escrow: %v", err)
+	}
+
+	escrowKey, err := ctx.GetStub().CreateCompositeKey(escrowKeyType, []string{auctionID, ctx.GetStub().GetTxID()})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	err = ctx.GetStub().PutState(escrowKey, escrow)
+	if err != nil {
+		return fmt.Errorf("failed to store identity escrow: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/dbogatov/dac-lib/dac"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+)
+
+// sendEscrowBid sends a commitment from a new nym of the credential sk, with the escrow of the credential
+// public key for the auditor auditorPk, without escrow if it is nil
+func (l *testLedger) sendEscrowBid(d *testDac, auctionID string, sk *FP256BN.BIG, auditorPk dac.PK) (string, error) {
+	skNym, pkNym := d.nym(sk)
+	_, com, comProof := l.testCommitment()
+	ctx := l.asNym(pkNym)
+	if auditorPk != nil {
+		pk := FP256BN.ECP2_generator().Mul(sk)
+		encryption, r := dac.AuditingEncrypt(d.prg, auditorPk, pk)
+		proof := dac.AuditingProve(d.prg, encryption, pk, sk, pkNym, skNym, auditorPk, r, d.h)
+		l.stub.TransientMap = map[string][]byte{"escrow": encryption.ToBytes(), "escrowProof": proof.ToBytes()}
+	}
+	return l.contract.SendCommitment(ctx, auctionID, com, comProof)
+}
+
+func TestIdentityEscrow(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	d := newTestDac(t)
+	auditorSk, auditorPk := dac.GenerateKeys(d.prg, 2)
+	l.must(l.contract.SetDacConfig(l.asAdmin(), d.hBase64(), ""))
+	if err := l.contract.RequireIdentityEscrow(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("identity escrow required without an auditor in the DAC configuration")
+	}
+	l.must(l.contract.SetDacConfig(l.asAdmin(), d.hBase64(),
+		base64.StdEncoding.EncodeToString(dac.PointToBytes(auditorPk))))
+	if err := l.contract.RequireIdentityEscrow(l.as("bidder", "Org1MSP"), "auction1"); err == nil {
+		t.Error("the rules were changed by another client than the seller")
+	}
+	l.must(l.contract.RequireIdentityEscrow(l.as("seller", "Org1MSP"), "auction1"))
+
+	alice := d.credential()
+	if _, err := l.sendEscrowBid(d, "auction1", alice, nil); err == nil {
+		t.Error("a bid without an identity escrow was accepted")
+	}
+	// the seller cannot choose the auditor
+	_, otherPk := dac.GenerateKeys(d.prg, 2)
+	if _, err := l.sendEscrowBid(d, "auction1", alice, otherPk); err == nil {
+		t.Error("a bid escrowed for another auditor than the one of the configuration was accepted")
+	}
+	bidID, err := l.sendEscrowBid(d, "auction1", alice, auditorPk)
+	l.must(err)
+
+	escrow, err := l.contract.QueryIdentityEscrow(l.as("reader", "Org2MSP"), "auction1", bidID)
+	l.must(err)
+	escrowBytes, err := base64.StdEncoding.DecodeString(escrow)
+	l.must(err)
+	pk := dac.AuditingEncryptionFromBytes(escrowBytes).AuditingDecrypt(auditorSk)
+	if !pk.(*FP256BN.ECP2).Equals(FP256BN.ECP2_generator().Mul(alice)) {
+		t.Error("the auditor does not find the public key of the bidder in the escrow")
+	}
+}
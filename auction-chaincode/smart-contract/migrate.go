@@ -0,0 +1,195 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	pedersen "github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// legacyVersion is the schema version of the auctions of the private data auction chaincode
+const legacyVersion = 1
+
+// LegacyAuction is an auction of the private data auction chaincode, auctions without a version are version 1
+type LegacyAuction struct {
+	Type         string               `json:"objectType"`
+	ItemSold     string               `json:"item"`
+	Seller       string               `json:"seller"`
+	Orgs         []string             `json:"organizations"`
+	PrivateBids  map[string]LegacyBid `json:"privateBids"`
+	RevealedBids map[string]FullBid   `json:"revealedBids"`
+	Winner       string               `json:"winner"`
+	Price        int                  `json:"price"`
+	Status       string               `json:"status"`
+	Version      int                  `json:"version,omitempty"`
+}
+
+// FullBid is a bid revealed in the clear in a private data auction
+type FullBid struct {
+	Type   string `json:"objectType"`
+	Price  int    `json:"price"`
+	Org    string `json:"org"`
+	Bidder string `json:"bidder"`
+}
+
+// LegacyBid is the hash of a bid stored in the implicit collection of the bidder's organization
+type LegacyBid struct {
+	Org  string `json:"org"`
+	Hash string `json:"hash"`
+}
+
+// MigrateAuction moves an open or closed auction of the private data auction chaincode legacyChaincode to
+// this chaincode, under the same ID and seller. The legacy auction is retired in the same transaction, so
+// the transaction must also meet its endorsement policy: a peer of every participating organization.
+// The bids revealed in the clear are carried over as commitments with r = 0, their openings are encrypted
+// for sellerPk with EncryptPublic so the proof of the winner covers them like any other revealed bid.
+// The seller has to pass the new public key of the auction and the ID of the verifying key to pin
+func (s *SmartContract) MigrateAuction(ctx contractapi.TransactionContextInterface, auctionID, legacyChaincode, sellerPk, keyID string) error {
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	// get org of submitting client
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	existing, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction %v: %v", auctionID, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("auction %v already exists", auctionID)
+	}
+
+	legacy, err := getLegacyAuction(ctx, legacyChaincode, auctionID)
+	if err != nil {
+		return err
+	}
+	if legacy.Seller != clientID {
+		return fmt.Errorf("auction can only be migrated by seller")
+	}
+
+	// get seller public key
+	pkBytes, err := base64.StdEncoding.DecodeString(sellerPk)
+	if err != nil || len(pkBytes) != SellerPkSize {
+		return fmt.Errorf("invalid seller public key")
+	}
+	var sellerPkBytes [SellerPkSize]byte
+	copy(sellerPkBytes[:], pkBytes)
+
+	auction := Auction{
+		Type:            "auction",
+		AuctionType:     SealedBidAuction,
+		ItemSold:        legacy.ItemSold,
+		Seller:          clientID,
+		SellerPk:        sellerPkBytes,
+		Commitments:     make(map[string][]byte),
+		CommitmentOrder: []string{},
+		EncryptedBids:   make(map[string]EncryptedBid),
+		Status:          legacy.Status,
+		Version:         AuctionVersion,
+		MigratedFrom:    legacyChaincode,
+	}
+	err = pinVerifyingKey(ctx, &auction, keyID)
+	if err != nil {
+		return err
+	}
+
+	// RetireAuction checks that an open auction has no bids and that the unrevealed bids of a closed
+	// auction cannot win, only the revealed bids are carried over
+	if legacy.Status == "closed" {
+		err = migrateRevealedBids(ctx, auctionID, legacy, &auction)
+		if err != nil {
+			return err
+		}
+	}
+	response := ctx.GetStub().InvokeChaincode(legacyChaincode, [][]byte{[]byte("RetireAuction"), []byte(auctionID)}, "")
+	if response.Status != shim.OK {
+		return fmt.Errorf("failed to retire auction %v: %v", auctionID, response.Message)
+	}
+
+	err = putAuction(ctx, auctionID, &auction)
+	if err != nil {
+		return fmt.Errorf("failed to put auction in public data: %v", err)
+	}
+
+	// set the seller of the auction as an endorser
+	err = setAssetStateBasedEndorsement(ctx, auctionID, clientOrgID)
+	if err != nil {
+		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
+	}
+
+	return setAuctionEvent(ctx, "AuctionMigrated", AuctionEvent{AuctionID: auctionID})
+}
+
+// getLegacyAuction is an internal helper function to read an auction of the private data auction chaincode
+func getLegacyAuction(ctx contractapi.TransactionContextInterface, legacyChaincode, auctionID string) (*LegacyAuction, error) {
+	response := ctx.GetStub().InvokeChaincode(legacyChaincode, [][]byte{[]byte("QueryAuction"), []byte(auctionID)}, "")
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("failed to get auction %v from %v: %v", auctionID, legacyChaincode, response.Message)
+	}
+
+	var legacy LegacyAuction
+	err := json.Unmarshal(response.Payload, &legacy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auction object JSON: %v", err)
+	}
+	if legacy.Version != 0 && legacy.Version != legacyVersion {
+		return nil, fmt.Errorf("cannot migrate auction of version %v", legacy.Version)
+	}
+	return &legacy, nil
+}
+
+// migrateRevealedBids is an internal helper function that adds the revealed bids of a legacy auction to
+// the commitments and encrypted bids of auction. The bids are ordered by the ID of the transaction that
+// placed them, the key of the bid of the legacy auction holds it
+func migrateRevealedBids(ctx contractapi.TransactionContextInterface, auctionID string, legacy *LegacyAuction, auction *Auction) error {
+	var bidKeys []string
+	for bidKey := range legacy.RevealedBids {
+		bidKeys = append(bidKeys, bidKey)
+	}
+	sort.Strings(bidKeys)
+	if auction.MaxBids > 0 && len(bidKeys) > auction.MaxBids {
+		return fmt.Errorf("%v bids, the proof system of the auction allows at most %v", len(bidKeys), auction.MaxBids)
+	}
+
+	for _, bidKey := range bidKeys {
+		bid := legacy.RevealedBids[bidKey]
+		if bid.Price < 0 {
+			return fmt.Errorf("invalid price of bid %v", bidKey)
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(bidKey)
+		if err != nil || len(attributes) != 2 || attributes[0] != auctionID {
+			return fmt.Errorf("invalid bid key %q", bidKey)
+		}
+		bidID := attributes[1]
+
+		data, err := pedersen.EncryptPublic(bid.Price, big.NewInt(0), &auction.SellerPk, pedersen.MigratedBidSeed(auctionID, bidID))
+		if err != nil {
+			return err
+		}
+		auction.Commitments[bidID] = wire.MarshalCommitment(pedersen.CommitWith(bid.Price, big.NewInt(0)))
+		auction.CommitmentOrder = append(auction.CommitmentOrder, bidID)
+		auction.EncryptedBids[bidID] = EncryptedBid{
+			Type:   "bid",
+			Data:   data,
+			Bidder: bid.Bidder,
+		}
+	}
+	return nil
+}
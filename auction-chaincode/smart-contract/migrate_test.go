@@ -0,0 +1,169 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	pedersen "github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const testLegacyChaincode = "auction"
+
+// legacyChaincode stands for the private data auction chaincode, it serves its auctions and records the
+// auctions that are retired. RetireAuction fails with retireError if it is set
+type legacyChaincode struct {
+	auctions    map[string]*LegacyAuction
+	retired     []string
+	retireError string
+}
+
+func (c *legacyChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (c *legacyChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	function, args := stub.GetFunctionAndParameters()
+	auction, exists := c.auctions[args[0]]
+	if !exists {
+		return shim.Error("auction not found")
+	}
+	switch function {
+	case "QueryAuction":
+		auctionBytes, _ := json.Marshal(auction)
+		return shim.Success(auctionBytes)
+	case "RetireAuction":
+		if c.retireError != "" {
+			return shim.Error(c.retireError)
+		}
+		c.retired = append(c.retired, args[0])
+		return shim.Success(nil)
+	}
+	return shim.Error("unknown function " + function)
+}
+
+// legacyLedger returns a test ledger on which the legacy chaincode is deployed
+func legacyLedger(t *testing.T) (*testLedger, *legacyChaincode) {
+	l := newTestLedger(t)
+	legacy := &legacyChaincode{auctions: make(map[string]*LegacyAuction)}
+	l.stub.MockPeerChaincode(testLegacyChaincode, shimtest.NewMockStub(testLegacyChaincode, legacy), "")
+	return l, legacy
+}
+
+// legacyBidKey returns the key of a bid in a legacy auction
+func (l *testLedger) legacyBidKey(auctionID, bidID string) string {
+	l.t.Helper()
+	bidKey, err := l.stub.CreateCompositeKey("bid", []string{auctionID, bidID})
+	l.must(err)
+	return bidKey
+}
+
+// migrate migrates an auction from the legacy chaincode, pinning the test key
+func (l *testLedger) migrate(id, auctionID string) error {
+	return l.contract.MigrateAuction(l.as(id, "Org1MSP"), auctionID, testLegacyChaincode,
+		base64.StdEncoding.EncodeToString(l.sellerPk[:]), testKeyID)
+}
+
+func TestMigrateAuction(t *testing.T) {
+	l, legacy := legacyLedger(t)
+	l.createAuction("auction1")
+	legacy.auctions["auction2"] = &LegacyAuction{Seller: "seller", ItemSold: "item", Status: "closed",
+		RevealedBids: map[string]FullBid{
+			l.legacyBidKey("auction2", "tx2"): {Price: 70, Bidder: "bob"},
+			l.legacyBidKey("auction2", "tx1"): {Price: 50, Bidder: "alice"},
+		}}
+
+	if err := l.migrate("alice", "auction2"); err == nil {
+		t.Error("an auction was migrated by another client than the seller")
+	}
+	legacy.retireError = "attestation missing"
+	if err := l.migrate("seller", "auction2"); err == nil {
+		t.Error("an auction was migrated without being retired")
+	}
+	legacy.retireError = ""
+	l.must(l.migrate("seller", "auction2"))
+	if len(legacy.retired) != 1 || legacy.retired[0] != "auction2" {
+		t.Errorf("retired auctions %v", legacy.retired)
+	}
+	if err := l.migrate("seller", "auction2"); err == nil {
+		t.Error("an auction was migrated twice")
+	}
+
+	auctionJSON, err := getAuction(l.as("reader", "Org2MSP"), "auction2")
+	l.must(err)
+	if auctionJSON.Status != "closed" || auctionJSON.MigratedFrom != testLegacyChaincode ||
+		auctionJSON.VerifyingKeyID != testKeyID || auctionJSON.Version != AuctionVersion {
+		t.Errorf("migrated auction %+v", auctionJSON)
+	}
+	// the revealed bids are ordered by the ID of their transaction, anyone can open them
+	if len(auctionJSON.CommitmentOrder) != 2 || auctionJSON.CommitmentOrder[0] != "tx1" || auctionJSON.CommitmentOrder[1] != "tx2" {
+		t.Fatalf("commitment order %v", auctionJSON.CommitmentOrder)
+	}
+	for bidID, price := range map[string]int{"tx1": 50, "tx2": 70} {
+		value, r, err := pedersen.DecryptPublic(auctionJSON.EncryptedBids[bidID].Data, l.sellerPk, pedersen.MigratedBidSeed("auction2", bidID))
+		if err != nil || value != price || r.Sign() != 0 {
+			t.Errorf("opening of %v: %v, %v, %v", bidID, value, r, err)
+		}
+		if !pedersen.CheckCommitBytes(value, r.Bytes(), auctionJSON.Commitments[bidID]) {
+			t.Errorf("the opening of %v does not open its commitment", bidID)
+		}
+		// the migrated bids are proven like any other revealed bid
+		commitment := pedersen.CommitWith(price, big.NewInt(0))
+		l.bids[bidID] = &testBid{id: bidID, price: price, r: big.NewInt(0), com: wire.MarshalCommitment(commitment), point: commitment}
+	}
+
+	l.must(l.contract.EndAuction(l.as("seller", "Org1MSP"), "auction2"))
+	l.must(l.declareWinner("auction2", "tx2"))
+}
+
+func TestMigrateAuctionRefused(t *testing.T) {
+	l, legacy := legacyLedger(t)
+	l.createAuction("auction1")
+	legacy.auctions["auction1"] = &LegacyAuction{Seller: "seller", Status: "open"}
+	legacy.auctions["auction2"] = &LegacyAuction{Seller: "seller", Status: "open", Version: legacyVersion + 1}
+	legacy.auctions["auction3"] = &LegacyAuction{Seller: "seller", Status: "closed",
+		RevealedBids: map[string]FullBid{l.legacyBidKey("auction4", "tx1"): {Price: 50, Bidder: "alice"}}}
+	legacy.auctions["auction5"] = &LegacyAuction{Seller: "seller", Status: "open"}
+
+	if err := l.migrate("seller", "auction1"); err == nil {
+		t.Error("an auction was migrated over an existing auction")
+	}
+	if err := l.migrate("seller", "auction2"); err == nil {
+		t.Error("an auction of a later version was migrated")
+	}
+	if err := l.migrate("seller", "auction3"); err == nil {
+		t.Error("a bid of another auction was migrated")
+	}
+	if err := l.migrate("seller", "auction4"); err == nil {
+		t.Error("an auction that does not exist was migrated")
+	}
+	if err := l.contract.MigrateAuction(l.as("seller", "Org1MSP"), "auction5", testLegacyChaincode, "", testKeyID); err == nil {
+		t.Error("an auction was migrated without a seller public key")
+	}
+	if err := l.contract.MigrateAuction(l.as("seller", "Org1MSP"), "auction5", testLegacyChaincode,
+		base64.StdEncoding.EncodeToString(l.sellerPk[:]), "unknownKey"); err == nil {
+		t.Error("an auction was migrated with an unknown verifying key")
+	}
+	if len(legacy.retired) != 0 {
+		t.Errorf("retired auctions %v", legacy.retired)
+	}
+
+	// an open auction is migrated without bids, the bidders send commitments to the new auction
+	l.must(l.migrate("seller", "auction5"))
+	auctionJSON, err := getAuction(l.as("reader", "Org2MSP"), "auction5")
+	l.must(err)
+	if auctionJSON.Status != "open" || len(auctionJSON.Commitments) != 0 {
+		t.Errorf("migrated auction %+v", auctionJSON)
+	}
+	_, err = l.sendBid("auction5", "alice", 50, nil)
+	l.must(err)
+}
@@ -0,0 +1,286 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AuctionEvent is the payload of the events emitted by the auctions. Sealed-bid auctions emit CommitmentSent,
+// AuctionClosed, BidRevealed, AuctionEnded and WinnerDeclared, without price
+type AuctionEvent struct {
+	AuctionID string `json:"auctionID"`
+	BidID     string `json:"bidID"`
+	Price     int    `json:"price"`
+}
+
+// CreateDutchAuction creates a descending-price auction. The price starts at startPrice and goes down by
+// decrement every second, starting from the timestamp of the transaction, until it reaches reservePrice.
+// The first bidder to accept the current price wins the auction
+func (s *SmartContract) CreateDutchAuction(ctx contractapi.TransactionContextInterface, auctionID, itemsold string, startPrice, decrement, reservePrice int) error {
+	if startPrice <= 0 || decrement < 0 || reservePrice <= 0 || reservePrice > startPrice {
+		return fmt.Errorf("invalid price schedule")
+	}
+
+	auction := Auction{
+		AuctionType:  DutchAuction,
+		StartPrice:   startPrice,
+		Decrement:    decrement,
+		ReservePrice: reservePrice,
+	}
+	return createOpenAuction(ctx, auctionID, itemsold, &auction)
+}
+
+// CreateEnglishAuction creates an ascending-price auction. Bids are public and must be at least startPrice,
+// and then at least minIncrement higher than the current highest bid. The seller ends the auction
+func (s *SmartContract) CreateEnglishAuction(ctx contractapi.TransactionContextInterface, auctionID, itemsold string, startPrice, minIncrement int) error {
+	if startPrice <= 0 || minIncrement <= 0 {
+		return fmt.Errorf("invalid start price or minimum increment")
+	}
+
+	auction := Auction{
+		AuctionType:  EnglishAuction,
+		StartPrice:   startPrice,
+		MinIncrement: minIncrement,
+	}
+	return createOpenAuction(ctx, auctionID, itemsold, &auction)
+}
+
+// AcceptPrice is used by an anonymous bidder to accept the current price of a Dutch auction. The price is
+// computed from the timestamp of the transaction, which must be close to the time of the endorsing peers so
+// that a bidder cannot forward-date its proposal to a lower price. The transaction fails if the price is
+// above maxPrice. The transaction ID identifies the winning bid
+func (s *SmartContract) AcceptPrice(ctx contractapi.TransactionContextInterface, auctionID string, maxPrice int) (string, error) {
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return "", err
+	}
+
+	if auctionJSON.AuctionType != DutchAuction {
+		return "", fmt.Errorf("operation is only supported by Dutch auctions")
+	}
+
+	// the first valid accept ends the auction
+	if auctionJSON.Status != "open" {
+		return "", fmt.Errorf("cannot accept the price of a closed or ended auction")
+	}
+
+	err = checkOpenBidder(ctx, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	txTime, err := getCheckedTxTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	price := auctionJSON.currentDutchPrice(txTime)
+	if price > maxPrice {
+		return "", fmt.Errorf("current price %v is higher than %v", price, maxPrice)
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	auctionJSON.WinningBid = txID
+	auctionJSON.Price = price
+	auctionJSON.Status = "ended"
+
+	err = storeIdentityEscrow(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = putAuction(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = setAuctionEvent(ctx, "PriceAccepted", AuctionEvent{AuctionID: auctionID, BidID: txID, Price: price})
+	if err != nil {
+		return "", err
+	}
+	return txID, nil
+}
+
+// PlaceBid is used by an anonymous bidder to place a public bid in an English auction, the seller cannot bid.
+// The transaction ID identifies the bid
+func (s *SmartContract) PlaceBid(ctx contractapi.TransactionContextInterface, auctionID string, price int) (string, error) {
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return "", err
+	}
+
+	if auctionJSON.AuctionType != EnglishAuction {
+		return "", fmt.Errorf("operation is only supported by English auctions")
+	}
+
+	if auctionJSON.Status != "open" {
+		return "", fmt.Errorf("cannot bid on a closed or ended auction")
+	}
+
+	err = checkOpenBidder(ctx, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+
+	minPrice := auctionJSON.StartPrice
+	if auctionJSON.WinningBid != "" {
+		minPrice = auctionJSON.Price + auctionJSON.MinIncrement
+	}
+	if price < minPrice {
+		return "", fmt.Errorf("bid must be at least %v", minPrice)
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	auctionJSON.WinningBid = txID
+	auctionJSON.Price = price
+
+	err = storeIdentityEscrow(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = putAuction(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = setAuctionEvent(ctx, "BidPlaced", AuctionEvent{AuctionID: auctionID, BidID: txID, Price: price})
+	if err != nil {
+		return "", err
+	}
+	return txID, nil
+}
+
+// QueryCurrentPrice returns the price a bidder has to pay at the time of the transaction: the current price
+// of a Dutch auction, or the minimum next bid of an English auction
+func (s *SmartContract) QueryCurrentPrice(ctx contractapi.TransactionContextInterface, auctionID string) (int, error) {
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return 0, err
+	}
+
+	switch auctionJSON.AuctionType {
+	case DutchAuction:
+		txTime, err := getTxTime(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return auctionJSON.currentDutchPrice(txTime), nil
+	case EnglishAuction:
+		if auctionJSON.WinningBid == "" {
+			return auctionJSON.StartPrice, nil
+		}
+		return auctionJSON.Price + auctionJSON.MinIncrement, nil
+	default:
+		return 0, fmt.Errorf("sealed-bid auctions have no public price")
+	}
+}
+
+// currentDutchPrice computes the price of a Dutch auction at the given time
+func (a *Auction) currentDutchPrice(time int64) int {
+	elapsed := time - a.StartTime
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	price := int64(a.StartPrice) - elapsed*int64(a.Decrement)
+	if price < int64(a.ReservePrice) {
+		return a.ReservePrice
+	}
+	return int(price)
+}
+
+// checkOpenBidder is an internal function checking that a bid of an open-outcry auction is submitted by an
+// anonymous DAC bidder and not by the seller, who could otherwise raise the price with shill bids. A seller
+// bidding with a DAC credential of its own is only found by the auditor of the identity escrow
+func checkOpenBidder(ctx contractapi.TransactionContextInterface, auctionJSON *Auction) error {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	if clientID == auctionJSON.Seller {
+		return fmt.Errorf("the seller cannot bid in its own auction")
+	}
+	_, err = getCreatorNym(ctx)
+	return err
+}
+
+// createOpenAuction is an internal function that stores a new open-outcry auction. The identity that
+// submits the transaction becomes the seller of the auction
+func createOpenAuction(ctx contractapi.TransactionContextInterface, auctionID, itemsold string, auction *Auction) error {
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	// get org of submitting client
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	existing, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction %v: %v", auctionID, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("auction %v already exists", auctionID)
+	}
+
+	startTime, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	auction.Type = "auction"
+	auction.ItemSold = itemsold
+	auction.Seller = clientID
+	auction.Commitments = make(map[string][]byte)
+	auction.CommitmentOrder = []string{}
+	auction.EncryptedBids = make(map[string]EncryptedBid)
+	auction.StartTime = startTime
+	auction.Status = "open"
+	auction.Version = AuctionVersion
+
+	err = putAuction(ctx, auctionID, auction)
+	if err != nil {
+		return fmt.Errorf("failed to put auction in public data: %v", err)
+	}
+
+	// set the seller of the auction as an endorser
+	err = setAssetStateBasedEndorsement(ctx, auctionID, clientOrgID)
+	if err != nil {
+		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
+	}
+
+	return nil
+}
+
+// endOpenAuction is an internal function used by EndAuction once the seller has been checked. An English
+// auction is won by the highest bid, a Dutch auction that is ended before a price was accepted has no winner
+func endOpenAuction(ctx contractapi.TransactionContextInterface, auctionID string, auctionJSON *Auction) error {
+	if auctionJSON.Status != "open" {
+		return fmt.Errorf("can only end an open auction")
+	}
+	auctionJSON.Status = "ended"
+
+	err := putAuction(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to end auction: %v", err)
+	}
+	return setAuctionEvent(ctx, "AuctionEnded", AuctionEvent{AuctionID: auctionID, BidID: auctionJSON.WinningBid, Price: auctionJSON.Price})
+}
+
+// setAuctionEvent is an internal function that emits an auction event, only one event can be set per transaction
+func setAuctionEvent(ctx contractapi.TransactionContextInterface, name string, event AuctionEvent) error {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().SetEvent(name, eventBytes)
+	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+	return nil
+}
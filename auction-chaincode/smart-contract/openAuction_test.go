@@ -0,0 +1,71 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// asBidder starts a new transaction submitted by an anonymous bidder with a new nym
+func (l *testLedger) asBidder(d *testDac) *contractapi.TransactionContext {
+	_, pkNym := d.nym(d.credential())
+	return l.asNym(pkNym)
+}
+
+func TestAcceptPriceTimestamp(t *testing.T) {
+	l := newTestLedger(t)
+	d := newTestDac(t)
+	l.must(l.contract.CreateDutchAuction(l.as("seller", "Org1MSP"), "auction1", "item", 1000, 1, 10))
+
+	// a forward-dated proposal would pay a lower price
+	ctx := l.asBidder(d)
+	l.stub.TxTimestamp.Seconds += 600
+	if _, err := l.contract.AcceptPrice(ctx, "auction1", 1000); err == nil {
+		t.Error("a forward-dated proposal was accepted")
+	}
+	ctx = l.asBidder(d)
+	l.stub.TxTimestamp.Seconds -= 600
+	if _, err := l.contract.AcceptPrice(ctx, "auction1", 1000); err == nil {
+		t.Error("a back-dated proposal was accepted")
+	}
+	if _, err := l.contract.AcceptPrice(l.as("bidder", "Org2MSP"), "auction1", 1000); err == nil {
+		t.Error("a price was accepted by a client that is not a DAC bidder")
+	}
+
+	bidID, err := l.contract.AcceptPrice(l.asBidder(d), "auction1", 1000)
+	l.must(err)
+	auction, err := getAuction(l.as("reader", "Org2MSP"), "auction1")
+	l.must(err)
+	if auction.WinningBid != bidID || auction.Status != "ended" || auction.Price < 1000-maxClockSkew {
+		t.Errorf("auction won by %v at %v, status %v", auction.WinningBid, auction.Price, auction.Status)
+	}
+}
+
+func TestPlaceBidder(t *testing.T) {
+	l := newTestLedger(t)
+	d := newTestDac(t)
+	l.must(l.contract.CreateEnglishAuction(l.as("seller", "Org1MSP"), "auction1", "item", 100, 10))
+
+	if _, err := l.contract.PlaceBid(l.as("seller", "Org1MSP"), "auction1", 100); err == nil {
+		t.Error("the seller placed a bid in its own auction")
+	}
+	if _, err := l.contract.PlaceBid(l.as("bidder", "Org2MSP"), "auction1", 100); err == nil {
+		t.Error("a bid was placed by a client that is not a DAC bidder")
+	}
+	_, err := l.contract.PlaceBid(l.asBidder(d), "auction1", 100)
+	l.must(err)
+	if _, err := l.contract.PlaceBid(l.asBidder(d), "auction1", 105); err == nil {
+		t.Error("a bid below the minimum increment was placed")
+	}
+	bidID, err := l.contract.PlaceBid(l.asBidder(d), "auction1", 110)
+	l.must(err)
+	auction, err := getAuction(l.as("reader", "Org2MSP"), "auction1")
+	l.must(err)
+	if auction.WinningBid != bidID || auction.Price != 110 {
+		t.Errorf("highest bid %v at %v", auction.WinningBid, auction.Price)
+	}
+}
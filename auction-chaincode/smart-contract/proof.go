@@ -0,0 +1,42 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"fmt"
+
+	"github.com/ckiere/test-network/auction-circuit/verifier"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// verifyWinner checks the invalid set and the proof that winningBidId is the highest of the bids that are not
+// excluded, with the verifying key of the auction. The checks are shared with the auditor of client-auctioneer
+func verifyWinner(ctx contractapi.TransactionContextInterface, auctionJSON *Auction, winningBidId string, proof []byte, invalidSet string) error {
+	if len(auctionJSON.CommitmentOrder) > auctionJSON.MaxBids {
+		return fmt.Errorf("%v bids, the proof system of the auction allows at most %v", len(auctionJSON.CommitmentOrder), auctionJSON.MaxBids)
+	}
+	bids := verifier.SealedBids{CommitmentOrder: auctionJSON.CommitmentOrder, Commitments: auctionJSON.Commitments,
+		EncryptedBids: make(map[string][]byte), SellerPk: &auctionJSON.SellerPk}
+	for txID, encryptedBid := range auctionJSON.EncryptedBids {
+		bids.EncryptedBids[txID] = encryptedBid.Data
+	}
+	_, err := verifier.VerifyWinner(&bids, func() (*verifier.VerifyingKey, error) {
+		return auctionVerifyingKey(ctx, auctionJSON)
+	}, winningBidId, proof, invalidSet)
+	return err
+}
+
+// addCommitment is an internal helper function adding a commitment to a sealed-bid auction. The proof of the
+// winner covers at most MaxBids commitments, a commitment more would keep the winner from being declared
+func addCommitment(auctionJSON *Auction, txID string, comBytes []byte) error {
+	if auctionJSON.MaxBids > 0 && len(auctionJSON.CommitmentOrder) >= auctionJSON.MaxBids {
+		return fmt.Errorf("the auction already has %v bids, the proof system of the auction allows at most %v", len(auctionJSON.CommitmentOrder), auctionJSON.MaxBids)
+	}
+	auctionJSON.Commitments[txID] = comBytes
+	// every commitment updates the auction, so the order of the list is the order of the
+	// transactions on the ledger. It is used to break ties between equal winning bids
+	auctionJSON.CommitmentOrder = append(auctionJSON.CommitmentOrder, txID)
+	return nil
+}
@@ -0,0 +1,251 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ckiere/test-network/auction-circuit/verifier"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const verifyingKeyType = "verifyingKey"
+
+// adminMSPID is the organization whose admins can register verifying keys and set the DAC configuration. An
+// admin has the admin OU of the node OUs of the organization, or the attribute adminAttribute set to true by its CA
+const adminMSPID = "Org1MSP"
+const adminOU = "admin"
+const adminAttribute = "auction.admin"
+
+// VerifyingKeyEntry is a verifying key of the registry. CircuitHash is the hash of the circuit the key was
+// generated for, as listed in the manifest of zk-generator and checked by its ceremony-verify. New auctions can pin the key between
+// ValidFrom and ValidUntil, in seconds since the epoch
+type VerifyingKeyEntry struct {
+	Type        string `json:"objectType"`
+	ID          string `json:"id"`
+	ProofSystem string `json:"proofSystem"`
+	MaxBids     int    `json:"maxBids"`
+	CircuitHash string `json:"circuitHash"`
+	Key         []byte `json:"key"`
+	ValidFrom   int64  `json:"validFrom"`
+	ValidUntil  int64  `json:"validUntil"`
+}
+
+// parsedKeys caches the verifying keys parsed by the chaincode process, by the hash of their proof system
+// and encoding. Parsing a key checks all its points, which costs more than verifying a proof
+var parsedKeys = struct {
+	sync.Mutex
+	keys map[[sha256.Size]byte]*verifier.VerifyingKey
+}{keys: make(map[[sha256.Size]byte]*verifier.VerifyingKey)}
+
+// RegisterVerifyingKey adds a verifying key generated by zk-generator to the registry, only clients of the
+// admin organization can register keys. proofSystem is groth16, verifyingKey is base64 encoded.
+// A registered key cannot be replaced, the auctions that pinned it keep verifying against it
+func (s *SmartContract) RegisterVerifyingKey(ctx contractapi.TransactionContextInterface, keyID, proofSystem, verifyingKey, circuitHash string, validFrom, validUntil int64) error {
+	err := checkAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if keyID == "" {
+		return fmt.Errorf("invalid key ID")
+	}
+	vkBytes, err := base64.StdEncoding.DecodeString(verifyingKey)
+	if err != nil {
+		return fmt.Errorf("invalid verifying key format")
+	}
+	hashBytes, err := hex.DecodeString(circuitHash)
+	if err != nil || len(hashBytes) != sha256.Size {
+		return fmt.Errorf("invalid circuit hash")
+	}
+	if validUntil <= validFrom {
+		return fmt.Errorf("invalid validity period")
+	}
+	key, err := parseVerifyingKey(proofSystem, vkBytes)
+	if err != nil {
+		return err
+	}
+
+	existing, err := getVerifyingKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("verifying key %v is already registered", keyID)
+	}
+	entry := VerifyingKeyEntry{
+		Type:        "verifyingKey",
+		ID:          keyID,
+		ProofSystem: proofSystem,
+		MaxBids:     key.MaxBids(),
+		CircuitHash: hex.EncodeToString(hashBytes),
+		Key:         vkBytes,
+		ValidFrom:   validFrom,
+		ValidUntil:  validUntil,
+	}
+	return putVerifyingKey(ctx, &entry)
+}
+
+// SetVerifyingKeyValidity changes the validity period of a registered key, e.g. to stop new auctions from
+// pinning a key. Only clients of the admin organization can change it
+func (s *SmartContract) SetVerifyingKeyValidity(ctx contractapi.TransactionContextInterface, keyID string, validFrom, validUntil int64) error {
+	err := checkAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if validUntil <= validFrom {
+		return fmt.Errorf("invalid validity period")
+	}
+	entry, err := getVerifyingKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("verifying key %v not found", keyID)
+	}
+	entry.ValidFrom = validFrom
+	entry.ValidUntil = validUntil
+	return putVerifyingKey(ctx, entry)
+}
+
+// QueryVerifyingKey returns a key of the registry
+func (s *SmartContract) QueryVerifyingKey(ctx contractapi.TransactionContextInterface, keyID string) (*VerifyingKeyEntry, error) {
+	entry, err := getVerifyingKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("verifying key %v not found", keyID)
+	}
+	return entry, nil
+}
+
+// checkAdmin is an internal helper function checking the submitting client is an admin of adminMSPID, who can
+// manage the registry and the DAC configuration
+func checkAdmin(ctx contractapi.TransactionContextInterface) error {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	if clientOrgID != adminMSPID {
+		return fmt.Errorf("client is not authorized to manage the configuration of the chaincode")
+	}
+	if ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true") == nil {
+		return nil
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to get client certificate %v", err)
+	}
+	if cert != nil {
+		for _, ou := range cert.Subject.OrganizationalUnit {
+			if ou == adminOU {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("client is not an admin of %v", adminMSPID)
+}
+
+// pinVerifyingKey is an internal helper function pinning a key of the registry to a new auction, the key
+// must be valid at the time of the transaction
+func pinVerifyingKey(ctx contractapi.TransactionContextInterface, auctionJSON *Auction, keyID string) error {
+	if keyID == "" {
+		return fmt.Errorf("auction requires the ID of a verifying key")
+	}
+	entry, err := getVerifyingKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("verifying key %v not found", keyID)
+	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if now < entry.ValidFrom || now >= entry.ValidUntil {
+		return fmt.Errorf("verifying key %v is not valid", keyID)
+	}
+	auctionJSON.VerifyingKeyID = keyID
+	auctionJSON.ProofSystem = entry.ProofSystem
+	auctionJSON.MaxBids = entry.MaxBids
+	return nil
+}
+
+// auctionVerifyingKey is an internal helper function returning the parsed key the proof of the winner of an
+// auction is verified against: the key it pinned, or the key set with the auction before the registry
+func auctionVerifyingKey(ctx contractapi.TransactionContextInterface, auctionJSON *Auction) (*verifier.VerifyingKey, error) {
+	if auctionJSON.VerifyingKeyID == "" {
+		return parseVerifyingKey(auctionJSON.ProofSystem, auctionJSON.VerifyingKey)
+	}
+	entry, err := getVerifyingKey(ctx, auctionJSON.VerifyingKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("verifying key %v not found", auctionJSON.VerifyingKeyID)
+	}
+	return parseVerifyingKey(entry.ProofSystem, entry.Key)
+}
+
+// parseVerifyingKey is an internal helper function parsing a verifying key, or returning it from the cache
+// of the chaincode process
+func parseVerifyingKey(proofSystem string, vk []byte) (*verifier.VerifyingKey, error) {
+	hash := sha256.Sum256(append([]byte(proofSystem+"\x00"), vk...))
+	parsedKeys.Lock()
+	defer parsedKeys.Unlock()
+	if key, ok := parsedKeys.keys[hash]; ok {
+		return key, nil
+	}
+	key, err := verifier.ParseVerifyingKey(proofSystem, vk)
+	if err != nil {
+		return nil, err
+	}
+	parsedKeys.keys[hash] = key
+	return key, nil
+}
+
+// getVerifyingKey is an internal helper function to read a key of the registry, nil if it does not exist
+func getVerifyingKey(ctx contractapi.TransactionContextInterface, keyID string) (*VerifyingKeyEntry, error) {
+	registryKey, err := ctx.GetStub().CreateCompositeKey(verifyingKeyType, []string{keyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	entryBytes, err := ctx.GetStub().GetState(registryKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verifying key: %v", err)
+	}
+	if entryBytes == nil {
+		return nil, nil
+	}
+	var entry VerifyingKeyEntry
+	err = json.Unmarshal(entryBytes, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create verifying key object JSON: %v", err)
+	}
+	return &entry, nil
+}
+
+// putVerifyingKey is an internal helper function to write a key of the registry
+func putVerifyingKey(ctx contractapi.TransactionContextInterface, entry *VerifyingKeyEntry) error {
+	registryKey, err := ctx.GetStub().CreateCompositeKey(verifyingKeyType, []string{entry.ID})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(registryKey, entryBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store verifying key: %v", err)
+	}
+	return nil
+}
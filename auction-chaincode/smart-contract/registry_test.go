@@ -0,0 +1,31 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"testing"
+)
+
+func TestCheckAdmin(t *testing.T) {
+	l := newTestLedger(t)
+	l.must(checkAdmin(l.asAdmin()))
+	l.must(checkAdmin(l.asIdentity(&testIdentity{id: "operator", mspID: adminMSPID,
+		attrs: map[string]string{adminAttribute: "true"}})))
+
+	refused := map[string]*testIdentity{
+		"a client of the admin organization": {id: "alice", mspID: adminMSPID, ous: []string{"client"}},
+		"an admin of another organization":   {id: "admin", mspID: "Org2MSP", ous: []string{adminOU}},
+		"a client whose attribute is false": {id: "operator", mspID: adminMSPID, ous: []string{"client"},
+			attrs: map[string]string{adminAttribute: "false"}},
+	}
+	for name, identity := range refused {
+		if err := checkAdmin(l.asIdentity(identity)); err == nil {
+			t.Errorf("%v is an admin", name)
+		}
+	}
+	if err := l.contract.SetDacConfig(l.as("alice", adminMSPID), "", ""); err == nil {
+		t.Error("a client of the admin organization set the DAC configuration")
+	}
+}
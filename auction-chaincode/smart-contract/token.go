@@ -0,0 +1,115 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const balanceKeyType = "balance"
+
+// minterMSPID is the organization whose clients can mint tokens
+const minterMSPID = "Org1MSP"
+
+// Mint creates new tokens on the account of the submitting client, only clients of the minter
+// organization can mint tokens
+func (s *SmartContract) Mint(ctx contractapi.TransactionContextInterface, amount int) error {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	if clientOrgID != minterMSPID {
+		return fmt.Errorf("client is not authorized to mint new tokens")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("mint amount must be a positive integer")
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	return addBalance(ctx, clientID, amount)
+}
+
+// Transfer moves tokens from the account of the submitting client to the recipient account
+func (s *SmartContract) Transfer(ctx contractapi.TransactionContextInterface, recipient string, amount int) error {
+	if amount <= 0 {
+		return fmt.Errorf("transfer amount must be a positive integer")
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	return transferTokens(ctx, clientID, recipient, amount)
+}
+
+// BalanceOf returns the balance of the given account
+func (s *SmartContract) BalanceOf(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	return getBalance(ctx, account)
+}
+
+// ClientAccountBalance returns the balance of the submitting client
+func (s *SmartContract) ClientAccountBalance(ctx contractapi.TransactionContextInterface) (int, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get client identity %v", err)
+	}
+	return getBalance(ctx, clientID)
+}
+
+// getBalance is an internal helper function returning the balance of an account
+func getBalance(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	balanceKey, err := ctx.GetStub().CreateCompositeKey(balanceKeyType, []string{account})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	balanceBytes, err := ctx.GetStub().GetState(balanceKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read balance: %v", err)
+	}
+	if balanceBytes == nil {
+		return 0, nil
+	}
+	return strconv.Atoi(string(balanceBytes))
+}
+
+// addBalance is an internal helper function adding delta to the balance of an account, the
+// balance cannot become negative
+func addBalance(ctx contractapi.TransactionContextInterface, account string, delta int) error {
+	balance, err := getBalance(ctx, account)
+	if err != nil {
+		return err
+	}
+	if balance+delta < 0 {
+		return fmt.Errorf("insufficient funds")
+	}
+
+	balanceKey, err := ctx.GetStub().CreateCompositeKey(balanceKeyType, []string{account})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	err = ctx.GetStub().PutState(balanceKey, []byte(strconv.Itoa(balance+delta)))
+	if err != nil {
+		return fmt.Errorf("failed to update balance: %v", err)
+	}
+	return nil
+}
+
+// transferTokens is an internal helper function moving tokens between two accounts
+func transferTokens(ctx contractapi.TransactionContextInterface, from, to string, amount int) error {
+	if from == to {
+		return nil
+	}
+	err := addBalance(ctx, from, -amount)
+	if err != nil {
+		return err
+	}
+	return addBalance(ctx, to, amount)
+}
@@ -0,0 +1,44 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"testing"
+)
+
+func TestMint(t *testing.T) {
+	l := newTestLedger(t)
+	if err := l.contract.Mint(l.as("bob", "Org2MSP"), 10); err == nil {
+		t.Error("only the minter organization can mint tokens")
+	}
+	if err := l.contract.Mint(l.as("alice", "Org1MSP"), 0); err == nil {
+		t.Error("a mint of 0 tokens was accepted")
+	}
+	l.must(l.contract.Mint(l.as("alice", "Org1MSP"), 10))
+	l.must(l.contract.Mint(l.as("alice", "Org1MSP"), 5))
+
+	balance, err := l.contract.ClientAccountBalance(l.as("alice", "Org1MSP"))
+	l.must(err)
+	if balance != 15 || l.balance("bob") != 0 {
+		t.Errorf("balances %v and %v after minting, expected 15 and 0", balance, l.balance("bob"))
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	l := newTestLedger(t)
+	l.must(l.contract.Mint(l.as("alice", "Org1MSP"), 10))
+
+	if err := l.contract.Transfer(l.as("alice", "Org1MSP"), "bob", 11); err == nil {
+		t.Error("a transfer larger than the balance was accepted")
+	}
+	if err := l.contract.Transfer(l.as("alice", "Org1MSP"), "bob", -1); err == nil {
+		t.Error("a negative transfer was accepted")
+	}
+	l.must(l.contract.Transfer(l.as("alice", "Org1MSP"), "bob", 4))
+	l.must(l.contract.Transfer(l.as("alice", "Org1MSP"), "alice", 6))
+	if l.balance("alice") != 6 || l.balance("bob") != 4 {
+		t.Errorf("balances %v and %v after the transfer, expected 6 and 4", l.balance("alice"), l.balance("bob"))
+	}
+}
@@ -0,0 +1,151 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	pedersen "github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/scopetag"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const tagKeyType = "tag"
+
+// RequireOneBidPerCredential can be used by the seller of a sealed-bid auction, before any commitment is
+// sent, to allow a single bid per DAC credential. Bidders then have to send the scope-exclusive pseudonym
+// of their credential for the auction, which is unlinkable between auctions. The tags are verified with the
+// DAC configuration set by the admin
+func (s *SmartContract) RequireOneBidPerCredential(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	_, err := getDacConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+
+	Seller := auctionJSON.Seller
+	if Seller != clientID {
+		return fmt.Errorf("rules of the auction can only be changed by seller")
+	}
+
+	if !auctionJSON.isSealedBid() {
+		return fmt.Errorf("operation is only supported by sealed-bid auctions")
+	}
+	if auctionJSON.Status != "open" || len(auctionJSON.Commitments) > 0 {
+		return fmt.Errorf("rules can only be changed before the first commitment")
+	}
+
+	auctionJSON.OneBidPerCredential = true
+	return putAuction(ctx, auctionID, auctionJSON)
+}
+
+// SendUniqueCommitment is used by the anonymous bidders to submit a commitment to a bid in an auction that
+// allows one bid per credential. The tag is the scope-exclusive pseudonym of the bidder's credential for the
+// auction, and tagProof proves that it belongs to the nym that submits the transaction
+func (s *SmartContract) SendUniqueCommitment(ctx contractapi.TransactionContextInterface, auctionID, commitment, proof, tag, tagProof string) (string, error) {
+	// verify the proof of knowledge of opening values
+	comBytes, err := base64.StdEncoding.DecodeString(commitment)
+	if err != nil {
+		return "", err
+	}
+	proofBytes, err := base64.StdEncoding.DecodeString(proof)
+	if err != nil {
+		return "", err
+	}
+	if !pedersen.CheckCommitProofBytes(proofBytes, comBytes, nil) {
+		return "", fmt.Errorf("invalid proof")
+	}
+	tagBytes, err := base64.StdEncoding.DecodeString(tag)
+	if err != nil {
+		return "", err
+	}
+	tagProofBytes, err := base64.StdEncoding.DecodeString(tagProof)
+	if err != nil {
+		return "", err
+	}
+
+	auctionJSON, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return "", err
+	}
+
+	if !auctionJSON.isSealedBid() || !auctionJSON.OneBidPerCredential {
+		return "", fmt.Errorf("auction does not restrict bids per credential, use SendCommitment")
+	}
+
+	// the auction needs to be open for users to add their bid
+	if auctionJSON.Status != "open" {
+		return "", fmt.Errorf("cannot join closed or ended auction")
+	}
+
+	// the tag must belong to the nym that submits the transaction
+	nymBytes, err := getCreatorNym(ctx)
+	if err != nil {
+		return "", err
+	}
+	dacConfig, err := getDacConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	err = scopetag.Verify(dacConfig.H, nymBytes, tagBytes, tagProofBytes, auctionID, comBytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag: %v", err)
+	}
+
+	// reject a second commitment from the same credential
+	tagHash := sha256.Sum256(tagBytes)
+	tagKey, err := ctx.GetStub().CreateCompositeKey(tagKeyType, []string{auctionID, fmt.Sprintf("%x", tagHash)})
+	if err != nil {
+		return "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+	usedBy, err := ctx.GetStub().GetState(tagKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read tag: %v", err)
+	}
+	if usedBy != nil {
+		return "", fmt.Errorf("credential already sent a commitment to this auction")
+	}
+
+	// use the transaction ID as a key for the commitment
+	txID := ctx.GetStub().GetTxID()
+	err = addCommitment(auctionJSON, txID, comBytes)
+	if err != nil {
+		return "", err
+	}
+
+	err = storeIdentityEscrow(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = bindDeposit(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = ctx.GetStub().PutState(tagKey, []byte(txID))
+	if err != nil {
+		return "", fmt.Errorf("failed to store tag: %v", err)
+	}
+	err = putAuction(ctx, auctionID, auctionJSON)
+	if err != nil {
+		return "", err
+	}
+	err = setAuctionEvent(ctx, "CommitmentSent", AuctionEvent{AuctionID: auctionID, BidID: txID})
+	if err != nil {
+		return "", err
+	}
+	return txID, nil
+}
@@ -0,0 +1,128 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	pedersen "github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/scopetag"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/dbogatov/fabric-amcl/amcl"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// testDac is a DAC configuration of the tests, with the random generator of its credentials
+type testDac struct {
+	prg *amcl.RAND
+	h   *FP256BN.ECP2
+}
+
+func newTestDac(t *testing.T) *testDac {
+	var seed [32]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		t.Fatal(err)
+	}
+	prg := amcl.NewRAND()
+	prg.Seed(len(seed), seed[:])
+	q := FP256BN.NewBIGints(FP256BN.CURVE_Order)
+	return &testDac{prg: prg, h: FP256BN.ECP2_generator().Mul(FP256BN.Randomnum(q, prg))}
+}
+
+func (d *testDac) hBase64() string {
+	hBytes := make([]byte, scopetag.PointSize)
+	d.h.ToBytes(hBytes)
+	return base64.StdEncoding.EncodeToString(hBytes)
+}
+
+// credential returns the secret key of a new credential
+func (d *testDac) credential() *FP256BN.BIG {
+	return FP256BN.Randomnum(FP256BN.NewBIGints(FP256BN.CURVE_Order), d.prg)
+}
+
+// nym returns a new nym of the credential sk
+func (d *testDac) nym(sk *FP256BN.BIG) (skNym *FP256BN.BIG, pkNym *FP256BN.ECP2) {
+	skNym = FP256BN.Randomnum(FP256BN.NewBIGints(FP256BN.CURVE_Order), d.prg)
+	pkNym = FP256BN.ECP2_generator().Mul(sk)
+	pkNym.Add(d.h.Mul(skNym))
+	return
+}
+
+// asNym starts a new transaction submitted by an anonymous bidder with the nym pkNym
+func (l *testLedger) asNym(pkNym *FP256BN.ECP2) *contractapi.TransactionContext {
+	ctx := l.as("bidder", "DacMSP")
+	nymBytes := make([]byte, scopetag.PointSize)
+	pkNym.ToBytes(nymBytes)
+	idBytes, err := proto.Marshal(&msp.SerializedIdemixIdentity{NymX: nymBytes[:len(nymBytes)/2], NymY: nymBytes[len(nymBytes)/2:]})
+	l.must(err)
+	l.stub.Creator, err = proto.Marshal(&msp.SerializedIdentity{Mspid: "DacMSP", IdBytes: idBytes})
+	l.must(err)
+	return ctx
+}
+
+// testCommitment returns a commitment to a bid and the proof of its opening, base64 encoded
+func (l *testLedger) testCommitment() (comBytes []byte, com string, proof string) {
+	c, r, err := pedersen.Commit(50)
+	l.must(err)
+	comBytes = wire.MarshalCommitment(c)
+	p, err := pedersen.ProveCommit(50, r, comBytes, nil)
+	l.must(err)
+	return comBytes, base64.StdEncoding.EncodeToString(comBytes), base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(p))
+}
+
+// sendUniqueBid sends a commitment from a new nym of the credential sk, with the tag of the credential tagSk for the
+// auction
+func (l *testLedger) sendUniqueBid(d *testDac, auctionID string, sk, tagSk *FP256BN.BIG) error {
+	skNym, pkNym := d.nym(sk)
+	comBytes, com, proof := l.testCommitment()
+	tag, tagProof := scopetag.Prove(d.prg, d.h, pkNym, tagSk, skNym, auctionID, comBytes)
+	_, err := l.contract.SendUniqueCommitment(l.asNym(pkNym), auctionID, com, proof, base64.StdEncoding.EncodeToString(tag),
+		base64.StdEncoding.EncodeToString(tagProof))
+	return err
+}
+
+func TestOneBidPerCredential(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	d := newTestDac(t)
+	if err := l.contract.RequireOneBidPerCredential(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("one bid per credential required without a DAC configuration")
+	}
+	if err := l.contract.SetDacConfig(l.as("seller", "Org2MSP"), d.hBase64(), ""); err == nil {
+		t.Error("the DAC configuration was set by a client of another organization than the admin")
+	}
+	if err := l.contract.SetDacConfig(l.asAdmin(), base64.StdEncoding.EncodeToString([]byte("h")), ""); err == nil {
+		t.Error("a DAC parameter h that is not a point was accepted")
+	}
+	l.must(l.contract.SetDacConfig(l.asAdmin(), d.hBase64(), ""))
+	if err := l.contract.RequireOneBidPerCredential(l.as("bidder", "Org1MSP"), "auction1"); err == nil {
+		t.Error("the rules were changed by another client than the seller")
+	}
+	l.must(l.contract.RequireOneBidPerCredential(l.as("seller", "Org1MSP"), "auction1"))
+
+	alice := d.credential()
+	bob := d.credential()
+	if err := l.sendUniqueBid(d, "auction1", alice, bob); err == nil {
+		t.Error("a bid with the tag of another credential was accepted")
+	}
+	l.must(l.sendUniqueBid(d, "auction1", alice, alice))
+	// every nym of a credential has the same tag in the auction
+	if err := l.sendUniqueBid(d, "auction1", alice, alice); err == nil {
+		t.Error("a second bid from the same credential was accepted")
+	}
+	l.must(l.sendUniqueBid(d, "auction1", bob, bob))
+
+	// the tags are verified with the h of the configuration
+	other := newTestDac(t)
+	carol := other.credential()
+	if err := l.sendUniqueBid(other, "auction1", carol, carol); err == nil {
+		t.Error("a bid from a credential of another DAC configuration was accepted")
+	}
+}
@@ -5,10 +5,14 @@ SPDX-License-Identifier: Apache-2.0
 package auction
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/msp"
 )
 
 // setAssetStateBasedEndorsement sets the endorsement policy of a new auction
@@ -32,4 +36,81 @@ func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface,
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// getAuction is an internal helper function to read an auction from the public state
+func getAuction(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
+	auctionBytes, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction %v: %v", auctionID, err)
+	}
+	if auctionBytes == nil {
+		return nil, fmt.Errorf("Auction interest object %v not found", auctionID)
+	}
+
+	var auctionJSON Auction
+	err = json.Unmarshal(auctionBytes, &auctionJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auction object JSON: %v", err)
+	}
+	return &auctionJSON, nil
+}
+
+// putAuction is an internal helper function to write an auction to the public state
+func putAuction(ctx contractapi.TransactionContextInterface, auctionID string, auctionJSON *Auction) error {
+	auctionBytes, err := json.Marshal(auctionJSON)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(auctionID, auctionBytes)
+	if err != nil {
+		return fmt.Errorf("failed to update auction: %v", err)
+	}
+	return nil
+}
+
+// getTxTime is an internal helper function returning the timestamp of the transaction in seconds
+func getTxTime(ctx contractapi.TransactionContextInterface) (int64, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	return timestamp.GetSeconds(), nil
+}
+
+// maxClockSkew is the largest difference in seconds between the timestamp of a transaction whose outcome depends
+// on time and the clock of the endorsing peer
+const maxClockSkew = 10
+
+// getCheckedTxTime is an internal helper function returning the timestamp of the transaction in seconds. The
+// client sets the timestamp of its proposal, the endorsing peers reject it if it is too far from their clock
+func getCheckedTxTime(ctx contractapi.TransactionContextInterface) (int64, error) {
+	txTime, err := getTxTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().Unix()
+	if txTime > now+maxClockSkew || txTime < now-maxClockSkew {
+		return 0, fmt.Errorf("transaction timestamp %v is more than %v seconds from the time of the peer", txTime, maxClockSkew)
+	}
+	return txTime, nil
+}
+
+// getCreatorNym is an internal helper function returning the DAC nym of the submitting client
+func getCreatorNym(ctx contractapi.TransactionContextInterface) ([]byte, error) {
+	creator, err := ctx.GetStub().GetCreator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator: %v", err)
+	}
+	serializedIdentity := &msp.SerializedIdentity{}
+	err = proto.Unmarshal(creator, serializedIdentity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal creator: %v", err)
+	}
+	dacIdentity := &msp.SerializedIdemixIdentity{}
+	err = proto.Unmarshal(serializedIdentity.IdBytes, dacIdentity)
+	if err != nil || len(dacIdentity.NymX) == 0 || len(dacIdentity.NymY) == 0 {
+		return nil, fmt.Errorf("submitting client is not an anonymous DAC identity")
+	}
+	return append(append([]byte{}, dacIdentity.NymX...), dacIdentity.NymY...), nil
+}
@@ -0,0 +1,91 @@
+// Package circuit defines the circuit proving the winner of a sealed-bid auction, and builds its witnesses
+package circuit
+
+import (
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/twistededwards"
+)
+
+// AuctionCircuit proves that the winning commitment opens to the highest of the committed bids, the
+// commitments are public and the bids are secret. Every commitment of the auction is public, a commitment
+// can only be left out of the comparison if it is excluded for a reason the chaincode checks
+type AuctionCircuit struct {
+	// struct tags on a variable is optional
+	// default uses variable name and secret visibility.
+	Values []frontend.Variable
+	Rs []frontend.Variable
+	ComsX []frontend.Variable `gnark:",public"`
+	ComsY []frontend.Variable `gnark:",public"`
+	// 1 for the commitments that are not revealed or whose opening is invalid, they open to the identity in the witness
+	Excluded []frontend.Variable `gnark:",public"`
+	WinningValue frontend.Variable
+	WinningR frontend.Variable
+	WinningComX frontend.Variable `gnark:",public"`
+	WinningComY frontend.Variable `gnark:",public"`
+	// position of the winning bid in the commitment order, ties are broken in favour of the earliest bid
+	WinningIndex frontend.Variable `gnark:",public"`
+	WinningSelector []frontend.Variable
+}
+
+// NewAuctionCircuit creates a circuit for auctions with at most maxBids bids
+func NewAuctionCircuit(maxBids int) *AuctionCircuit {
+	return &AuctionCircuit{
+		Values:          make([]frontend.Variable, maxBids),
+		Rs:              make([]frontend.Variable, maxBids),
+		ComsX:           make([]frontend.Variable, maxBids),
+		ComsY:           make([]frontend.Variable, maxBids),
+		Excluded:        make([]frontend.Variable, maxBids),
+		WinningSelector: make([]frontend.Variable, maxBids),
+	}
+}
+
+// Define declares the circuit constraints
+func (circuit *AuctionCircuit) Define(curveID ecc.ID, cs *frontend.ConstraintSystem) error {
+	// constants
+	curve, _ := twistededwards.NewEdCurve(ecc.BLS12_381)
+	// check winning commitment
+	circuit.CheckCommitment(curve, circuit.WinningValue, circuit.WinningR, circuit.WinningComX, circuit.WinningComY, cs)
+	// the selector is a one-hot encoding of the winning index
+	selected := cs.Constant(0)
+	index := cs.Constant(0)
+	for i := range circuit.WinningSelector {
+		cs.AssertIsBoolean(circuit.WinningSelector[i])
+		selected = cs.Add(selected, circuit.WinningSelector[i])
+		index = cs.Add(index, cs.Mul(circuit.WinningSelector[i], i))
+	}
+	cs.AssertIsEqual(selected, 1)
+	cs.AssertIsEqual(index, circuit.WinningIndex)
+	// check all other bids (valid commitment and value lower than winning bid)
+	seen := cs.Constant(0)
+	for i := range circuit.Values {
+		// excluded bids are checked against the identity, the commitment to 0 with randomness 0
+		cs.AssertIsBoolean(circuit.Excluded[i])
+		included := cs.Sub(1, circuit.Excluded[i])
+		comX := cs.Mul(included, circuit.ComsX[i])
+		comY := cs.Add(cs.Mul(included, circuit.ComsY[i]), circuit.Excluded[i])
+		circuit.CheckCommitment(curve, circuit.Values[i], circuit.Rs[i], comX, comY, cs)
+		// the selected bid must be the winning commitment, and cannot be excluded
+		cs.AssertIsEqual(cs.Mul(circuit.WinningSelector[i], cs.Sub(circuit.ComsX[i], circuit.WinningComX)), 0)
+		cs.AssertIsEqual(cs.Mul(circuit.WinningSelector[i], cs.Sub(circuit.ComsY[i], circuit.WinningComY)), 0)
+		cs.AssertIsEqual(cs.Mul(circuit.WinningSelector[i], circuit.Excluded[i]), 0)
+		// bids placed before the winning bid must be strictly lower, bids placed after it can be equal
+		seen = cs.Add(seen, circuit.WinningSelector[i])
+		before := cs.Mul(cs.Sub(1, seen), included)
+		cs.AssertIsLessOrEqual(cs.Add(circuit.Values[i], before), circuit.WinningValue)
+	}
+	return nil
+}
+
+// CheckCommitment asserts that (comX, comY) is the Pedersen commitment to value with randomness r
+func (circuit *AuctionCircuit) CheckCommitment(curve twistededwards.EdCurve, value, r, comX, comY frontend.Variable, cs *frontend.ConstraintSystem) {
+	// com = g^value h^r
+	com := twistededwards.Point{}
+	com.ScalarMulFixedBase(cs, curve.BaseX, curve.BaseY, value, curve)
+	temp := twistededwards.Point{}
+	temp.ScalarMulFixedBase(cs, &commitment.Hx, &commitment.Hy, r, curve)
+	com.AddGeneric(cs, &com, &temp, curve)
+	cs.AssertIsEqual(com.X, comX)
+	cs.AssertIsEqual(com.Y, comY)
+}
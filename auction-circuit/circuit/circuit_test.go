@@ -0,0 +1,167 @@
+package circuit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+const testMaxBids = 3
+
+func testBids(values ...int) []Bid {
+	bids := make([]Bid, len(values))
+	for i, v := range values {
+		r := big.NewInt(int64(1000 + i))
+		bids[i] = Bid{Commitment: *commitment.CommitWith(v, r), Value: v, R: r}
+	}
+	return bids
+}
+
+// reassign replaces the value of an assigned variable
+func reassign(v *frontend.Variable, value interface{}) {
+	*v = frontend.Variable{}
+	v.Assign(value)
+}
+
+func TestWitness(t *testing.T) {
+	r1cs, err := frontend.Compile(ecc.BLS12_381, backend.GROTH16, NewAuctionCircuit(testMaxBids))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two equal highest bids, the earliest one wins
+	bids := testBids(300, 500, 500)
+	if Winner(bids) != 1 {
+		t.Fatalf("winner %v, want 1", Winner(bids))
+	}
+	witness, err := NewWitness(testMaxBids, bids, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.IsSolved(r1cs, witness); err != nil {
+		t.Fatalf("witness of the earliest highest bid: %v", err)
+	}
+	witness, _ = NewWitness(testMaxBids, bids, 2)
+	if err := groth16.IsSolved(r1cs, witness); err == nil {
+		t.Error("witness of the latest highest bid solves the circuit")
+	}
+	witness, _ = NewWitness(testMaxBids, bids, 0)
+	if err := groth16.IsSolved(r1cs, witness); err == nil {
+		t.Error("witness of a lower bid solves the circuit")
+	}
+
+	// unused slots hold the identity
+	witness, err = NewWitness(testMaxBids, bids[:1], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.IsSolved(r1cs, witness); err != nil {
+		t.Fatalf("witness with unused slots: %v", err)
+	}
+
+	// an excluded bid can be higher than the winner, but cannot win
+	bids = testBids(300, 900, 500)
+	bids[1].Excluded = true
+	if Winner(bids) != 2 {
+		t.Fatalf("winner %v, want 2", Winner(bids))
+	}
+	witness, err = NewWitness(testMaxBids, bids, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.IsSolved(r1cs, witness); err != nil {
+		t.Fatalf("witness with an excluded bid: %v", err)
+	}
+	if _, err := NewWitness(testMaxBids, bids, 1); err == nil {
+		t.Error("witness of an excluded winner built")
+	}
+	// an included bid higher than the winner cannot be hidden by the witness
+	witness, _ = NewWitness(testMaxBids, bids, 2)
+	reassign(&witness.Values[1], 900)
+	reassign(&witness.Rs[1], bids[1].R)
+	reassign(&witness.Excluded[1], 0)
+	if err := groth16.IsSolved(r1cs, witness); err == nil {
+		t.Error("witness with a higher included bid solves the circuit")
+	}
+	// an excluded slot does not open its commitment
+	witness, _ = NewWitness(testMaxBids, bids, 2)
+	reassign(&witness.Values[1], 900)
+	reassign(&witness.Rs[1], bids[1].R)
+	if err := groth16.IsSolved(r1cs, witness); err == nil {
+		t.Error("witness with an opened excluded slot solves the circuit")
+	}
+	// the winning slot cannot be excluded
+	witness, _ = NewWitness(testMaxBids, bids, 2)
+	reassign(&witness.Values[2], 0)
+	reassign(&witness.Rs[2], 0)
+	reassign(&witness.Excluded[2], 1)
+	if err := groth16.IsSolved(r1cs, witness); err == nil {
+		t.Error("witness with an excluded winner solves the circuit")
+	}
+
+	if _, err := NewWitness(testMaxBids, testBids(1, 2, 3, 4), 3); err == nil {
+		t.Error("witness with too many bids built")
+	}
+	if _, err := NewWitness(testMaxBids, bids, 3); err == nil {
+		t.Error("witness with the winning index of an unused slot built")
+	}
+}
+
+// the public inputs of a witness are the ones the chaincode rebuilds from the commitments on the ledger
+func TestPublicInputs(t *testing.T) {
+	bids := testBids(700, 200)
+	bids[1].Excluded = true
+	coms := make([]twistededwards.PointAffine, len(bids))
+	comBytes := make([][]byte, len(bids))
+	excluded := make([]bool, len(bids))
+	for i := range bids {
+		coms[i] = bids[i].Commitment
+		comBytes[i] = wire.MarshalCommitment(&coms[i])
+		excluded[i] = bids[i].Excluded
+	}
+	witness, err := NewWitness(testMaxBids, bids, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := NewPublicWitness(testMaxBids, coms, excluded, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := wire.PublicInputs(comBytes, excluded, 0, testMaxBids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, assignment := range []*AuctionCircuit{witness, public} {
+		got, err := PublicInputs(assignment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%v public inputs, want %v", len(got), len(want))
+		}
+		for i := range want {
+			if !got[i].Equal(&want[i]) {
+				t.Errorf("public input %v is %v, want %v", i, got[i].String(), want[i].String())
+			}
+		}
+	}
+
+	layout := PublicInputLayout(NewAuctionCircuit(2))
+	wantLayout := []string{"ComsX[0]", "ComsX[1]", "ComsY[0]", "ComsY[1]", "Excluded[0]", "Excluded[1]",
+		"WinningComX", "WinningComY", "WinningIndex"}
+	if len(layout) != len(wantLayout) {
+		t.Fatalf("layout %v", layout)
+	}
+	for i := range wantLayout {
+		if layout[i] != wantLayout[i] {
+			t.Errorf("public input %v is %v, want %v", i, layout[i], wantLayout[i])
+		}
+	}
+}
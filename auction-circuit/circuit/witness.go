@@ -0,0 +1,178 @@
+package circuit
+
+import (
+	"errors"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Bid is a commitment of an auction with its opening. The opening of an excluded bid is not used
+type Bid struct {
+	Commitment twistededwards.PointAffine
+	Value      int
+	R          *big.Int
+	Excluded   bool
+}
+
+// Winner returns the index of the highest bid that is not excluded, the earliest one among equal highest
+// bids, or -1 if there is no such bid
+func Winner(bids []Bid) int {
+	winner := -1
+	for i := range bids {
+		if bids[i].Excluded {
+			continue
+		}
+		if winner < 0 || bids[i].Value > bids[winner].Value {
+			winner = i
+		}
+	}
+	return winner
+}
+
+// NewWitness returns the full witness of the circuit for maxBids bids in which the bid at index winner
+// wins. The bids are all the commitments of the auction in their order, the excluded ones open to the
+// identity. Unused slots are filled with the commitment to 0 with randomness 0, the identity
+func NewWitness(maxBids int, bids []Bid, winner int) (*AuctionCircuit, error) {
+	if len(bids) > maxBids {
+		return nil, fmt.Errorf("%v bids, the circuit allows at most %v", len(bids), maxBids)
+	}
+	if winner < 0 || winner >= len(bids) || bids[winner].Excluded {
+		return nil, errors.New("invalid winning index")
+	}
+	witness := NewAuctionCircuit(maxBids)
+	for i := 0; i < maxBids; i++ {
+		bid := identityBid()
+		if i < len(bids) {
+			bid = bids[i]
+		}
+		if bid.Excluded {
+			witness.Values[i].Assign(0)
+			witness.Rs[i].Assign(0)
+			witness.Excluded[i].Assign(1)
+		} else {
+			witness.Values[i].Assign(bid.Value)
+			witness.Rs[i].Assign(bid.R)
+			witness.Excluded[i].Assign(0)
+		}
+		witness.ComsX[i].Assign(bid.Commitment.X)
+		witness.ComsY[i].Assign(bid.Commitment.Y)
+		if i == winner {
+			witness.WinningSelector[i].Assign(1)
+		} else {
+			witness.WinningSelector[i].Assign(0)
+		}
+	}
+	witness.WinningValue.Assign(bids[winner].Value)
+	witness.WinningR.Assign(bids[winner].R)
+	witness.WinningComX.Assign(bids[winner].Commitment.X)
+	witness.WinningComY.Assign(bids[winner].Commitment.Y)
+	witness.WinningIndex.Assign(winner)
+	return witness, nil
+}
+
+// NewPublicWitness returns the public part of the witness, used to verify a proof. excluded flags the
+// commitments that are left out of the comparison
+func NewPublicWitness(maxBids int, commitments []twistededwards.PointAffine, excluded []bool, winner int) (*AuctionCircuit, error) {
+	if len(commitments) > maxBids {
+		return nil, fmt.Errorf("%v bids, the circuit allows at most %v", len(commitments), maxBids)
+	}
+	if len(excluded) != len(commitments) {
+		return nil, errors.New("one exclusion flag is needed per commitment")
+	}
+	if winner < 0 || winner >= len(commitments) || excluded[winner] {
+		return nil, errors.New("invalid winning index")
+	}
+	public := NewAuctionCircuit(maxBids)
+	for i := 0; i < maxBids; i++ {
+		com := identityBid().Commitment
+		if i < len(commitments) {
+			com = commitments[i]
+		}
+		public.ComsX[i].Assign(com.X)
+		public.ComsY[i].Assign(com.Y)
+		if i < len(excluded) && excluded[i] {
+			public.Excluded[i].Assign(1)
+		} else {
+			public.Excluded[i].Assign(0)
+		}
+	}
+	public.WinningComX.Assign(commitments[winner].X)
+	public.WinningComY.Assign(commitments[winner].Y)
+	public.WinningIndex.Assign(winner)
+	return public, nil
+}
+
+// ZeroPublicWitness assigns zero to every public input
+func ZeroPublicWitness(maxBids int) *AuctionCircuit {
+	public := NewAuctionCircuit(maxBids)
+	for i := 0; i < maxBids; i++ {
+		public.ComsX[i].Assign(0)
+		public.ComsY[i].Assign(0)
+		public.Excluded[i].Assign(0)
+	}
+	public.WinningComX.Assign(0)
+	public.WinningComY.Assign(0)
+	public.WinningIndex.Assign(0)
+	return public
+}
+
+// identityBid is the bid of an unused slot
+func identityBid() Bid {
+	bid := Bid{R: big.NewInt(0)}
+	bid.Commitment.Y.SetOne()
+	return bid
+}
+
+// PublicInputs returns the public inputs of an assigned circuit, in the order of the public witness
+func PublicInputs(circuit *AuctionCircuit) ([]fr.Element, error) {
+	var inputs []fr.Element
+	names := PublicInputLayout(circuit)
+	value := reflect.ValueOf(circuit).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if !strings.Contains(value.Type().Field(i).Tag.Get("gnark"), "public") {
+			continue
+		}
+		var variables []frontend.Variable
+		if field := value.Field(i); field.Kind() == reflect.Slice {
+			variables = field.Interface().([]frontend.Variable)
+		} else {
+			variables = []frontend.Variable{field.Interface().(frontend.Variable)}
+		}
+		for _, v := range variables {
+			assigned := frontend.GetAssignedValue(v)
+			if assigned == nil {
+				return nil, fmt.Errorf("public input %v is not assigned", names[len(inputs)])
+			}
+			var e fr.Element
+			e.SetInterface(assigned)
+			inputs = append(inputs, e)
+		}
+	}
+	return inputs, nil
+}
+
+// PublicInputLayout returns the names of the public inputs of the circuit, in the order of the public witness
+func PublicInputLayout(circuit *AuctionCircuit) []string {
+	var names []string
+	value := reflect.ValueOf(circuit).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !strings.Contains(field.Tag.Get("gnark"), "public") {
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice {
+			for j := 0; j < value.Field(i).Len(); j++ {
+				names = append(names, field.Name+"["+strconv.Itoa(j)+"]")
+			}
+		} else {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
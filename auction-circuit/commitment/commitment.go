@@ -0,0 +1,165 @@
+// Package commitment implements the Pedersen commitments to bids on the twisted Edwards curve of BLS12-381,
+// the proofs of knowledge of their opening and the encryption of the opening for the seller
+package commitment
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
+	"math/big"
+)
+
+var curveParams = twistededwards.GetEdwardsCurve()
+var order = curveParams.Order
+
+// Hx and Hy are the coordinates of the second generator h of the commitments, com = g^value h^r
+var Hx = *new(fr.Element).SetString("51295569138718539371092613972351202357326289069440880621285444911501458459494")
+var Hy = *new(fr.Element).SetString("49831129265363587078046764490824666482509464638593900758877649985443393819454")
+var h = twistededwards.NewPointAffine(Hx, Hy)
+
+// this is how h was generated
+/*p := twistededwards.PointAffine{}
+base := twistededwards.GetEdwardsCurve().Base
+order := twistededwards.GetEdwardsCurve().Order
+order.Mul(&order, big.NewInt(1))
+hash := sha256.New()
+hash.Write(base.Marshal())
+d := hash.Sum(nil)
+d[0] = 0
+_, err := p.SetBytes(d)
+fmt.Println(err)
+p.ScalarMul(&p, big.NewInt(8))
+fmt.Println(p.X.String())
+fmt.Println(p.Y.String())
+fmt.Println(p.IsOnCurve())
+p.ScalarMul(&p, &order)
+fmt.Println(p.X.String())
+fmt.Println(p.Y.String())
+fmt.Println(p.IsOnCurve())
+*/
+
+// Commit commits to value with a random r
+func Commit(value int) (*twistededwards.PointAffine, *big.Int, error) {
+	r, err := Random()
+	if err != nil {
+		return nil, nil, err
+	}
+	return CommitWith(value, r), r, nil
+}
+
+// CommitWith returns g^value h^r
+func CommitWith(value int, r *big.Int) *twistededwards.PointAffine {
+	p := twistededwards.PointAffine{}
+	i := big.NewInt(int64(value))
+	p.ScalarMul(&curveParams.Base, i)
+	temp := twistededwards.PointAffine{}
+	temp.ScalarMul(&h, r)
+	p.Add(&p, &temp)
+	return &p
+}
+
+// CheckCommit checks that value and r open com
+func CheckCommit(value int, r *big.Int, com *twistededwards.PointAffine) bool {
+	if value < 0 || !com.IsOnCurve() {
+		return false
+	}
+	return CommitWith(value, r).Equal(com)
+}
+
+// CheckCommitBytes checks that value and the randomness rBytes open the encoded commitment comBytes
+func CheckCommitBytes(value int, rBytes, comBytes []byte) bool {
+	com, err := wire.UnmarshalCommitment(comBytes)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(rBytes)
+	return CheckCommit(value, r, com)
+}
+
+// ProveCommit proves the knowledge of the opening of the encoded commitment comBytes, the proof is bound
+// to the message m
+func ProveCommit(value int, r *big.Int, comBytes, m []byte) (*wire.OpeningProof, error) {
+	// commitment
+	r1, err := Random()
+	if err != nil {
+		return nil, err
+	}
+	r2, err := Random()
+	if err != nil {
+		return nil, err
+	}
+	t := CommitWith(0, r2)
+	temp := twistededwards.PointAffine{}
+	temp.ScalarMul(&curveParams.Base, r1)
+	t.Add(t, &temp)
+	// challenge
+	c := new(big.Int)
+	c.SetBytes(hashTranscript(t, comBytes, m))
+	// response
+	i := big.NewInt(int64(value))
+	s1 := new(big.Int)
+	s2 := new(big.Int)
+	s1.Mul(i, c)
+	s1.Add(s1, r1)
+	s1.Mod(s1, &order)
+	s2.Mul(r, c)
+	s2.Add(s2, r2)
+	s2.Mod(s2, &order)
+	return &wire.OpeningProof{T: *t, S1: s1, S2: s2}, nil
+}
+
+// CheckCommitProofBytes checks an encoded proof of opening of the encoded commitment comBytes
+func CheckCommitProofBytes(proofBytes, comBytes, m []byte) bool {
+	proof, err := wire.UnmarshalOpeningProof(proofBytes)
+	if err != nil {
+		return false
+	}
+	return CheckCommitProof(proof, comBytes, m)
+}
+
+// CheckCommitProof checks g^s1 h^s2 = T com^c
+func CheckCommitProof(proof *wire.OpeningProof, comBytes, m []byte) bool {
+	// transform comBytes into a point and check it is on the curve
+	com, err := wire.UnmarshalCommitment(comBytes)
+	if err != nil || !proof.T.IsOnCurve() {
+		return false
+	}
+	c := new(big.Int)
+	c.SetBytes(hashTranscript(&proof.T, comBytes, m))
+
+	left := twistededwards.PointAffine{}
+	left.ScalarMul(com, c)
+	left.Add(&left, &proof.T)
+
+	right := twistededwards.PointAffine{}
+	temp := twistededwards.PointAffine{}
+	right.ScalarMul(&curveParams.Base, proof.S1)
+	temp.ScalarMul(&h, proof.S2)
+	right.Add(&right, &temp)
+
+	return left.Equal(&right)
+}
+
+func hashTranscript(t *twistededwards.PointAffine, comBytes, m []byte) []byte {
+	h := sha256.New()
+	h.Write(t.Marshal())
+	h.Write(comBytes)
+	h.Write(m)
+	return h.Sum(nil)
+}
+
+// Random returns a random non-zero scalar of the curve
+func Random() (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, &order)
+		if err != nil {
+			return nil, err
+		}
+
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
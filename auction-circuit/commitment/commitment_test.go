@@ -0,0 +1,158 @@
+package commitment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// commitments computed with the parameters of the first release, changing h or the encoding breaks the
+// commitments already on the ledger
+var commitVectors = []struct {
+	value int
+	r     int64
+	hex   string
+}{
+	{0, 0, "010100000000000000000000000000000000000000000000000000000000000000"},
+	{42, 7, "01cdd4b8094c1c3b80d2b1ec5b9d50653e3d1351a408a161432d8b2cfcb59213e2"},
+	{1000, 123456789, "01a54a6009885e4506b782d05f7bd966ed150ff0bc811017a327f4764772dc9526"},
+}
+
+// proof of opening of the commitment to 42 with r = 7, bound to the message "sealed bid"
+const openingProofVector = "012719acb64906fc22402ad07394bab694e62e3bb869c050394369c7e9ce9deab70c84978e8fa6b5128b230d52cc00" +
+	"5417e5926dbb88cd31c76be812b519f4539a04e990147047207515011c1bbce279f968ab854ac6130d36fdf015efafe78657"
+
+func TestCommitVectors(t *testing.T) {
+	for _, v := range commitVectors {
+		com := CommitWith(v.value, big.NewInt(v.r))
+		if got := hex.EncodeToString(wire.MarshalCommitment(com)); got != v.hex {
+			t.Errorf("commitment to %v with r = %v: got %v, want %v", v.value, v.r, got, v.hex)
+		}
+		comBytes, _ := hex.DecodeString(v.hex)
+		if !CheckCommitBytes(v.value, big.NewInt(v.r).Bytes(), comBytes) {
+			t.Errorf("commitment to %v with r = %v does not open", v.value, v.r)
+		}
+		if CheckCommitBytes(v.value+1, big.NewInt(v.r).Bytes(), comBytes) {
+			t.Errorf("commitment to %v with r = %v opens to %v", v.value, v.r, v.value+1)
+		}
+		// commitments sent before the encoding was versioned
+		if !CheckCommitBytes(v.value, big.NewInt(v.r).Bytes(), comBytes[1:]) {
+			t.Errorf("unversioned commitment to %v with r = %v does not open", v.value, v.r)
+		}
+	}
+}
+
+func TestOpeningProofVector(t *testing.T) {
+	comBytes, _ := hex.DecodeString(commitVectors[1].hex)
+	proofBytes, _ := hex.DecodeString(openingProofVector)
+	if !CheckCommitProofBytes(proofBytes, comBytes, []byte("sealed bid")) {
+		t.Fatal("proof of opening rejected")
+	}
+	if CheckCommitProofBytes(proofBytes, comBytes, []byte("other bid")) {
+		t.Error("proof of opening accepted for another message")
+	}
+	if CheckCommitProofBytes(proofBytes, comBytes[1:], []byte("sealed bid")) {
+		t.Error("proof of opening accepted for another encoding of the commitment")
+	}
+	if !CheckCommitProofBytes(proofBytes[1:], comBytes, []byte("sealed bid")) {
+		t.Error("unversioned proof of opening rejected")
+	}
+}
+
+func TestProveCommit(t *testing.T) {
+	com, r, err := Commit(250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comBytes := wire.MarshalCommitment(com)
+	proof, err := ProveCommit(250, r, comBytes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofBytes := wire.MarshalOpeningProof(proof)
+	if len(proofBytes) != 1+wire.OpeningProofSize {
+		t.Fatalf("proof of opening of %v bytes", len(proofBytes))
+	}
+	if !CheckCommitProofBytes(proofBytes, comBytes, nil) {
+		t.Error("proof of opening rejected")
+	}
+	other := wire.MarshalCommitment(CommitWith(250, big.NewInt(1)))
+	if CheckCommitProofBytes(proofBytes, other, nil) {
+		t.Error("proof of opening accepted for another commitment")
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	pk, sk, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := new(big.Int).SetString("3618502788666131106986593281521497120414687020801267626233049500247285301239", 10)
+	c, err := Encrypt(1234, r, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, opened, err := Decrypt(c, pk, sk)
+	if err != nil || value != 1234 || opened.Cmp(r) != 0 {
+		t.Errorf("decrypted %v, %v, %v", value, opened, err)
+	}
+	// the key of the box decrypts this opening alone
+	key, err := SharedKey(c, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, opened, err = DecryptWithKey(c, pk, key)
+	if err != nil || value != 1234 || opened.Cmp(r) != 0 {
+		t.Errorf("decrypted with the key %v, %v, %v", value, opened, err)
+	}
+	other, err := Encrypt(1234, r, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = DecryptWithKey(other, pk, key); err != ErrDecryption {
+		t.Errorf("another opening decrypted with the key, %v", err)
+	}
+
+	c[len(c)-1] ^= 1
+	if _, opened, err = Decrypt(c, pk, sk); err == nil || opened.Sign() != 0 {
+		t.Error("tampered opening decrypted")
+	}
+	if _, _, err = DecryptWithKey(c, pk, key); err != ErrDecryption {
+		t.Errorf("tampered opening decrypted with the key, %v", err)
+	}
+}
+
+func TestEncryptPublic(t *testing.T) {
+	pk, sk, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := MigratedBidSeed("auction1", "tx1")
+	c, err := EncryptPublic(1234, big.NewInt(0), pk, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := EncryptPublic(1234, big.NewInt(0), pk, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(c) != hex.EncodeToString(again) {
+		t.Error("the same seed gave two ciphertexts")
+	}
+	// the seller decrypts it like any other opening
+	value, opened, err := Decrypt(c, pk, sk)
+	if err != nil || value != 1234 || opened.Sign() != 0 {
+		t.Errorf("decrypted %v, %v, %v", value, opened, err)
+	}
+	value, opened, err = DecryptPublic(c, pk, seed)
+	if err != nil || value != 1234 || opened.Sign() != 0 {
+		t.Errorf("decrypted with the seed %v, %v, %v", value, opened, err)
+	}
+	if _, _, err = DecryptPublic(c, pk, []byte("another seed")); err != ErrDecryption {
+		t.Errorf("decrypted with another seed, %v", err)
+	}
+}
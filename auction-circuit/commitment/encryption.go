@@ -0,0 +1,109 @@
+package commitment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/nacl/box"
+	"math/big"
+)
+
+// sealed opening of a commitment, the value in little-endian followed by r in big-endian
+const openingSize = 4 + 32
+
+// ErrDecryption is returned when a box cannot be opened, as opposed to a box holding an invalid opening
+var ErrDecryption = errors.New("decryption failed")
+
+// Encrypt encrypts the opening of a commitment for the seller public key pk
+func Encrypt(value int, r *big.Int, pk *[32]byte) (c []byte, err error) {
+	// encrypt using X25519 key exchange and Salsa20/Poly1305
+	return box.SealAnonymous(nil, encodeOpening(value, r), pk, rand.Reader)
+}
+
+// EncryptPublic encrypts an opening that is already public for the seller public key pk. The ephemeral key of
+// the box is derived from seed, so every peer computes the same ciphertext and anyone who knows the seed can
+// decrypt it with DecryptPublic
+func EncryptPublic(value int, r *big.Int, pk *[32]byte, seed []byte) (c []byte, err error) {
+	ephemeralSk := sha256.Sum256(seed)
+	return box.SealAnonymous(nil, encodeOpening(value, r), pk, bytes.NewReader(ephemeralSk[:]))
+}
+
+// DecryptPublic decrypts an opening encrypted with EncryptPublic without the seller secret key
+func DecryptPublic(c []byte, pk *[32]byte, seed []byte) (value int, r *big.Int, err error) {
+	ephemeralSk := sha256.Sum256(seed)
+	var key [32]byte
+	box.Precompute(&key, pk, &ephemeralSk)
+	return DecryptWithKey(c, pk, &key)
+}
+
+// MigratedBidSeed returns the seed of the encryption of the opening of a bid migrated from the private data
+// auction chaincode, anyone can decrypt it since the bid was revealed in the clear
+func MigratedBidSeed(auctionID, bidID string) []byte {
+	return []byte("migrated\x00" + auctionID + "\x00" + bidID)
+}
+
+// encodeOpening encodes the value in little-endian followed by r
+func encodeOpening(value int, r *big.Int) []byte {
+	msg := make([]byte, openingSize)
+	binary.LittleEndian.PutUint32(msg[:4], uint32(value))
+	r.FillBytes(msg[4:])
+	return msg
+}
+
+// Decrypt decrypts the opening of a commitment with the seller key pair, r is 0 if decryption fails
+func Decrypt(c []byte, pk, sk *[32]byte) (value int, r *big.Int, err error) {
+	msg, ok := box.OpenAnonymous(nil, c, pk, sk)
+	if !ok {
+		return 0, big.NewInt(0), ErrDecryption
+	}
+	return decodeOpening(msg)
+}
+
+func decodeOpening(msg []byte) (value int, r *big.Int, err error) {
+	if len(msg) != openingSize {
+		return 0, big.NewInt(0), fmt.Errorf("invalid opening of %v bytes", len(msg))
+	}
+	valueBytes := msg[:4]
+	value = int(binary.LittleEndian.Uint32(valueBytes))
+	r = new(big.Int)
+	r.SetBytes(msg[4:])
+	return
+}
+
+// SharedKey returns the key of the box of an encrypted opening, so that anyone can decrypt that opening alone
+// with DecryptWithKey. It is how the seller shows that the opening of an excluded bid is invalid
+func SharedKey(c []byte, sk *[32]byte) (*[32]byte, error) {
+	if len(c) < box.AnonymousOverhead {
+		return nil, fmt.Errorf("encrypted opening too short")
+	}
+	var ephemeralPk, key [32]byte
+	copy(ephemeralPk[:], c[:32])
+	box.Precompute(&key, &ephemeralPk, sk)
+	return &key, nil
+}
+
+// DecryptWithKey decrypts an encrypted opening for the seller public key pk with its shared key. The
+// decryption only succeeds with the key of the box, r is 0 if it fails
+func DecryptWithKey(c []byte, pk, key *[32]byte) (value int, r *big.Int, err error) {
+	if len(c) < box.AnonymousOverhead {
+		return 0, big.NewInt(0), ErrDecryption
+	}
+	// nonce of box.SealAnonymous
+	hash, err := blake2b.New(24, nil)
+	if err != nil {
+		return 0, big.NewInt(0), err
+	}
+	hash.Write(c[:32])
+	hash.Write(pk[:])
+	var nonce [24]byte
+	copy(nonce[:], hash.Sum(nil))
+	msg, ok := box.OpenAfterPrecomputation(nil, c[32:], &nonce, key)
+	if !ok {
+		return 0, big.NewInt(0), ErrDecryption
+	}
+	return decodeOpening(msg)
+}
@@ -0,0 +1,10 @@
+module github.com/ckiere/test-network/auction-circuit
+
+go 1.15
+
+require (
+	github.com/consensys/gnark v0.4.0
+	github.com/consensys/gnark-crypto v0.4.1-0.20210428083642-6bd055b79906
+	github.com/dbogatov/fabric-amcl v0.0.0-20190731091901-c69f438d7884
+	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
+)
@@ -0,0 +1,122 @@
+// Package scopetag implements the scope-exclusive pseudonyms of DAC credentials: the tag of a credential for a
+// scope (e.g. an auction ID) is the same for every nym of the credential, tags of different scopes are unlinkable
+package scopetag
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/dbogatov/fabric-amcl/amcl"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+)
+
+// PointSize is the size of an encoded point of G2, the tag, the nym and h have this size
+const PointSize = 4 * int(FP256BN.MODBYTES)
+
+// ProofSize is the size of an encoded proof
+const ProofSize = 2*PointSize + 2*int(FP256BN.MODBYTES)
+
+// Prove computes the tag of the credential with secret key sk for the scope, tag = H(scope)^sk, and a proof that
+// the tag and the nym pkNym = g^sk h^skNym use the same secret key. The proof is bound to the message m
+func Prove(prg *amcl.RAND, h, pkNym *FP256BN.ECP2, sk, skNym *FP256BN.BIG, scope string, m []byte) (tag []byte, proof []byte) {
+	q := FP256BN.NewBIGints(FP256BN.CURVE_Order)
+	g := FP256BN.ECP2_generator()
+	hs := scopeBase(scope)
+
+	t := hs.Mul(sk)
+
+	// commitments
+	r1 := FP256BN.Randomnum(q, prg)
+	r2 := FP256BN.Randomnum(q, prg)
+	a := g.Mul(r1)
+	a.Add(h.Mul(r2))
+	b := hs.Mul(r1)
+	// challenge
+	c := challenge(a, b, pkNym, t, scope, m)
+	// responses
+	s1 := FP256BN.Modmul(sk, c, q).Plus(r1)
+	s1.Mod(q)
+	s2 := FP256BN.Modmul(skNym, c, q).Plus(r2)
+	s2.Mod(q)
+
+	tag = make([]byte, PointSize)
+	t.ToBytes(tag)
+	proof = make([]byte, ProofSize)
+	a.ToBytes(proof[:PointSize])
+	b.ToBytes(proof[PointSize : 2*PointSize])
+	s1.ToBytes(proof[2*PointSize : 2*PointSize+int(FP256BN.MODBYTES)])
+	s2.ToBytes(proof[2*PointSize+int(FP256BN.MODBYTES):])
+	return
+}
+
+// Verify checks the proof that tag is the scope-exclusive pseudonym of the owner of the DAC nym pkNym, i.e. that
+// tag = H(scope)^sk and pkNym = g^sk h^skNym for the same sk. hBytes is the h parameter of the DAC configuration
+func Verify(hBytes, pkNymBytes, tag, proof []byte, scope string, m []byte) error {
+	if len(hBytes) != PointSize || len(pkNymBytes) != PointSize || len(tag) != PointSize || len(proof) != ProofSize {
+		return errors.New("invalid scope tag proof length")
+	}
+	q := FP256BN.NewBIGints(FP256BN.CURVE_Order)
+	g := FP256BN.ECP2_generator()
+	h := FP256BN.ECP2_fromBytes(hBytes)
+	hs := scopeBase(scope)
+	pkNym := FP256BN.ECP2_fromBytes(pkNymBytes)
+	t := FP256BN.ECP2_fromBytes(tag)
+	a := FP256BN.ECP2_fromBytes(proof[:PointSize])
+	b := FP256BN.ECP2_fromBytes(proof[PointSize : 2*PointSize])
+	s1 := FP256BN.FromBytes(proof[2*PointSize : 2*PointSize+int(FP256BN.MODBYTES)])
+	s1.Mod(q)
+	s2 := FP256BN.FromBytes(proof[2*PointSize+int(FP256BN.MODBYTES):])
+	s2.Mod(q)
+	if h.Is_infinity() || pkNym.Is_infinity() || t.Is_infinity() || a.Is_infinity() || b.Is_infinity() {
+		return errors.New("invalid point in scope tag proof")
+	}
+	// the points are on the twist but may have a component of low order, the twist has points of order other than q
+	for _, p := range []*FP256BN.ECP2{h, pkNym, t, a, b} {
+		if !inG2(p, q) {
+			return errors.New("point of scope tag proof not in G2")
+		}
+	}
+
+	c := challenge(a, b, pkNym, t, scope, m)
+
+	// g^s1 h^s2 = a pkNym^c
+	left := g.Mul(s1)
+	left.Add(h.Mul(s2))
+	right := pkNym.Mul(c)
+	right.Add(a)
+	if !left.Equals(right) {
+		return errors.New("scope tag proof does not match the nym")
+	}
+	// H(scope)^s1 = b tag^c
+	left = hs.Mul(s1)
+	right = t.Mul(c)
+	right.Add(b)
+	if !left.Equals(right) {
+		return errors.New("scope tag proof does not match the tag")
+	}
+	return nil
+}
+
+// inG2 tells if a point of the twist is in the subgroup G2 of order q
+func inG2(p *FP256BN.ECP2, q *FP256BN.BIG) bool {
+	return p.Mul(q).Is_infinity()
+}
+
+// scopeBase hashes the scope to a point of G2 with unknown discrete logarithm
+func scopeBase(scope string) *FP256BN.ECP2 {
+	digest := sha256.Sum256(append([]byte("dac-scope-tag:"), scope...))
+	return FP256BN.ECP2_mapit(digest[:])
+}
+
+func challenge(a, b, pkNym, t *FP256BN.ECP2, scope string, m []byte) *FP256BN.BIG {
+	hash := sha256.New()
+	for _, p := range []*FP256BN.ECP2{a, b, pkNym, t} {
+		pBytes := make([]byte, PointSize)
+		p.ToBytes(pBytes)
+		hash.Write(pBytes)
+	}
+	hash.Write([]byte(scope))
+	hash.Write(m)
+	c := FP256BN.FromBytes(hash.Sum(nil))
+	c.Mod(FP256BN.NewBIGints(FP256BN.CURVE_Order))
+	return c
+}
@@ -0,0 +1,129 @@
+package scopetag
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/dbogatov/fabric-amcl/amcl"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+)
+
+func newRand(t *testing.T) *amcl.RAND {
+	var seed [32]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		t.Fatal(err)
+	}
+	prg := amcl.NewRAND()
+	prg.Seed(len(seed), seed[:])
+	return prg
+}
+
+// testNym is a nym pkNym = g^sk h^skNym of the credential with secret key sk
+type testNym struct {
+	sk, skNym *FP256BN.BIG
+	pkNym     *FP256BN.ECP2
+}
+
+func newTestNym(prg *amcl.RAND, h *FP256BN.ECP2, sk *FP256BN.BIG) testNym {
+	q := FP256BN.NewBIGints(FP256BN.CURVE_Order)
+	skNym := FP256BN.Randomnum(q, prg)
+	pkNym := FP256BN.ECP2_generator().Mul(sk)
+	pkNym.Add(h.Mul(skNym))
+	return testNym{sk, skNym, pkNym}
+}
+
+func pointBytes(p *FP256BN.ECP2) []byte {
+	pBytes := make([]byte, PointSize)
+	p.ToBytes(pBytes)
+	return pBytes
+}
+
+func TestScopeTag(t *testing.T) {
+	prg := newRand(t)
+	q := FP256BN.NewBIGints(FP256BN.CURVE_Order)
+	h := FP256BN.ECP2_generator().Mul(FP256BN.Randomnum(q, prg))
+	hBytes := pointBytes(h)
+	sk := FP256BN.Randomnum(q, prg)
+	nym := newTestNym(prg, h, sk)
+
+	tag, proof := Prove(prg, h, nym.pkNym, nym.sk, nym.skNym, "auction1", []byte("commitment"))
+	if err := Verify(hBytes, pointBytes(nym.pkNym), tag, proof, "auction1", []byte("commitment")); err != nil {
+		t.Fatalf("valid tag rejected: %v", err)
+	}
+	if Verify(hBytes, pointBytes(nym.pkNym), tag, proof, "auction2", []byte("commitment")) == nil {
+		t.Error("tag verified for another scope")
+	}
+	if Verify(hBytes, pointBytes(nym.pkNym), tag, proof, "auction1", []byte("other commitment")) == nil {
+		t.Error("tag verified for another message")
+	}
+	other := newTestNym(prg, h, FP256BN.Randomnum(q, prg))
+	if Verify(hBytes, pointBytes(other.pkNym), tag, proof, "auction1", []byte("commitment")) == nil {
+		t.Error("tag verified for the nym of another credential")
+	}
+
+	// another nym of the same credential has the same tag in the scope, and another one in other scopes
+	second := newTestNym(prg, h, sk)
+	secondTag, secondProof := Prove(prg, h, second.pkNym, second.sk, second.skNym, "auction1", []byte("second commitment"))
+	if err := Verify(hBytes, pointBytes(second.pkNym), secondTag, secondProof, "auction1", []byte("second commitment")); err != nil {
+		t.Fatalf("valid tag of a second nym rejected: %v", err)
+	}
+	if string(secondTag) != string(tag) {
+		t.Error("two nyms of a credential have different tags in a scope")
+	}
+	otherScopeTag, _ := Prove(prg, h, second.pkNym, second.sk, second.skNym, "auction2", []byte("commitment"))
+	if string(otherScopeTag) == string(tag) {
+		t.Error("a credential has the same tag in two scopes")
+	}
+	otherTag, _ := Prove(prg, h, other.pkNym, other.sk, other.skNym, "auction1", []byte("commitment"))
+	if string(otherTag) == string(tag) {
+		t.Error("two credentials have the same tag in a scope")
+	}
+}
+
+// lowOrderPoint returns a point of the twist whose order divides the cofactor of G2
+func lowOrderPoint(t *testing.T) *FP256BN.ECP2 {
+	q := FP256BN.NewBIGints(FP256BN.CURVE_Order)
+	for x := 1; x < 100; x++ {
+		p := FP256BN.NewECP2fp2(FP256BN.NewFP2int(x))
+		if p.Is_infinity() {
+			continue
+		}
+		if low := p.Mul(q); !low.Is_infinity() {
+			return low
+		}
+	}
+	t.Fatal("no point of low order found")
+	return nil
+}
+
+func TestScopeTagSubgroup(t *testing.T) {
+	prg := newRand(t)
+	q := FP256BN.NewBIGints(FP256BN.CURVE_Order)
+	h := FP256BN.ECP2_generator().Mul(FP256BN.Randomnum(q, prg))
+	hBytes := pointBytes(h)
+	nym := newTestNym(prg, h, FP256BN.Randomnum(q, prg))
+	tag, proof := Prove(prg, h, nym.pkNym, nym.sk, nym.skNym, "auction1", []byte("commitment"))
+	low := lowOrderPoint(t)
+	if inG2(low, q) {
+		t.Fatal("the point of low order is in G2")
+	}
+
+	// a low order component added to the tag, a or b is on the curve but not in G2
+	addLow := func(pBytes []byte) []byte {
+		p := FP256BN.ECP2_fromBytes(pBytes)
+		p.Add(low)
+		return pointBytes(p)
+	}
+	lowTag := addLow(tag)
+	lowA := append(addLow(proof[:PointSize]), proof[PointSize:]...)
+	lowB := append(append(append([]byte{}, proof[:PointSize]...), addLow(proof[PointSize:2*PointSize])...), proof[2*PointSize:]...)
+	for name, forged := range map[string][2][]byte{"tag": {lowTag, proof}, "a": {tag, lowA}, "b": {tag, lowB}} {
+		err := Verify(hBytes, pointBytes(nym.pkNym), forged[0], forged[1], "auction1", []byte("commitment"))
+		if err == nil || err.Error() != "point of scope tag proof not in G2" {
+			t.Errorf("%v with a low order component: %v", name, err)
+		}
+	}
+	if err := Verify(hBytes, pointBytes(nym.pkNym), tag, proof, "auction1", []byte("commitment")); err != nil {
+		t.Errorf("valid tag rejected: %v", err)
+	}
+}
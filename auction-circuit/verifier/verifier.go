@@ -0,0 +1,131 @@
+// Package verifier checks the proofs of the winner of an auction without gnark, so that the chaincode only
+// depends on gnark-crypto
+package verifier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// VerifyingKey is a parsed verifying key of the proof system, it can be reused to verify any number
+// of proofs
+type VerifyingKey struct {
+	proofSystem string
+	maxBids     int
+	groth16     *Groth16VerifyingKey
+}
+
+// ParseVerifyingKey decodes a verifying key of the proof system and checks it is the key of an auction circuit
+func ParseVerifyingKey(proofSystem string, vk []byte) (*VerifyingKey, error) {
+	key := &VerifyingKey{proofSystem: proofSystem}
+	var nbPublic int
+	var err error
+	switch proofSystem {
+	case wire.ProofSystemGroth16:
+		key.groth16, err = UnmarshalGroth16VerifyingKey(vk)
+		if err != nil {
+			return nil, err
+		}
+		nbPublic = len(key.groth16.K) - 1
+	default:
+		return nil, fmt.Errorf("unknown proof system %q", proofSystem)
+	}
+	key.maxBids, err = wire.MaxBids(nbPublic)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ProofSystem returns the proof system of the key
+func (key *VerifyingKey) ProofSystem() string {
+	return key.proofSystem
+}
+
+// MaxBids returns the maximum number of bids of the circuit of the key
+func (key *VerifyingKey) MaxBids() int {
+	return key.maxBids
+}
+
+// Verify checks a proof of the winner, encoded by the backend of the proof system, against its public inputs
+func (key *VerifyingKey) Verify(proof []byte, public []fr.Element) error {
+	return VerifyGroth16(key.groth16, proof, public)
+}
+
+// Groth16VerifyingKey is the verifying key of gnark, K holds one point for the constant wire and one for
+// each public input
+type Groth16VerifyingKey struct {
+	AlphaG1, BetaG1 bls12381.G1Affine
+	BetaG2, GammaG2 bls12381.G2Affine
+	DeltaG1         bls12381.G1Affine
+	DeltaG2         bls12381.G2Affine
+	K               []bls12381.G1Affine
+}
+
+// UnmarshalGroth16VerifyingKey decodes a verifying key written by gnark
+func UnmarshalGroth16VerifyingKey(vk []byte) (*Groth16VerifyingKey, error) {
+	key := new(Groth16VerifyingKey)
+	err := decodeAll(vk, &key.AlphaG1, &key.BetaG1, &key.BetaG2, &key.GammaG2, &key.DeltaG1, &key.DeltaG2, &key.K)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verifying key: %v", err)
+	}
+	if len(key.K) == 0 {
+		return nil, errors.New("invalid verifying key: no constant wire")
+	}
+	return key, nil
+}
+
+// VerifyGroth16 checks e(A, B) = e(α, β).e(K0 + Σ pub_i K_i, γ).e(C, δ) for a proof written by gnark
+func VerifyGroth16(vk *Groth16VerifyingKey, proof []byte, public []fr.Element) error {
+	if len(public) != len(vk.K)-1 {
+		return fmt.Errorf("%v public inputs, verifying key has %v", len(public), len(vk.K)-1)
+	}
+	var a, c bls12381.G1Affine
+	var b bls12381.G2Affine
+	if err := decodeAll(proof, &a, &b, &c); err != nil {
+		return fmt.Errorf("invalid proof: %v", err)
+	}
+	if !a.IsInSubGroup() || !b.IsInSubGroup() || !c.IsInSubGroup() {
+		return errors.New("invalid proof: points are not in the subgroup")
+	}
+
+	scalars := make([]fr.Element, len(public))
+	for i := range public {
+		scalars[i] = public[i]
+		scalars[i].FromMont()
+	}
+	var kSum bls12381.G1Jac
+	kSum.MultiExp(vk.K[1:], scalars)
+	kSum.AddMixed(&vk.K[0])
+	var k, negAlpha, negK, negC bls12381.G1Affine
+	k.FromJacobian(&kSum)
+	negAlpha.Neg(&vk.AlphaG1)
+	negK.Neg(&k)
+	negC.Neg(&c)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{a, negAlpha, negK, negC},
+		[]bls12381.G2Affine{b, vk.BetaG2, vk.GammaG2, vk.DeltaG2})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid proof")
+	}
+	return nil
+}
+
+func decodeAll(b []byte, fields ...interface{}) error {
+	dec := bls12381.NewDecoder(bytes.NewReader(b))
+	for _, v := range fields {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+	if int(dec.BytesRead()) != len(b) {
+		return errors.New("trailing bytes")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"math/big"
+)
+
+// InvalidBid is an entry of the invalid set of a sealed-bid auction: the opening the seller decrypted from
+// the encrypted bid and the key of its box, with which anyone can decrypt that bid again
+type InvalidBid struct {
+	Price int     `json:"price"`
+	R     big.Int `json:"r"`
+	Key   []byte  `json:"key"`
+}
+
+// SealedBids are the bids of a sealed-bid auction the proof of the winner is checked against: the commitments
+// in the order they were sent and the encrypted openings of the revealed bids, by bid ID
+type SealedBids struct {
+	CommitmentOrder []string
+	Commitments     map[string][]byte
+	EncryptedBids   map[string][]byte
+	SellerPk        *[32]byte
+}
+
+// VerifyWinner checks the invalid set and the proof that winningBid is the highest of the bids that are not
+// excluded, as DeclareWinner does. Every commitment is a public input, in the commitment order, and is excluded
+// if it was not revealed or if it is in the invalid set with an opening that does not match it. The key is
+// only loaded if some bid is valid. It returns false if no bid is valid and no winner was declared
+func VerifyWinner(bids *SealedBids, key func() (*VerifyingKey, error), winningBid string, proof []byte, invalidSet string) (bool, error) {
+	invalidBids := make(map[string]InvalidBid)
+	if invalidSet != "" {
+		if err := json.Unmarshal([]byte(invalidSet), &invalidBids); err != nil {
+			return false, fmt.Errorf("invalid set format: %v", err)
+		}
+	}
+	for bidID, invalidBid := range invalidBids {
+		if err := bids.checkInvalidBid(bidID, &invalidBid); err != nil {
+			return false, err
+		}
+	}
+
+	order := bids.CommitmentOrder
+	excluded := wire.Excluded(order, func(bidID string) bool {
+		_, revealed := bids.EncryptedBids[bidID]
+		return revealed
+	}, func(bidID string) bool {
+		_, invalid := invalidBids[bidID]
+		return invalid
+	})
+	var commitments [][]byte
+	winningIndex := -1
+	valid := 0
+	for i, bidID := range order {
+		if !excluded[i] {
+			valid++
+			if bidID == winningBid {
+				winningIndex = i
+			}
+		}
+		commitments = append(commitments, bids.Commitments[bidID])
+	}
+	if valid == 0 {
+		if winningBid != "" {
+			return false, fmt.Errorf("no valid bid to win the auction")
+		}
+		return false, nil
+	}
+	if winningIndex < 0 {
+		return false, fmt.Errorf("winning bid is not a valid revealed bid")
+	}
+
+	verifyingKey, err := key()
+	if err != nil {
+		return false, err
+	}
+	proofSystem, proof, err := wire.UnmarshalWinnerProof(proof)
+	if err != nil {
+		return false, err
+	}
+	if proofSystem != verifyingKey.ProofSystem() {
+		return false, fmt.Errorf("proof of the winner uses %v, the verifying key %v", proofSystem, verifyingKey.ProofSystem())
+	}
+	public, err := wire.PublicInputs(commitments, excluded, winningIndex, verifyingKey.MaxBids())
+	if err != nil {
+		return false, err
+	}
+	if err := verifyingKey.Verify(proof, public); err != nil {
+		return false, fmt.Errorf("invalid proof of the winner: %v", err)
+	}
+	return true, nil
+}
+
+// checkInvalidBid checks that a bid of the invalid set was revealed and that its encrypted opening, decrypted
+// with the key given by the seller, does not open its commitment. The key is only accepted if it opens the
+// box, so the seller cannot exclude a valid bid
+func (bids *SealedBids) checkInvalidBid(bidID string, invalidBid *InvalidBid) error {
+	encryptedBid, revealed := bids.EncryptedBids[bidID]
+	if !revealed {
+		return fmt.Errorf("bid %v of the invalid set is not revealed", bidID)
+	}
+	if len(invalidBid.Key) != 32 {
+		return fmt.Errorf("invalid key for bid %v", bidID)
+	}
+	var key [32]byte
+	copy(key[:], invalidBid.Key)
+	price, r, err := commitment.DecryptWithKey(encryptedBid, bids.SellerPk, &key)
+	if err == commitment.ErrDecryption {
+		return fmt.Errorf("key of bid %v does not decrypt it", bidID)
+	}
+	if err == nil && commitment.CheckCommitBytes(price, r.Bytes(), bids.Commitments[bidID]) {
+		return fmt.Errorf("bid %v of the invalid set opens its commitment", bidID)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package verifier
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"golang.org/x/crypto/nacl/box"
+)
+
+var errKeyLoaded = errors.New("key loaded")
+
+// testBids returns a valid bid, a revealed bid whose opening does not match its commitment and a bid that is not
+// revealed, with the box keys of the revealed bids
+func testBids(t *testing.T) (*SealedBids, map[string]*[32]byte) {
+	pk, sk, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bids := &SealedBids{CommitmentOrder: []string{"valid", "invalid", "unrevealed"}, Commitments: make(map[string][]byte),
+		EncryptedBids: make(map[string][]byte), SellerPk: pk}
+	keys := make(map[string]*[32]byte)
+	for i, bidID := range bids.CommitmentOrder {
+		value := 50 + i
+		com, r, err := commitment.Commit(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bids.Commitments[bidID] = wire.MarshalCommitment(com)
+		if bidID == "unrevealed" {
+			continue
+		}
+		if bidID == "invalid" {
+			value++
+		}
+		bids.EncryptedBids[bidID], err = commitment.Encrypt(value, r, pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[bidID], err = commitment.SharedKey(bids.EncryptedBids[bidID], sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bids, keys
+}
+
+func invalidSet(t *testing.T, keys map[string]*[32]byte) string {
+	// R is only marshaled through a pointer
+	invalidBids := make(map[string]*InvalidBid)
+	for bidID, key := range keys {
+		invalidBids[bidID] = &InvalidBid{R: *big.NewInt(0), Key: key[:]}
+	}
+	set, err := json.Marshal(invalidBids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(set)
+}
+
+func TestVerifyWinnerInvalidSet(t *testing.T) {
+	bids, keys := testBids(t)
+	key := func() (*VerifyingKey, error) { return nil, errKeyLoaded }
+
+	// the checks pass up to the verification of the proof
+	if _, err := VerifyWinner(bids, key, "valid", nil, invalidSet(t, map[string]*[32]byte{"invalid": keys["invalid"]})); err != errKeyLoaded {
+		t.Errorf("valid invalid set rejected: %v", err)
+	}
+	if _, err := VerifyWinner(bids, key, "valid", nil, "{}"); err != errKeyLoaded {
+		t.Errorf("empty invalid set rejected: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		winningBid string
+		invalid    map[string]*[32]byte
+	}{
+		{"a valid bid in the invalid set", "valid", map[string]*[32]byte{"valid": keys["valid"]}},
+		{"an unrevealed bid in the invalid set", "valid", map[string]*[32]byte{"unrevealed": keys["valid"]}},
+		{"the key of another bid", "valid", map[string]*[32]byte{"invalid": keys["valid"]}},
+		{"an invalid winner", "invalid", map[string]*[32]byte{"invalid": keys["invalid"]}},
+		{"an unrevealed winner", "unrevealed", nil},
+	}
+	for _, test := range tests {
+		if _, err := VerifyWinner(bids, key, test.winningBid, nil, invalidSet(t, test.invalid)); err == nil || err == errKeyLoaded {
+			t.Errorf("%v accepted", test.name)
+		}
+	}
+
+	// without a valid bid, there is no winner and no proof
+	bids.CommitmentOrder = []string{"invalid", "unrevealed"}
+	valid, err := VerifyWinner(bids, key, "", nil, invalidSet(t, map[string]*[32]byte{"invalid": keys["invalid"]}))
+	if err != nil || valid {
+		t.Errorf("auction without a valid bid: %v", err)
+	}
+	if _, err := VerifyWinner(bids, key, "invalid", nil, invalidSet(t, map[string]*[32]byte{"invalid": keys["invalid"]})); err == nil {
+		t.Error("a winner declared without a valid bid")
+	}
+}
@@ -0,0 +1,69 @@
+package wire
+
+import (
+	"errors"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// Excluded returns for each bid of the commitment order whether it is left out of the proof of the winner:
+// the bids that are not revealed and the ones whose opening is invalid
+func Excluded(commitmentOrder []string, revealed, invalid func(bidID string) bool) []bool {
+	excluded := make([]bool, len(commitmentOrder))
+	for i, bidID := range commitmentOrder {
+		excluded[i] = !revealed(bidID) || invalid(bidID)
+	}
+	return excluded
+}
+
+// NbPublicInputs returns the number of public inputs of the circuit for maxBids bids: the coordinates of
+// the commitments, their exclusion flags, the coordinates of the winning commitment and the winning index
+func NbPublicInputs(maxBids int) int {
+	return 3*maxBids + 3
+}
+
+// MaxBids returns the maximum number of bids of the circuit with nbPublic public inputs
+func MaxBids(nbPublic int) (int, error) {
+	if nbPublic < NbPublicInputs(1) || (nbPublic-3)%3 != 0 {
+		return 0, errors.New("not the public inputs of an auction circuit")
+	}
+	return (nbPublic - 3) / 3, nil
+}
+
+// PublicInputs returns the public inputs of the circuit for maxBids bids, in the order of the public witness:
+// the x then the y coordinates of the commitments, their exclusion flags, the winning commitment and the
+// winning index. Unused slots hold the identity point, the commitment to 0 with randomness 0, and are not
+// excluded
+func PublicInputs(commitments [][]byte, excluded []bool, winningIndex, maxBids int) ([]fr.Element, error) {
+	if len(commitments) > maxBids {
+		return nil, fmt.Errorf("%v bids, the proof allows at most %v", len(commitments), maxBids)
+	}
+	if len(excluded) != len(commitments) {
+		return nil, errors.New("one exclusion flag is needed per commitment")
+	}
+	if winningIndex < 0 || winningIndex >= len(commitments) || excluded[winningIndex] {
+		return nil, errors.New("invalid winning index")
+	}
+	xs := make([]fr.Element, maxBids)
+	ys := make([]fr.Element, maxBids)
+	flags := make([]fr.Element, maxBids)
+	for i := range xs {
+		if i >= len(commitments) {
+			ys[i].SetOne()
+			continue
+		}
+		com, err := UnmarshalCommitment(commitments[i])
+		if err != nil {
+			return nil, fmt.Errorf("commitment %v: %v", i, err)
+		}
+		xs[i] = com.X
+		ys[i] = com.Y
+		if excluded[i] {
+			flags[i].SetOne()
+		}
+	}
+	public := append(append(xs, ys...), flags...)
+	var index fr.Element
+	index.SetUint64(uint64(winningIndex))
+	return append(public, xs[winningIndex], ys[winningIndex], index), nil
+}
@@ -0,0 +1,125 @@
+// Package wire defines the byte encodings exchanged between the bidders, the auctioneer and the chaincode:
+// commitments, proofs of opening, proofs of the winner and their public inputs. Encodings start with
+// Version, the decoders also accept the unversioned encodings of commitments and proofs of opening that
+// were sent before the format was versioned
+package wire
+
+import (
+	"errors"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
+	"math/big"
+)
+
+// Version is the version of the encodings
+const Version = 1
+
+// sizes of the unversioned encodings
+const (
+	PointSize        = 32
+	ScalarSize       = 32
+	OpeningProofSize = PointSize + 2*ScalarSize
+)
+
+// ProofSystemGroth16 is the proof system of the proofs of the winner, the name is the backend of zk-generator
+const ProofSystemGroth16 = "groth16"
+
+// identifiers of the proof systems in the encoding of a proof of the winner
+var proofSystemIDs = map[string]byte{
+	ProofSystemGroth16: 1,
+}
+
+// MarshalCommitment encodes a commitment as the version followed by the compressed point
+func MarshalCommitment(com *twistededwards.PointAffine) []byte {
+	return append([]byte{Version}, com.Marshal()...)
+}
+
+// UnmarshalCommitment decodes a commitment and checks that it is on the curve
+func UnmarshalCommitment(b []byte) (*twistededwards.PointAffine, error) {
+	pointBytes, err := payload(b, PointSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commitment: %v", err)
+	}
+	com := new(twistededwards.PointAffine)
+	if err := com.Unmarshal(pointBytes); err != nil || !com.IsOnCurve() {
+		return nil, errors.New("invalid commitment: not a point of the curve")
+	}
+	return com, nil
+}
+
+// OpeningProof is a proof of knowledge of the opening of a commitment, T = g^r1 h^r2 and the responses
+// s1 = r1 + c.value and s2 = r2 + c.r
+type OpeningProof struct {
+	T  twistededwards.PointAffine
+	S1 *big.Int
+	S2 *big.Int
+}
+
+// MarshalOpeningProof encodes a proof of opening as the version, the compressed T and the big-endian responses
+func MarshalOpeningProof(proof *OpeningProof) []byte {
+	b := make([]byte, 1+OpeningProofSize)
+	b[0] = Version
+	copy(b[1:], proof.T.Marshal())
+	proof.S1.FillBytes(b[1+PointSize : 1+PointSize+ScalarSize])
+	proof.S2.FillBytes(b[1+PointSize+ScalarSize:])
+	return b
+}
+
+// UnmarshalOpeningProof decodes a proof of opening, the responses are reduced modulo the order of the curve
+func UnmarshalOpeningProof(b []byte) (*OpeningProof, error) {
+	proofBytes, err := payload(b, OpeningProofSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof of opening: %v", err)
+	}
+	proof := new(OpeningProof)
+	if err := proof.T.Unmarshal(proofBytes[:PointSize]); err != nil || !proof.T.IsOnCurve() {
+		return nil, errors.New("invalid proof of opening: not a point of the curve")
+	}
+	order := twistededwards.GetEdwardsCurve().Order
+	proof.S1 = new(big.Int).SetBytes(proofBytes[PointSize : PointSize+ScalarSize])
+	proof.S1.Mod(proof.S1, &order)
+	proof.S2 = new(big.Int).SetBytes(proofBytes[PointSize+ScalarSize:])
+	proof.S2.Mod(proof.S2, &order)
+	return proof, nil
+}
+
+// payload returns the content of a versioned encoding of size bytes, or of an unversioned one
+func payload(b []byte, size int) ([]byte, error) {
+	switch {
+	case len(b) == size:
+		return b, nil
+	case len(b) == size+1 && b[0] == Version:
+		return b[1:], nil
+	case len(b) == size+1:
+		return nil, fmt.Errorf("unsupported version %v", b[0])
+	default:
+		return nil, fmt.Errorf("invalid length %v", len(b))
+	}
+}
+
+// MarshalWinnerProof encodes a proof of the winner as the version, the identifier of the proof system and
+// the proof encoded by its backend
+func MarshalWinnerProof(proofSystem string, proof []byte) ([]byte, error) {
+	id, ok := proofSystemIDs[proofSystem]
+	if !ok {
+		return nil, fmt.Errorf("unknown proof system %q", proofSystem)
+	}
+	return append([]byte{Version, id}, proof...), nil
+}
+
+// UnmarshalWinnerProof decodes a proof of the winner and returns its proof system and the proof encoded by
+// its backend
+func UnmarshalWinnerProof(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("invalid proof of the winner: too short")
+	}
+	if b[0] != Version {
+		return "", nil, fmt.Errorf("invalid proof of the winner: unsupported version %v", b[0])
+	}
+	for proofSystem, id := range proofSystemIDs {
+		if b[1] == id {
+			return proofSystem, b[2:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("invalid proof of the winner: unknown proof system %v", b[1])
+}
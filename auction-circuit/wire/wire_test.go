@@ -0,0 +1,175 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
+)
+
+// encoding of the commitment to 42 with r = 7
+const commitmentHex = "01cdd4b8094c1c3b80d2b1ec5b9d50653e3d1351a408a161432d8b2cfcb59213e2"
+
+func TestCommitmentEncoding(t *testing.T) {
+	b, _ := hex.DecodeString(commitmentHex)
+	com, err := UnmarshalCommitment(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(MarshalCommitment(com), b) {
+		t.Error("commitment does not encode to its decoding")
+	}
+	legacy, err := UnmarshalCommitment(b[1:])
+	if err != nil || !legacy.Equal(com) {
+		t.Errorf("unversioned commitment decoded to %v, %v", legacy, err)
+	}
+
+	for _, invalid := range [][]byte{
+		nil,
+		b[2:],
+		append([]byte{Version + 1}, b[1:]...),
+		append(b, 0),
+	} {
+		if _, err := UnmarshalCommitment(invalid); err == nil {
+			t.Errorf("commitment %x decoded", invalid)
+		}
+	}
+}
+
+func TestOpeningProofEncoding(t *testing.T) {
+	var proof OpeningProof
+	proof.T.Y.SetOne()
+	proof.S1 = big.NewInt(0x0102)
+	proof.S2 = big.NewInt(0x0304)
+	b := MarshalOpeningProof(&proof)
+	// version, compressed T, big-endian responses
+	want := "01" + "01" + "00000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000102" +
+		"0000000000000000000000000000000000000000000000000000000000000304"
+	if got := hex.EncodeToString(b); got != want {
+		t.Fatalf("proof of opening encoded as %v, want %v", got, want)
+	}
+
+	for _, encoded := range [][]byte{b, b[1:]} {
+		decoded, err := UnmarshalOpeningProof(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.T.Equal(&proof.T) || decoded.S1.Cmp(proof.S1) != 0 || decoded.S2.Cmp(proof.S2) != 0 {
+			t.Errorf("proof of opening %x decoded to %v", encoded, decoded)
+		}
+	}
+
+	// the responses are reduced modulo the order of the curve
+	order := twistededwards.GetEdwardsCurve().Order
+	proof.S1 = new(big.Int).Add(&order, big.NewInt(5))
+	decoded, err := UnmarshalOpeningProof(MarshalOpeningProof(&proof))
+	if err != nil || decoded.S1.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("response decoded to %v, %v", decoded, err)
+	}
+
+	if _, err := UnmarshalOpeningProof(b[2:]); err == nil {
+		t.Error("truncated proof of opening decoded")
+	}
+}
+
+func TestWinnerProofEncoding(t *testing.T) {
+	for proofSystem, header := range map[string]string{ProofSystemGroth16: "0101"} {
+		b, err := MarshalWinnerProof(proofSystem, []byte{0xaa, 0xbb})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(b); got != header+"aabb" {
+			t.Errorf("%v proof encoded as %v", proofSystem, got)
+		}
+		decodedSystem, proof, err := UnmarshalWinnerProof(b)
+		if err != nil || decodedSystem != proofSystem || !bytes.Equal(proof, []byte{0xaa, 0xbb}) {
+			t.Errorf("%v proof decoded to %v, %x, %v", proofSystem, decodedSystem, proof, err)
+		}
+	}
+
+	if _, err := MarshalWinnerProof("bulletproofs", nil); err == nil {
+		t.Error("unknown proof system encoded")
+	}
+	for _, invalid := range [][]byte{nil, {Version}, {Version + 1, 1}, {Version, 2}, {Version, 3}} {
+		if _, _, err := UnmarshalWinnerProof(invalid); err == nil {
+			t.Errorf("proof of the winner %x decoded", invalid)
+		}
+	}
+}
+
+func TestPublicInputs(t *testing.T) {
+	b, _ := hex.DecodeString(commitmentHex)
+	com, _ := UnmarshalCommitment(b)
+	var identity twistededwards.PointAffine
+	identity.Y.SetOne()
+
+	public, err := PublicInputs([][]byte{MarshalCommitment(&identity), b}, []bool{true, false}, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(public) != NbPublicInputs(3) {
+		t.Fatalf("%v public inputs, want %v", len(public), NbPublicInputs(3))
+	}
+	var one, winningIndex fr.Element
+	one.SetOne()
+	winningIndex.SetUint64(1)
+	// x coordinates, y coordinates, exclusion flags, unused slots hold the identity and are not excluded,
+	// then the winning commitment and index
+	want := []fr.Element{
+		{}, com.X, {},
+		one, com.Y, one,
+		one, {}, {},
+		com.X, com.Y, winningIndex,
+	}
+	for i := range want {
+		if !public[i].Equal(&want[i]) {
+			t.Errorf("public input %v is %v, want %v", i, public[i].String(), want[i].String())
+		}
+	}
+
+	if _, err := PublicInputs([][]byte{b, b}, []bool{false, false}, 0, 1); err == nil {
+		t.Error("more commitments than bids accepted")
+	}
+	if _, err := PublicInputs([][]byte{b}, []bool{false}, 1, 2); err == nil {
+		t.Error("winning index of an unused slot accepted")
+	}
+	if _, err := PublicInputs([][]byte{b, b}, []bool{true, false}, 0, 2); err == nil {
+		t.Error("winning index of an excluded bid accepted")
+	}
+	if _, err := PublicInputs([][]byte{b, b}, []bool{false}, 0, 2); err == nil {
+		t.Error("missing exclusion flag accepted")
+	}
+	if _, err := PublicInputs([][]byte{b[2:]}, []bool{false}, 0, 2); err == nil {
+		t.Error("invalid commitment accepted")
+	}
+
+	for maxBids := 1; maxBids <= 64; maxBids++ {
+		if n, err := MaxBids(NbPublicInputs(maxBids)); err != nil || n != maxBids {
+			t.Errorf("%v bids, got %v, %v", maxBids, n, err)
+		}
+	}
+	if _, err := MaxBids(NbPublicInputs(10) - 1); err == nil {
+		t.Error("public inputs of another circuit accepted")
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	revealed := map[string]bool{"a": true, "b": true, "d": true}
+	invalid := map[string]bool{"b": true}
+	excluded := Excluded([]string{"a", "b", "c", "d"}, func(bidID string) bool {
+		return revealed[bidID]
+	}, func(bidID string) bool {
+		return invalid[bidID]
+	})
+	want := []bool{false, true, true, false}
+	for i := range want {
+		if excluded[i] != want[i] {
+			t.Errorf("exclusion flags %v, want %v", excluded, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,195 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// BidAttestation is the statement of an organization that none of its unrevealed bids is higher
+// than Price. The key of the attestation can only be endorsed by the peers of the organization,
+// so the statement is signed by the peer that read the bids from the implicit collection
+type BidAttestation struct {
+	Type   string `json:"objectType"`
+	Org    string `json:"org"`
+	Price  int    `json:"price"`
+	Status string `json:"status"`
+	TxID   string `json:"txID"`
+}
+
+const attestationKeyType = "attestation"
+
+// AttestMaxBid is used by each participating organization once the auction is closed and its
+// bidders have revealed. The peer of the organization checks that no unrevealed bid in its
+// implicit collection is higher than the highest revealed price, and records the statement.
+// The client has to target a peer of their own organization
+func (s *SmartContract) AttestMaxBid(ctx contractapi.TransactionContextInterface, auctionID string) error {
+
+	err := verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return err
+	}
+	peerMSPID, err := shim.GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed getting the peer's MSPID: %v", err)
+	}
+
+	auctionBytes, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction %v: %v", auctionID, err)
+	}
+	if auctionBytes == nil {
+		return fmt.Errorf("Auction interest object %v not found", auctionID)
+	}
+
+	var auctionJSON Auction
+	err = json.Unmarshal(auctionBytes, &auctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create auction object JSON: %v", err)
+	}
+
+	if auctionJSON.Status != "closed" {
+		return fmt.Errorf("can only attest the bids of a closed auction")
+	}
+	if !contains(auctionJSON.Orgs, peerMSPID) {
+		return fmt.Errorf("organization %v does not participate in the auction", peerMSPID)
+	}
+
+	// the statement is made for the highest price revealed so far, revealing more bids can
+	// only raise the price of the auction
+	_, price := highestRevealedBid(auctionJSON.RevealedBids)
+	maxBid, err := maxUnrevealedBid(ctx, peerMSPID, auctionJSON.RevealedBids, auctionJSON.PrivateBids)
+	if err != nil {
+		return err
+	}
+	if maxBid > price {
+		return fmt.Errorf("Cannot attest, bidder has a higher price that is not revealed")
+	}
+
+	attestation := BidAttestation{
+		Type:   attestationKeyType,
+		Org:    peerMSPID,
+		Price:  price,
+		Status: "attested",
+		TxID:   ctx.GetStub().GetTxID(),
+	}
+	return putAttestation(ctx, auctionID, &attestation)
+}
+
+// QueryAttestation allows all members of the channel to read the attestation of an organization
+func (s *SmartContract) QueryAttestation(ctx contractapi.TransactionContextInterface, auctionID string, org string) (*BidAttestation, error) {
+
+	attestation, err := getAttestation(ctx, auctionID, org)
+	if err != nil {
+		return nil, err
+	}
+	if attestation == nil {
+		return nil, fmt.Errorf("attestation does not exist")
+	}
+
+	return attestation, nil
+}
+
+// addAttestation is an internal helper function to create the pending attestation of an organization
+// that joins the auction. Only the peers of the organization can endorse the key from then on
+func addAttestation(ctx contractapi.TransactionContextInterface, auctionID string, org string) error {
+
+	attestation, err := getAttestation(ctx, auctionID, org)
+	if err != nil {
+		return err
+	}
+	// the organization already joined the auction before
+	if attestation != nil {
+		return nil
+	}
+
+	attestation = &BidAttestation{
+		Type:   attestationKeyType,
+		Org:    org,
+		Status: "pending",
+	}
+	err = putAttestation(ctx, auctionID, attestation)
+	if err != nil {
+		return err
+	}
+
+	attestationKey, err := ctx.GetStub().CreateCompositeKey(attestationKeyType, []string{auctionID, org})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	err = setAssetStateBasedEndorsement(ctx, attestationKey, org)
+	if err != nil {
+		return fmt.Errorf("failed setting state based endorsement for attestation: %v", err)
+	}
+
+	return nil
+}
+
+// checkAttestations is an internal helper function to check that every participating organization
+// attested that none of its unrevealed bids is higher than the price of the auction
+func checkAttestations(ctx contractapi.TransactionContextInterface, auctionID string, auctionJSON *Auction) error {
+
+	for _, org := range auctionJSON.Orgs {
+		attestation, err := getAttestation(ctx, auctionID, org)
+		if err != nil {
+			return err
+		}
+		if attestation == nil || attestation.Status != "attested" {
+			return fmt.Errorf("organization %v has not attested its unrevealed bids", org)
+		}
+		if attestation.Price > auctionJSON.Price {
+			return fmt.Errorf("attestation of organization %v is for a higher price than the auction", org)
+		}
+	}
+
+	return nil
+}
+
+// getAttestation is an internal helper function to read the attestation of an organization
+func getAttestation(ctx contractapi.TransactionContextInterface, auctionID string, org string) (*BidAttestation, error) {
+
+	attestationKey, err := ctx.GetStub().CreateCompositeKey(attestationKeyType, []string{auctionID, org})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	attestationBytes, err := ctx.GetStub().GetState(attestationKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attestation %v: %v", attestationKey, err)
+	}
+	if attestationBytes == nil {
+		return nil, nil
+	}
+
+	var attestation BidAttestation
+	err = json.Unmarshal(attestationBytes, &attestation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attestation object JSON: %v", err)
+	}
+
+	return &attestation, nil
+}
+
+// putAttestation is an internal helper function to write the attestation of an organization
+func putAttestation(ctx contractapi.TransactionContextInterface, auctionID string, attestation *BidAttestation) error {
+
+	attestationKey, err := ctx.GetStub().CreateCompositeKey(attestationKeyType, []string{auctionID, attestation.Org})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	attestationBytes, err := json.Marshal(attestation)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(attestationKey, attestationBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put attestation: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// closedAuction creates an auction with a bid of alice of Org1MSP and a bid of bob of Org2MSP, and closes it
+func closedAuction(t *testing.T, alicePrice, bobPrice int) (*testLedger, *testBid, *testBid) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	alice := l.sendBid("auction1", "alice", "Org1MSP", alicePrice)
+	bob := l.sendBid("auction1", "bob", "Org2MSP", bobPrice)
+	l.must(l.contract.CloseAuction(l.as("seller", "Org1MSP"), "auction1"))
+	return l, alice, bob
+}
+
+// attest attests the unrevealed bids of an organization, submitted by a client of the organization
+func (l *testLedger) attest(auctionID, org string) error {
+	return l.contract.AttestMaxBid(l.as("attester", org), auctionID)
+}
+
+func TestAttestMaxBid(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	alice := l.sendBid("auction1", "alice", "Org1MSP", 50)
+	bob := l.sendBid("auction1", "bob", "Org2MSP", 80)
+	if err := l.attest("auction1", "Org1MSP"); err == nil {
+		t.Error("the bids of an open auction were attested")
+	}
+	l.must(l.contract.CloseAuction(l.as("seller", "Org1MSP"), "auction1"))
+	l.must(l.contract.RevealBid(l.withBid(alice), "auction1", alice.txID))
+
+	// the unrevealed bid of bob is higher than the price
+	if err := l.attest("auction1", "Org2MSP"); err == nil {
+		t.Error("an organization with a higher unrevealed bid attested")
+	}
+	if err := l.attest("auction1", "Org3MSP"); err == nil {
+		t.Error("an organization that does not participate attested")
+	}
+	ctx := l.as("attester", "Org2MSP")
+	os.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+	if err := l.contract.AttestMaxBid(ctx, "auction1"); err == nil {
+		t.Error("a client attested on the peer of another organization")
+	}
+
+	l.must(l.attest("auction1", "Org1MSP"))
+	attestation, err := l.contract.QueryAttestation(l.as("reader", "Org3MSP"), "auction1", "Org1MSP")
+	l.must(err)
+	if attestation.Status != "attested" || attestation.Price != 50 || attestation.TxID == "" {
+		t.Errorf("attestation %+v", attestation)
+	}
+
+	// once bob revealed, Org2MSP has no unrevealed bid
+	l.must(l.contract.RevealBid(l.withBid(bob), "auction1", bob.txID))
+	l.must(l.attest("auction1", "Org2MSP"))
+	attestation, err = l.contract.QueryAttestation(l.as("reader", "Org3MSP"), "auction1", "Org2MSP")
+	l.must(err)
+	if attestation.Price != 80 {
+		t.Errorf("attestation %+v", attestation)
+	}
+}
+
+func TestAttestationEndorsers(t *testing.T) {
+	l, _, _ := closedAuction(t, 50, 80)
+	// only the peers of an organization can endorse its attestation, another organization cannot lie for it
+	for _, org := range []string{"Org1MSP", "Org2MSP"} {
+		attestationKey, err := l.stub.CreateCompositeKey(attestationKeyType, []string{"auction1", org})
+		l.must(err)
+		if orgs := l.endorsers(attestationKey); !reflect.DeepEqual(orgs, []string{org}) {
+			t.Errorf("endorsers %v of the attestation of %v", orgs, org)
+		}
+		attestation, err := l.contract.QueryAttestation(l.as("reader", "Org3MSP"), "auction1", org)
+		l.must(err)
+		if attestation.Status != "pending" {
+			t.Errorf("attestation %+v before the organization attested", attestation)
+		}
+	}
+}
+
+func TestCheckAttestations(t *testing.T) {
+	l, alice, bob := closedAuction(t, 50, 80)
+	l.must(l.contract.RevealBid(l.withBid(alice), "auction1", alice.txID))
+	l.must(l.attest("auction1", "Org1MSP"))
+
+	// Org2MSP did not attest its unrevealed bid
+	if err := l.contract.EndAuction(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("the auction ended without the attestation of Org2MSP")
+	}
+
+	// an attestation for a higher price than the auction does not cover the bids between both prices
+	lying := &BidAttestation{Type: attestationKeyType, Org: "Org2MSP", Price: 90, Status: "attested"}
+	l.must(putAttestation(l.as("attester", "Org2MSP"), "auction1", lying))
+	if err := l.contract.EndAuction(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("the auction ended with an attestation for a higher price")
+	}
+
+	l.must(l.contract.RevealBid(l.withBid(bob), "auction1", bob.txID))
+	l.must(l.attest("auction1", "Org2MSP"))
+	if err := l.contract.EndAuction(l.as("alice", "Org1MSP"), "auction1"); err == nil {
+		t.Error("the auction was ended by another client than the seller")
+	}
+	l.must(l.contract.EndAuction(l.as("seller", "Org1MSP"), "auction1"))
+	auctionJSON := l.auction("auction1")
+	if auctionJSON.Status != "ended" || auctionJSON.Winner != "bob" || auctionJSON.Price != 80 {
+		t.Errorf("ended auction %+v", auctionJSON)
+	}
+}
+
+func TestCheckAttestationsRevealedAfter(t *testing.T) {
+	l, alice, bob := closedAuction(t, 50, 80)
+	l.must(l.contract.RevealBid(l.withBid(alice), "auction1", alice.txID))
+	l.must(l.attest("auction1", "Org1MSP"))
+	l.must(l.contract.RevealBid(l.withBid(bob), "auction1", bob.txID))
+	l.must(l.attest("auction1", "Org2MSP"))
+
+	// the attestation of Org1MSP was made for a lower price, revealing bids only raises the price
+	l.must(l.contract.EndAuction(l.as("seller", "Org1MSP"), "auction1"))
+	if auctionJSON := l.auction("auction1"); auctionJSON.Winner != "bob" || auctionJSON.Price != 80 {
+		t.Errorf("winner %v at %v", auctionJSON.Winner, auctionJSON.Price)
+	}
+}
+
+func TestEndAuctionTie(t *testing.T) {
+	l, alice, bob := closedAuction(t, 60, 60)
+	l.must(l.contract.RevealBid(l.withBid(alice), "auction1", alice.txID))
+	l.must(l.contract.RevealBid(l.withBid(bob), "auction1", bob.txID))
+	l.must(l.attest("auction1", "Org1MSP"))
+	l.must(l.attest("auction1", "Org2MSP"))
+	l.must(l.contract.EndAuction(l.as("seller", "Org1MSP"), "auction1"))
+
+	// the bid with the lowest key wins the tie
+	winner := "alice"
+	if l.bidKey("auction1", bob) < l.bidKey("auction1", alice) {
+		winner = "bob"
+	}
+	if auctionJSON := l.auction("auction1"); auctionJSON.Winner != winner || auctionJSON.Price != 60 {
+		t.Errorf("winner %v at %v, expected %v", auctionJSON.Winner, auctionJSON.Price, winner)
+	}
+}
+
+func TestHighestRevealedBid(t *testing.T) {
+	if winner, price := highestRevealedBid(nil); winner != "" || price != 0 {
+		t.Errorf("winner %v at %v without bids", winner, price)
+	}
+
+	revealedBids := map[string]FullBid{
+		"bid3": {Price: 70, Bidder: "carol"},
+		"bid2": {Price: 70, Bidder: "bob"},
+		"bid4": {Price: 70, Bidder: "dave"},
+		"bid1": {Price: 40, Bidder: "alice"},
+	}
+	// the order of the map changes between iterations, the winner does not
+	for i := 0; i < 20; i++ {
+		if winner, price := highestRevealedBid(revealedBids); winner != "bob" || price != 70 {
+			t.Fatalf("winner %v at %v, expected bob at 70", winner, price)
+		}
+	}
+
+	revealedBids["bid5"] = FullBid{Price: 71, Bidder: "eve"}
+	if winner, price := highestRevealedBid(revealedBids); winner != "eve" || price != 71 {
+		t.Errorf("winner %v at %v, expected eve at 71", winner, price)
+	}
+}
@@ -28,6 +28,8 @@ type Auction struct {
 	Winner       string             `json:"winner"`
 	Price        int                `json:"price"`
 	Status       string             `json:"status"`
+	Ended        int64              `json:"ended,omitempty"`
+	Version      int                `json:"version,omitempty"`
 }
 
 // FullBid is the structure of a revealed bid
@@ -46,6 +48,10 @@ type BidHash struct {
 
 const bidKeyType = "bid"
 
+// auctionVersion is the schema version of the auctions of this chaincode, auctions without a version
+// were created before it was introduced and have the same schema
+const auctionVersion = 1
+
 // CreateAuction creates on auction on the public channel. The identity that
 // submits the transacion becomes the seller of the auction
 func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string) error {
@@ -76,6 +82,7 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 		RevealedBids: revealedBids,
 		Winner:       "",
 		Status:       "open",
+		Version:      auctionVersion,
 	}
 
 	auctionBytes, err := json.Marshal(auction)
@@ -95,6 +102,12 @@ func (s *SmartContract) CreateAuction(ctx contractapi.TransactionContextInterfac
 		return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 	}
 
+	// the organization of the seller attests its own unrevealed bids before the auction ends
+	err = addAttestation(ctx, auctionID, clientOrgID)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -216,6 +229,10 @@ func (s *SmartContract) SubmitBid(ctx contractapi.TransactionContextInterface, a
 		if err != nil {
 			return fmt.Errorf("failed setting state based endorsement for new organization: %v", err)
 		}
+		err = addAttestation(ctx, auctionID, clientOrgID)
+		if err != nil {
+			return err
+		}
 	}
 
 	newAuctionBytes, _ := json.Marshal(auctionJSON)
@@ -462,26 +479,35 @@ func (s *SmartContract) EndAuction(ctx contractapi.TransactionContextInterface,
 	}
 
 	// determine the highest bid
-	for _, bid := range revealedBidMap {
-		if bid.Price > auctionJSON.Price {
-			auctionJSON.Winner = bid.Bidder
-			auctionJSON.Price = bid.Price
-		}
-	}
+	auctionJSON.Winner, auctionJSON.Price = highestRevealedBid(revealedBidMap)
 
-	// check if there is a winning bid that has yet to be revealed
-	err = queryAllBids(ctx, auctionJSON.Price, auctionJSON.RevealedBids, auctionJSON.PrivateBids)
+	// check that no participating organization has a winning bid that has yet to be revealed
+	err = checkAttestations(ctx, auctionID, &auctionJSON)
 	if err != nil {
 		return fmt.Errorf("Cannot close auction: %v", err)
 	}
 
 	auctionJSON.Status = string("ended")
 
+	// the retention period of the bids starts when the auction ends
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	auctionJSON.Ended = timestamp.GetSeconds()
+
 	closedAuction, _ := json.Marshal(auctionJSON)
 
 	err = ctx.GetStub().PutState(auctionID, closedAuction)
 	if err != nil {
 		return fmt.Errorf("failed to close auction: %v", err)
 	}
+
+	// the organizations purge their bids once their retention period is over
+	endedEvent, _ := json.Marshal(AuctionEndedEvent{AuctionID: auctionID, Orgs: auctionJSON.Orgs, Ended: auctionJSON.Ended})
+	err = ctx.GetStub().SetEvent("AuctionEnded", endedEvent)
+	if err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
 	return nil
 }
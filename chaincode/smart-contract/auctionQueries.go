@@ -7,8 +7,8 @@ package auction
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
-	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -89,60 +89,65 @@ func (s *SmartContract) GetID(ctx contractapi.TransactionContextInterface) (stri
 	return clientID, nil
 }
 
-// queryAllBids is an internal function that is used to determine if a winning bid has yet to be revealed
-func queryAllBids(ctx contractapi.TransactionContextInterface, auctionPrice int, revealedBidders map[string]FullBid, bidders map[string]BidHash) error {
+// maxUnrevealedBid is an internal function that returns the highest price of the bids of an
+// organization that are not revealed. The bids are read from the implicit collection of the
+// organization, so it can only run on a peer of that organization
+func maxUnrevealedBid(ctx contractapi.TransactionContextInterface, org string, revealedBidders map[string]FullBid, bidders map[string]BidHash) (int, error) {
 
-	// Get MSP ID of peer org
-	peerMSPID, err := shim.GetMSPID()
-	if err != nil {
-		return fmt.Errorf("failed getting the peer's MSPID: %v", err)
-	}
-
-	var error error
-	error = nil
+	collection := "_implicit_org_" + org
+	maxPrice := 0
 
 	for bidKey, privateBid := range bidders {
 
-		if _, bidInAuction := revealedBidders[bidKey]; bidInAuction {
+		if _, bidInAuction := revealedBidders[bidKey]; bidInAuction || privateBid.Org != org {
 
-			//bid is already revealed, no action to take
+			//bid is already revealed or is held by another organization, no action to take
 
 		} else {
 
-			collection := "_implicit_org_" + privateBid.Org
-
-			if privateBid.Org == peerMSPID {
-
-				bidJSON, err := ctx.GetStub().GetPrivateData(collection, bidKey)
-				if err != nil {
-					return fmt.Errorf("failed to get bid %v: %v", bidKey, err)
-				}
-				if bidJSON == nil {
-					return fmt.Errorf("bid %v does not exist", bidKey)
-				}
-
-				var bid *FullBid
-				err = json.Unmarshal(bidJSON, &bid)
-				if err != nil {
-					return err
-				}
-
-				if bid.Price > auctionPrice {
-					error = fmt.Errorf("Cannot close auction, bidder has a higher price: %v", err)
-				}
-
-			} else {
-
-				Hash, err := ctx.GetStub().GetPrivateDataHash(collection, bidKey)
-				if err != nil {
-					return fmt.Errorf("failed to read bid hash from collection: %v", err)
-				}
-				if Hash == nil {
-					return fmt.Errorf("bid hash does not exist: %s", bidKey)
-				}
+			bidJSON, err := ctx.GetStub().GetPrivateData(collection, bidKey)
+			if err != nil {
+				return 0, fmt.Errorf("failed to get bid %v: %v", bidKey, err)
+			}
+			if bidJSON == nil {
+				return 0, fmt.Errorf("bid %v does not exist", bidKey)
+			}
+
+			var bid *FullBid
+			err = json.Unmarshal(bidJSON, &bid)
+			if err != nil {
+				return 0, err
+			}
+
+			if bid.Price > maxPrice {
+				maxPrice = bid.Price
 			}
 		}
 	}
 
-	return error
+	return maxPrice, nil
+}
+
+// highestRevealedBid is an internal function that returns the bidder and price of the highest
+// revealed bid. Among equal prices the bid with the lowest key wins, so that the peers of every
+// organization endorse the same winner
+func highestRevealedBid(revealedBidders map[string]FullBid) (string, int) {
+
+	var bidKeys []string
+	for bidKey := range revealedBidders {
+		bidKeys = append(bidKeys, bidKey)
+	}
+	sort.Strings(bidKeys)
+
+	winner := ""
+	price := 0
+	for _, bidKey := range bidKeys {
+		bid := revealedBidders[bidKey]
+		if bid.Price > price {
+			winner = bid.Bidder
+			price = bid.Price
+		}
+	}
+
+	return winner, price
 }
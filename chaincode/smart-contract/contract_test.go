@@ -0,0 +1,200 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// testStub adds the private data functions that the mock stub does not implement. The hash of
+// private data is the SHA-256 of its value, as on a peer. proposal is the signed proposal of the
+// transaction, if it is set
+type testStub struct {
+	*shimtest.MockStub
+	proposal *peer.SignedProposal
+}
+
+func (s *testStub) GetSignedProposal() (*peer.SignedProposal, error) {
+	if s.proposal != nil {
+		return s.proposal, nil
+	}
+	return s.MockStub.GetSignedProposal()
+}
+
+func (s *testStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, err := s.GetPrivateData(collection, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *testStub) DelPrivateData(collection, key string) error {
+	delete(s.PvtState[collection], key)
+	return nil
+}
+
+func (s *testStub) PurgePrivateData(collection, key string) error {
+	return s.DelPrivateData(collection, key)
+}
+
+// testIdentity is the identity of the client submitting a transaction to the mock stub
+type testIdentity struct {
+	id    string
+	mspID string
+}
+
+func (c *testIdentity) GetID() (string, error) {
+	return c.id, nil
+}
+
+func (c *testIdentity) GetMSPID() (string, error) {
+	return c.mspID, nil
+}
+
+func (c *testIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (c *testIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	return fmt.Errorf("attribute %v not found", attrName)
+}
+
+func (c *testIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{}, nil
+}
+
+// testLedger runs the transactions of the contract on a mock stub, one after the other
+type testLedger struct {
+	t        *testing.T
+	stub     *testStub
+	contract SmartContract
+	nbTx     int
+}
+
+// testBid is a bid stored in the implicit collection of the organization of its bidder
+type testBid struct {
+	txID   string
+	bidder string
+	org    string
+	json   []byte
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+	// the peer of every transaction is a peer of the organization of the client
+	mspID, set := os.LookupEnv("CORE_PEER_LOCALMSPID")
+	t.Cleanup(func() {
+		if set {
+			os.Setenv("CORE_PEER_LOCALMSPID", mspID)
+		} else {
+			os.Unsetenv("CORE_PEER_LOCALMSPID")
+		}
+	})
+	return &testLedger{t: t, stub: &testStub{MockStub: shimtest.NewMockStub("auction", nil)}}
+}
+
+// as starts a new transaction submitted by the client id of the organization mspID to a peer of mspID
+func (l *testLedger) as(id, mspID string) *contractapi.TransactionContext {
+	l.nbTx++
+	l.stub.MockTransactionStart(fmt.Sprintf("tx%v", l.nbTx))
+	l.stub.TransientMap = nil
+	l.stub.proposal = nil
+	os.Setenv("CORE_PEER_LOCALMSPID", mspID)
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(l.stub)
+	ctx.SetClientIdentity(&testIdentity{id, mspID})
+	return ctx
+}
+
+// withBid starts a new transaction of the bidder with bids in the transient map, under "bid" and then "updatedBid"
+func (l *testLedger) withBid(bid *testBid, bids ...[]byte) *contractapi.TransactionContext {
+	ctx := l.as(bid.bidder, bid.org)
+	transient := map[string][]byte{"bid": bid.json}
+	if len(bids) > 0 {
+		transient["updatedBid"] = bids[0]
+	}
+	l.stub.TransientMap = transient
+	return ctx
+}
+
+// must fails the test if a transaction failed
+func (l *testLedger) must(err error) {
+	l.t.Helper()
+	if err != nil {
+		l.t.Fatal(err)
+	}
+}
+
+// createAuction creates an auction sold by seller of Org1MSP
+func (l *testLedger) createAuction(auctionID string) {
+	l.t.Helper()
+	l.must(l.contract.CreateAuction(l.as("seller", "Org1MSP"), auctionID, "item"))
+}
+
+// bidJSON returns the JSON of a bid, as the application creates it
+func bidJSON(bidder, org string, price int) []byte {
+	bidBytes, _ := json.Marshal(FullBid{Type: bidKeyType, Price: price, Org: org, Bidder: bidder})
+	return bidBytes
+}
+
+// storeBid stores a bid in the implicit collection of the organization of the bidder, without submitting it
+func (l *testLedger) storeBid(auctionID, bidder, org string, price int) *testBid {
+	l.t.Helper()
+	bid := &testBid{bidder: bidder, org: org, json: bidJSON(bidder, org, price)}
+	txID, err := l.contract.Bid(l.withBid(bid), auctionID)
+	l.must(err)
+	bid.txID = txID
+	return bid
+}
+
+// sendBid stores a bid and submits its hash to the auction
+func (l *testLedger) sendBid(auctionID, bidder, org string, price int) *testBid {
+	l.t.Helper()
+	bid := l.storeBid(auctionID, bidder, org, price)
+	l.must(l.contract.SubmitBid(l.as(bidder, org), auctionID, bid.txID))
+	return bid
+}
+
+// auction reads an auction from the state
+func (l *testLedger) auction(auctionID string) *Auction {
+	l.t.Helper()
+	auctionBytes, err := l.stub.GetState(auctionID)
+	l.must(err)
+	var auctionJSON Auction
+	l.must(json.Unmarshal(auctionBytes, &auctionJSON))
+	return &auctionJSON
+}
+
+// endorsers returns the sorted organizations of the state based endorsement policy of a key
+func (l *testLedger) endorsers(key string) []string {
+	l.t.Helper()
+	policy, err := l.stub.GetStateValidationParameter(key)
+	l.must(err)
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	l.must(err)
+	orgs := endorsementPolicy.ListOrgs()
+	sort.Strings(orgs)
+	return orgs
+}
+
+// bidKey returns the key of a bid in the implicit collection of its organization
+func (l *testLedger) bidKey(auctionID string, bid *testBid) string {
+	l.t.Helper()
+	bidKey, err := l.stub.CreateCompositeKey(bidKeyType, []string{auctionID, bid.txID})
+	l.must(err)
+	return bidKey
+}
@@ -0,0 +1,130 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// migrationChaincode is the name of the commitment auction chaincode, the only chaincode auctions can be migrated to
+const migrationChaincode = "blindauction"
+
+// RetireAuction is called by MigrateAuction of the commitment auction chaincode in the transaction that
+// moves an auction to it. The auction is marked as migrated, so that it no longer accepts bids or reveals,
+// and the retention period of the bids starts. An open auction can only be retired if it has no bids, a
+// closed auction once every participating organization attested that its unrevealed bids are not higher
+// than the revealed ones, so that no bid that could win is left behind. Only the seller can retire the auction,
+// and only from MigrateAuction, a direct call would retire the auction without moving it
+func (s *SmartContract) RetireAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
+
+	err := checkMigrationProposal(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	auctionBytes, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction %v: %v", auctionID, err)
+	}
+	if auctionBytes == nil {
+		return fmt.Errorf("Auction interest object %v not found", auctionID)
+	}
+
+	var auctionJSON Auction
+	err = json.Unmarshal(auctionBytes, &auctionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create auction object JSON: %v", err)
+	}
+
+	// get ID of submitting client
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	if auctionJSON.Seller != clientID {
+		return fmt.Errorf("auction can only be migrated by seller")
+	}
+
+	switch auctionJSON.Status {
+	case "open":
+		if len(auctionJSON.PrivateBids) > 0 {
+			return fmt.Errorf("cannot migrate an open auction with bids, close it and have the bids revealed first")
+		}
+	case "closed":
+		if len(auctionJSON.RevealedBids) == 0 {
+			return fmt.Errorf("No bids have been revealed, cannot migrate auction")
+		}
+		_, price := highestRevealedBid(auctionJSON.RevealedBids)
+		err = checkAttestations(ctx, auctionID, &Auction{Orgs: auctionJSON.Orgs, Price: price})
+		if err != nil {
+			return fmt.Errorf("Cannot migrate auction: %v", err)
+		}
+	default:
+		return fmt.Errorf("can only migrate an open or closed auction")
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	auctionJSON.Status = "migrated"
+	auctionJSON.Ended = timestamp.GetSeconds()
+
+	migratedAuction, _ := json.Marshal(auctionJSON)
+
+	err = ctx.GetStub().PutState(auctionID, migratedAuction)
+	if err != nil {
+		return fmt.Errorf("failed to migrate auction: %v", err)
+	}
+
+	return nil
+}
+
+// checkMigrationProposal is an internal helper function checking that the signed proposal of the transaction
+// invokes MigrateAuction of the commitment auction chaincode for the auction. Another chaincode with a
+// MigrateAuction function could retire the auction without moving it
+func checkMigrationProposal(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil || signedProposal == nil {
+		return fmt.Errorf("failed to get signed proposal: %v", err)
+	}
+	proposal := &peer.Proposal{}
+	err = proto.Unmarshal(signedProposal.ProposalBytes, proposal)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal proposal: %v", err)
+	}
+	proposalPayload := &peer.ChaincodeProposalPayload{}
+	err = proto.Unmarshal(proposal.Payload, proposalPayload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal proposal payload: %v", err)
+	}
+	invocation := &peer.ChaincodeInvocationSpec{}
+	err = proto.Unmarshal(proposalPayload.Input, invocation)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal chaincode invocation: %v", err)
+	}
+	spec := invocation.ChaincodeSpec
+	if spec == nil || spec.Input == nil || len(spec.Input.Args) < 2 {
+		return fmt.Errorf("auction can only be retired by MigrateAuction")
+	}
+	if spec.ChaincodeId == nil || spec.ChaincodeId.Name != migrationChaincode {
+		return fmt.Errorf("auction can only be retired by MigrateAuction of %v", migrationChaincode)
+	}
+	// contractapi functions may be called with the name of their contract as prefix
+	function := string(spec.Input.Args[0])
+	if function != "MigrateAuction" && !strings.HasSuffix(function, ":MigrateAuction") {
+		return fmt.Errorf("auction can only be retired by MigrateAuction")
+	}
+	if string(spec.Input.Args[1]) != auctionID {
+		return fmt.Errorf("auction %v is not the one being migrated", auctionID)
+	}
+	return nil
+}
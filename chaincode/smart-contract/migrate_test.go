@@ -0,0 +1,124 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// retire starts a transaction of the seller whose proposal invokes function of chaincode with args, and
+// retires the auction from it
+func (l *testLedger) retire(auctionID, chaincode, function string, args ...string) error {
+	ctx := l.as("seller", "Org1MSP")
+	l.stub.proposal = invocationProposal(l, chaincode, function, args...)
+	return l.contract.RetireAuction(ctx, auctionID)
+}
+
+// invocationProposal returns a signed proposal invoking function of chaincode with args
+func invocationProposal(l *testLedger, chaincode, function string, args ...string) *peer.SignedProposal {
+	l.t.Helper()
+	input := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		input = append(input, []byte(arg))
+	}
+	invocation, err := proto.Marshal(&peer.ChaincodeInvocationSpec{ChaincodeSpec: &peer.ChaincodeSpec{
+		ChaincodeId: &peer.ChaincodeID{Name: chaincode},
+		Input:       &peer.ChaincodeInput{Args: input},
+	}})
+	l.must(err)
+	payload, err := proto.Marshal(&peer.ChaincodeProposalPayload{Input: invocation})
+	l.must(err)
+	proposal, err := proto.Marshal(&peer.Proposal{Payload: payload})
+	l.must(err)
+	return &peer.SignedProposal{ProposalBytes: proposal}
+}
+
+func TestRetireAuction(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+
+	refused := map[string][]string{
+		"a direct call":                       {"auction", "RetireAuction", "auction1"},
+		"MigrateAuction of another chaincode": {"other", "MigrateAuction", "auction1", "auction", "pk", "key"},
+		"the migration of another auction":    {migrationChaincode, "MigrateAuction", "auction2", "auction", "pk", "key"},
+		"another function":                    {migrationChaincode, "CreateAuction", "auction1", "item", "pk", "key"},
+		"MigrateAuction without arguments":    {migrationChaincode, "MigrateAuction"},
+	}
+	for name, invocation := range refused {
+		if err := l.retire("auction1", invocation[0], invocation[1], invocation[2:]...); err == nil {
+			t.Errorf("the auction was retired by %v", name)
+		}
+	}
+	if err := l.contract.RetireAuction(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("the auction was retired without a proposal")
+	}
+	ctx := l.as("alice", "Org1MSP")
+	l.stub.proposal = invocationProposal(l, migrationChaincode, "MigrateAuction", "auction1", "auction", "pk", "key")
+	if err := l.contract.RetireAuction(ctx, "auction1"); err == nil {
+		t.Error("the auction was retired by another client than the seller")
+	}
+
+	// the function may be called with the name of its contract as prefix
+	l.must(l.retire("auction1", migrationChaincode, "SmartContract:MigrateAuction", "auction1", "auction", "pk", "key"))
+	auctionJSON := l.auction("auction1")
+	if auctionJSON.Status != "migrated" || auctionJSON.Ended == 0 {
+		t.Errorf("retired auction %+v", auctionJSON)
+	}
+	if err := l.retire("auction1", migrationChaincode, "MigrateAuction", "auction1"); err == nil {
+		t.Error("the auction was retired twice")
+	}
+	bid := &testBid{bidder: "bob", org: "Org2MSP", json: bidJSON("bob", "Org2MSP", 60)}
+	txID, err := l.contract.Bid(l.withBid(bid), "auction1")
+	l.must(err)
+	if err := l.contract.SubmitBid(l.as("bob", "Org2MSP"), "auction1", txID); err == nil {
+		t.Error("a bid was submitted to a retired auction")
+	}
+}
+
+func TestRetireAuctionWithBids(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	alice := l.sendBid("auction1", "alice", "Org1MSP", 50)
+	l.sendBid("auction1", "bob", "Org2MSP", 80)
+
+	if err := l.retire("auction1", migrationChaincode, "MigrateAuction", "auction1"); err == nil {
+		t.Error("an open auction with bids was retired")
+	}
+	l.must(l.contract.CloseAuction(l.as("seller", "Org1MSP"), "auction1"))
+	if err := l.retire("auction1", migrationChaincode, "MigrateAuction", "auction1"); err == nil {
+		t.Error("a closed auction without revealed bids was retired")
+	}
+	l.must(l.contract.RevealBid(l.withBid(alice), "auction1", alice.txID))
+	l.must(l.attest("auction1", "Org1MSP"))
+
+	// the unrevealed bid of bob could win, Org2MSP cannot attest
+	if err := l.attest("auction1", "Org2MSP"); err == nil {
+		t.Error("an organization with a higher unrevealed bid attested")
+	}
+	if err := l.retire("auction1", migrationChaincode, "MigrateAuction", "auction1"); err == nil {
+		t.Error("an auction was retired without the attestation of every organization")
+	}
+}
+
+func TestRetireClosedAuction(t *testing.T) {
+	l, alice, bob := closedAuction(t, 80, 50)
+	l.must(l.contract.RevealBid(l.withBid(alice), "auction1", alice.txID))
+	l.must(l.attest("auction1", "Org1MSP"))
+	// the unrevealed bid of bob cannot win, it is left behind
+	l.must(l.attest("auction1", "Org2MSP"))
+	l.must(l.retire("auction1", migrationChaincode, "MigrateAuction", "auction1"))
+	if status := l.auction("auction1").Status; status != "migrated" {
+		t.Errorf("status %v of the retired auction", status)
+	}
+	if err := l.contract.RevealBid(l.withBid(bob), "auction1", bob.txID); err == nil {
+		t.Error("a bid was revealed in a retired auction")
+	}
+	if err := l.contract.EndAuction(l.as("seller", "Org1MSP"), "auction1"); err == nil {
+		t.Error("a retired auction was ended")
+	}
+}
@@ -0,0 +1,157 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AuctionEndedEvent is emitted by EndAuction, so that the participating organizations can purge
+// their bids once their retention period is over
+type AuctionEndedEvent struct {
+	AuctionID string   `json:"auctionID"`
+	Orgs      []string `json:"organizations"`
+	Ended     int64    `json:"ended"`
+}
+
+// retentionKey is the key of the retention period in the implicit collection of an organization
+const retentionKey = "retentionPeriod"
+
+// SetRetentionPeriod sets how many seconds the bids of the client's organization are kept after
+// an auction ends. The period is stored in the implicit collection of the organization, so each
+// organization decides for its own bids. The client has to target a peer of their own organization
+func (s *SmartContract) SetRetentionPeriod(ctx contractapi.TransactionContextInterface, seconds int64) error {
+
+	if seconds < 0 {
+		return fmt.Errorf("retention period cannot be negative")
+	}
+
+	err := verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData(collection, retentionKey, []byte(strconv.FormatInt(seconds, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to put retention period into collection: %v", err)
+	}
+
+	return nil
+}
+
+// QueryRetentionPeriod returns the retention period of the bids of the client's organization in seconds,
+// the bids can be purged as soon as the auction ends if it was never set
+func (s *SmartContract) QueryRetentionPeriod(ctx contractapi.TransactionContextInterface) (int64, error) {
+
+	err := verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return getRetentionPeriod(ctx)
+}
+
+// PurgeBids purges every bid of an ended or migrated auction from the implicit collection of the client's
+// organization, once the retention period of the organization is over. The bids that were never
+// submitted to the auction are purged as well. PurgePrivateData also removes the bids from the private
+// write sets of past blocks on the peers of the organization, it needs Fabric 2.5 peers.
+// The function returns the number of purged bids
+func (s *SmartContract) PurgeBids(ctx contractapi.TransactionContextInterface, auctionID string) (int, error) {
+
+	err := verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	auctionBytes, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get auction %v: %v", auctionID, err)
+	}
+	if auctionBytes == nil {
+		return 0, fmt.Errorf("Auction not found: %v", auctionID)
+	}
+
+	var auctionJSON Auction
+	err = json.Unmarshal(auctionBytes, &auctionJSON)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create auction object JSON: %v", err)
+	}
+
+	if auctionJSON.Status != "ended" && auctionJSON.Status != "migrated" {
+		return 0, fmt.Errorf("can only purge the bids of an ended or migrated auction")
+	}
+
+	retention, err := getRetentionPeriod(ctx)
+	if err != nil {
+		return 0, err
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	if timestamp.GetSeconds() < auctionJSON.Ended+retention {
+		return 0, fmt.Errorf("bids of auction %v are retained until %v", auctionID, auctionJSON.Ended+retention)
+	}
+
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	// the bids of the auction share the first attribute of their composite key
+	bidsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(collection, bidKeyType, []string{auctionID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read bids from collection: %v", err)
+	}
+	defer bidsIterator.Close()
+
+	purged := 0
+	for bidsIterator.HasNext() {
+		bid, err := bidsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+		err = ctx.GetStub().PurgePrivateData(collection, bid.Key)
+		if err != nil {
+			return 0, fmt.Errorf("failed to purge bid from collection: %v", err)
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// getRetentionPeriod is an internal helper function to read the retention period of the client's organization
+func getRetentionPeriod(ctx contractapi.TransactionContextInterface) (int64, error) {
+
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	retentionBytes, err := ctx.GetStub().GetPrivateData(collection, retentionKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read retention period from collection: %v", err)
+	}
+	if retentionBytes == nil {
+		return 0, nil
+	}
+
+	retention, err := strconv.ParseInt(string(retentionBytes), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse retention period: %v", err)
+	}
+
+	return retention, nil
+}
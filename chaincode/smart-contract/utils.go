@@ -64,6 +64,32 @@ func addAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface,
 	return nil
 }
 
+// delAssetStateBasedEndorsement removes an organization from the endorsers of the auction
+func delAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, auctionID string, orgToRemove string) error {
+
+	endorsementPolicy, err := ctx.GetStub().GetStateValidationParameter(auctionID)
+	if err != nil {
+		return err
+	}
+
+	newEndorsementPolicy, err := statebased.NewStateEP(endorsementPolicy)
+	if err != nil {
+		return err
+	}
+
+	newEndorsementPolicy.DelOrgs(orgToRemove)
+	policy, err := newEndorsementPolicy.Policy()
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy bytes from org: %v", err)
+	}
+	err = ctx.GetStub().SetStateValidationParameter(auctionID, policy)
+	if err != nil {
+		return fmt.Errorf("failed to set validation parameter on auction: %v", err)
+	}
+
+	return nil
+}
+
 // getCollectionName is an internal helper function to get collection of submitting client identity.
 func getCollectionName(ctx contractapi.TransactionContextInterface) (string, error) {
 
@@ -0,0 +1,234 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// WithdrawBid is used by a bidder to retract their bid while the auction is open. The bid is
+// deleted from the private data collection of the bidder's organization and removed from the
+// auction. The bid must be passed in the transient map to prove that the client is the bidder
+func (s *SmartContract) WithdrawBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) error {
+
+	auctionJSON, err := getOpenAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	collection, bidKey, err := checkBidOwner(ctx, auctionID, txID)
+	if err != nil {
+		return err
+	}
+
+	// delete the bid from the organization's implicit data collection
+	err = ctx.GetStub().DelPrivateData(collection, bidKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete bid from collection: %v", err)
+	}
+
+	// a bid that was not submitted is not part of the auction yet
+	privateBid, submitted := auctionJSON.PrivateBids[bidKey]
+	if !submitted {
+		return nil
+	}
+	delete(auctionJSON.PrivateBids, bidKey)
+
+	// the organization no longer endorses the auction if this was its last bid, the
+	// organization of the seller always does
+	if !hasBidsFromOrg(auctionJSON.PrivateBids, privateBid.Org) && privateBid.Org != auctionJSON.Orgs[0] {
+		var orgs []string
+		for _, org := range auctionJSON.Orgs {
+			if org != privateBid.Org {
+				orgs = append(orgs, org)
+			}
+		}
+		auctionJSON.Orgs = orgs
+
+		err = delAssetStateBasedEndorsement(ctx, auctionID, privateBid.Org)
+		if err != nil {
+			return fmt.Errorf("failed removing organization from state based endorsement: %v", err)
+		}
+	}
+
+	newAuctionBytes, _ := json.Marshal(auctionJSON)
+
+	err = ctx.GetStub().PutState(auctionID, newAuctionBytes)
+	if err != nil {
+		return fmt.Errorf("failed to update auction: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateBid is used by a bidder to replace their bid while the auction is open. The current bid
+// is passed in the transient map under "bid" to prove that the client is the bidder, and the new
+// bid under "updatedBid". If the bid was submitted, its hash in the auction is replaced as well
+func (s *SmartContract) UpdateBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) error {
+
+	auctionJSON, err := getOpenAuction(ctx, auctionID)
+	if err != nil {
+		return err
+	}
+
+	collection, bidKey, err := checkBidOwner(ctx, auctionID, txID)
+	if err != nil {
+		return err
+	}
+
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient: %v", err)
+	}
+	updatedBidJSON, ok := transientMap["updatedBid"]
+	if !ok {
+		return fmt.Errorf("updatedBid key not found in the transient map")
+	}
+
+	// the new bid keeps the same bidder and organization
+	var updatedBid FullBid
+	err = json.Unmarshal(updatedBidJSON, &updatedBid)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity %v", err)
+	}
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if updatedBid.Bidder != clientID || updatedBid.Org != clientOrgID {
+		return fmt.Errorf("updated bid must have the same bidder and organization")
+	}
+	if updatedBid.Type != bidKeyType || updatedBid.Price <= 0 {
+		return fmt.Errorf("updated bid must be a bid with a positive price")
+	}
+
+	// replace the bid in the organization's implicit data collection
+	err = ctx.GetStub().PutPrivateData(collection, bidKey, updatedBidJSON)
+	if err != nil {
+		return fmt.Errorf("failed to input price into collection: %v", err)
+	}
+
+	if _, submitted := auctionJSON.PrivateBids[bidKey]; !submitted {
+		return nil
+	}
+
+	// the hash of private data is the SHA-256 of its value, it is the hash that RevealBid
+	// finds in the collection once the update is committed
+	updatedHash := sha256.Sum256(updatedBidJSON)
+	auctionJSON.PrivateBids[bidKey] = BidHash{
+		Org:  clientOrgID,
+		Hash: fmt.Sprintf("%x", updatedHash),
+	}
+
+	newAuctionBytes, _ := json.Marshal(auctionJSON)
+
+	err = ctx.GetStub().PutState(auctionID, newAuctionBytes)
+	if err != nil {
+		return fmt.Errorf("failed to update auction: %v", err)
+	}
+
+	return nil
+}
+
+// getOpenAuction is an internal helper function to read an auction that accepts changes to bids
+func getOpenAuction(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
+
+	auctionBytes, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction %v: %v", auctionID, err)
+	}
+	if auctionBytes == nil {
+		return nil, fmt.Errorf("Auction not found: %v", auctionID)
+	}
+
+	var auctionJSON Auction
+	err = json.Unmarshal(auctionBytes, &auctionJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auction object JSON: %v", err)
+	}
+
+	if auctionJSON.Status != "open" {
+		return nil, fmt.Errorf("bids can only be changed while the auction is open")
+	}
+
+	return &auctionJSON, nil
+}
+
+// checkBidOwner is an internal helper function to check that the bid passed in the transient map
+// is the bid stored in the collection of the client's organization, and that the client is its
+// bidder. The hash of the bid is used, so that every endorsing peer can check it
+func checkBidOwner(ctx contractapi.TransactionContextInterface, auctionID string, txID string) (string, string, error) {
+
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", "", fmt.Errorf("error getting transient: %v", err)
+	}
+	transientBidJSON, ok := transientMap["bid"]
+	if !ok {
+		return "", "", fmt.Errorf("bid key not found in the transient map")
+	}
+
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get implicit collection name: %v", err)
+	}
+
+	bidKey, err := ctx.GetStub().CreateCompositeKey(bidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create composite key: %v", err)
+	}
+
+	bidHash, err := ctx.GetStub().GetPrivateDataHash(collection, bidKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read bid hash from collection: %v", err)
+	}
+	if bidHash == nil {
+		return "", "", fmt.Errorf("bid hash does not exist: %s", bidKey)
+	}
+
+	calculatedBidJSONHash := sha256.Sum256(transientBidJSON)
+	if !bytes.Equal(calculatedBidJSONHash[:], bidHash) {
+		return "", "", fmt.Errorf("hash %x for bid JSON %s does not match hash in collection: %x",
+			calculatedBidJSONHash,
+			transientBidJSON,
+			bidHash,
+		)
+	}
+
+	var bid FullBid
+	err = json.Unmarshal(transientBidJSON, &bid)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get client identity %v", err)
+	}
+	if bid.Bidder != clientID {
+		return "", "", fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
+	}
+
+	return collection, bidKey, nil
+}
+
+// hasBidsFromOrg is an internal helper function to check if an organization still has bids in the auction
+func hasBidsFromOrg(bidders map[string]BidHash, org string) bool {
+	for _, privateBid := range bidders {
+		if privateBid.Org == org {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auction
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWithdrawBid(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	alice := l.sendBid("auction1", "alice", "Org1MSP", 50)
+	bob := l.sendBid("auction1", "bob", "Org2MSP", 60)
+	if orgs := l.endorsers("auction1"); !reflect.DeepEqual(orgs, []string{"Org1MSP", "Org2MSP"}) {
+		t.Fatalf("endorsers %v after the bids", orgs)
+	}
+
+	stolen := &testBid{txID: bob.txID, bidder: "carol", org: "Org2MSP", json: bob.json}
+	if err := l.contract.WithdrawBid(l.withBid(stolen), "auction1", bob.txID); err == nil {
+		t.Error("a bid was withdrawn by another client than its bidder")
+	}
+	forged := &testBid{txID: bob.txID, bidder: "bob", org: "Org2MSP", json: bidJSON("bob", "Org2MSP", 1)}
+	if err := l.contract.WithdrawBid(l.withBid(forged), "auction1", bob.txID); err == nil {
+		t.Error("a bid was withdrawn with another bid in the transient map")
+	}
+
+	// the last bid of Org2MSP is withdrawn, the organization no longer endorses the auction
+	l.must(l.contract.WithdrawBid(l.withBid(bob), "auction1", bob.txID))
+	if value, _ := l.stub.GetPrivateData("_implicit_org_Org2MSP", l.bidKey("auction1", bob)); value != nil {
+		t.Error("the withdrawn bid is still in the collection")
+	}
+	auctionJSON := l.auction("auction1")
+	if _, exists := auctionJSON.PrivateBids[l.bidKey("auction1", bob)]; exists || len(auctionJSON.PrivateBids) != 1 {
+		t.Errorf("private bids %v after the withdrawal", auctionJSON.PrivateBids)
+	}
+	if !reflect.DeepEqual(auctionJSON.Orgs, []string{"Org1MSP"}) {
+		t.Errorf("organizations %v after the withdrawal", auctionJSON.Orgs)
+	}
+	if orgs := l.endorsers("auction1"); !reflect.DeepEqual(orgs, []string{"Org1MSP"}) {
+		t.Errorf("endorsers %v after the withdrawal", orgs)
+	}
+	if err := l.contract.WithdrawBid(l.withBid(bob), "auction1", bob.txID); err == nil {
+		t.Error("a bid was withdrawn twice")
+	}
+
+	// the organization of the seller endorses the auction without bids
+	l.must(l.contract.WithdrawBid(l.withBid(alice), "auction1", alice.txID))
+	auctionJSON = l.auction("auction1")
+	if len(auctionJSON.PrivateBids) != 0 || !reflect.DeepEqual(auctionJSON.Orgs, []string{"Org1MSP"}) {
+		t.Errorf("auction %+v without bids", auctionJSON)
+	}
+	if orgs := l.endorsers("auction1"); !reflect.DeepEqual(orgs, []string{"Org1MSP"}) {
+		t.Errorf("endorsers %v without bids", orgs)
+	}
+}
+
+func TestWithdrawBidKeepsOrg(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	bob := l.sendBid("auction1", "bob", "Org2MSP", 60)
+	carol := l.sendBid("auction1", "carol", "Org2MSP", 70)
+
+	// another bid of Org2MSP is left
+	l.must(l.contract.WithdrawBid(l.withBid(bob), "auction1", bob.txID))
+	if orgs := l.endorsers("auction1"); !reflect.DeepEqual(orgs, []string{"Org1MSP", "Org2MSP"}) {
+		t.Errorf("endorsers %v after the withdrawal", orgs)
+	}
+	if orgs := l.auction("auction1").Orgs; !reflect.DeepEqual(orgs, []string{"Org1MSP", "Org2MSP"}) {
+		t.Errorf("organizations %v after the withdrawal", orgs)
+	}
+
+	// a bid that was not submitted is only deleted from the collection
+	dave := l.storeBid("auction1", "dave", "Org3MSP", 80)
+	l.must(l.contract.WithdrawBid(l.withBid(dave), "auction1", dave.txID))
+	if value, _ := l.stub.GetPrivateData("_implicit_org_Org3MSP", l.bidKey("auction1", dave)); value != nil {
+		t.Error("the withdrawn bid is still in the collection")
+	}
+	if len(l.auction("auction1").PrivateBids) != 1 {
+		t.Errorf("private bids %v after the withdrawal", l.auction("auction1").PrivateBids)
+	}
+
+	l.must(l.contract.CloseAuction(l.as("seller", "Org1MSP"), "auction1"))
+	if err := l.contract.WithdrawBid(l.withBid(carol), "auction1", carol.txID); err == nil {
+		t.Error("a bid was withdrawn from a closed auction")
+	}
+}
+
+func TestUpdateBid(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAuction("auction1")
+	bob := l.sendBid("auction1", "bob", "Org2MSP", 60)
+
+	invalid := map[string][]byte{
+		"a bid of another bidder":        bidJSON("carol", "Org2MSP", 70),
+		"a bid of another organization":  bidJSON("bob", "Org3MSP", 70),
+		"a bid with a price of 0":        bidJSON("bob", "Org2MSP", 0),
+		"a bid with a negative price":    bidJSON("bob", "Org2MSP", -70),
+		"an object that is not a bid":    []byte(`{"objectType":"auction","price":70,"org":"Org2MSP","bidder":"bob"}`),
+		"a value that is not a bid JSON": []byte("70"),
+	}
+	for name, updatedBid := range invalid {
+		if err := l.contract.UpdateBid(l.withBid(bob, updatedBid), "auction1", bob.txID); err == nil {
+			t.Errorf("the bid was updated with %v", name)
+		}
+	}
+	if err := l.contract.UpdateBid(l.withBid(bob), "auction1", bob.txID); err == nil {
+		t.Error("the bid was updated without an updated bid")
+	}
+
+	updated := &testBid{txID: bob.txID, bidder: "bob", org: "Org2MSP", json: bidJSON("bob", "Org2MSP", 70)}
+	l.must(l.contract.UpdateBid(l.withBid(bob, updated.json), "auction1", bob.txID))
+	hash := sha256.Sum256(updated.json)
+	if privateBid := l.auction("auction1").PrivateBids[l.bidKey("auction1", bob)]; privateBid.Hash != fmt.Sprintf("%x", hash) {
+		t.Errorf("hash %v of the updated bid", privateBid.Hash)
+	}
+	if err := l.contract.UpdateBid(l.withBid(bob, bidJSON("bob", "Org2MSP", 80)), "auction1", bob.txID); err == nil {
+		t.Error("the bid was updated with the replaced bid in the transient map")
+	}
+
+	// only the updated bid can be revealed
+	l.must(l.contract.CloseAuction(l.as("seller", "Org1MSP"), "auction1"))
+	if err := l.contract.UpdateBid(l.withBid(updated, bidJSON("bob", "Org2MSP", 80)), "auction1", bob.txID); err == nil {
+		t.Error("a bid was updated in a closed auction")
+	}
+	if err := l.contract.RevealBid(l.withBid(bob), "auction1", bob.txID); err == nil {
+		t.Error("the replaced bid was revealed")
+	}
+	l.must(l.contract.RevealBid(l.withBid(updated), "auction1", bob.txID))
+	if revealed := l.auction("auction1").RevealedBids[l.bidKey("auction1", bob)]; revealed.Price != 70 {
+		t.Errorf("revealed price %v", revealed.Price)
+	}
+}
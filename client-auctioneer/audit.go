@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/verifier"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// registryPrefix is the prefix of the composite keys of the verifying key registry of the chaincode
+const registryPrefix = "\x00verifyingKey\x00"
+
+// transition is the change of status a transaction function is allowed to make to a sealed-bid auction, and
+// whether only the seller can send it
+type transition struct {
+	from, to string
+	seller   bool
+}
+
+var transitions = map[string]transition{
+	"CreateAuction":              {"", "open", true},
+	// a migrated auction keeps the status it had in the private data auction chaincode, open or closed
+	"MigrateAuction":             {"", "open", true},
+	"RequireDeposit":             {"open", "open", true},
+	"RequireIdentityEscrow":      {"open", "open", true},
+	"RequireOneBidPerCredential": {"open", "open", true},
+	"SendCommitment":             {"open", "open", false},
+	"SendUniqueCommitment":       {"open", "open", false},
+	"CloseAuction":               {"open", "closed", true},
+	"RevealBid":                  {"closed", "closed", false},
+	"EndAuction":                 {"closed", "ended", true},
+	"DeclareWinner":              {"ended", "ended", true},
+	"PayWinningBid":              {"ended", "ended", false},
+	"ForfeitUnpaidDeposit":       {"ended", "ended", true},
+	"ReclaimDeposit":             {"ended", "ended", false},
+}
+
+// auditReport is the result of the audit of a sealed-bid auction. Passed is true if no finding was made
+type auditReport struct {
+	AuctionID         string       `json:"auctionID"`
+	Source            string       `json:"source"`
+	Blocks            int          `json:"blocks"`
+	FirstBlock        uint64       `json:"firstBlock"`
+	LastBlock         uint64       `json:"lastBlock"`
+	HashChain         string       `json:"hashChain"`
+	OrdererSignatures string       `json:"ordererSignatures,omitempty"`
+	Seller            string       `json:"seller"`
+	Status            string       `json:"status"`
+	WinningBid        string       `json:"winningBid,omitempty"`
+	VerifyingKeyID    string       `json:"verifyingKeyID,omitempty"`
+	VerifyingKeyHash  string       `json:"verifyingKeyHash,omitempty"`
+	ProofSystem       string       `json:"proofSystem,omitempty"`
+	CommitmentProofs  proofCount   `json:"commitmentProofs"`
+	RevealProofs      proofCount   `json:"revealProofs"`
+	WinnerProof       string       `json:"winnerProof"`
+	LedgerState       string       `json:"ledgerState,omitempty"`
+	Transactions      []*auditedTx `json:"transactions"`
+	Findings          []string     `json:"findings"`
+	Passed            bool         `json:"passed"`
+}
+
+type proofCount struct {
+	Checked int `json:"checked"`
+	Failed  int `json:"failed"`
+}
+
+// auditedTx is a transaction of the history of the auction. Invalid transactions are listed but did not
+// change the auction
+type auditedTx struct {
+	TxID           string    `json:"txID"`
+	Block          uint64    `json:"block"`
+	Index          int       `json:"index"`
+	Timestamp      time.Time `json:"timestamp"`
+	Function       string    `json:"function"`
+	Creator        string    `json:"creator"`
+	BySeller       bool      `json:"bySeller"`
+	ValidationCode string    `json:"validationCode"`
+	Status         string    `json:"status,omitempty"`
+	Findings       []string  `json:"findings,omitempty"`
+}
+
+// auditor rebuilds the history of an auction from the transactions of the ledger
+type auditor struct {
+	report *auditReport
+	// registry entries written by valid transactions, by composite key
+	keys    map[string][]byte
+	seller  []byte
+	auction *Auction
+	raw     []byte
+}
+
+// fail records a finding, for a transaction of the history if tx is not nil
+func (a *auditor) fail(tx *auditedTx, format string, args ...interface{}) {
+	finding := fmt.Sprintf(format, args...)
+	if tx != nil {
+		tx.Findings = append(tx.Findings, finding)
+		finding = fmt.Sprintf("transaction %v: %v", tx.TxID, finding)
+	}
+	a.report.Findings = append(a.report.Findings, finding)
+}
+
+// auditLedger audits an auction from the blocks of the peers and compares the result with the state of the
+// auction on the ledger
+func auditLedger(username, auctionID string, endpoints []string) {
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer sdk.Close()
+	channelContext := sdk.ChannelContext(channelName, fabsdk.WithUser(username), fabsdk.WithOrg("org1"))
+	ledgerClient, err := ledger.New(channelContext)
+	if err != nil {
+		panic(err)
+	}
+	client, err := channel.New(channelContext, channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	blocks, err := queryBlocks(ledgerClient, endpoints)
+	if err != nil {
+		panic(err)
+	}
+	a, err := auditAuction(auctionID, blocks)
+	if err != nil {
+		panic(err)
+	}
+	a.report.Source = "peer " + strings.Join(endpoints, ",")
+
+	txs, err := chaincodeTransactions(blocks, chaincodeID)
+	if err != nil {
+		panic(err)
+	}
+	for _, tx := range txs {
+		if audited := a.find(tx.TxID); audited != nil {
+			if err := confirmValidation(ledgerClient, tx, endpoints); err != nil {
+				a.fail(audited, "%v", err)
+			}
+		}
+	}
+	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryAuction", Args: [][]byte{[]byte(auctionID)}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		a.fail(nil, "querying the auction: %v", err)
+	} else if sameJSON(response.Payload, a.raw) {
+		a.report.LedgerState = "matches"
+	} else {
+		a.report.LedgerState = "differs"
+		a.fail(nil, "the state of the auction on the ledger differs from the one rebuilt from the blocks")
+	}
+	a.print()
+}
+
+// auditSnapshot audits an auction from the blocks of a ledger snapshot on disk
+func auditSnapshot(auctionID, path string) {
+	blocks, err := readSnapshot(path)
+	if err != nil {
+		panic(err)
+	}
+	a, err := auditAuction(auctionID, blocks)
+	if err != nil {
+		panic(err)
+	}
+	a.report.Source = "snapshot " + path
+	// the peers checked the signatures of the blocks they return, the blocks of a snapshot are checked here
+	a.report.OrdererSignatures = "valid"
+	if err := checkOrdererSignatures(blocks); err != nil {
+		a.report.OrdererSignatures = err.Error()
+		a.fail(nil, "%v", err)
+	}
+	a.print()
+}
+
+// print writes the report as JSON on the standard output, the exit status is 1 if the audit failed
+func (a *auditor) print() {
+	a.report.Passed = len(a.report.Findings) == 0
+	reportBytes, err := json.MarshalIndent(a.report, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(reportBytes))
+	if !a.report.Passed {
+		os.Exit(1)
+	}
+}
+
+// find returns the transaction of the history with the given ID
+func (a *auditor) find(txID string) *auditedTx {
+	for _, tx := range a.report.Transactions {
+		if tx.TxID == txID {
+			return tx
+		}
+	}
+	return nil
+}
+
+// auditAuction rebuilds the history of an auction from the blocks: every valid transaction that wrote the
+// auction is replayed on the previous state. The proofs of the commitments, of the reveals and of the winner
+// are checked again, as well as the order of the transitions and the identity of the seller
+func auditAuction(auctionID string, blocks []*common.Block) (*auditor, error) {
+	txs, err := chaincodeTransactions(blocks, chaincodeID)
+	if err != nil {
+		return nil, err
+	}
+	a := &auditor{report: &auditReport{AuctionID: auctionID, Blocks: len(blocks), HashChain: "valid",
+		Transactions: []*auditedTx{}, Findings: []string{}}, keys: make(map[string][]byte)}
+	if len(blocks) > 0 {
+		a.report.FirstBlock, a.report.LastBlock = blocks[0].Header.Number, blocks[len(blocks)-1].Header.Number
+	}
+	if err := checkHashChain(blocks); err != nil {
+		a.report.HashChain = err.Error()
+		a.fail(nil, "%v", err)
+	}
+
+	for _, tx := range txs {
+		if tx.Valid {
+			for _, w := range tx.Writes {
+				if strings.HasPrefix(w.Key, registryPrefix) && !w.IsDelete {
+					a.keys[w.Key] = w.Value
+				}
+			}
+		}
+		value, written := tx.write(auctionID)
+		if !written && tx.arg(0) != auctionID {
+			continue
+		}
+		audited := &auditedTx{TxID: tx.TxID, Block: tx.Block, Index: tx.Index, Timestamp: tx.Timestamp,
+			Function: tx.function(), Creator: tx.MSPID, BySeller: a.seller != nil && bytes.Equal(tx.Creator, a.seller),
+			ValidationCode: tx.ValidationCode}
+		a.report.Transactions = append(a.report.Transactions, audited)
+		// invalid transactions and the ones that do not write the auction leave it unchanged
+		if !tx.Valid || !written {
+			continue
+		}
+		if value == nil {
+			a.fail(audited, "the auction was deleted")
+			a.auction, a.raw = nil, nil
+			continue
+		}
+		next := new(Auction)
+		if err := json.Unmarshal(value, next); err != nil {
+			a.fail(audited, "invalid auction state: %v", err)
+			continue
+		}
+		audited.Status = next.Status
+		a.replay(tx, audited, next)
+		a.auction, a.raw = next, value
+	}
+
+	if a.auction == nil {
+		a.fail(nil, "auction %v not found in blocks %v to %v", auctionID, a.report.FirstBlock, a.report.LastBlock)
+		return a, nil
+	}
+	a.report.Status = a.auction.Status
+	a.report.WinningBid = a.auction.WinningBid
+	if a.report.WinnerProof == "" {
+		a.report.WinnerProof = "not declared"
+	}
+	return a, nil
+}
+
+// replay checks a transaction that changed the auction from its previous state to next
+func (a *auditor) replay(tx *ledgerTx, audited *auditedTx, next *Auction) {
+	prev := a.auction
+	fn := tx.function()
+	rule, known := transitions[fn]
+	if !known {
+		a.fail(audited, "unexpected function %v changed the auction", fn)
+		return
+	}
+	prevStatus := ""
+	if prev != nil {
+		prevStatus = prev.Status
+	}
+	if fn == "MigrateAuction" && next.Status == "closed" {
+		rule.to = "closed"
+	}
+	if prevStatus != rule.from || next.Status != rule.to {
+		a.fail(audited, "%v moved the auction from %q to %q", fn, prevStatus, next.Status)
+	}
+	if fn == "CreateAuction" || fn == "MigrateAuction" {
+		a.created(tx, audited, next)
+	} else if prev != nil && next.Seller != prev.Seller {
+		a.fail(audited, "the seller of the auction changed")
+	} else if prev != nil && (next.VerifyingKeyID != prev.VerifyingKeyID || next.ProofSystem != prev.ProofSystem ||
+		!bytes.Equal(next.VerifyingKey, prev.VerifyingKey)) {
+		a.fail(audited, "the verifying key of the auction changed")
+	}
+	if rule.seller && !audited.BySeller {
+		a.fail(audited, "%v was not sent by the seller", fn)
+	}
+
+	switch fn {
+	case "SendCommitment", "SendUniqueCommitment":
+		a.report.CommitmentProofs.Checked++
+		if err := checkCommitmentTx(tx, next); err != nil {
+			a.report.CommitmentProofs.Failed++
+			a.fail(audited, "%v", err)
+		}
+	case "RevealBid":
+		a.report.RevealProofs.Checked++
+		if err := checkRevealTx(tx, prev, next); err != nil {
+			a.report.RevealProofs.Failed++
+			a.fail(audited, "%v", err)
+		}
+	case "DeclareWinner":
+		a.declared(tx, audited, prev, next)
+	case "MigrateAuction":
+		a.report.RevealProofs.Checked += len(next.CommitmentOrder)
+		for _, bidID := range next.CommitmentOrder {
+			if err := checkMigratedBid(tx.arg(0), bidID, next); err != nil {
+				a.report.RevealProofs.Failed++
+				a.fail(audited, "%v", err)
+			}
+		}
+	}
+}
+
+// created records the seller and the verifying key pinned by the auction
+func (a *auditor) created(tx *ledgerTx, audited *auditedTx, auction *Auction) {
+	a.seller = tx.Creator
+	audited.BySeller = true
+	a.report.Seller = tx.MSPID
+	a.report.VerifyingKeyID = auction.VerifyingKeyID
+	a.report.ProofSystem = auction.ProofSystem
+	if auction.AuctionType != "" && auction.AuctionType != "sealed" {
+		a.fail(audited, "%v auctions cannot be audited, only sealed-bid auctions", auction.AuctionType)
+	}
+	if auction.VerifyingKeyID != "" && a.keys[registryPrefix+auction.VerifyingKeyID+"\x00"] == nil {
+		a.fail(audited, "verifying key %v was not registered when the auction was created", auction.VerifyingKeyID)
+	}
+}
+
+// declared checks the proof of the winner against the verifying key of the auction
+func (a *auditor) declared(tx *ledgerTx, audited *auditedTx, prev, next *Auction) {
+	if prev == nil {
+		return
+	}
+	if prev.WinningBid != "" || prev.InvalidSet != "" {
+		a.fail(audited, "the winner was declared twice")
+	}
+	winningBid, invalidSet := tx.arg(1), tx.arg(3)
+	if invalidSet == "" {
+		invalidSet = "{}"
+	}
+	if next.WinningBid != winningBid || next.InvalidSet != invalidSet {
+		a.fail(audited, "the declared winner differs from the arguments of the transaction")
+	}
+	proof, err := base64.StdEncoding.DecodeString(tx.arg(2))
+	if err != nil {
+		a.fail(audited, "invalid proof encoding")
+		a.report.WinnerProof = "invalid"
+		return
+	}
+	valid, err := checkWinner(prev, a.verifyingKey, winningBid, proof, invalidSet)
+	if err != nil {
+		a.fail(audited, "%v", err)
+		a.report.WinnerProof = "invalid"
+	} else if !valid {
+		a.report.WinnerProof = "no valid bid"
+	} else {
+		a.report.WinnerProof = "valid"
+	}
+}
+
+// verifyingKey returns the key the proof of the winner of the auction is verified with, the key of the
+// registry it pinned or the key of the auction if it was created before the registry
+func (a *auditor) verifyingKey(auction *Auction) (*verifier.VerifyingKey, error) {
+	proofSystem, vk := auction.ProofSystem, auction.VerifyingKey
+	if auction.VerifyingKeyID != "" {
+		entryBytes := a.keys[registryPrefix+auction.VerifyingKeyID+"\x00"]
+		if entryBytes == nil {
+			return nil, fmt.Errorf("verifying key %v not found in the blocks", auction.VerifyingKeyID)
+		}
+		var entry struct {
+			ProofSystem string `json:"proofSystem"`
+			Key         []byte `json:"key"`
+		}
+		if err := json.Unmarshal(entryBytes, &entry); err != nil {
+			return nil, fmt.Errorf("invalid verifying key %v: %v", auction.VerifyingKeyID, err)
+		}
+		proofSystem, vk = entry.ProofSystem, entry.Key
+	}
+	if vk == nil {
+		return nil, fmt.Errorf("the auction has no verifying key")
+	}
+	hash := sha256.Sum256(vk)
+	a.report.VerifyingKeyHash = hex.EncodeToString(hash[:])
+	return verifier.ParseVerifyingKey(proofSystem, vk)
+}
+
+// checkCommitmentTx checks the proof of knowledge of the opening of a commitment and that the commitment
+// was added to the auction under the ID of the transaction
+func checkCommitmentTx(tx *ledgerTx, next *Auction) error {
+	comBytes, err1 := base64.StdEncoding.DecodeString(tx.arg(1))
+	proofBytes, err2 := base64.StdEncoding.DecodeString(tx.arg(2))
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("invalid commitment or proof encoding")
+	}
+	if !commitment.CheckCommitProofBytes(proofBytes, comBytes, nil) {
+		return fmt.Errorf("invalid proof of the commitment")
+	}
+	order := next.CommitmentOrder
+	if !bytes.Equal(next.Commitments[tx.TxID], comBytes) || len(order) == 0 || order[len(order)-1] != tx.TxID {
+		return fmt.Errorf("the commitment was not added to the auction as sent")
+	}
+	return nil
+}
+
+// checkMigratedBid checks that a bid carried over from the private data auction chaincode opens its commitment.
+// The bid was revealed in the clear there, its opening is encrypted with a seed anyone can derive
+func checkMigratedBid(auctionID, bidID string, next *Auction) error {
+	pk := next.SellerPk
+	value, r, err := commitment.DecryptPublic(next.EncryptedBids[bidID].Data, &pk, commitment.MigratedBidSeed(auctionID, bidID))
+	if err != nil {
+		return fmt.Errorf("the opening of migrated bid %v cannot be decrypted: %v", bidID, err)
+	}
+	if !commitment.CheckCommitBytes(value, r.Bytes(), next.Commitments[bidID]) {
+		return fmt.Errorf("the opening of migrated bid %v does not match its commitment", bidID)
+	}
+	return nil
+}
+
+// checkRevealTx checks the proof that the encrypted bid opens the commitment it reveals
+func checkRevealTx(tx *ledgerTx, prev, next *Auction) error {
+	bidID := tx.arg(1)
+	data, err1 := base64.StdEncoding.DecodeString(tx.arg(3))
+	proofBytes, err2 := base64.StdEncoding.DecodeString(tx.arg(4))
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("invalid encrypted bid or proof encoding")
+	}
+	comBytes, exists := prev.Commitments[bidID]
+	if !exists {
+		return fmt.Errorf("bid %v revealed without commitment", bidID)
+	}
+	if !commitment.CheckCommitProofBytes(proofBytes, comBytes, data) {
+		return fmt.Errorf("invalid proof of the reveal of bid %v", bidID)
+	}
+	if !bytes.Equal(next.EncryptedBids[bidID].Data, data) {
+		return fmt.Errorf("the encrypted bid %v was not added to the auction as sent", bidID)
+	}
+	return nil
+}
+
+// checkWinner runs the checks of DeclareWinner in the chaincode, shared in the verifier package: the bids of the
+// invalid set are revealed and their decrypted opening does not match their commitment, and the proof shows that
+// winningBid is the highest of the other revealed bids with the verifying key of the auction. It returns false if
+// no bid is valid and no winner was declared
+func checkWinner(auction *Auction, verifyingKey func(*Auction) (*verifier.VerifyingKey, error), winningBid string, proof []byte, invalidSet string) (bool, error) {
+	bids := verifier.SealedBids{CommitmentOrder: auction.CommitmentOrder, Commitments: auction.Commitments,
+		EncryptedBids: make(map[string][]byte), SellerPk: &auction.SellerPk}
+	for bidID, encryptedBid := range auction.EncryptedBids {
+		bids.EncryptedBids[bidID] = encryptedBid.Data
+	}
+	return verifier.VerifyWinner(&bids, func() (*verifier.VerifyingKey, error) {
+		return verifyingKey(auction)
+	}, winningBid, proof, invalidSet)
+}
+
+// sameJSON returns true if two JSON documents hold the same values
+func sameJSON(a, b []byte) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testAuctionID = "auction1"
+
+var (
+	testSeller = identity("Org1MSP", "seller")
+	testAlice  = identity("Org1MSP", "alice")
+	testBob    = identity("Org2MSP", "bob")
+)
+
+// testAuction writes the history of a sealed-bid auction on a test channel, one transaction per block
+type testAuction struct {
+	*testChannel
+	auction Auction
+}
+
+// newTestAuction returns a channel on which the seller created an auction
+func newTestAuction(t *testing.T) *testAuction {
+	a := &testAuction{testChannel: newTestChannel(t)}
+	a.auction = Auction{Type: "auction", ItemSold: "item", Seller: "seller", Status: "open",
+		Commitments: make(map[string][]byte), EncryptedBids: make(map[string]EncryptedBid)}
+	a.auction.SellerPk[0] = 9
+	a.send(testSeller, "CreateAuction", "item", base64.StdEncoding.EncodeToString(a.auction.SellerPk[:]), "")
+	return a
+}
+
+// nextTxID returns the ID of the next transaction of the channel
+func (a *testAuction) nextTxID() string {
+	return "tx" + strconv.Itoa(a.nbTx+1)
+}
+
+// send adds a transaction of the auction that writes its current state, it returns the block of the transaction
+func (a *testAuction) send(creator []byte, fn string, args ...string) *common.Block {
+	auctionBytes, err := json.Marshal(a.auction)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	tx, _ := a.endorserTx(creator, append([]string{"blindauction:" + fn, testAuctionID}, args...),
+		&kvrwset.KVWrite{Key: testAuctionID, Value: auctionBytes})
+	return a.addBlock(tx)
+}
+
+// setStatus moves the auction to a status with a transaction of creator
+func (a *testAuction) setStatus(creator []byte, fn, status string) *common.Block {
+	a.auction.Status = status
+	return a.send(creator, fn)
+}
+
+// commit sends the commitment of a bid with its proof, it returns the ID of the bid and the randomness of the
+// commitment
+func (a *testAuction) commit(bidder []byte, value int) (string, *big.Int) {
+	com, r, err := commitment.Commit(value)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	comBytes := wire.MarshalCommitment(com)
+	proof, err := commitment.ProveCommit(value, r, comBytes, nil)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	bidID := a.nextTxID()
+	a.auction.Commitments[bidID] = comBytes
+	a.auction.CommitmentOrder = append(a.auction.CommitmentOrder, bidID)
+	a.send(bidder, "SendCommitment", base64.StdEncoding.EncodeToString(comBytes),
+		base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(proof)))
+	return bidID, r
+}
+
+// reveal sends the encrypted opening of a bid with the proof that it opens the commitment of the bid
+func (a *testAuction) reveal(bidder []byte, bidID string, value int, r *big.Int) *common.Block {
+	data, err := commitment.Encrypt(value, r, &a.auction.SellerPk)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	proof, err := commitment.ProveCommit(value, r, a.auction.Commitments[bidID], data)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	a.auction.EncryptedBids[bidID] = EncryptedBid{Type: "encryptedBid", Data: data, Bidder: string(bidder)}
+	return a.send(bidder, "RevealBid", bidID, string(bidder), base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(proof)))
+}
+
+// audit audits the auction from the blocks
+func (a *testAuction) audit(blocks []*common.Block) *auditReport {
+	a.t.Helper()
+	auditor, err := auditAuction(testAuctionID, blocks)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	auditor.report.Passed = len(auditor.report.Findings) == 0
+	return auditor.report
+}
+
+// hasFinding returns true if one of the findings of the report contains s
+func hasFinding(report *auditReport, s string) bool {
+	for _, finding := range report.Findings {
+		if strings.Contains(finding, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuditAuction(t *testing.T) {
+	a := newTestAuction(t)
+	aliceBid, aliceR := a.commit(testAlice, 50)
+	bobBid, bobR := a.commit(testBob, 70)
+	// a transaction of another auction and an invalid transaction do not change the history
+	other, _ := a.endorserTx(testBob, []string{"CloseAuction", "auction2"}, &kvrwset.KVWrite{Key: "auction2", Value: []byte("{}")})
+	a.addBlock(other)
+	a.auction.Status = "closed"
+	invalid := a.send(testAlice, "CloseAuction")
+	invalid.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER][0] = byte(pb.TxValidationCode_MVCC_READ_CONFLICT)
+	a.send(testSeller, "CloseAuction")
+	a.reveal(testAlice, aliceBid, 50, aliceR)
+	a.reveal(testBob, bobBid, 70, bobR)
+	a.setStatus(testSeller, "EndAuction", "ended")
+
+	report := a.audit(a.blocks)
+	if !report.Passed {
+		t.Fatalf("findings %v", report.Findings)
+	}
+	if report.Seller != "Org1MSP" || report.Status != "ended" || report.HashChain != "valid" ||
+		report.WinnerProof != "not declared" || report.LastBlock != uint64(len(a.blocks)-1) {
+		t.Errorf("report %+v", report)
+	}
+	if report.CommitmentProofs != (proofCount{Checked: 2}) || report.RevealProofs != (proofCount{Checked: 2}) {
+		t.Errorf("commitment proofs %+v, reveal proofs %+v", report.CommitmentProofs, report.RevealProofs)
+	}
+	var functions []string
+	for _, tx := range report.Transactions {
+		functions = append(functions, tx.Function)
+		if tx.BySeller != (tx.Function == "CreateAuction" || tx.Function == "EndAuction" ||
+			tx.Function == "CloseAuction" && tx.ValidationCode == "VALID") {
+			t.Errorf("transaction %v %v by the seller: %v", tx.TxID, tx.Function, tx.BySeller)
+		}
+	}
+	expected := "CreateAuction SendCommitment SendCommitment CloseAuction CloseAuction RevealBid RevealBid EndAuction"
+	if strings.Join(functions, " ") != expected {
+		t.Errorf("history %v", functions)
+	}
+	if tx := report.Transactions[3]; tx.ValidationCode != "MVCC_READ_CONFLICT" || tx.Status != "" {
+		t.Errorf("invalid transaction %+v", tx)
+	}
+}
+
+func TestAuditWrongSeller(t *testing.T) {
+	a := newTestAuction(t)
+	a.commit(testAlice, 50)
+	// another client of the organization of the seller closes the auction
+	a.setStatus(identity("Org1MSP", "mallory"), "CloseAuction", "closed")
+	a.auction.Seller = "mallory"
+	a.setStatus(testSeller, "EndAuction", "ended")
+
+	report := a.audit(a.blocks)
+	if report.Passed {
+		t.Fatal("the audit passed")
+	}
+	if !hasFinding(report, "CloseAuction was not sent by the seller") {
+		t.Errorf("findings %v", report.Findings)
+	}
+	if !hasFinding(report, "the seller of the auction changed") {
+		t.Errorf("findings %v", report.Findings)
+	}
+	if tx := report.Transactions[2]; tx.BySeller || len(tx.Findings) != 1 {
+		t.Errorf("transaction %+v", tx)
+	}
+}
+
+func TestAuditTransitions(t *testing.T) {
+	a := newTestAuction(t)
+	bidID, r := a.commit(testAlice, 50)
+	// the auction ends without being closed, and a bid is revealed with the opening of another value
+	a.setStatus(testSeller, "EndAuction", "ended")
+	a.auction.Status = "closed"
+	a.send(testSeller, "CloseAuction")
+	otherR := new(big.Int).Add(r, big.NewInt(1))
+	a.reveal(testAlice, bidID, 50, otherR)
+
+	report := a.audit(a.blocks)
+	if !hasFinding(report, `EndAuction moved the auction from "open" to "ended"`) {
+		t.Errorf("findings %v", report.Findings)
+	}
+	if !hasFinding(report, `CloseAuction moved the auction from "ended" to "closed"`) {
+		t.Errorf("findings %v", report.Findings)
+	}
+	if report.RevealProofs != (proofCount{Checked: 1, Failed: 1}) {
+		t.Errorf("reveal proofs %+v, findings %v", report.RevealProofs, report.Findings)
+	}
+}
+
+func TestAuditTamperedBlock(t *testing.T) {
+	a := newTestAuction(t)
+	bidID, r := a.commit(testAlice, 50)
+	a.setStatus(testSeller, "CloseAuction", "closed")
+	a.reveal(testAlice, bidID, 50, r)
+
+	// the bid of alice is raised in the block of its commitment
+	forged := a.auction
+	forged.Status = "open"
+	forged.Commitments = map[string][]byte{bidID: wire.MarshalCommitment(commitment.CommitWith(90, r))}
+	forgedBytes, err := json.Marshal(forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := a.endorserTx(testAlice, []string{"SendCommitment", testAuctionID}, &kvrwset.KVWrite{Key: testAuctionID, Value: forgedBytes})
+	tampered := a.blocksCopy()
+	tampered[2].Data.Data[0] = tx
+
+	report := a.audit(tampered)
+	if report.Passed || !strings.Contains(report.HashChain, "data hash of block 2") {
+		t.Errorf("hash chain %v, findings %v", report.HashChain, report.Findings)
+	}
+}
+
+func TestAuditMissingBlock(t *testing.T) {
+	a := newTestAuction(t)
+	bidID, r := a.commit(testAlice, 50)
+	a.setStatus(testSeller, "CloseAuction", "closed")
+	a.reveal(testAlice, bidID, 50, r)
+
+	// the block that closes the auction is left out of the snapshot
+	missing := append(a.blocksCopy()[:3], a.blocks[4])
+	report := a.audit(missing)
+	if report.Passed || !strings.Contains(report.HashChain, "block 3 is missing") {
+		t.Errorf("hash chain %v", report.HashChain)
+	}
+	if !hasFinding(report, `RevealBid moved the auction from "open" to "closed"`) {
+		t.Errorf("findings %v", report.Findings)
+	}
+}
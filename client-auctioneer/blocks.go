@@ -0,0 +1,518 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ledgerTx is an endorser transaction of the auction chaincode read from a block, with the arguments of its
+// proposal and the writes of its results in the namespace of the chaincode
+type ledgerTx struct {
+	Block          uint64
+	Index          int
+	TxID           string
+	Timestamp      time.Time
+	Creator        []byte
+	MSPID          string
+	Valid          bool
+	ValidationCode string
+	Args           [][]byte
+	Writes         []*kvrwset.KVWrite
+}
+
+// function returns the name of the transaction function, without the name of the contract
+func (tx *ledgerTx) function() string {
+	if len(tx.Args) == 0 {
+		return ""
+	}
+	name := string(tx.Args[0])
+	return name[strings.LastIndex(name, ":")+1:]
+}
+
+// arg returns the i-th argument of the transaction function, empty if it is missing
+func (tx *ledgerTx) arg(i int) string {
+	if i+1 >= len(tx.Args) {
+		return ""
+	}
+	return string(tx.Args[i+1])
+}
+
+// write returns the value written to key by the transaction, nil if the key is not written or deleted
+func (tx *ledgerTx) write(key string) (value []byte, written bool) {
+	for _, w := range tx.Writes {
+		if w.Key == key {
+			if w.IsDelete {
+				return nil, true
+			}
+			return w.Value, true
+		}
+	}
+	return nil, false
+}
+
+// queryBlocks reads every block of the channel from the peers
+func queryBlocks(client *ledger.Client, endpoints []string) ([]*common.Block, error) {
+	info, err := client.QueryInfo(ledger.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		return nil, err
+	}
+	var blocks []*common.Block
+	for number := uint64(0); number < info.BCI.Height; number++ {
+		block, err := client.QueryBlock(number, ledger.WithTargetEndpoints(endpoints...))
+		if err != nil {
+			return nil, fmt.Errorf("block %v: %v", number, err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// confirmValidation checks the validation code of a transaction read from a block against the one the peers
+// return for it
+func confirmValidation(client *ledger.Client, tx *ledgerTx, endpoints []string) error {
+	processed, err := client.QueryTransaction(fab.TransactionID(tx.TxID), ledger.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		return err
+	}
+	code := pb.TxValidationCode(processed.ValidationCode).String()
+	if code != tx.ValidationCode {
+		return fmt.Errorf("validation code %v in the block, %v on the peer", tx.ValidationCode, code)
+	}
+	return nil
+}
+
+// readSnapshot reads the blocks of a ledger snapshot on disk. path is a block file of a peer (blockfile_*),
+// a block fetched with peer channel fetch (*.block), or a directory of such files
+func readSnapshot(path string) ([]*common.Block, error) {
+	files := []string{path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "blockfile_*"))
+		if err != nil {
+			return nil, err
+		}
+		fetched, err := filepath.Glob(filepath.Join(path, "*.block"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fetched...)
+	}
+	var blocks []*common.Block
+	for _, file := range files {
+		fileBytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var fileBlocks []*common.Block
+		if strings.HasPrefix(filepath.Base(file), "blockfile_") {
+			fileBlocks, err = readBlockFile(fileBytes)
+		} else {
+			block := new(common.Block)
+			err = proto.Unmarshal(fileBytes, block)
+			fileBlocks = []*common.Block{block}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+		blocks = append(blocks, fileBlocks...)
+	}
+	if len(blocks) == 0 {
+		return nil, errors.New("no block found in the snapshot")
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Header.Number < blocks[j].Header.Number
+	})
+	return blocks, nil
+}
+
+// readBlockFile reads the blocks of a block file of a peer, every block is prefixed with its length
+func readBlockFile(b []byte) ([]*common.Block, error) {
+	var blocks []*common.Block
+	for len(b) > 0 {
+		size, n := proto.DecodeVarint(b)
+		if n == 0 || size > uint64(len(b)-n) {
+			return nil, errors.New("truncated block file")
+		}
+		block, err := deserializeBlock(b[n : n+int(size)])
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		b = b[n+int(size):]
+	}
+	return blocks, nil
+}
+
+// deserializeBlock decodes a block in the format of the block files: the number and hashes of the header,
+// then the transactions and the metadata, each preceded by their count
+func deserializeBlock(b []byte) (*common.Block, error) {
+	buf := proto.NewBuffer(b)
+	block := &common.Block{Header: new(common.BlockHeader), Data: new(common.BlockData), Metadata: new(common.BlockMetadata)}
+	var err error
+	if block.Header.Number, err = buf.DecodeVarint(); err != nil {
+		return nil, err
+	}
+	if block.Header.DataHash, err = buf.DecodeRawBytes(true); err != nil {
+		return nil, err
+	}
+	if block.Header.PreviousHash, err = buf.DecodeRawBytes(true); err != nil {
+		return nil, err
+	}
+	nbTx, err := buf.DecodeVarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < nbTx; i++ {
+		txBytes, err := buf.DecodeRawBytes(true)
+		if err != nil {
+			return nil, err
+		}
+		block.Data.Data = append(block.Data.Data, txBytes)
+	}
+	nbMetadata, err := buf.DecodeVarint()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < nbMetadata; i++ {
+		metadata, err := buf.DecodeRawBytes(true)
+		if err != nil {
+			return nil, err
+		}
+		block.Metadata.Metadata = append(block.Metadata.Metadata, metadata)
+	}
+	return block, nil
+}
+
+// checkHashChain checks the data hash of every block and that each block points to the header of the
+// previous one, so that blocks read from disk cannot be changed or left out without it being detected
+func checkHashChain(blocks []*common.Block) error {
+	for i, block := range blocks {
+		dataHash := sha256.Sum256(bytes.Join(block.Data.Data, nil))
+		if !bytes.Equal(dataHash[:], block.Header.DataHash) {
+			return fmt.Errorf("data hash of block %v does not match its transactions", block.Header.Number)
+		}
+		if i == 0 {
+			continue
+		}
+		previous := blocks[i-1].Header
+		if block.Header.Number != previous.Number+1 {
+			return fmt.Errorf("block %v is missing", previous.Number+1)
+		}
+		if !bytes.Equal(headerHash(previous), block.Header.PreviousHash) {
+			return fmt.Errorf("block %v does not follow block %v", block.Header.Number, previous.Number)
+		}
+	}
+	return nil
+}
+
+// headerHash returns the hash of a block header, the hash of its ASN.1 encoding
+func headerHash(header *common.BlockHeader) []byte {
+	hash := sha256.Sum256(headerBytes(header))
+	return hash[:]
+}
+
+// headerBytes returns the ASN.1 encoding of a block header, that is hashed and signed by the orderers
+func headerBytes(header *common.BlockHeader) []byte {
+	encoded, err := asn1.Marshal(struct {
+		Number       *big.Int
+		PreviousHash []byte
+		DataHash     []byte
+	}{new(big.Int).SetUint64(header.Number), header.PreviousHash, header.DataHash})
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// ordererConfig holds the certificates of the MSPs of the orderer organizations of a channel configuration,
+// by MSP ID
+type ordererConfig map[string]*x509.VerifyOptions
+
+// checkOrdererSignatures checks that every block is signed by an orderer of the channel configuration in
+// effect for it. The first block must be a config block, the genesis block or one fetched with peer channel
+// fetch config, it is the trusted configuration of the channel. Each config block replaces the configuration
+// once its own signature is checked
+func checkOrdererSignatures(blocks []*common.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	config, err := blockConfig(blocks[0])
+	if err != nil {
+		return fmt.Errorf("block %v: %v", blocks[0].Header.Number, err)
+	}
+	if config == nil {
+		return fmt.Errorf("block %v is not a config block, the orderer signatures cannot be checked", blocks[0].Header.Number)
+	}
+	for _, block := range blocks {
+		// the genesis block is not signed
+		if block.Header.Number > 0 {
+			if err := config.verifyBlock(block); err != nil {
+				return err
+			}
+		}
+		next, err := blockConfig(block)
+		if err != nil {
+			return fmt.Errorf("block %v: %v", block.Header.Number, err)
+		}
+		if next != nil {
+			config = next
+		}
+	}
+	return nil
+}
+
+// blockConfig returns the orderer organizations of a config block, nil if the block is not a config block
+func blockConfig(block *common.Block) (ordererConfig, error) {
+	if len(block.Data.Data) != 1 {
+		return nil, nil
+	}
+	envelope := new(common.Envelope)
+	if err := proto.Unmarshal(block.Data.Data[0], envelope); err != nil {
+		return nil, err
+	}
+	payload := new(common.Payload)
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, errors.New("missing header")
+	}
+	channelHeader := new(common.ChannelHeader)
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, channelHeader); err != nil {
+		return nil, err
+	}
+	if channelHeader.Type != int32(common.HeaderType_CONFIG) {
+		return nil, nil
+	}
+	configEnvelope := new(common.ConfigEnvelope)
+	if err := proto.Unmarshal(payload.Data, configEnvelope); err != nil {
+		return nil, err
+	}
+	if configEnvelope.Config == nil || configEnvelope.Config.ChannelGroup == nil ||
+		configEnvelope.Config.ChannelGroup.Groups["Orderer"] == nil {
+		return nil, errors.New("config without orderer organizations")
+	}
+
+	config := make(ordererConfig)
+	for org, group := range configEnvelope.Config.ChannelGroup.Groups["Orderer"].Groups {
+		value, ok := group.Values["MSP"]
+		if !ok {
+			return nil, fmt.Errorf("orderer organization %v has no MSP", org)
+		}
+		mspConfig := new(msp.MSPConfig)
+		if err := proto.Unmarshal(value.Value, mspConfig); err != nil {
+			return nil, err
+		}
+		fabricConfig := new(msp.FabricMSPConfig)
+		if err := proto.Unmarshal(mspConfig.Config, fabricConfig); err != nil {
+			return nil, err
+		}
+		options := &x509.VerifyOptions{Roots: x509.NewCertPool(), Intermediates: x509.NewCertPool(),
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+		for _, cert := range fabricConfig.RootCerts {
+			options.Roots.AppendCertsFromPEM(cert)
+		}
+		for _, cert := range fabricConfig.IntermediateCerts {
+			options.Intermediates.AppendCertsFromPEM(cert)
+		}
+		config[fabricConfig.Name] = options
+	}
+	return config, nil
+}
+
+// verifyBlock checks that one of the signatures of a block is a valid signature of an orderer
+func (c ordererConfig) verifyBlock(block *common.Block) error {
+	if len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_SIGNATURES) {
+		return fmt.Errorf("block %v is not signed", block.Header.Number)
+	}
+	metadata := new(common.Metadata)
+	if err := proto.Unmarshal(block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES], metadata); err != nil {
+		return fmt.Errorf("signatures of block %v: %v", block.Header.Number, err)
+	}
+	err := fmt.Errorf("block %v is not signed", block.Header.Number)
+	for _, signature := range metadata.Signatures {
+		if err = c.verifySignature(block.Header, metadata.Value, signature); err == nil {
+			return nil
+		}
+		err = fmt.Errorf("block %v: %v", block.Header.Number, err)
+	}
+	return err
+}
+
+// verifySignature checks the signature of an orderer over the metadata value, its signature header and the
+// header of the block. The certificate of the orderer is checked at the start of its validity, since the
+// blocks can be older than its expiry
+func (c ordererConfig) verifySignature(header *common.BlockHeader, value []byte, signature *common.MetadataSignature) error {
+	signatureHeader := new(common.SignatureHeader)
+	if err := proto.Unmarshal(signature.SignatureHeader, signatureHeader); err != nil {
+		return err
+	}
+	identity := new(msp.SerializedIdentity)
+	if err := proto.Unmarshal(signatureHeader.Creator, identity); err != nil {
+		return err
+	}
+	options, ok := c[identity.Mspid]
+	if !ok {
+		return fmt.Errorf("%v is not an orderer organization", identity.Mspid)
+	}
+	certBlock, _ := pem.Decode(identity.IdBytes)
+	if certBlock == nil {
+		return errors.New("the identity of the signer is not a PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return err
+	}
+	verifyOptions := *options
+	verifyOptions.CurrentTime = cert.NotBefore
+	if _, err := cert.Verify(verifyOptions); err != nil {
+		return fmt.Errorf("certificate of the signer: %v", err)
+	}
+	publicKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("the key of the signer is not an ECDSA key")
+	}
+	digest := sha256.Sum256(bytes.Join([][]byte{value, signature.SignatureHeader, headerBytes(header)}, nil))
+	if !ecdsa.VerifyASN1(publicKey, digest[:], signature.Signature) {
+		return fmt.Errorf("invalid signature of %v", identity.Mspid)
+	}
+	return nil
+}
+
+// chaincodeTransactions returns the endorser transactions of a chaincode in the blocks, valid or not, in the
+// order of the ledger
+func chaincodeTransactions(blocks []*common.Block, chaincode string) ([]*ledgerTx, error) {
+	var txs []*ledgerTx
+	for _, block := range blocks {
+		var filter []byte
+		if len(block.Metadata.Metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+			filter = block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+		}
+		for i, envelopeBytes := range block.Data.Data {
+			tx, err := parseTransaction(envelopeBytes, chaincode)
+			if err != nil {
+				return nil, fmt.Errorf("block %v transaction %v: %v", block.Header.Number, i, err)
+			}
+			if tx == nil {
+				continue
+			}
+			tx.Block, tx.Index = block.Header.Number, i
+			code := pb.TxValidationCode_INVALID_OTHER_REASON
+			if i < len(filter) {
+				code = pb.TxValidationCode(filter[i])
+			}
+			tx.Valid, tx.ValidationCode = code == pb.TxValidationCode_VALID, code.String()
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+// parseTransaction decodes an envelope of a block, it returns nil if it is not an endorser transaction of
+// the chaincode
+func parseTransaction(envelopeBytes []byte, chaincode string) (*ledgerTx, error) {
+	envelope := new(common.Envelope)
+	if err := proto.Unmarshal(envelopeBytes, envelope); err != nil {
+		return nil, err
+	}
+	payload := new(common.Payload)
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, errors.New("missing header")
+	}
+	channelHeader := new(common.ChannelHeader)
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, channelHeader); err != nil {
+		return nil, err
+	}
+	if channelHeader.Type != int32(common.HeaderType_ENDORSER_TRANSACTION) {
+		return nil, nil
+	}
+	signatureHeader := new(common.SignatureHeader)
+	if err := proto.Unmarshal(payload.Header.SignatureHeader, signatureHeader); err != nil {
+		return nil, err
+	}
+	transaction := new(pb.Transaction)
+	if err := proto.Unmarshal(payload.Data, transaction); err != nil {
+		return nil, err
+	}
+	if len(transaction.Actions) == 0 {
+		return nil, errors.New("transaction without action")
+	}
+	actionPayload := new(pb.ChaincodeActionPayload)
+	if err := proto.Unmarshal(transaction.Actions[0].Payload, actionPayload); err != nil {
+		return nil, err
+	}
+	proposalPayload := new(pb.ChaincodeProposalPayload)
+	if err := proto.Unmarshal(actionPayload.ChaincodeProposalPayload, proposalPayload); err != nil {
+		return nil, err
+	}
+	invocation := new(pb.ChaincodeInvocationSpec)
+	if err := proto.Unmarshal(proposalPayload.Input, invocation); err != nil {
+		return nil, err
+	}
+	spec := invocation.ChaincodeSpec
+	if spec == nil || spec.ChaincodeId == nil || spec.ChaincodeId.Name != chaincode || spec.Input == nil {
+		return nil, nil
+	}
+	if actionPayload.Action == nil {
+		return nil, errors.New("transaction without endorsed action")
+	}
+	responsePayload := new(pb.ProposalResponsePayload)
+	if err := proto.Unmarshal(actionPayload.Action.ProposalResponsePayload, responsePayload); err != nil {
+		return nil, err
+	}
+	chaincodeAction := new(pb.ChaincodeAction)
+	if err := proto.Unmarshal(responsePayload.Extension, chaincodeAction); err != nil {
+		return nil, err
+	}
+	readWriteSet := new(rwset.TxReadWriteSet)
+	if err := proto.Unmarshal(chaincodeAction.Results, readWriteSet); err != nil {
+		return nil, err
+	}
+
+	tx := &ledgerTx{TxID: channelHeader.TxId, Creator: signatureHeader.Creator, Args: spec.Input.Args}
+	if channelHeader.Timestamp != nil {
+		tx.Timestamp = time.Unix(channelHeader.Timestamp.Seconds, int64(channelHeader.Timestamp.Nanos)).UTC()
+	}
+	creator := new(msp.SerializedIdentity)
+	if proto.Unmarshal(signatureHeader.Creator, creator) == nil {
+		tx.MSPID = creator.Mspid
+	}
+	for _, nsReadWriteSet := range readWriteSet.NsRwset {
+		if nsReadWriteSet.Namespace != chaincode {
+			continue
+		}
+		kvReadWriteSet := new(kvrwset.KVRWSet)
+		if err := proto.Unmarshal(nsReadWriteSet.Rwset, kvReadWriteSet); err != nil {
+			return nil, err
+		}
+		tx.Writes = append(tx.Writes, kvReadWriteSet.Writes...)
+	}
+	return tx, nil
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testOrdererMSP = "OrdererMSP"
+
+// testSigner is an orderer signing the blocks of a test channel
+type testSigner struct {
+	mspID string
+	cert  []byte
+	key   *ecdsa.PrivateKey
+}
+
+// testChannel builds the blocks of a channel, the genesis block holds the CA of the orderer organization and
+// the other blocks are signed by an orderer
+type testChannel struct {
+	t       *testing.T
+	caCert  *x509.Certificate
+	caKey   *ecdsa.PrivateKey
+	caPEM   []byte
+	orderer *testSigner
+	blocks  []*common.Block
+	nbTx    int
+}
+
+// newTestChannel returns a channel with its genesis block
+func newTestChannel(t *testing.T) *testChannel {
+	c := &testChannel{t: t}
+	c.caKey = c.newKey()
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "ca.orderer"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), IsCA: true,
+		BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &c.caKey.PublicKey, c.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.caCert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	c.caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	c.orderer = c.newSigner(testOrdererMSP, c.caCert, c.caKey)
+	c.addBlock(c.configTx(c.caPEM))
+	return c
+}
+
+func (c *testChannel) newKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return key
+}
+
+// newSigner returns an orderer of mspID with a certificate issued by a CA. The certificate expired, the
+// blocks it signed remain valid
+func (c *testChannel) newSigner(mspID string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) *testSigner {
+	key := c.newKey()
+	template := &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "orderer"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(-time.Minute), KeyUsage: x509.KeyUsageDigitalSignature}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return &testSigner{mspID: mspID, cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), key: key}
+}
+
+func (c *testChannel) marshal(message proto.Message) []byte {
+	b, err := proto.Marshal(message)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return b
+}
+
+// envelope returns a transaction of the channel with the given header type and data
+func (c *testChannel) envelope(headerType common.HeaderType, txID string, creator, data []byte) []byte {
+	channelHeader := &common.ChannelHeader{Type: int32(headerType), ChannelId: "mychannel", TxId: txID,
+		Timestamp: &timestamp.Timestamp{Seconds: time.Now().Unix()}}
+	payload := &common.Payload{Header: &common.Header{ChannelHeader: c.marshal(channelHeader),
+		SignatureHeader: c.marshal(&common.SignatureHeader{Creator: creator})}, Data: data}
+	return c.marshal(&common.Envelope{Payload: c.marshal(payload), Signature: []byte("signature")})
+}
+
+// configTx returns a config transaction whose orderer organization trusts the CA certificates
+func (c *testChannel) configTx(caCerts ...[]byte) []byte {
+	mspConfig := &msp.MSPConfig{Config: c.marshal(&msp.FabricMSPConfig{Name: testOrdererMSP, RootCerts: caCerts})}
+	config := &common.Config{ChannelGroup: &common.ConfigGroup{Groups: map[string]*common.ConfigGroup{
+		"Orderer": {Groups: map[string]*common.ConfigGroup{
+			"OrdererOrg": {Values: map[string]*common.ConfigValue{"MSP": {Value: c.marshal(mspConfig)}}},
+		}},
+	}}}
+	return c.envelope(common.HeaderType_CONFIG, "", nil, c.marshal(&common.ConfigEnvelope{Config: config}))
+}
+
+// identity returns the serialized identity of a client, name stands for its certificate
+func identity(mspID, name string) []byte {
+	b, _ := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: []byte(name)})
+	return b
+}
+
+// endorserTx returns a transaction of the auction chaincode with its arguments and the keys it writes, the
+// ID of the transaction is returned as well
+func (c *testChannel) endorserTx(creator []byte, args []string, writes ...*kvrwset.KVWrite) ([]byte, string) {
+	c.nbTx++
+	txID := "tx" + strconv.Itoa(c.nbTx)
+	var input [][]byte
+	for _, arg := range args {
+		input = append(input, []byte(arg))
+	}
+	invocation := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{
+		ChaincodeId: &pb.ChaincodeID{Name: chaincodeID}, Input: &pb.ChaincodeInput{Args: input}}}
+	results := &rwset.TxReadWriteSet{NsRwset: []*rwset.NsReadWriteSet{
+		{Namespace: chaincodeID, Rwset: c.marshal(&kvrwset.KVRWSet{Writes: writes})}}}
+	responsePayload := &pb.ProposalResponsePayload{Extension: c.marshal(&pb.ChaincodeAction{Results: c.marshal(results)})}
+	actionPayload := &pb.ChaincodeActionPayload{
+		ChaincodeProposalPayload: c.marshal(&pb.ChaincodeProposalPayload{Input: c.marshal(invocation)}),
+		Action:                   &pb.ChaincodeEndorsedAction{ProposalResponsePayload: c.marshal(responsePayload)}}
+	transaction := &pb.Transaction{Actions: []*pb.TransactionAction{{Payload: c.marshal(actionPayload)}}}
+	return c.envelope(common.HeaderType_ENDORSER_TRANSACTION, txID, creator, c.marshal(transaction)), txID
+}
+
+// addBlock appends a block of valid transactions to the channel and signs it with the orderer
+func (c *testChannel) addBlock(envelopes ...[]byte) *common.Block {
+	block := &common.Block{Header: &common.BlockHeader{Number: uint64(len(c.blocks))}, Data: &common.BlockData{Data: envelopes},
+		Metadata: &common.BlockMetadata{Metadata: make([][]byte, len(common.BlockMetadataIndex_name))}}
+	if len(c.blocks) > 0 {
+		block.Header.PreviousHash = headerHash(c.blocks[len(c.blocks)-1].Header)
+	}
+	dataHash := sha256.Sum256(bytes.Join(envelopes, nil))
+	block.Header.DataHash = dataHash[:]
+	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = make([]byte, len(envelopes))
+	if block.Header.Number > 0 {
+		c.sign(block, c.orderer)
+	}
+	c.blocks = append(c.blocks, block)
+	return block
+}
+
+// sign replaces the signatures of a block with the signature of an orderer
+func (c *testChannel) sign(block *common.Block, signer *testSigner) {
+	value := []byte("last config")
+	signatureHeader := c.marshal(&common.SignatureHeader{Creator: identity(signer.mspID, string(signer.cert)), Nonce: []byte("nonce")})
+	digest := sha256.Sum256(bytes.Join([][]byte{value, signatureHeader, headerBytes(block.Header)}, nil))
+	signature, err := ecdsa.SignASN1(rand.Reader, signer.key, digest[:])
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES] = c.marshal(&common.Metadata{Value: value,
+		Signatures: []*common.MetadataSignature{{SignatureHeader: signatureHeader, Signature: signature}}})
+}
+
+// blocksCopy returns a deep copy of the blocks of the channel, to be tampered with
+func (c *testChannel) blocksCopy() []*common.Block {
+	var blocks []*common.Block
+	for _, block := range c.blocks {
+		blocks = append(blocks, proto.Clone(block).(*common.Block))
+	}
+	return blocks
+}
+
+func TestCheckHashChain(t *testing.T) {
+	c := newTestChannel(t)
+	for i := 0; i < 3; i++ {
+		tx, _ := c.endorserTx(identity("Org1MSP", "seller"), []string{"CreateAuction", "auction1"})
+		c.addBlock(tx)
+	}
+	if err := checkHashChain(c.blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := c.blocksCopy()
+	tampered[2].Data.Data[0] = c.configTx(c.caPEM)
+	if err := checkHashChain(tampered); err == nil || !strings.Contains(err.Error(), "data hash of block 2") {
+		t.Errorf("tampered transaction: %v", err)
+	}
+	// the data hash is updated as well, the next block does not point to the header anymore
+	dataHash := sha256.Sum256(tampered[2].Data.Data[0])
+	tampered[2].Header.DataHash = dataHash[:]
+	if err := checkHashChain(tampered); err == nil || !strings.Contains(err.Error(), "block 3 does not follow block 2") {
+		t.Errorf("tampered header: %v", err)
+	}
+
+	missing := append(c.blocksCopy()[:2], c.blocks[3])
+	if err := checkHashChain(missing); err == nil || !strings.Contains(err.Error(), "block 2 is missing") {
+		t.Errorf("missing block: %v", err)
+	}
+}
+
+func TestCheckOrdererSignatures(t *testing.T) {
+	c := newTestChannel(t)
+	for i := 0; i < 2; i++ {
+		tx, _ := c.endorserTx(identity("Org1MSP", "seller"), []string{"CreateAuction", "auction1"})
+		c.addBlock(tx)
+	}
+	if err := checkOrdererSignatures(c.blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkOrdererSignatures(c.blocks[1:]); err == nil || !strings.Contains(err.Error(), "not a config block") {
+		t.Errorf("blocks without config block: %v", err)
+	}
+
+	unsigned := c.blocksCopy()
+	unsigned[1].Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES] = nil
+	if err := checkOrdererSignatures(unsigned); err == nil || !strings.Contains(err.Error(), "block 1 is not signed") {
+		t.Errorf("unsigned block: %v", err)
+	}
+
+	// the attacker rewrites the chain from block 1 and signs it with its own key
+	rewritten := c.blocksCopy()
+	rewritten[1].Header.DataHash = []byte("changed")
+	rewritten[2].Header.PreviousHash = headerHash(rewritten[1].Header)
+	c.sign(rewritten[1], &testSigner{mspID: testOrdererMSP, cert: c.orderer.cert, key: c.newKey()})
+	if err := checkOrdererSignatures(rewritten); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("block signed with another key: %v", err)
+	}
+	// the signature of the orderer over block 1 does not cover the new header of block 2
+	c.sign(rewritten[1], c.orderer)
+	if err := checkOrdererSignatures(rewritten); err == nil || !strings.Contains(err.Error(), "block 2") {
+		t.Errorf("block following a rewritten block: %v", err)
+	}
+
+	otherCAKey := c.newKey()
+	otherCA := &x509.Certificate{SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: "ca.other"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), IsCA: true,
+		BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}
+	der, err := x509.CreateCertificate(rand.Reader, otherCA, otherCA, &otherCAKey.PublicKey, otherCAKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherCA, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	untrusted := c.blocksCopy()
+	c.sign(untrusted[1], c.newSigner(testOrdererMSP, otherCA, otherCAKey))
+	if err := checkOrdererSignatures(untrusted); err == nil || !strings.Contains(err.Error(), "certificate of the signer") {
+		t.Errorf("block signed by an orderer of another CA: %v", err)
+	}
+	c.sign(untrusted[1], c.newSigner("Org1MSP", c.caCert, c.caKey))
+	if err := checkOrdererSignatures(untrusted); err == nil || !strings.Contains(err.Error(), "not an orderer organization") {
+		t.Errorf("block signed by a peer organization: %v", err)
+	}
+
+	// a config block changes the CA of the orderers for the next blocks
+	c.addBlock(c.configTx(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	tx, _ := c.endorserTx(identity("Org1MSP", "seller"), []string{"CloseAuction", "auction1"})
+	c.addBlock(tx)
+	if err := checkOrdererSignatures(c.blocks); err == nil || !strings.Contains(err.Error(), "block 4") {
+		t.Errorf("block signed by the orderer removed from the config: %v", err)
+	}
+	c.sign(c.blocks[4], c.newSigner(testOrdererMSP, otherCA, otherCAKey))
+	if err := checkOrdererSignatures(c.blocks); err != nil {
+		t.Errorf("block signed by the orderer of the new config: %v", err)
+	}
+}
+
+// serializeBlock encodes a block in the format of the block files of a peer
+func serializeBlock(block *common.Block) []byte {
+	buf := proto.NewBuffer(nil)
+	buf.EncodeVarint(block.Header.Number)
+	buf.EncodeRawBytes(block.Header.DataHash)
+	buf.EncodeRawBytes(block.Header.PreviousHash)
+	buf.EncodeVarint(uint64(len(block.Data.Data)))
+	for _, tx := range block.Data.Data {
+		buf.EncodeRawBytes(tx)
+	}
+	buf.EncodeVarint(uint64(len(block.Metadata.Metadata)))
+	for _, metadata := range block.Metadata.Metadata {
+		buf.EncodeRawBytes(metadata)
+	}
+	return buf.Bytes()
+}
+
+func TestReadSnapshot(t *testing.T) {
+	c := newTestChannel(t)
+	for i := 0; i < 3; i++ {
+		tx, _ := c.endorserTx(identity("Org1MSP", "seller"), []string{"CreateAuction", "auction1"})
+		c.addBlock(tx)
+	}
+	dir := t.TempDir()
+	// blocks 0 to 2 are in a block file, block 3 was fetched
+	blockFile := proto.NewBuffer(nil)
+	for _, block := range c.blocks[:3] {
+		blockFile.EncodeRawBytes(serializeBlock(block))
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "blockfile_000000"), blockFile.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "mychannel_3.block"), c.marshal(c.blocks[3]), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := readSnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != len(c.blocks) {
+		t.Fatalf("%v blocks read", len(blocks))
+	}
+	for i, block := range blocks {
+		if !proto.Equal(block, c.blocks[i]) {
+			t.Errorf("block %v read as %v", i, block.Header)
+		}
+	}
+	if err := checkHashChain(blocks); err != nil {
+		t.Error(err)
+	}
+	if err := checkOrdererSignatures(blocks); err != nil {
+		t.Error(err)
+	}
+
+	truncated := blockFile.Bytes()[:len(blockFile.Bytes())-1]
+	if err := ioutil.WriteFile(filepath.Join(dir, "blockfile_000000"), truncated, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSnapshot(dir); err == nil {
+		t.Error("a truncated block file was read")
+	}
+	if _, err := readSnapshot(t.TempDir()); err == nil {
+		t.Error("blocks were read from an empty directory")
+	}
+}
@@ -0,0 +1,525 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"golang.org/x/crypto/nacl/box"
+	"google.golang.org/grpc"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// daemonAddress is the unix socket of the control API of the daemon
+	daemonAddress = "unix:///tmp/auctioneer.sock"
+	// storeFile is the database of the daemon, in the working directory next to the keys
+	storeFile = "auctioneer.db"
+	// passphraseVariable is the environment variable holding the passphrase of the store
+	passphraseVariable = "AUCTIONEER_PASSPHRASE"
+	// tickInterval is how often the daemon checks the deadlines
+	tickInterval = 5 * time.Second
+)
+
+// sealedBidEvents are the events of the chaincode that move a sealed-bid auction forward
+const sealedBidEvents = "^(CommitmentSent|AuctionClosed|BidRevealed|AuctionEnded|WinnerDeclared|AuctionMigrated)$"
+
+// scheduleRequest asks the daemon to run a new sealed-bid auction. The bidding phase lasts BiddingSeconds from
+// now, the reveal phase RevealSeconds from the moment the auction is closed
+type scheduleRequest struct {
+	AuctionID      string       `json:"auctionID"`
+	Item           string       `json:"item"`
+	BiddingSeconds int64        `json:"biddingSeconds"`
+	RevealSeconds  int64        `json:"revealSeconds"`
+	Rules          auctionRules `json:"rules"`
+	// chaincode of the private data auction the auction is migrated from, empty for a new auction
+	MigrateFrom    string       `json:"migrateFrom,omitempty"`
+}
+
+// auctionList is the list of the auctions of the daemon, without their secret keys
+type auctionList struct {
+	Auctions []auctionRecord `json:"auctions"`
+}
+
+type empty struct{}
+
+// auctionLedger is the chaincode as the daemon sees it
+type auctionLedger interface {
+	query(auctionID string) (*Auction, error)
+	submit(fcn string, args ...[]byte) error
+}
+
+// channelLedger queries and submits the transactions of the seller on the peers of the endpoints
+type channelLedger struct {
+	client    *channel.Client
+	endpoints []string
+}
+
+func (l *channelLedger) query(auctionID string) (*Auction, error) {
+	return queryAuction(l.client, auctionID, l.endpoints)
+}
+
+func (l *channelLedger) submit(fcn string, args ...[]byte) error {
+	_, err := l.client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: fcn, Args: args},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(l.endpoints...))
+	return err
+}
+
+// eventSource registers for the events of the chaincode, the event client of the SDK
+type eventSource interface {
+	RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error)
+	Unregister(reg fab.Registration)
+}
+
+// daemon runs the sealed-bid auctions of its store. Each auction is moved forward by one goroutine at a time,
+// on the events of the chaincode and at its deadlines
+type daemon struct {
+	store  *auctionStore
+	ledger auctionLedger
+	tick   time.Duration
+	// stop ends the event loop, nil while the process runs
+	stop chan struct{}
+
+	mu   sync.Mutex
+	busy map[string]bool
+}
+
+// runDaemon runs the auctioneer daemon: it resumes the auctions of the store from the state of the ledger,
+// then serves the control API and drives the auctions until the process is stopped
+func runDaemon(username string, endpoints []string) {
+	passphrase := os.Getenv(passphraseVariable)
+	if passphrase == "" {
+		panic(fmt.Sprintf("the passphrase of the store must be set in %v", passphraseVariable))
+	}
+	// the auctions are proved with the keys of the working directory
+	readKeyManifest()
+	store, err := openAuctionStore(storeFile, passphrase)
+	if err != nil {
+		panic(err)
+	}
+	defer store.Close()
+
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer sdk.Close()
+	channelContext := sdk.ChannelContext(channelName, fabsdk.WithUser(username), fabsdk.WithOrg("org1"))
+	client, err := channel.New(channelContext, channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	eventClient, err := event.New(channelContext, event.WithBlockEvents())
+	if err != nil {
+		panic(err)
+	}
+
+	d := &daemon{store: store, ledger: &channelLedger{client: client, endpoints: endpoints}, tick: tickInterval,
+		busy: make(map[string]bool)}
+	go d.serve()
+	d.run(eventClient)
+}
+
+// run resumes every auction from the state of the ledger, then moves the auctions forward on the events of the
+// chaincode and at every tick. If the event service closes the channel of the events the daemon registers again,
+// until it succeeds the auctions only move at the ticks
+func (d *daemon) run(events eventSource) {
+	registration, ccEvents, err := events.RegisterChaincodeEvent(chaincodeID, sealedBidEvents)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if registration != nil {
+			events.Unregister(registration)
+		}
+	}()
+	register := func() {
+		registration, ccEvents, err = events.RegisterChaincodeEvent(chaincodeID, sealedBidEvents)
+		if err != nil {
+			log.Printf("registering for the chaincode events: %v", err)
+			registration, ccEvents = nil, nil
+		}
+	}
+
+	d.stepDue(true)
+	ticker := time.NewTicker(d.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case ccEvent, ok := <-ccEvents:
+			if !ok {
+				log.Printf("the chaincode events were closed, registering again")
+				events.Unregister(registration)
+				register()
+				continue
+			}
+			var auctionEvent AuctionEvent
+			if err := json.Unmarshal(ccEvent.Payload, &auctionEvent); err != nil {
+				continue
+			}
+			if record, err := d.store.get(auctionEvent.AuctionID); err == nil && record != nil && !record.done() {
+				log.Printf("auction %v: %v %v", auctionEvent.AuctionID, ccEvent.EventName, auctionEvent.BidID)
+				d.step(auctionEvent.AuctionID)
+			}
+		case <-ticker.C:
+			if ccEvents == nil {
+				register()
+			}
+			d.stepDue(false)
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// stepDue moves forward the auctions with a deadline that has passed, or every auction that is not done
+func (d *daemon) stepDue(all bool) {
+	records, err := d.store.list()
+	if err != nil {
+		log.Printf("listing the auctions: %v", err)
+		return
+	}
+	now := time.Now().Unix()
+	for _, record := range records {
+		due := record.Phase == phaseNew || record.Phase == phaseEnded ||
+			(record.Phase == phaseOpen && now >= record.CloseAt) ||
+			(record.Phase == phaseClosed && now >= record.EndAt)
+		if !record.done() && (all || due) {
+			d.step(record.AuctionID)
+		}
+	}
+}
+
+// step moves an auction forward in its own goroutine, unless it is already being moved
+func (d *daemon) step(auctionID string) {
+	d.mu.Lock()
+	if d.busy[auctionID] {
+		d.mu.Unlock()
+		return
+	}
+	d.busy[auctionID] = true
+	d.mu.Unlock()
+
+	go func() {
+		defer func() {
+			d.mu.Lock()
+			delete(d.busy, auctionID)
+			d.mu.Unlock()
+		}()
+		record, err := d.store.get(auctionID)
+		if err != nil || record == nil {
+			log.Printf("auction %v: %v", auctionID, err)
+			return
+		}
+		for {
+			phase := record.Phase
+			err = d.advance(record)
+			if err != nil {
+				record.Error = err.Error()
+				log.Printf("auction %v: %v", auctionID, err)
+			} else {
+				record.Error = ""
+			}
+			record.UpdatedAt = time.Now().Unix()
+			if err := d.store.put(record); err != nil {
+				log.Printf("auction %v: %v", auctionID, err)
+				return
+			}
+			if err != nil || record.Phase == phase || record.done() {
+				return
+			}
+			log.Printf("auction %v: %v", auctionID, record.Phase)
+		}
+	}()
+}
+
+// advance follows the status of an auction on the ledger and runs the transaction of the seller that is due,
+// at most one per call. Errors are kept in the record, the auction is tried again at the next tick
+func (d *daemon) advance(record *auctionRecord) (err error) {
+	// the helpers shared with the command line panic on errors
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	auction, err := d.ledger.query(record.AuctionID)
+	if record.Phase == phaseNew {
+		if err != nil && strings.Contains(err.Error(), "auction does not exist") && record.MigrateFrom != "" {
+			return d.migrate(record)
+		}
+		if err != nil && strings.Contains(err.Error(), "auction does not exist") {
+			return d.create(record)
+		}
+		if err != nil {
+			return err
+		}
+		// created before the daemon stopped, the rules are set again if no bid was placed yet
+		if auction.SellerPk != pkArray(record.Pk) {
+			record.Phase = phaseFailed
+			return fmt.Errorf("auction %v was created by another seller", record.AuctionID)
+		}
+		if auction.Status == "open" && len(auction.CommitmentOrder) == 0 {
+			if err := d.applyRules(record); err != nil {
+				return err
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	switch {
+	// the invalid set of a declared auction is never empty, it is at least the empty JSON object
+	case auction.WinningBid != "" || auction.InvalidSet != "":
+		record.Phase = phaseDeclared
+		record.WinningBid = auction.WinningBid
+		return nil
+	case auction.Status == "ended":
+		record.Phase = phaseEnded
+		return d.declare(record, auction)
+	case auction.Status == "closed":
+		record.Phase = phaseClosed
+		if record.EndAt == 0 {
+			record.EndAt = now + record.RevealSeconds
+		}
+		revealedAll := len(auction.EncryptedBids) == len(auction.CommitmentOrder)
+		if now < record.EndAt && !revealedAll {
+			return nil
+		}
+		if len(auction.EncryptedBids) == 0 {
+			record.Phase = phaseFailed
+			return fmt.Errorf("no bid was revealed, the auction cannot be ended")
+		}
+		return d.ledger.submit("EndAuction", []byte(record.AuctionID))
+	case auction.Status == "open":
+		record.Phase = phaseOpen
+		if now < record.CloseAt {
+			return nil
+		}
+		err = d.ledger.submit("CloseAuction", []byte(record.AuctionID))
+		if err == nil {
+			record.EndAt = now + record.RevealSeconds
+		}
+		return err
+	default:
+		record.Phase = phaseFailed
+		return fmt.Errorf("unexpected status %q", auction.Status)
+	}
+}
+
+// create creates a new auction on the ledger with its rules
+func (d *daemon) create(record *auctionRecord) error {
+	err := d.ledger.submit("CreateAuction", []byte(record.AuctionID), []byte(record.Item),
+		[]byte(base64.StdEncoding.EncodeToString(record.Pk)), []byte(record.KeyID))
+	if err != nil {
+		return err
+	}
+	if err := d.applyRules(record); err != nil {
+		return err
+	}
+	record.Phase = phaseOpen
+	return nil
+}
+
+// applyRules sets the rules of a new auction
+func (d *daemon) applyRules(record *auctionRecord) error {
+	for _, request := range ruleRequests(record.AuctionID, record.Rules) {
+		if err := d.ledger.submit(request.Fcn, request.Args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate moves the auction from the private data auction chaincode, the auction keeps the status it had there
+func (d *daemon) migrate(record *auctionRecord) error {
+	err := d.ledger.submit("MigrateAuction", []byte(record.AuctionID), []byte(record.MigrateFrom),
+		[]byte(base64.StdEncoding.EncodeToString(record.Pk)), []byte(record.KeyID))
+	if err != nil {
+		return err
+	}
+	record.Phase = phaseOpen
+	return nil
+}
+
+// declare decrypts the bids of an ended auction with its secret key, has the proof computed by the prover
+// service and declares the winner
+func (d *daemon) declare(record *auctionRecord, auction *Auction) error {
+	sk, err := d.store.secretKey(record)
+	if err != nil {
+		return err
+	}
+	pk := pkArray(record.Pk)
+	bestID, proofBytes, invalidSet, err := decideAuction(record.AuctionID, auction, &pk, sk)
+	if err != nil {
+		return err
+	}
+	err = d.ledger.submit("DeclareWinner", []byte(record.AuctionID), []byte(bestID),
+		[]byte(base64.StdEncoding.EncodeToString(proofBytes)), invalidSet)
+	if err != nil {
+		return err
+	}
+	record.Phase = phaseDeclared
+	record.WinningBid = bestID
+	return nil
+}
+
+// schedule creates the key pair of a new auction and stores it before anything is sent to the ledger
+func (d *daemon) schedule(req *scheduleRequest) (*auctionRecord, error) {
+	if req.AuctionID == "" || req.BiddingSeconds <= 0 || req.RevealSeconds <= 0 {
+		return nil, fmt.Errorf("invalid auction ID or phase durations")
+	}
+	keyID, err := readVerifyingKeyID()
+	if err != nil {
+		return nil, fmt.Errorf("reading the verifying key of the working directory: %v", err)
+	}
+	pk, sk, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sealedSk, err := d.store.db.Seal([]byte(req.AuctionID), sk[:])
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	record := &auctionRecord{
+		AuctionID:     req.AuctionID,
+		Item:          req.Item,
+		Rules:         req.Rules,
+		KeyID:         keyID,
+		Pk:            pk[:],
+		SealedSk:      sealedSk,
+		Phase:         phaseNew,
+		CloseAt:       now + req.BiddingSeconds,
+		RevealSeconds: req.RevealSeconds,
+		UpdatedAt:     now,
+		MigrateFrom:   req.MigrateFrom,
+	}
+	err = d.store.create(record)
+	if err != nil {
+		return nil, err
+	}
+	d.step(record.AuctionID)
+	return record, nil
+}
+
+func pkArray(pk []byte) [SellerPkSize]byte {
+	var key [SellerPkSize]byte
+	copy(key[:], pk)
+	return key
+}
+
+// serve serves the control API of the daemon on its unix socket
+func (d *daemon) serve() {
+	path := strings.TrimPrefix(daemonAddress, "unix://")
+	os.Remove(path)
+	// the socket is created only open to the user of the daemon, changing its mode after Listen would leave
+	// it open to other users until then
+	mask := syscall.Umask(0177)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(mask)
+	if err != nil {
+		panic(err)
+	}
+	server := grpc.NewServer()
+	server.RegisterService(&daemonServiceDesc, d)
+	err = server.Serve(listener)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// daemonServiceDesc is the control API of the daemon, its messages are encoded with the JSON codec of the
+// prover service
+var daemonServiceDesc = grpc.ServiceDesc{
+	ServiceName: "auction.auctioneer.Auctioneer",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Schedule",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(scheduleRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				record, err := srv.(*daemon).schedule(req)
+				if err != nil {
+					return nil, err
+				}
+				record.SealedSk = nil
+				return record, nil
+			},
+		},
+		{
+			MethodName: "List",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				if err := dec(new(empty)); err != nil {
+					return nil, err
+				}
+				records, err := srv.(*daemon).store.list()
+				if err != nil {
+					return nil, err
+				}
+				for i := range records {
+					records[i].SealedSk = nil
+				}
+				return &auctionList{Auctions: records}, nil
+			},
+		},
+	},
+	Metadata: "client-auctioneer/daemon.go",
+}
+
+// callDaemon calls a method of the control API of a running daemon
+func callDaemon(method string, req, resp interface{}) {
+	conn, err := grpc.Dial(daemonAddress, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")))
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err = conn.Invoke(ctx, "/auction.auctioneer.Auctioneer/"+method, req, resp)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// scheduleAuction asks the daemon to run a new sealed-bid auction
+func scheduleAuction(auctionID, itemName string, biddingSeconds, revealSeconds int64) {
+	var record auctionRecord
+	callDaemon("Schedule", &scheduleRequest{AuctionID: auctionID, Item: itemName, BiddingSeconds: biddingSeconds,
+		RevealSeconds: revealSeconds}, &record)
+	fmt.Printf("auction %v scheduled, bidding closes at %v\n", record.AuctionID, time.Unix(record.CloseAt, 0).Format(time.RFC3339))
+}
+
+// listAuctions prints the auctions of the daemon
+func listAuctions() {
+	var list auctionList
+	callDaemon("List", &empty{}, &list)
+	for _, record := range list.Auctions {
+		fmt.Printf("%v: %v, winner %q %v\n", record.AuctionID, record.Phase, record.WinningBid, record.Error)
+	}
+}
+
+// migrateAuction asks the daemon to take over an auction of the private data auction chaincode legacyChaincode
+func migrateAuction(auctionID, legacyChaincode string, biddingSeconds, revealSeconds int64) {
+	var record auctionRecord
+	callDaemon("Schedule", &scheduleRequest{AuctionID: auctionID, BiddingSeconds: biddingSeconds,
+		RevealSeconds: revealSeconds, MigrateFrom: legacyChaincode}, &record)
+	fmt.Printf("auction %v scheduled for migration from %v\n", record.AuctionID, legacyChaincode)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLedger runs the transactions of the seller on auctions in memory
+type fakeLedger struct {
+	mu        sync.Mutex
+	auctions  map[string]*Auction
+	submitted []string
+}
+
+func newFakeLedger() *fakeLedger {
+	return &fakeLedger{auctions: make(map[string]*Auction)}
+}
+
+func (l *fakeLedger) query(auctionID string) (*Auction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	auction, ok := l.auctions[auctionID]
+	if !ok {
+		return nil, errors.New("auction does not exist")
+	}
+	copied := *auction
+	return &copied, nil
+}
+
+func (l *fakeLedger) submit(fcn string, args ...[]byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	auctionID := string(args[0])
+	l.submitted = append(l.submitted, fcn)
+	switch fcn {
+	case "CreateAuction", "MigrateAuction":
+		auction := &Auction{Status: "open"}
+		pk, err := base64.StdEncoding.DecodeString(string(args[2]))
+		if err != nil {
+			return err
+		}
+		copy(auction.SellerPk[:], pk)
+		l.auctions[auctionID] = auction
+	case "CloseAuction":
+		// a bid that is not revealed yet
+		l.auctions[auctionID].Status = "closed"
+		l.auctions[auctionID].CommitmentOrder = []string{"bid1"}
+	case "EndAuction":
+		l.auctions[auctionID].Status = "ended"
+	}
+	return nil
+}
+
+// calls returns the transactions submitted since the last call
+func (l *fakeLedger) calls() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	calls := strings.Join(l.submitted, " ")
+	l.submitted = nil
+	return calls
+}
+
+func newTestDaemon(t *testing.T, ledger auctionLedger) *daemon {
+	t.Helper()
+	store := openTestStore(t, filepath.Join(t.TempDir(), storeFile))
+	t.Cleanup(func() { store.Close() })
+	return &daemon{store: store, ledger: ledger, tick: 10 * time.Millisecond, stop: make(chan struct{}),
+		busy: make(map[string]bool)}
+}
+
+// waitIdle waits until no auction is being moved forward
+func (d *daemon) waitIdle(t *testing.T) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		d.mu.Lock()
+		idle := len(d.busy) == 0
+		d.mu.Unlock()
+		if idle {
+			return
+		}
+	}
+	t.Fatal("the auctions are still moving")
+}
+
+func (d *daemon) phase(t *testing.T, auctionID string) *auctionRecord {
+	t.Helper()
+	record, err := d.store.get(auctionID)
+	if err != nil || record == nil {
+		t.Fatalf("auction %v: %v, %v", auctionID, record, err)
+	}
+	return record
+}
+
+func TestAdvanceResumesPhases(t *testing.T) {
+	ledger := newFakeLedger()
+	d := newTestDaemon(t, ledger)
+	pk := []byte("0123456789abcdef0123456789abcdef")
+	past := time.Now().Unix() - 1
+	future := time.Now().Unix() + 3600
+
+	// the daemon stopped before CreateAuction
+	record := &auctionRecord{AuctionID: "auction1", Pk: pk, Phase: phaseNew, CloseAt: future, RevealSeconds: 60,
+		Rules: auctionRules{Deposit: 10, PaymentWindow: 60}}
+	if err := d.advance(record); err != nil || record.Phase != phaseOpen {
+		t.Fatalf("phase %v: %v", record.Phase, err)
+	}
+	if calls := ledger.calls(); calls != "CreateAuction RequireDeposit" {
+		t.Errorf("created with %v", calls)
+	}
+
+	// the daemon stopped after CreateAuction, before the phase was stored: the rules are set again
+	record.Phase = phaseNew
+	if err := d.advance(record); err != nil || record.Phase != phaseOpen {
+		t.Fatalf("phase %v: %v", record.Phase, err)
+	}
+	if calls := ledger.calls(); calls != "RequireDeposit" {
+		t.Errorf("resumed with %v", calls)
+	}
+
+	// the bidding phase is not over
+	if err := d.advance(record); err != nil || record.Phase != phaseOpen || ledger.calls() != "" {
+		t.Fatalf("phase %v: %v", record.Phase, err)
+	}
+
+	// the daemon stopped after CloseAuction, the auction is not closed again
+	ledger.auctions["auction1"].Status = "closed"
+	ledger.auctions["auction1"].CommitmentOrder = []string{"bid1", "bid2"}
+	record.CloseAt = past
+	if err := d.advance(record); err != nil || record.Phase != phaseClosed || record.EndAt == 0 {
+		t.Fatalf("phase %v, end %v: %v", record.Phase, record.EndAt, err)
+	}
+	if calls := ledger.calls(); calls != "" {
+		t.Errorf("resumed with %v", calls)
+	}
+
+	// the reveal phase is over
+	ledger.auctions["auction1"].EncryptedBids = map[string]EncryptedBid{"bid1": {}}
+	record.EndAt = past
+	if err := d.advance(record); err != nil || ledger.calls() != "EndAuction" {
+		t.Fatalf("phase %v: %v", record.Phase, err)
+	}
+
+	// the winner was declared before the daemon stopped
+	ledger.auctions["auction1"].WinningBid = "bid1"
+	ledger.auctions["auction1"].InvalidSet = "{}"
+	if err := d.advance(record); err != nil || record.Phase != phaseDeclared || record.WinningBid != "bid1" {
+		t.Fatalf("phase %v: %v", record.Phase, err)
+	}
+	if !record.done() || ledger.calls() != "" {
+		t.Error("the declared auction is not done")
+	}
+}
+
+func TestAdvanceFailures(t *testing.T) {
+	ledger := newFakeLedger()
+	d := newTestDaemon(t, ledger)
+	ledger.auctions["auction1"] = &Auction{Status: "open"}
+	record := &auctionRecord{AuctionID: "auction1", Pk: []byte("0123456789abcdef0123456789abcdef"), Phase: phaseNew}
+	if err := d.advance(record); err == nil || record.Phase != phaseFailed {
+		t.Errorf("the auction of another seller is in phase %v", record.Phase)
+	}
+
+	ledger.auctions["auction2"] = &Auction{Status: "closed", CommitmentOrder: []string{"bid1"}}
+	record = &auctionRecord{AuctionID: "auction2", Phase: phaseClosed, EndAt: time.Now().Unix() - 1}
+	if err := d.advance(record); err == nil || record.Phase != phaseFailed {
+		t.Errorf("the auction without revealed bids is in phase %v", record.Phase)
+	}
+	if calls := ledger.calls(); calls != "" {
+		t.Errorf("submitted %v", calls)
+	}
+}
+
+func TestStepDueAfterRestart(t *testing.T) {
+	ledger := newFakeLedger()
+	dir := t.TempDir()
+	store := openTestStore(t, filepath.Join(dir, storeFile))
+	pk := []byte("0123456789abcdef0123456789abcdef")
+	past := time.Now().Unix() - 1
+	records := []*auctionRecord{
+		{AuctionID: "new", Pk: pk, Phase: phaseNew, CloseAt: past, RevealSeconds: 3600},
+		{AuctionID: "open", Pk: pk, Phase: phaseOpen, CloseAt: time.Now().Unix() + 3600},
+		{AuctionID: "declared", Pk: pk, Phase: phaseDeclared},
+	}
+	for _, record := range records {
+		if err := store.create(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ledger.auctions["open"] = &Auction{Status: "open"}
+	copy(ledger.auctions["open"].SellerPk[:], pk)
+	store.Close()
+
+	// the restarted daemon moves every auction from the state of the ledger
+	store = openTestStore(t, filepath.Join(dir, storeFile))
+	defer store.Close()
+	d := &daemon{store: store, ledger: ledger, busy: make(map[string]bool)}
+	d.stepDue(true)
+	d.waitIdle(t)
+	// the phase follows the ledger at the next step
+	if record := d.phase(t, "new"); record.Phase != phaseOpen || record.EndAt == 0 || record.Error != "" {
+		t.Errorf("new auction in phase %v: %v", record.Phase, record.Error)
+	}
+	if record := d.phase(t, "open"); record.Phase != phaseOpen {
+		t.Errorf("open auction in phase %v", record.Phase)
+	}
+	if calls := ledger.calls(); calls != "CreateAuction CloseAuction" {
+		t.Errorf("submitted %v", calls)
+	}
+	if _, ok := ledger.auctions["declared"]; ok {
+		t.Error("the declared auction was moved")
+	}
+	d.stepDue(false)
+	d.waitIdle(t)
+	if record := d.phase(t, "new"); record.Phase != phaseClosed {
+		t.Errorf("closed auction in phase %v", record.Phase)
+	}
+	if calls := ledger.calls(); calls != "" {
+		t.Errorf("submitted %v", calls)
+	}
+}
+
+func TestScheduleWithoutKey(t *testing.T) {
+	ledger := newFakeLedger()
+	d := newTestDaemon(t, ledger)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &scheduleRequest{AuctionID: "auction1", BiddingSeconds: 60, RevealSeconds: 60}
+	if _, err := d.schedule(req); err == nil {
+		t.Fatal("an auction was scheduled without a verifying key")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "vk"), []byte("vk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	record, err := d.schedule(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(record.KeyID) != 64 {
+		t.Errorf("key ID %v", record.KeyID)
+	}
+	d.waitIdle(t)
+	if record := d.phase(t, "auction1"); record.Phase != phaseOpen {
+		t.Errorf("scheduled auction in phase %v", record.Phase)
+	}
+}
+
+// fakeEvents is an event source whose registrations are closed or refused by the test
+type fakeEvents struct {
+	mu            sync.Mutex
+	registrations []chan *fab.CCEvent
+	refuse        bool
+}
+
+func (e *fakeEvents) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.refuse {
+		return nil, nil, errors.New("event service unavailable")
+	}
+	events := make(chan *fab.CCEvent, 1)
+	e.registrations = append(e.registrations, events)
+	return events, events, nil
+}
+
+func (e *fakeEvents) Unregister(reg fab.Registration) {}
+
+// last returns the number of registrations and the channel of the last one
+func (e *fakeEvents) last() (int, chan *fab.CCEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.registrations) == 0 {
+		return 0, nil
+	}
+	return len(e.registrations), e.registrations[len(e.registrations)-1]
+}
+
+func (e *fakeEvents) waitRegistrations(t *testing.T, n int) chan *fab.CCEvent {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		if count, events := e.last(); count == n {
+			return events
+		}
+	}
+	t.Fatalf("expected %v registrations", n)
+	return nil
+}
+
+func TestRunRegistersAgain(t *testing.T) {
+	ledger := newFakeLedger()
+	d := newTestDaemon(t, ledger)
+	// an open auction the events move forward
+	future := time.Now().Unix() + 3600
+	if err := d.store.create(&auctionRecord{AuctionID: "auction1", Phase: phaseOpen, CloseAt: future, RevealSeconds: 3600}); err != nil {
+		t.Fatal(err)
+	}
+	ledger.auctions["auction1"] = &Auction{Status: "open"}
+	events := &fakeEvents{}
+	done := make(chan struct{})
+	go func() {
+		d.run(events)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		close(d.stop)
+		<-done
+	})
+
+	// the event service closes the channel and refuses a new registration, the daemon registers again at a tick
+	ccEvents := events.waitRegistrations(t, 1)
+	d.waitIdle(t)
+	events.mu.Lock()
+	events.refuse = true
+	events.mu.Unlock()
+	close(ccEvents)
+	time.Sleep(5 * d.tick)
+	events.mu.Lock()
+	events.refuse = false
+	events.mu.Unlock()
+	ccEvents = events.waitRegistrations(t, 2)
+
+	// the events of the new registration move the auctions
+	ledger.mu.Lock()
+	ledger.auctions["auction1"].Status = "closed"
+	ledger.auctions["auction1"].CommitmentOrder = []string{"bid1"}
+	ledger.mu.Unlock()
+	ccEvents <- &fab.CCEvent{EventName: "AuctionClosed", Payload: []byte(`{"auctionID":"auction1"}`)}
+	for start := time.Now(); d.phase(t, "auction1").Phase != phaseClosed; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("the event did not move the auction")
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"io/ioutil"
+	"strconv"
+)
+
+// mintTokens mints tokens on the account of the user, the user has to be a client of the minter organization
+func mintTokens(username string, amount int, endpoints []string) {
+	client := newOrgChannelClient(username)
+	_, err := client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "Mint", Args: [][]byte{[]byte(strconv.Itoa(amount))}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Minted %v tokens\n", amount)
+}
+
+// lockDeposit locks the deposit of an auction from the account of the user, and writes the secret of the
+// voucher to secretFile. The anonymous bidder who gets the file binds the deposit to their bid
+func lockDeposit(username, auctionID, secretFile string, endpoints []string) {
+	client := newOrgChannelClient(username)
+
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		panic(err)
+	}
+	digest := sha256.Sum256(secret)
+	_, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "LockDeposit", Args: [][]byte{[]byte(auctionID),
+		[]byte(hex.EncodeToString(digest[:]))}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	err = ioutil.WriteFile(secretFile, secret, 0600)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Deposit locked, voucher %x\n", digest)
+}
+
+// payWinningBid pays the winning bid of an auction from the account of the user. openingFile contains the
+// opening values of the winning commitment, written by the anonymous bidder
+func payWinningBid(username, auctionID, openingFile string, endpoints []string) {
+	openingBytes, err := ioutil.ReadFile(openingFile)
+	if err != nil {
+		panic(err)
+	}
+	var opening struct {
+		Price int    `json:"price"`
+		R     []byte `json:"r"`
+	}
+	err = json.Unmarshal(openingBytes, &opening)
+	if err != nil {
+		panic(err)
+	}
+
+	client := newOrgChannelClient(username)
+	_, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "PayWinningBid", Args: [][]byte{[]byte(auctionID),
+		[]byte(strconv.Itoa(opening.Price)), []byte(base64.StdEncoding.EncodeToString(opening.R))}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Paid %v for auction %v\n", opening.Price, auctionID)
+}
+
+// newOrgChannelClient creates a channel client using the identity of a user of org1
+func newOrgChannelClient(username string) *channel.Client {
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	client, err := channel.New(sdk.ChannelContext(channelName, fabsdk.WithUser(username), fabsdk.WithOrg("org1")), channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
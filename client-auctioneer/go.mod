@@ -3,8 +3,28 @@ module client-auctioneer
 go 1.15
 
 require (
-	github.com/consensys/gnark v0.4.0
-	github.com/consensys/gnark-crypto v0.4.1-0.20210428083642-6bd055b79906
+	github.com/ckiere/test-network/auction-circuit v0.0.0
+	github.com/ckiere/test-network/client-dac-go v0.0.0
+	github.com/ckiere/test-network/prover-service v0.0.0
+	github.com/golang/protobuf v1.5.3
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200707132912-fee30f3ccd23
 	github.com/hyperledger/fabric-sdk-go v1.0.0
-	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
+	github.com/prometheus/client_golang v1.1.0
+	go.etcd.io/bbolt v1.3.5
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.14.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	golang.org/x/crypto v0.10.0
+	google.golang.org/grpc v1.54.0
 )
+
+replace github.com/hyperledger/fabric-sdk-go v1.0.0 => ../client-dac-go/internal-fabric-sdk-go
+
+replace github.com/ckiere/test-network/auction-circuit => ../auction-circuit
+
+replace github.com/ckiere/test-network/prover-service => ../prover-service
+
+replace github.com/ckiere/test-network/client-dac-go => ../client-dac-go
+
+replace client-loadgen => ../client-loadgen
@@ -1,18 +1,13 @@
 package main
 
 import (
-	"bytes"
-	"client-auctioneer/crypto"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"github.com/consensys/gnark-crypto/ecc"
-	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
-	twistededwards2 "github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
-	"github.com/consensys/gnark/backend/groth16"
-	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/std/algebra/twistededwards"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
@@ -20,6 +15,7 @@ import (
 	"golang.org/x/crypto/nacl/box"
 	"math/big"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -27,9 +23,9 @@ const channelName = "auction"
 const chaincodeID = "blindauction"
 const SellerPkSize = 32
 const MaxBids = 10
-
-var hx = new(fr.Element).SetString("51295569138718539371092613972351202357326289069440880621285444911501458459494")
-var hy = new(fr.Element).SetString("49831129265363587078046764490824666482509464638593900758877649985443393819454")
+// auctionVersion is the latest schema version of the auctions the client reads, auctions without a
+// version have the schema of version 2
+const auctionVersion = 2
 
 // Auction data
 type Auction struct {
@@ -38,17 +34,30 @@ type Auction struct {
 	Seller       string                    `json:"seller"`
 	SellerPk	 [SellerPkSize]byte        `json:"sellerPk"`
 	Commitments  map[string] []byte        `json:"commitments"`
+	CommitmentOrder []string               `json:"commitmentOrder"`
 	EncryptedBids map[string] EncryptedBid `json:"encryptedBids"`
 	InvalidSet   string                    `json:"invalidSet"`
 	WinningBid   string                    `json:"winningBid"`
 	Proof        []byte                    `json:"proof"`
 	Status       string                    `json:"status"`
+	AuctionType  string                    `json:"auctionType"`
+	// key of the registry the proof of the winner is verified with, VerifyingKey is the key of auctions
+	// created before the registry
+	ProofSystem  string                    `json:"proofSystem"`
+	MaxBids      int                       `json:"maxBids"`
+	VerifyingKeyID string                  `json:"verifyingKeyID"`
+	VerifyingKey []byte                    `json:"verifyingKey"`
+	// schema version, MigratedFrom is the private data auction chaincode a migrated auction comes from
+	Version      int                       `json:"version"`
+	MigratedFrom string                    `json:"migratedFrom"`
 }
 
 type Bid struct {
 	Type     string `json:"objectType"`
 	Price    int    `json:"price"`
 	R        big.Int `json:"r"`
+	// key of the box of the encrypted bid, so that the chaincode can check the opening is invalid
+	Key      []byte `json:"key,omitempty"`
 }
 
 // EncryptedBid contains the values needed to open a commitment to a bid, encrypted with the public key of the seller
@@ -58,51 +67,184 @@ type EncryptedBid struct {
 	Bidder   string `json:"bidder"`
 }
 
-type AuctionCircuit struct {
-	// struct tags on a variable is optional
-	// default uses variable name and secret visibility.
-	Values [MaxBids]frontend.Variable
-	Rs [MaxBids]frontend.Variable
-	ComsX [MaxBids]frontend.Variable `gnark:",public"`
-	ComsY [MaxBids]frontend.Variable `gnark:",public"`
-	WinningValue frontend.Variable
-	WinningR frontend.Variable
-	WinningComX frontend.Variable `gnark:",public"`
-	WinningComY frontend.Variable `gnark:",public"`
-}
-
-// Define declares the circuit constraints
-func (circuit *AuctionCircuit) Define(curveID ecc.ID, cs *frontend.ConstraintSystem) error {
-	// constants
-	curve, _ := twistededwards.NewEdCurve(ecc.BLS12_381)
-	// check winning commitment
-	circuit.CheckCommitment(curve, circuit.WinningValue, circuit.WinningR, circuit.WinningComX, circuit.WinningComY, cs)
-	// check all other bids (valid commitment and value lower than winning bid)
-	for i := 0; i < MaxBids; i++ {
-		circuit.CheckCommitment(curve, circuit.Values[i], circuit.Rs[i], circuit.ComsX[i], circuit.ComsY[i], cs)
-		cs.AssertIsLessOrEqual(circuit.Values[i], circuit.WinningValue)
-	}
-	return nil
-}
-
-func (circuit *AuctionCircuit) CheckCommitment(curve twistededwards.EdCurve, value, r, comX, comY frontend.Variable, cs *frontend.ConstraintSystem) {
-	// com = g^value h^r
-	com := twistededwards.Point{}
-	com.ScalarMulFixedBase(cs, curve.BaseX, curve.BaseY, value, curve)
-	temp := twistededwards.Point{}
-	temp.ScalarMulFixedBase(cs, hx, hy, r, curve)
-	com.AddGeneric(cs, &com, &temp, curve)
-	cs.AssertIsEqual(com.X, comX)
-	cs.AssertIsEqual(com.Y, comY)
-}
-
 func main() {
+	os.Args = startTracing(serveMetrics(os.Args))
+	defer stopTracing()
 	argc := len(os.Args)
 	if argc > 1 {
 		cmd := os.Args[1]
 		if cmd == "client" {
 			if argc > 5 {
-				launchClient(os.Args[2], os.Args[3], os.Args[4], os.Args[5:])
+				launchClient(os.Args[2], os.Args[3], os.Args[4], auctionRules{}, os.Args[5:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "uniqueclient" || cmd == "escrowclient" {
+			// same as client, but only one bid per DAC credential is allowed, or bidders
+			// escrow their identity for the auditor of the DAC configuration of the chaincode
+			if argc > 5 {
+				rules := auctionRules{OneBidPerCredential: cmd == "uniqueclient", IdentityEscrow: cmd == "escrowclient"}
+				launchClient(os.Args[2], os.Args[3], os.Args[4], rules, os.Args[5:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "depositclient" {
+			// same as client, but every bid requires a deposit that has to be paid back within the payment window
+			if argc > 7 {
+				deposit, err1 := strconv.Atoi(os.Args[5])
+				paymentWindow, err2 := strconv.Atoi(os.Args[6])
+				if err1 == nil && err2 == nil && deposit > 0 && paymentWindow > 0 {
+					rules := auctionRules{Deposit: deposit, PaymentWindow: paymentWindow}
+					launchClient(os.Args[2], os.Args[3], os.Args[4], rules, os.Args[7:])
+				} else {
+					fmt.Println("Invalid deposit or payment window")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "registerkey" {
+			if argc > 4 {
+				days, err := strconv.Atoi(os.Args[3])
+				if err == nil && days > 0 {
+					registerVerifyingKey(os.Args[2], days, os.Args[4:])
+				} else {
+					fmt.Println("Invalid validity period")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "setdacconfig" {
+			// sets the DAC configuration the chaincode verifies the bidders with
+			if argc > 4 {
+				setDacConfig(os.Args[2], os.Args[3], os.Args[4:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "daemon" {
+			// runs the sealed-bid auctions scheduled with the schedule command
+			if argc > 3 {
+				runDaemon(os.Args[2], os.Args[3:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "schedule" {
+			if argc > 5 {
+				bidding, err1 := strconv.ParseInt(os.Args[4], 10, 64)
+				reveal, err2 := strconv.ParseInt(os.Args[5], 10, 64)
+				if err1 == nil && err2 == nil && bidding > 0 && reveal > 0 {
+					scheduleAuction(os.Args[2], os.Args[3], bidding, reveal)
+				} else {
+					fmt.Println("Invalid phase durations")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "migrate" {
+			// same as schedule, for an auction of the private data auction chaincode that is moved to this one
+			if argc > 5 {
+				bidding, err1 := strconv.ParseInt(os.Args[4], 10, 64)
+				reveal, err2 := strconv.ParseInt(os.Args[5], 10, 64)
+				if err1 == nil && err2 == nil && bidding > 0 && reveal > 0 {
+					migrateAuction(os.Args[2], os.Args[3], bidding, reveal)
+				} else {
+					fmt.Println("Invalid phase durations")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "auctions" {
+			listAuctions()
+		} else if cmd == "audit" {
+			// checks an auction again from the blocks of the peers
+			if argc > 4 {
+				auditLedger(os.Args[2], os.Args[3], os.Args[4:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "auditsnapshot" {
+			// same as audit, from block files copied from a peer or fetched with peer channel fetch
+			if argc == 4 {
+				auditSnapshot(os.Args[2], os.Args[3])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "purge" {
+			// purges the bids of the organization from the private data auction chaincode once they expire
+			if argc > 5 {
+				runPurger(os.Args[2], os.Args[3], os.Args[4], os.Args[5:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "retention" {
+			if argc > 6 {
+				seconds, err := strconv.ParseInt(os.Args[5], 10, 64)
+				if err == nil && seconds >= 0 {
+					setRetentionPeriod(os.Args[2], os.Args[3], os.Args[4], seconds, os.Args[6:])
+				} else {
+					fmt.Println("Invalid retention period")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "mint" {
+			if argc > 4 {
+				amount, err := strconv.Atoi(os.Args[3])
+				if err == nil && amount > 0 {
+					mintTokens(os.Args[2], amount, os.Args[4:])
+				} else {
+					fmt.Println("Invalid amount")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "lockdeposit" {
+			if argc > 5 {
+				lockDeposit(os.Args[2], os.Args[3], os.Args[4], os.Args[5:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "pay" {
+			if argc > 5 {
+				payWinningBid(os.Args[2], os.Args[3], os.Args[4], os.Args[5:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "exportidentity" {
+			if argc == 4 {
+				exportIdentity(os.Args[2], os.Args[3])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "sign" {
+			if argc == 5 {
+				signFile(os.Args[2], os.Args[3], os.Args[4])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "dutch" {
+			if argc > 8 {
+				startPrice, err1 := strconv.Atoi(os.Args[5])
+				decrement, err2 := strconv.Atoi(os.Args[6])
+				reservePrice, err3 := strconv.Atoi(os.Args[7])
+				if err1 == nil && err2 == nil && err3 == nil && reservePrice > 0 && reservePrice <= startPrice && decrement >= 0 {
+					launchDutchAuction(os.Args[2], os.Args[3], os.Args[4], startPrice, decrement, reservePrice, os.Args[8:])
+				} else {
+					fmt.Println("Invalid price schedule")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "english" {
+			if argc > 8 {
+				startPrice, err1 := strconv.Atoi(os.Args[5])
+				minIncrement, err2 := strconv.Atoi(os.Args[6])
+				duration, err3 := strconv.Atoi(os.Args[7])
+				if err1 == nil && err2 == nil && err3 == nil && startPrice > 0 && minIncrement > 0 && duration > 0 {
+					launchEnglishAuction(os.Args[2], os.Args[3], os.Args[4], startPrice, minIncrement,
+						time.Duration(duration)*time.Second, os.Args[8:])
+				} else {
+					fmt.Println("Invalid auction parameters")
+				}
 			} else {
 				fmt.Println("Wrong number of arguments")
 			}
@@ -114,14 +256,15 @@ func main() {
 	}
 }
 
-func launchClient(username string, auctionID, itemName string, endpoints []string) {
-	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"))
+// launchClient runs a sealed-bid auction with the given rules
+func launchClient(username string, auctionID, itemName string, rules auctionRules, endpoints []string) {
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
 	if err != nil {
 		panic(err)
 	}
 
 	dacClientChannelContext := sdk.ChannelContext(channelName, fabsdk.WithUser(username), fabsdk.WithOrg("org1"))
-	client, err := channel.New(dacClientChannelContext)
+	client, err := channel.New(dacClientChannelContext, channelOptions()...)
 	if err != nil {
 		panic(err)
 	}
@@ -132,10 +275,16 @@ func launchClient(username string, auctionID, itemName string, endpoints []strin
 		panic(err)
 	}
 	pkBase64 := base64.StdEncoding.EncodeToString(pk[:])
-	// start auction
-	client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "CreateAuction", Args: [][]byte{[]byte(auctionID),
-		[]byte(itemName), []byte(pkBase64)}},
+	// start auction, the chaincode verifies the proof of the winner against the registered key of the
+	// working directory, the prover service proves it with the proving key of the same ID
+	readKeyManifest()
+	_, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "CreateAuction", Args: [][]byte{[]byte(auctionID),
+		[]byte(itemName), []byte(pkBase64), []byte(verifyingKeyID())}},
 		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	applyAuctionRules(client, auctionID, rules, endpoints)
 	// pause to wait for the second phase of the auction
 	time.Sleep(time.Duration(30) * time.Second)
 
@@ -150,118 +299,100 @@ func launchClient(username string, auctionID, itemName string, endpoints []strin
 		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
 
 	// query the auction
-	response, _ := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryAuction", Args: [][]byte{[]byte(auctionID)}},
+	auction, err := queryAuction(client, auctionID, endpoints)
+	if err != nil {
+		panic(err)
+	}
+	bestID, proofBytes, invalidSet, err := decideAuction(auctionID, auction, pk, sk)
+	if err != nil {
+		panic(err)
+	}
+
+	// declare winner
+	client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "DeclareWinner", Args: [][]byte{[]byte(auctionID),
+		[]byte(bestID), []byte(base64.StdEncoding.EncodeToString(proofBytes)), invalidSet}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+}
+
+// queryAuction reads an auction from the ledger
+func queryAuction(client *channel.Client, auctionID string, endpoints []string) (*Auction, error) {
+	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryAuction", Args: [][]byte{[]byte(auctionID)}},
 		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		return nil, err
+	}
 	var auction Auction
-	auctionBytes := response.Payload
-	fmt.Print(auctionBytes)
-	err = json.Unmarshal(auctionBytes, &auction)
+	err = json.Unmarshal(response.Payload, &auction)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	if auction.Version > auctionVersion {
+		return nil, fmt.Errorf("auction %v has schema version %v, the client reads up to version %v", auctionID,
+			auction.Version, auctionVersion)
+	}
+	return &auction, nil
+}
 
+// decideAuction decrypts the revealed bids of an ended auction and returns the winning bid, the proof of the
+// winner and the invalid set to declare. The winning bid is empty if no bid is valid
+func decideAuction(auctionID string, auction *Auction, pk, sk *[32]byte) (string, []byte, []byte, error) {
 	// get the encrypted bids
-	var witness AuctionCircuit
 	encryptedBids := auction.EncryptedBids
 	commitments := auction.Commitments
-	invalidBids := make(map[string] Bid)
-	bestPrice := -1
-	bestID := ""
-	n := 0
-	var bestCom twistededwards2.PointAffine
-	var bestR *big.Int
-	// check the number of bids is not greater than the max number of bids in a proof
+	invalidBids := make(map[string] *Bid)
+	var bids []circuit.Bid
+	// check the number of commitments is not greater than the max number of bids in a proof
 	// if it is, the auction requires manual intervention
-	if len(encryptedBids) > MaxBids {
-		panic("Too many bids in the auction")
+	if len(auction.CommitmentOrder) > MaxBids {
+		return "", nil, nil, fmt.Errorf("too many bids in the auction")
 	}
-	for name, encryptedBid := range encryptedBids {
-		comBytes, exists := commitments[name]
-		// only take the bid into account if there was a commitment for it
-		// this should always be true, otherwise there is a flaw in the smart contract
-		if exists {
-			price, r, err := crypto.Decrypt(encryptedBid.Data, pk, sk)
-			com := twistededwards2.PointAffine{}
-			err2 := com.Unmarshal(comBytes)
+	// every commitment is placed in the witness in the order of the ledger, the ones that are not revealed
+	// or whose opening is invalid are excluded. Among equal highest bids the earliest one wins
+	for _, name := range auction.CommitmentOrder {
+		com, err := wire.UnmarshalCommitment(commitments[name])
+		if err != nil {
+			return "", nil, nil, err
+		}
+		bid := circuit.Bid{Commitment: *com, Excluded: true}
+		if encryptedBid, revealed := encryptedBids[name]; revealed {
+			price, r, err := commitment.Decrypt(encryptedBid.Data, pk, sk)
 			// check the decryption is valid
-			if err == nil && err2 == nil && crypto.CheckCommit(price, r, &com) {
-				witness.Values[n].Assign(price)
-				witness.Rs[n].Assign(r)
-				witness.ComsX[n].Assign(com.X)
-				witness.ComsY[n].Assign(com.Y)
-
-				if price > bestPrice {
-					bestPrice = price
-					bestID = name
-					bestCom = com
-					bestR = r
-				}
-				n++
+			if err == nil && commitment.CheckCommit(price, r, com) {
+				bid.Value, bid.R, bid.Excluded = price, r, false
 			} else {
-				fmt.Errorf("decryption of bid %v invalid", name)
-				invalidBids[name] = Bid{
+				fmt.Printf("decryption of bid %v invalid\n", name)
+				decryptionFailures.Inc()
+				key, err := commitment.SharedKey(encryptedBid.Data, sk)
+				if err != nil {
+					return "", nil, nil, err
+				}
+				invalidBids[name] = &Bid{
 					Type:  "bid",
 					Price: price,
 					R:     *r,
+					Key:   key[:],
 				}
 			}
 		}
+		bids = append(bids, bid)
 	}
 
-	// Compute proof
+	// Compute proof with the prover service, unused slots of the witness hold the identity so that the
+	// chaincode can rebuild the public inputs from the commitments on the ledger
 	var proofBytes []byte
-	if n > 0 {
-		witness.WinningValue.Assign(bestPrice)
-		witness.WinningR.Assign(bestR)
-		witness.WinningComX.Assign(bestCom.X)
-		witness.WinningComY.Assign(bestCom.Y)
-
-		// fill non used bids with a value of 0
-		for i := n; i < MaxBids; i++ {
-			value := 0
-			com, r, _ := crypto.Commit(value)
-			witness.Values[i].Assign(value)
-			witness.Rs[i].Assign(r)
-			witness.ComsX[i].Assign(com.X)
-			witness.ComsY[i].Assign(com.Y)
-		}
-
-		// load circuit
-		circuitFile, err := os.Open("circuit")
-		defer circuitFile.Close()
-		r1cs := groth16.NewCS(ecc.BLS12_381)
-		_, err = r1cs.ReadFrom(circuitFile)
-		if err != nil {
-			panic(err)
-		}
-
-		// load proving key
-		prkFile, err := os.Open("pk")
-		defer prkFile.Close()
-		prk := groth16.NewProvingKey(ecc.BLS12_381)
-		_, err = prk.ReadFrom(prkFile)
-		if err != nil {
-			panic(err)
-		}
-
-		proof, err := groth16.Prove(r1cs, prk, &witness)
+	bestID := ""
+	if winner := circuit.Winner(bids); winner >= 0 {
+		bestID = auction.CommitmentOrder[winner]
+		var err error
+		proofBytes, err = proveWithService(auctionID, bids, winner)
 		if err != nil {
-			panic(err)
+			return "", nil, nil, err
 		}
-
-		var proofBuf bytes.Buffer
-		proof.WriteTo(&proofBuf)
-		proofBytes = proofBuf.Bytes()
 	}
 	// put invalid bids into a JSON
 	invalidSet, err := json.Marshal(invalidBids)
 	if err != nil {
-		panic(err)
+		return "", nil, nil, err
 	}
-
-	// declare winner
-	client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "DeclareWinner", Args: [][]byte{[]byte(auctionID),
-		[]byte(bestID), []byte(base64.StdEncoding.EncodeToString(proofBytes)), invalidSet}},
-		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
-
+	return bestID, proofBytes, invalidSet, nil
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log"
+	"net"
+	"net/http"
+)
+
+// metricsEnabled is set by the --metrics-addr option, the SDK then records the metrics of the channel clients
+var metricsEnabled bool
+
+// provingDuration is the time the prover service took to prove the winner of an auction, by proof system. It
+// does not include the time the job was queued or the keys were loaded
+var provingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "auctioneer",
+	Name:      "proving_duration_seconds",
+	Help:      "The time to prove the winner of an auction.",
+	Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+}, []string{"proof_system"})
+
+// decryptionFailures counts the revealed bids whose opening could not be decrypted or does not open the
+// commitment, they are added to the invalid set
+var decryptionFailures = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "auctioneer",
+	Name:      "decryption_failures_total",
+	Help:      "The number of revealed bids with an invalid opening.",
+})
+
+func init() {
+	prometheus.MustRegister(provingDuration, decryptionFailures)
+}
+
+// serveMetrics serves the metrics over HTTP at /metrics if the arguments before the command contain
+// --metrics-addr, and returns the arguments without the option. The address is the one to listen on, such as :9444
+func serveMetrics(args []string) []string {
+	addr, args := cutOption(args, "--metrics-addr")
+	if addr == "" {
+		return args
+	}
+
+	// listen before the command runs so that a wrong address fails at once
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		log.Fatal(http.Serve(listener, mux))
+	}()
+	metricsEnabled = true
+	return args
+}
+
+// sdkOptions returns the options of the SDK, the metrics of the channel clients are recorded if they are served
+func sdkOptions() []fabsdk.Option {
+	var options []fabsdk.Option
+	if metricsEnabled {
+		options = append(options, fabsdk.WithPrometheusMetrics())
+	}
+	return options
+}
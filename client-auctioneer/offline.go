@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/offlinesign"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"io/ioutil"
+	"os"
+)
+
+// creatorConfig is the public identity transactions are created for, the bidder client reads it to propose
+// transactions that are signed offline
+type creatorConfig struct {
+	Creator []byte `json:"creator"`
+}
+
+// exportIdentity writes the serialized X.509 identity of the user to the creator file, so that a connected
+// bidder client creates proposals for this identity while the key stays on the signer
+func exportIdentity(username, org string) {
+	sdk, err := fabsdk.New(config.FromFile("connection-" + org + ".yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer sdk.Close()
+	identity, err := sdk.Context(fabsdk.WithUser(username), fabsdk.WithOrg(org))()
+	if err != nil {
+		panic(err)
+	}
+	creator, err := identity.Serialize()
+	if err != nil {
+		panic(err)
+	}
+	creatorBytes, err := json.Marshal(creatorConfig{Creator: creator})
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(username+".creator.json", creatorBytes, 0644); err != nil {
+		panic(err)
+	}
+}
+
+// signFile signs a proposal or transaction payload with the X.509 key of the user and writes the signature next
+// to the file. The object is decoded and shown before signing and must be created for the identity of the user.
+// No connection is needed, the key is read from the MSP of the user
+func signFile(username, org, fileName string) {
+	sdk, err := fabsdk.New(config.FromFile("connection-" + org + ".yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer sdk.Close()
+	identity, err := sdk.Context(fabsdk.WithUser(username), fabsdk.WithOrg(org))()
+	if err != nil {
+		panic(err)
+	}
+	object, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		panic(err)
+	}
+	decoded, _, err := offlinesign.Decode(fileName, object)
+	if err != nil {
+		panic(err)
+	}
+	creator, err := identity.Serialize()
+	if err != nil {
+		panic(err)
+	}
+	if !bytes.Equal(decoded.Creator, creator) {
+		panic(fmt.Errorf("%v is not created for the identity of %v", fileName, username))
+	}
+	if err := offlinesign.Confirm(decoded, os.Stdin, os.Stdout); err != nil {
+		panic(err)
+	}
+	signature, err := identity.SigningManager().Sign(object, identity.PrivateKey())
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(fileName+offlinesign.SignatureSuffix, signature, 0644); err != nil {
+		panic(err)
+	}
+}
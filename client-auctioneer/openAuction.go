@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"strconv"
+	"time"
+)
+
+// AuctionEvent is the payload of the events emitted by open-outcry auctions
+type AuctionEvent struct {
+	AuctionID string `json:"auctionID"`
+	BidID     string `json:"bidID"`
+	Price     int    `json:"price"`
+}
+
+// launchDutchAuction creates a Dutch auction and waits until a bidder accepts the price. If the price
+// reaches the reserve price and nobody accepted it after a minute, the auction is ended without winner
+func launchDutchAuction(username, auctionID, itemName string, startPrice, decrement, reservePrice int, endpoints []string) {
+	args := [][]byte{[]byte(auctionID), []byte(itemName), []byte(strconv.Itoa(startPrice)),
+		[]byte(strconv.Itoa(decrement)), []byte(strconv.Itoa(reservePrice))}
+	duration := time.Minute
+	if decrement > 0 {
+		duration += time.Duration((startPrice-reservePrice)/decrement) * time.Second
+	}
+	runOpenAuction(username, auctionID, "CreateDutchAuction", args, "PriceAccepted", duration, endpoints)
+}
+
+// launchEnglishAuction creates an English auction, prints the bids as they are placed and ends the
+// auction after the given duration
+func launchEnglishAuction(username, auctionID, itemName string, startPrice, minIncrement int, duration time.Duration, endpoints []string) {
+	args := [][]byte{[]byte(auctionID), []byte(itemName), []byte(strconv.Itoa(startPrice)),
+		[]byte(strconv.Itoa(minIncrement))}
+	runOpenAuction(username, auctionID, "CreateEnglishAuction", args, "BidPlaced", duration, endpoints)
+}
+
+// runOpenAuction creates an open-outcry auction and listens to its events until the deadline. The auction is
+// ended by the seller at the deadline unless a PriceAccepted event already ended it
+func runOpenAuction(username, auctionID, createFcn string, args [][]byte, eventName string, duration time.Duration, endpoints []string) {
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer sdk.Close()
+
+	channelContext := sdk.ChannelContext(channelName, fabsdk.WithUser(username), fabsdk.WithOrg("org1"))
+	client, err := channel.New(channelContext, channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	eventClient, err := event.New(channelContext, event.WithBlockEvents())
+	if err != nil {
+		panic(err)
+	}
+	registration, events, err := eventClient.RegisterChaincodeEvent(chaincodeID, eventName)
+	if err != nil {
+		panic(err)
+	}
+	defer eventClient.Unregister(registration)
+
+	_, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: createFcn, Args: args},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Auction %v created\n", auctionID)
+
+	deadline := time.After(duration)
+	for {
+		select {
+		case ccEvent := <-events:
+			var auctionEvent AuctionEvent
+			if err := json.Unmarshal(ccEvent.Payload, &auctionEvent); err != nil || auctionEvent.AuctionID != auctionID {
+				continue
+			}
+			fmt.Printf("%v: bid %v at price %v\n", ccEvent.EventName, auctionEvent.BidID, auctionEvent.Price)
+			// a Dutch auction ends with the first accepted price
+			if ccEvent.EventName == "PriceAccepted" {
+				return
+			}
+		case <-deadline:
+			_, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "EndAuction", Args: [][]byte{[]byte(auctionID)}},
+				channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+			if err != nil {
+				fmt.Printf("failed to end auction: %v\n", err)
+			}
+			return
+		}
+	}
+}
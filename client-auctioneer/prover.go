@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/ckiere/test-network/prover-service/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+)
+
+// proof systems of the keys generated by zk-generator
+const (
+	backendGroth16 = wire.ProofSystemGroth16
+)
+
+// proofTimeout bounds the wait for the proof of the winner, including the jobs queued before it
+const proofTimeout = 30 * time.Minute
+
+// keyManifest is the part of the manifest of zk-generator read by the auctioneer
+type keyManifest struct {
+	MaxBids     int    `json:"maxBids"`
+	Backend     string `json:"backend"`
+	CircuitHash string `json:"circuitHash"`
+}
+
+// readKeyManifest reads the manifest of the keys in the working directory. Keys generated before the
+// manifest was introduced are Groth16 keys
+func readKeyManifest() keyManifest {
+	manifest := keyManifest{MaxBids: MaxBids, Backend: backendGroth16}
+	manifestBytes, err := ioutil.ReadFile("manifest.json")
+	if os.IsNotExist(err) {
+		return manifest
+	}
+	if err != nil {
+		panic(err)
+	}
+	err = json.Unmarshal(manifestBytes, &manifest)
+	if err != nil {
+		panic(err)
+	}
+	if manifest.MaxBids != MaxBids {
+		panic(fmt.Sprintf("keys are for %v bids, the auctioneer for %v", manifest.MaxBids, MaxBids))
+	}
+	return manifest
+}
+
+// verifyingKeyID returns the ID of the key in the working directory in the registry of the chaincode, the
+// hex encoded SHA-256 of vk
+func verifyingKeyID() string {
+	keyID, err := readVerifyingKeyID()
+	if err != nil {
+		panic(err)
+	}
+	return keyID
+}
+
+// readVerifyingKeyID is verifyingKeyID returning an error for the daemon, the hash is the one listed in the
+// manifest of zk-generator
+func readVerifyingKeyID() (string, error) {
+	vkBytes, err := ioutil.ReadFile("vk")
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(vkBytes)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// registerVerifyingKey registers the key in the working directory in the registry of the chaincode, valid for
+// the given number of days. Only users of the key admin organization of the chaincode can register keys
+func registerVerifyingKey(username string, days int, endpoints []string) {
+	manifest := readKeyManifest()
+	vkBytes, err := ioutil.ReadFile("vk")
+	if err != nil {
+		panic(err)
+	}
+	validFrom := time.Now().Unix()
+	validUntil := validFrom + int64(days)*24*3600
+	keyID := verifyingKeyID()
+	// the hash of the circuit in the manifest does not depend on its compilation, ceremony-verify checks it
+	if manifest.CircuitHash == "" {
+		panic("manifest has no circuit hash, the keys were generated by an older zk-generator")
+	}
+
+	client := newOrgChannelClient(username)
+	_, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "RegisterVerifyingKey", Args: [][]byte{[]byte(keyID),
+		[]byte(manifest.Backend), []byte(base64.StdEncoding.EncodeToString(vkBytes)), []byte(manifest.CircuitHash),
+		[]byte(strconv.FormatInt(validFrom, 10)), []byte(strconv.FormatInt(validUntil, 10))}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Registered verifying key %v\n", keyID)
+}
+
+// proveWithService hands the witness of the winner of an auction off to the prover service and waits for the
+// proof, encoded with its proof system for the chaincode. The bids are in the commitment order of the auction
+func proveWithService(auctionID string, bids []circuit.Bid, winner int) ([]byte, error) {
+	req := &api.JobRequest{AuctionID: auctionID, KeyID: verifyingKeyID(), Winner: winner}
+	for _, bid := range bids {
+		jobBid := api.Bid{Commitment: wire.MarshalCommitment(&bid.Commitment), Excluded: bid.Excluded}
+		if !bid.Excluded {
+			jobBid.Value = bid.Value
+			jobBid.R = bid.R.Bytes()
+		}
+		req.Bids = append(req.Bids, jobBid)
+	}
+
+	client, err := api.Dial(api.DefaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), proofTimeout)
+	defer cancel()
+	job, err := client.Submit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("proving job %v submitted for auction %v\n", job.ID, auctionID)
+	job, err = client.Wait(ctx, job.ID, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != api.JobDone {
+		return nil, fmt.Errorf("proving job %v failed: %v", job.ID, job.Error)
+	}
+	provingDuration.WithLabelValues(readKeyManifest().Backend).Observe(float64(job.ProvingMs) / 1000)
+	fmt.Printf("proof computed after %v attempts, queued %v ms, keys loaded in %v ms, proved in %v ms\n", job.Attempts,
+		job.QueuedMs, job.LoadingMs, job.ProvingMs)
+	return job.Proof, nil
+}
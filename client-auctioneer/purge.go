@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/event"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
+	"log"
+	"strconv"
+	"time"
+)
+
+// AuctionEndedEvent is the event of the private data auction chaincode when an auction ends
+type AuctionEndedEvent struct {
+	AuctionID string   `json:"auctionID"`
+	Orgs      []string `json:"organizations"`
+	Ended     int64    `json:"ended"`
+}
+
+// purger deletes the bids of an organization from its implicit collection once their retention period is over
+type purger struct {
+	client      *channel.Client
+	chaincodeID string
+	mspID       string
+	endpoints   []string
+	// purge time of the ended auctions of the organization that are not purged yet, by auction ID
+	due map[string]int64
+}
+
+// runPurger purges the bids of the organization of the user for every auction of the private data auction
+// chaincode ccName. The events are replayed from the first block, so the auctions that ended while the purger
+// was not running are purged too. Every organization runs its own purger, the endpoints are its peers
+func runPurger(username, org, ccName string, endpoints []string) {
+	sdk, err := fabsdk.New(config.FromFile("connection-" + org + ".yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer sdk.Close()
+	identity, err := sdk.Context(fabsdk.WithUser(username), fabsdk.WithOrg(org))()
+	if err != nil {
+		panic(err)
+	}
+	channelContext := sdk.ChannelContext(channelName, fabsdk.WithUser(username), fabsdk.WithOrg(org))
+	client, err := channel.New(channelContext, channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	eventClient, err := event.New(channelContext, event.WithBlockEvents(), event.WithSeekType(seek.FromBlock), event.WithBlockNum(0))
+	if err != nil {
+		panic(err)
+	}
+	registration, events, err := eventClient.RegisterChaincodeEvent(ccName, "^AuctionEnded$")
+	if err != nil {
+		panic(err)
+	}
+	defer eventClient.Unregister(registration)
+	// the auctions migrated to the commitment auction chaincode are retired by the same transaction
+	migratedRegistration, migrated, err := eventClient.RegisterChaincodeEvent(chaincodeID, "^AuctionMigrated$")
+	if err != nil {
+		panic(err)
+	}
+	defer eventClient.Unregister(migratedRegistration)
+
+	p := &purger{client: client, chaincodeID: ccName, mspID: identity.Identifier().MSPID, endpoints: endpoints,
+		due: make(map[string]int64)}
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case ccEvent := <-events:
+			var endedEvent AuctionEndedEvent
+			if err := json.Unmarshal(ccEvent.Payload, &endedEvent); err == nil {
+				p.schedule(&endedEvent)
+			}
+		case ccEvent := <-migrated:
+			var auctionEvent AuctionEvent
+			if err := json.Unmarshal(ccEvent.Payload, &auctionEvent); err == nil {
+				p.scheduleMigrated(auctionEvent.AuctionID)
+			}
+		case <-ticker.C:
+			p.purgeDue()
+		}
+	}
+}
+
+// schedule records when the bids of an ended or migrated auction can be purged, if the organization took part in it
+func (p *purger) schedule(endedEvent *AuctionEndedEvent) {
+	participated := false
+	for _, org := range endedEvent.Orgs {
+		participated = participated || org == p.mspID
+	}
+	if !participated {
+		return
+	}
+	retention, err := p.retentionPeriod()
+	if err != nil {
+		// purge at the next check, the chaincode refuses if the period is not over
+		log.Printf("auction %v: reading the retention period: %v", endedEvent.AuctionID, err)
+	}
+	p.due[endedEvent.AuctionID] = endedEvent.Ended + retention
+	log.Printf("auction %v: bids are purged at %v", endedEvent.AuctionID, time.Unix(endedEvent.Ended+retention, 0))
+}
+
+// scheduleMigrated schedules the purge of an auction migrated from the chaincode of the purger, the retention
+// period started when the auction was retired
+func (p *purger) scheduleMigrated(auctionID string) {
+	response, err := p.client.Query(channel.Request{ChaincodeID: p.chaincodeID, Fcn: "QueryAuction", Args: [][]byte{[]byte(auctionID)}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(p.endpoints...))
+	if err != nil {
+		// migrated from another chaincode
+		return
+	}
+	var legacy struct {
+		Orgs   []string `json:"organizations"`
+		Status string   `json:"status"`
+		Ended  int64    `json:"ended"`
+	}
+	if err := json.Unmarshal(response.Payload, &legacy); err != nil || legacy.Status != "migrated" {
+		return
+	}
+	p.schedule(&AuctionEndedEvent{AuctionID: auctionID, Orgs: legacy.Orgs, Ended: legacy.Ended})
+}
+
+// purgeDue purges the auctions with a retention period that is over, a failed purge is tried again at the next check
+func (p *purger) purgeDue() {
+	now := time.Now().Unix()
+	for auctionID, purgeAt := range p.due {
+		if now < purgeAt {
+			continue
+		}
+		response, err := p.client.Execute(channel.Request{ChaincodeID: p.chaincodeID, Fcn: "PurgeBids", Args: [][]byte{[]byte(auctionID)}},
+			channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(p.endpoints...))
+		if err != nil {
+			log.Printf("auction %v: purging the bids: %v", auctionID, err)
+			continue
+		}
+		log.Printf("auction %v: %s bids purged", auctionID, response.Payload)
+		delete(p.due, auctionID)
+	}
+}
+
+// retentionPeriod queries the retention period of the organization from its peers
+func (p *purger) retentionPeriod() (int64, error) {
+	response, err := p.client.Query(channel.Request{ChaincodeID: p.chaincodeID, Fcn: "QueryRetentionPeriod"},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(p.endpoints...))
+	if err != nil {
+		return 0, err
+	}
+	retention, err := strconv.ParseInt(string(response.Payload), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention period %q", response.Payload)
+	}
+	return retention, nil
+}
+
+// setRetentionPeriod sets the retention period of the bids of the organization of the user
+func setRetentionPeriod(username, org, ccName string, seconds int64, endpoints []string) {
+	sdk, err := fabsdk.New(config.FromFile("connection-" + org + ".yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	defer sdk.Close()
+	client, err := channel.New(sdk.ChannelContext(channelName, fabsdk.WithUser(username), fabsdk.WithOrg(org)), channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	_, err = client.Execute(channel.Request{ChaincodeID: ccName, Fcn: "SetRetentionPeriod",
+		Args: [][]byte{[]byte(strconv.FormatInt(seconds, 10))}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"io/ioutil"
+	"strconv"
+)
+
+// auctionRules are the optional rules set by the seller before the first bid
+type auctionRules struct {
+	OneBidPerCredential bool
+	IdentityEscrow      bool
+	// Deposit is the deposit required with every bid, in tokens, 0 if no deposit is required
+	Deposit             int
+	PaymentWindow       int
+}
+
+// dacParameters are the public DAC parameters needed by the chaincode, the byte slices are encoded
+// in base64 in the JSON configuration
+type dacParameters struct {
+	Hbytes         []byte `json:"h"`
+	AuditorPkBytes []byte `json:"auditorpk"`
+}
+
+// applyAuctionRules sets the rules of a newly created auction
+func applyAuctionRules(client *channel.Client, auctionID string, rules auctionRules, endpoints []string) {
+	for _, request := range ruleRequests(auctionID, rules) {
+		_, err := client.Execute(request, channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ruleRequests returns the transactions of the seller that set the rules of a newly created auction
+func ruleRequests(auctionID string, rules auctionRules) []channel.Request {
+	var requests []channel.Request
+	if rules.Deposit > 0 {
+		requests = append(requests, channel.Request{ChaincodeID: chaincodeID, Fcn: "RequireDeposit", Args: [][]byte{[]byte(auctionID),
+			[]byte(strconv.Itoa(rules.Deposit)), []byte(strconv.Itoa(rules.PaymentWindow))}})
+	}
+	if rules.OneBidPerCredential {
+		// the chaincode verifies the tags with the DAC configuration set by the admin
+		requests = append(requests, channel.Request{ChaincodeID: chaincodeID, Fcn: "RequireOneBidPerCredential", Args: [][]byte{[]byte(auctionID)}})
+	}
+	if rules.IdentityEscrow {
+		// the bidders escrow their identity for the auditor of the DAC configuration set by the admin
+		requests = append(requests, channel.Request{ChaincodeID: chaincodeID, Fcn: "RequireIdentityEscrow", Args: [][]byte{[]byte(auctionID)}})
+	}
+	return requests
+}
+
+// setDacConfig sets the DAC configuration of the chaincode from a DAC configuration file, with its auditor if
+// it has one. The client must be an admin of the chaincode
+func setDacConfig(username, dacConfigFile string, endpoints []string) {
+	dacConfig := readDacParameters(dacConfigFile)
+	client := newOrgChannelClient(username)
+	_, err := client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "SetDacConfig", Args: [][]byte{
+		[]byte(base64.StdEncoding.EncodeToString(dacConfig.Hbytes)), []byte(base64.StdEncoding.EncodeToString(dacConfig.AuditorPkBytes))}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("DAC configuration set")
+}
+
+// readDacParameters reads the public DAC parameters of a DAC configuration file
+func readDacParameters(dacConfigFile string) dacParameters {
+	var dacConfig dacParameters
+	configBytes, err := ioutil.ReadFile(dacConfigFile)
+	if err != nil {
+		panic(err)
+	}
+	err = json.Unmarshal(configBytes, &dacConfig)
+	if err != nil {
+		panic(err)
+	}
+	return dacConfig
+}
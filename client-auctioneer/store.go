@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/sealedstore"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+// Phases of an auction in the store of the daemon, the ledger is the reference and the phase follows the
+// status of the auction on the ledger
+const (
+	// the key pair is stored but the auction may not be on the ledger yet
+	phaseNew      = "new"
+	phaseOpen     = "open"
+	phaseClosed   = "closed"
+	phaseEnded    = "ended"
+	phaseDeclared = "declared"
+	// the auction requires manual intervention, see the error of the record
+	phaseFailed   = "failed"
+)
+
+var auctionsBucket = []byte("auctions")
+
+// auctionRecord is the state the daemon keeps for an auction. SealedSk is the secret key of the auction sealed
+// with the key of the store. The deadlines are in seconds since the epoch, EndAt is set when the auction is closed
+type auctionRecord struct {
+	AuctionID     string       `json:"auctionID"`
+	Item          string       `json:"item"`
+	Rules         auctionRules `json:"rules"`
+	KeyID         string       `json:"keyID"`
+	Pk            []byte       `json:"pk"`
+	SealedSk      []byte       `json:"sealedSk,omitempty"`
+	Phase         string       `json:"phase"`
+	CloseAt       int64        `json:"closeAt"`
+	RevealSeconds int64        `json:"revealSeconds"`
+	EndAt         int64        `json:"endAt,omitempty"`
+	WinningBid    string       `json:"winningBid,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	UpdatedAt     int64        `json:"updatedAt"`
+	MigrateFrom   string       `json:"migrateFrom,omitempty"`
+}
+
+// done returns true if the daemon has nothing left to do for the auction
+func (r *auctionRecord) done() bool {
+	return r.Phase == phaseDeclared || r.Phase == phaseFailed
+}
+
+// auctionStore is the embedded database of the auctioneer daemon. The secret keys of the auctions are sealed
+// with a key derived from the passphrase of the daemon, the database file alone does not open the bids
+type auctionStore struct {
+	db *sealedstore.DB
+}
+
+// openAuctionStore opens or creates the database at path. The passphrase is checked against the one the
+// database was created with
+func openAuctionStore(path, passphrase string) (*auctionStore, error) {
+	db, err := sealedstore.Open(path, passphrase, []byte("auctioneer"), time.Second, auctionsBucket)
+	if err != nil {
+		return nil, err
+	}
+	return &auctionStore{db: db}, nil
+}
+
+// Close closes the database
+func (s *auctionStore) Close() error {
+	return s.db.Close()
+}
+
+// secretKey returns the secret key of an auction
+func (s *auctionStore) secretKey(record *auctionRecord) (*[32]byte, error) {
+	sk, err := s.db.Unseal([]byte(record.AuctionID), record.SealedSk)
+	if err != nil {
+		return nil, err
+	}
+	if len(sk) != 32 {
+		return nil, errors.New("invalid secret key")
+	}
+	var key [32]byte
+	copy(key[:], sk)
+	return &key, nil
+}
+
+// get returns the record of an auction, nil if the store does not have it
+func (s *auctionStore) get(auctionID string) (*auctionRecord, error) {
+	var record *auctionRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		recordBytes := tx.Bucket(auctionsBucket).Get([]byte(auctionID))
+		if recordBytes == nil {
+			return nil
+		}
+		record = new(auctionRecord)
+		return json.Unmarshal(recordBytes, record)
+	})
+	return record, err
+}
+
+// put writes the record of an auction
+func (s *auctionStore) put(record *auctionRecord) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(auctionsBucket).Put([]byte(record.AuctionID), recordBytes)
+	})
+}
+
+// create writes the record of a new auction, unless the store already has an auction with its ID
+func (s *auctionStore) create(record *auctionRecord) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(auctionsBucket)
+		if bucket.Get([]byte(record.AuctionID)) != nil {
+			return fmt.Errorf("auction %v already exists", record.AuctionID)
+		}
+		return bucket.Put([]byte(record.AuctionID), recordBytes)
+	})
+}
+
+// list returns the records of every auction, ordered by ID
+func (s *auctionStore) list() ([]auctionRecord, error) {
+	var records []auctionRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(auctionsBucket).ForEach(func(_, recordBytes []byte) error {
+			var record auctionRecord
+			if err := json.Unmarshal(recordBytes, &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+			return nil
+		})
+	})
+	return records, err
+}
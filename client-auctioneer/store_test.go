@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T, path string) *auctionStore {
+	t.Helper()
+	store, err := openAuctionStore(path, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), storeFile)
+	store := openTestStore(t, path)
+	sk := bytes.Repeat([]byte{7}, 32)
+	sealedSk, err := store.db.Seal([]byte("auction2"), sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &auctionRecord{AuctionID: "auction2", Pk: []byte("pk"), SealedSk: sealedSk, Phase: phaseNew}
+	if err := store.create(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.create(&auctionRecord{AuctionID: "auction2", Phase: phaseNew}); err == nil {
+		t.Error("an auction was created twice")
+	}
+	if err := store.create(&auctionRecord{AuctionID: "auction1", Phase: phaseNew}); err != nil {
+		t.Fatal(err)
+	}
+	record.Phase = phaseClosed
+	record.EndAt = 100
+	if err := store.put(record); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// the daemon stopped, its auctions and their keys are read again
+	if _, err := openAuctionStore(path, "wrong"); err == nil {
+		t.Fatal("the store opened with a wrong passphrase")
+	}
+	store = openTestStore(t, path)
+	defer store.Close()
+	stored, err := store.get("auction2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored == nil || stored.Phase != phaseClosed || stored.EndAt != 100 {
+		t.Fatalf("stored record %+v", stored)
+	}
+	storedSk, err := store.secretKey(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(storedSk[:], sk) {
+		t.Error("the secret key changed")
+	}
+	// the key of an auction is bound to its record, it does not open the bids of another auction
+	if _, err := store.secretKey(&auctionRecord{AuctionID: "auction1", SealedSk: sealedSk}); err == nil {
+		t.Error("the secret key of auction2 was read for auction1")
+	}
+	if missing, err := store.get("auction3"); err != nil || missing != nil {
+		t.Errorf("missing auction %v, %v", missing, err)
+	}
+	records, err := store.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].AuctionID != "auction1" || records[1].AuctionID != "auction2" {
+		t.Errorf("listed %+v", records)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/dacidentity"
+	"github.com/dbogatov/dac-lib/dac"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"io/ioutil"
+	"path/filepath"
+)
+
+const auditorSkFileName = "DacAuditorSk.json"
+
+// escrowTransientMap returns the identity escrow of the current nym of the user, to be sent in the transient
+// map of every bid. It is nil if the DAC configuration has no auditor
+func escrowTransientMap(user *dacidentity.User) map[string][]byte {
+	escrow, proof := user.IdentityEscrow()
+	if escrow == nil {
+		return nil
+	}
+	return map[string][]byte{"escrow": escrow, "escrowProof": proof}
+}
+
+// createAuditorFiles generates the auditor key pair, stores the secret key and adds the public key
+// to the DAC configuration. Identities created after this escrow their public key for the auditor
+func createAuditorFiles() {
+	prg := dacidentity.NewRand()
+	configBytes, _ := ioutil.ReadFile(configFileName)
+	dacConfig, err := dacidentity.CreateConfigFromBytes(configBytes)
+	if err != nil {
+		panic(err)
+	}
+	// the auditor key has to be in the same group as the user keys
+	auditorSk, auditorPk := dac.GenerateKeys(prg, 2)
+	auditorSkBytes := make([]byte, FP256BN.MODBYTES)
+	auditorSk.ToBytes(auditorSkBytes)
+	dacConfig.AuditorPkBytes = dac.PointToBytes(auditorPk)
+	configBytes, _ = json.Marshal(dacConfig)
+	ioutil.WriteFile(configFileName, configBytes, 0644)
+	ioutil.WriteFile(auditorSkFileName, auditorSkBytes, 0600)
+}
+
+// deanonymize decrypts the identity escrow of a bid with the auditor secret key. It prints the public key
+// of the bidder and the name of the identity file in the current directory with the same key, if any
+func deanonymize(username, auctionID, txID string, endpoints []string) {
+	auditorSkBytes, err := ioutil.ReadFile(auditorSkFileName)
+	if err != nil {
+		panic(err)
+	}
+	auditorSk := FP256BN.FromBytes(auditorSkBytes)
+
+	client, _ := newDacChannelClient(username)
+	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryIdentityEscrow", Args: [][]byte{[]byte(auctionID), []byte(txID)}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	escrow, err := base64.StdEncoding.DecodeString(string(response.Payload))
+	if err != nil {
+		panic(err)
+	}
+	encryption := dac.AuditingEncryptionFromBytes(escrow)
+	pkBytes := dac.PointToBytes(encryption.AuditingDecrypt(auditorSk))
+	fmt.Printf("Bidder public key: %v\n", base64.StdEncoding.EncodeToString(pkBytes))
+
+	// look for a known identity with this public key
+	files, _ := filepath.Glob("*.json")
+	for _, file := range files {
+		idConfigBytes, _ := ioutil.ReadFile(file)
+		var idConfig dacidentity.CredentialsConfig
+		if json.Unmarshal(idConfigBytes, &idConfig) == nil && bytes.Equal(idConfig.PkBytes, pkBytes) {
+			fmt.Printf("Bidder identity: %v\n", file)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"time"
+)
+
+// sealedAuction contains the public fields of a sealed-bid auction the bidder needs
+type sealedAuction struct {
+	auctionRules
+	Commitments   map[string][]byte          `json:"commitments"`
+	EncryptedBids map[string]json.RawMessage `json:"encryptedBids"`
+	InvalidSet    string                     `json:"invalidSet"`
+	WinningBid    string                     `json:"winningBid"`
+	Status        string                     `json:"status"`
+	Version       int                        `json:"version"`
+}
+
+// auctionVersion is the latest schema version of the auctions the bidder reads, auctions without a version
+// have the schema of version 2
+const auctionVersion = 2
+
+// declared returns true if the seller declared the outcome of the auction, the invalid set of a declared
+// auction is never empty
+func (a *sealedAuction) declared() bool {
+	return a.WinningBid != "" || a.InvalidSet != ""
+}
+
+// placeBid commits to a bid in a sealed-bid auction. The opening values are stored before the commitment is
+// sent, the bid is revealed later with the reveal command
+func placeBid(username, auctionID string, price int, endpoints []string) {
+	client, user := newDacChannelClient(username)
+	auction := querySealedAuction(client, auctionID, endpoints)
+	if auction.Status != "open" {
+		fmt.Println("Auction is not open")
+		return
+	}
+
+	// commit to a bid and prove knowledge of opening values
+	com, r, err := commitment.Commit(price)
+	if err != nil {
+		panic(err)
+	}
+	comBytes := wire.MarshalCommitment(com)
+	proof, err := proveCommit("commit", price, r, comBytes, nil)
+	if err != nil {
+		panic(err)
+	}
+	proofBytes := wire.MarshalOpeningProof(proof)
+
+	bid := &bidRecord{Ref: newRef(), AuctionID: auctionID, Price: price, R: r.Bytes(), Commitment: comBytes,
+		Nym: user.NymPublicKey(), Status: bidPending, PlacedAt: time.Now().Unix()}
+	withBidStore(username, func(store *bidStore) {
+		store.put(bid)
+	})
+
+	comBase64 := base64.StdEncoding.EncodeToString(comBytes)
+	proofBase64 := base64.StdEncoding.EncodeToString(proofBytes)
+	transientMap := escrowTransientMap(user)
+	if auction.Deposit > 0 {
+		// bind the deposit to the commitment, the opening values are kept in the store to pay if the bid wins
+		transientMap = addDepositSecret(transientMap, username)
+	}
+	var response channel.Response
+	if auction.OneBidPerCredential {
+		// prove that this is the only bid of the credential in the auction
+		tag, tagProof := user.ScopeTag(auctionID, comBytes)
+		response, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "SendUniqueCommitment", Args: [][]byte{[]byte(auctionID),
+			[]byte(comBase64), []byte(proofBase64), []byte(base64.StdEncoding.EncodeToString(tag)),
+			[]byte(base64.StdEncoding.EncodeToString(tagProof))}, TransientMap: transientMap},
+			channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	} else {
+		response, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "SendCommitment", Args: [][]byte{[]byte(auctionID), []byte(comBase64), []byte(proofBase64)},
+			TransientMap: transientMap},
+			channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	}
+	if err != nil {
+		// the commitment may still be on the ledger, the status command finds it from the stored commitment
+		fmt.Printf("Bid %v is pending: %v\n", bid.Ref, err)
+		return
+	}
+
+	bid.TxID = string(response.Payload)
+	bid.Status = bidCommitted
+	withBidStore(username, func(store *bidStore) {
+		store.put(bid)
+	})
+	fmt.Printf("Bid %v committed, transaction %v\n", bid.Ref, bid.TxID)
+}
+
+// revealBids reveals the stored bids of the user in a closed auction, encrypted with the public key of the seller
+func revealBids(username, auctionID string, endpoints []string) {
+	client, _ := newDacChannelClient(username)
+	auction := querySealedAuction(client, auctionID, endpoints)
+	var auctioneerPk *[32]byte
+	revealStoredBids(username, auctionID, auction, func(bid *bidRecord) error {
+		if auctioneerPk == nil {
+			auctioneerPk = queryAuctioneerPk(client, auctionID, endpoints)
+		}
+		// encrypt the bid
+		r := bid.randomness()
+		encryptedBid, err := commitment.Encrypt(bid.Price, r, auctioneerPk)
+		if err != nil {
+			panic(err)
+		}
+		// generate proof of knowledge of opening values
+		proof, err := proveCommit("reveal", bid.Price, r, bid.Commitment, encryptedBid)
+		if err != nil {
+			panic(err)
+		}
+
+		encryptedBidBase64 := base64.StdEncoding.EncodeToString(encryptedBid)
+		proofBase64 := base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(proof))
+		_, err = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "RevealBid", Args: [][]byte{[]byte(auctionID), []byte(bid.TxID),
+			[]byte(""), []byte(encryptedBidBase64), []byte(proofBase64)}},
+			channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+		return err
+	})
+}
+
+// revealStoredBids updates the stored bids of an auction from the ledger and reveals the committed ones with
+// reveal. The store is not locked while a bid is revealed, the other commands of the user can use it
+func revealStoredBids(username, auctionID string, auction *sealedAuction, reveal func(bid *bidRecord) error) {
+	var bids []*bidRecord
+	withBidStore(username, func(store *bidStore) {
+		bids = store.bids(auctionID)
+		for _, bid := range bids {
+			if updateBidStatus(bid, auction) {
+				store.put(bid)
+			}
+		}
+	})
+	if auction.Status != "closed" {
+		fmt.Println("Auction is not closed")
+		return
+	}
+
+	for _, bid := range bids {
+		if bid.Status != bidCommitted {
+			continue
+		}
+		if err := reveal(bid); err != nil {
+			fmt.Printf("failed to reveal bid %v: %v\n", bid.Ref, err)
+			continue
+		}
+		bid.Status = bidRevealed
+		withBidStore(username, func(store *bidStore) {
+			store.put(bid)
+		})
+		fmt.Printf("Bid %v revealed\n", bid.Ref)
+	}
+}
+
+// printBidStatus updates the stored bids of the user from the ledger and prints them
+func printBidStatus(username string, endpoints []string) {
+	client, _ := newDacChannelClient(username)
+	withBidStore(username, func(store *bidStore) {
+		auctions := make(map[string]*sealedAuction)
+		for _, bid := range store.bids("") {
+			if !bid.final() {
+				auction, exists := auctions[bid.AuctionID]
+				if !exists {
+					auction = querySealedAuction(client, bid.AuctionID, endpoints)
+					auctions[bid.AuctionID] = auction
+				}
+				if updateBidStatus(bid, auction) {
+					store.put(bid)
+				}
+			}
+			fmt.Printf("%v  %v  price %v  %v  %v\n", bid.Ref, bid.AuctionID, bid.Price, bid.Status, bid.TxID)
+		}
+	})
+}
+
+// claimBid checks whether a stored bid of the user won the auction. If the auction requires a deposit, the
+// opening values of the winning bid are written to username.opening for the account that pays it
+func claimBid(username, auctionID string, endpoints []string) {
+	client, _ := newDacChannelClient(username)
+	auction := querySealedAuction(client, auctionID, endpoints)
+	var winner *bidRecord
+	withBidStore(username, func(store *bidStore) {
+		for _, bid := range store.bids(auctionID) {
+			if updateBidStatus(bid, auction) {
+				store.put(bid)
+			}
+			if bid.Status == bidWon {
+				winner = bid
+			}
+		}
+	})
+	if !auction.declared() {
+		fmt.Println("The winner of the auction is not declared yet")
+		return
+	}
+	if winner == nil {
+		fmt.Println("No bid of the user won the auction")
+		return
+	}
+	fmt.Printf("Bid %v won auction %v at price %v\n", winner.Ref, auctionID, winner.Price)
+	if auction.Deposit > 0 {
+		writeBidOpening(username, winner.Price, winner.randomness())
+		fmt.Printf("Opening values written to %v.opening, pay the winning bid before the deadline\n", username)
+	}
+}
+
+// updateBidStatus follows a bid on the ledger and returns true if its status changed. A pending bid is found
+// from its commitment, commitments are random so that no other bid has the same one
+func updateBidStatus(bid *bidRecord, auction *sealedAuction) bool {
+	status, txID := bid.Status, bid.TxID
+	if bid.TxID == "" {
+		for id, comBytes := range auction.Commitments {
+			if bytes.Equal(comBytes, bid.Commitment) {
+				bid.TxID = id
+				bid.Status = bidCommitted
+			}
+		}
+	}
+	if bid.TxID == "" {
+		// the commitment did not reach the ledger before the auction was closed
+		if auction.Status != "open" {
+			bid.Status = bidLost
+		}
+	} else if auction.declared() {
+		if auction.WinningBid == bid.TxID {
+			bid.Status = bidWon
+		} else {
+			bid.Status = bidLost
+		}
+	} else if _, revealed := auction.EncryptedBids[bid.TxID]; revealed {
+		bid.Status = bidRevealed
+	}
+	return bid.Status != status || bid.TxID != txID
+}
+
+// withBidStore runs f with the bid store of the user, the store is only locked while f runs
+func withBidStore(username string, f func(store *bidStore)) {
+	store := openBidStore(username)
+	defer store.Close()
+	f(store)
+}
+
+// querySealedAuction reads a sealed-bid auction from the ledger
+func querySealedAuction(client *channel.Client, auctionID string, endpoints []string) *sealedAuction {
+	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryAuction", Args: [][]byte{[]byte(auctionID)}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	var auction sealedAuction
+	err = json.Unmarshal(response.Payload, &auction)
+	if err != nil {
+		panic(err)
+	}
+	if auction.Version > auctionVersion {
+		panic(fmt.Sprintf("auction %v has schema version %v, the client reads up to version %v", auctionID,
+			auction.Version, auctionVersion))
+	}
+	return &auction
+}
+
+// queryAuctioneerPk reads the public key of the seller the bids are encrypted with
+func queryAuctioneerPk(client *channel.Client, auctionID string, endpoints []string) *[32]byte {
+	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryAuctioneerPk", Args: [][]byte{[]byte(auctionID)}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	auctioneerPkBytes, err := base64.StdEncoding.DecodeString(string(response.Payload))
+	if err != nil {
+		panic(err)
+	}
+	var auctioneerPk [32]byte
+	copy(auctioneerPk[:], auctioneerPkBytes)
+	return &auctioneerPk
+}
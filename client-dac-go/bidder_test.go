@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// storeProcessVariable holds the user whose store the helper process writes a bid to
+const storeProcessVariable = "BIDDER_TEST_STORE_USER"
+
+func TestUpdateBidStatus(t *testing.T) {
+	auction := &sealedAuction{Status: "open", Commitments: map[string][]byte{"tx1": []byte("com1")}}
+	pending := &bidRecord{Commitment: []byte("com1"), Status: bidPending}
+	// the commitment reached the ledger although the client did not get the response
+	if !updateBidStatus(pending, auction) || pending.Status != bidCommitted || pending.TxID != "tx1" {
+		t.Errorf("pending bid on the ledger %+v", pending)
+	}
+	if updateBidStatus(pending, auction) {
+		t.Error("the status of a committed bid changed")
+	}
+	lost := &bidRecord{Commitment: []byte("com2"), Status: bidPending}
+	if updateBidStatus(lost, auction) || lost.Status != bidPending {
+		t.Errorf("pending bid of an open auction %+v", lost)
+	}
+
+	auction.Status = "closed"
+	auction.EncryptedBids = map[string]json.RawMessage{"tx1": json.RawMessage("{}")}
+	if !updateBidStatus(lost, auction) || lost.Status != bidLost || lost.TxID != "" {
+		t.Errorf("pending bid of a closed auction %+v", lost)
+	}
+	if !updateBidStatus(pending, auction) || pending.Status != bidRevealed {
+		t.Errorf("revealed bid %+v", pending)
+	}
+
+	auction.Status, auction.WinningBid = "ended", "tx1"
+	if !updateBidStatus(pending, auction) || pending.Status != bidWon {
+		t.Errorf("winning bid %+v", pending)
+	}
+	other := &bidRecord{TxID: "tx2", Status: bidCommitted}
+	if !updateBidStatus(other, auction) || other.Status != bidLost {
+		t.Errorf("losing bid %+v", other)
+	}
+	auction.WinningBid, auction.InvalidSet = "", "{}"
+	pending.Status = bidRevealed
+	if !updateBidStatus(pending, auction) || pending.Status != bidLost {
+		t.Errorf("bid of an auction without winner %+v", pending)
+	}
+}
+
+// TestBidStoreProcess is run by TestRevealStoredBids in another process, it places a bid in the store of the
+// user while the reveal is running
+func TestBidStoreProcess(t *testing.T) {
+	username := os.Getenv(storeProcessVariable)
+	if username == "" {
+		t.Skip("run by TestRevealStoredBids")
+	}
+	withBidStore(username, func(store *bidStore) {
+		store.put(&bidRecord{Ref: "other", AuctionID: "auction2", Price: 30, Status: bidPending, PlacedAt: time.Now().Unix()})
+	})
+}
+
+func TestRevealStoredBids(t *testing.T) {
+	username := filepath.Join(t.TempDir(), "alice")
+	os.Setenv(passphraseVariable, "passphrase")
+	defer os.Unsetenv(passphraseVariable)
+	withBidStore(username, func(store *bidStore) {
+		store.put(&bidRecord{Ref: "bid1", AuctionID: "auction1", Price: 50, Commitment: []byte("com1"), Status: bidPending, PlacedAt: 1})
+		store.put(&bidRecord{Ref: "bid2", AuctionID: "auction1", Price: 60, Commitment: []byte("com2"), Status: bidPending, PlacedAt: 2})
+		store.put(&bidRecord{Ref: "bid3", AuctionID: "auction1", Price: 70, TxID: "tx3", Status: bidCommitted, PlacedAt: 3})
+	})
+
+	// the commitment of bid1 reached the ledger while it was pending, bid2 never did
+	auction := &sealedAuction{Status: "closed", Commitments: map[string][]byte{"tx1": []byte("com1"), "tx3": []byte("com3")}}
+	var revealed []string
+	revealStoredBids(username, "auction1", auction, func(bid *bidRecord) error {
+		revealed = append(revealed, bid.Ref)
+		if bid.Ref == "bid3" {
+			return errors.New("endorsement failure")
+		}
+		// another command of the user uses the store while the bid is revealed
+		cmd := exec.Command(os.Args[0], "-test.run=^TestBidStoreProcess$")
+		cmd.Env = append(os.Environ(), storeProcessVariable+"="+username)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("store of the other process: %v\n%s", err, output)
+		}
+		return nil
+	})
+	if len(revealed) != 2 || revealed[0] != "bid1" || revealed[1] != "bid3" {
+		t.Fatalf("revealed %v", revealed)
+	}
+
+	status := make(map[string]string)
+	withBidStore(username, func(store *bidStore) {
+		for _, bid := range store.bids("") {
+			status[bid.Ref] = bid.Status
+		}
+	})
+	expected := map[string]string{"bid1": bidRevealed, "bid2": bidLost, "bid3": bidCommitted, "other": bidPending}
+	for ref, s := range expected {
+		if status[ref] != s {
+			t.Errorf("bid %v is %v, expected %v", ref, status[ref], s)
+		}
+	}
+	if len(status) != len(expected) {
+		t.Errorf("stored bids %v", status)
+	}
+}
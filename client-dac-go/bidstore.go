@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/sealedstore"
+	bolt "go.etcd.io/bbolt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+)
+
+// passphraseVariable is the environment variable holding the passphrase of the bid store
+const passphraseVariable = "BIDDER_PASSPHRASE"
+
+// Status of a bid in the store
+const (
+	// the opening values are stored but the commitment may not be on the ledger
+	bidPending   = "pending"
+	bidCommitted = "committed"
+	bidRevealed  = "revealed"
+	bidWon       = "won"
+	bidLost      = "lost"
+)
+
+var bidsBucket = []byte("bids")
+
+// bidRecord is a bid of the user. The opening values are written before the commitment is sent, so that a
+// commitment on the ledger can always be revealed. Nym is the public key of the nym the commitment was sent with
+type bidRecord struct {
+	Ref        string `json:"ref"`
+	AuctionID  string `json:"auctionID"`
+	TxID       string `json:"txID,omitempty"`
+	Price      int    `json:"price"`
+	R          []byte `json:"r"`
+	Commitment []byte `json:"commitment"`
+	Nym        []byte `json:"nym"`
+	Status     string `json:"status"`
+	PlacedAt   int64  `json:"placedAt"`
+	UpdatedAt  int64  `json:"updatedAt"`
+}
+
+// randomness returns the randomness of the commitment of the bid
+func (b *bidRecord) randomness() *big.Int {
+	return new(big.Int).SetBytes(b.R)
+}
+
+// final returns true if nothing is left to do for the bid
+func (b *bidRecord) final() bool {
+	return b.Status == bidWon || b.Status == bidLost
+}
+
+// bidStore is the local store of the bids of a user, shared by the bid, reveal, status and claim commands.
+// Every record is sealed with a key derived from the passphrase, the file alone does not tell which auctions
+// the user bid in. The file is locked while a command uses it
+type bidStore struct {
+	db *sealedstore.DB
+}
+
+// openBidStore opens or creates the bid store of a user, the passphrase is read from the environment
+func openBidStore(username string) *bidStore {
+	passphrase := os.Getenv(passphraseVariable)
+	if passphrase == "" {
+		panic(fmt.Sprintf("the passphrase of the bid store must be set in %v", passphraseVariable))
+	}
+	// another command of the same user may be using the store
+	db, err := sealedstore.Open(username+".bids", passphrase, []byte("bidder"), time.Minute, bidsBucket)
+	if err != nil {
+		panic(err)
+	}
+	return &bidStore{db: db}
+}
+
+// Close closes the store
+func (s *bidStore) Close() error {
+	return s.db.Close()
+}
+
+// newRef returns a random reference for a new bid, it is not related to the auction or the transaction
+func newRef() string {
+	ref := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, ref); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(ref)
+}
+
+// put writes a bid
+func (s *bidStore) put(bid *bidRecord) {
+	bid.UpdatedAt = time.Now().Unix()
+	bidBytes, err := json.Marshal(bid)
+	if err != nil {
+		panic(err)
+	}
+	sealed, err := s.db.Seal([]byte(bid.Ref), bidBytes)
+	if err != nil {
+		panic(err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bidsBucket).Put([]byte(bid.Ref), sealed)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// bids returns the bids of an auction, or every bid if auctionID is empty, in the order they were placed
+func (s *bidStore) bids(auctionID string) []*bidRecord {
+	var bids []*bidRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bidsBucket).ForEach(func(ref, sealed []byte) error {
+			bidBytes, err := s.db.Unseal(ref, sealed)
+			if err != nil {
+				return err
+			}
+			bid := new(bidRecord)
+			if err := json.Unmarshal(bidBytes, bid); err != nil {
+				return err
+			}
+			if auctionID == "" || bid.AuctionID == auctionID {
+				bids = append(bids, bid)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+	sort.Slice(bids, func(i, j int) bool {
+		return bids[i].PlacedAt < bids[j].PlacedAt
+	})
+	return bids
+}
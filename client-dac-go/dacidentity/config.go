@@ -11,6 +11,8 @@ type DacConfig struct {
 	YsBytes1    [][]byte `json:"ys1"`
 	YsBytes2    [][]byte `json:"ys2"`
 	RootPkBytes []byte   `json:"rootpk"`
+	// optional auditor public key, nym identities then contain an escrow of the user public key
+	AuditorPkBytes []byte `json:"auditorpk,omitempty"`
 }
 
 func (c *DacConfig) H() (interface{}, error) {
@@ -41,6 +43,11 @@ func (c *DacConfig) RootPk() (interface{}, error) {
 	return dac.PointFromBytes(c.RootPkBytes)
 }
 
+// AuditorPk returns the public key of the auditor, or nil if there is no auditor
+func (c *DacConfig) AuditorPk() (interface{}, error) {
+	return dac.PointFromBytes(c.AuditorPkBytes)
+}
+
 func CreateConfig() (*DacConfig, dac.SK) {
 	YsNum := 10
 	prg := NewRand()
@@ -0,0 +1,31 @@
+package dacidentity
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// proofDuration is the time to generate the DAC proofs of the user: the credential proof of a nym, the proof
+// of the identity escrow and the proof of a scope tag
+var proofDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "dac",
+	Name:      "proof_duration_seconds",
+	Help:      "The time to generate a DAC proof.",
+	Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+}, []string{"proof"})
+
+// nymRotations counts the nyms created by UpdateNymIdentity
+var nymRotations = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "dac",
+	Name:      "nym_rotations_total",
+	Help:      "The number of nyms created.",
+})
+
+func init() {
+	prometheus.MustRegister(proofDuration, nymRotations)
+}
+
+// observeProof records the time since start of the generation of a proof
+func observeProof(proof string, start time.Time) {
+	proofDuration.WithLabelValues(proof).Observe(time.Since(start).Seconds())
+}
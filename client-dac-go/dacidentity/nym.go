@@ -0,0 +1,30 @@
+package dacidentity
+
+import (
+	"github.com/dbogatov/dac-lib/dac"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+)
+
+// NymConfig holds the keys of a nym, a signer process stores them to sign the transactions created for the nym later
+type NymConfig struct {
+	SkNymBytes []byte `json:"sknym"`
+	PkNymBytes []byte `json:"pknym"`
+}
+
+// NymConfig returns the keys of the current nym
+func (u *User) NymConfig() NymConfig {
+	skNymBytes := make([]byte, FP256BN.MODBYTES)
+	u.nymKey.privateNymKey.ToBytes(skNymBytes)
+	return NymConfig{SkNymBytes: skNymBytes, PkNymBytes: dac.PointToBytes(u.nymKey.publicNymKey)}
+}
+
+// RestoreNym signs with the nym of the given keys instead of the current nym. The proof and escrow of the nym are
+// not stored, the identity of the nym is serialized when the nym is created
+func (u *User) RestoreNym(nymConfig NymConfig) error {
+	pkNym, err := dac.PointFromBytes(nymConfig.PkNymBytes)
+	if err != nil {
+		return err
+	}
+	u.nymKey = NymKey{privateKey: u.sk, privateNymKey: FP256BN.FromBytes(nymConfig.SkNymBytes), publicNymKey: pkNym, h: u.H}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/logging/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr/remote"
 
 	cryptosuiteimpl "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/fabpvdr"
@@ -16,6 +17,7 @@ var logger = logging.NewLogger("fabsdk")
 
 // ProviderFactory represents the default SDK provider factory.
 type ProviderFactory struct {
+	signer *remote.Client
 }
 
 // NewProviderFactory returns the default SDK provider factory.
@@ -24,6 +26,13 @@ func NewProviderFactory() *ProviderFactory {
 	return &f
 }
 
+// NewRemoteProviderFactory returns a provider factory whose signing manager signs the keys of the signer daemon of
+// the client, the nyms in memory are still signed locally
+func NewRemoteProviderFactory(signer *remote.Client) *ProviderFactory {
+	f := ProviderFactory{signer: signer}
+	return &f
+}
+
 // CreateCryptoSuiteProvider returns a new default implementation of BCCSP
 func (f *ProviderFactory) CreateCryptoSuiteProvider(config core.CryptoSuiteConfig) (core.CryptoSuite, error) {
 	if config.SecurityProvider() != "sw" {
@@ -35,7 +44,11 @@ func (f *ProviderFactory) CreateCryptoSuiteProvider(config core.CryptoSuiteConfi
 
 // CreateSigningManager returns a new default implementation of signing manager
 func (f *ProviderFactory) CreateSigningManager(cryptoProvider core.CryptoSuite) (core.SigningManager, error) {
-	return New(cryptoProvider)
+	signingManager, err := New(cryptoProvider)
+	if err != nil || f.signer == nil {
+		return signingManager, err
+	}
+	return remote.New(cryptoProvider, f.signer, signingManager)
 }
 
 // CreateInfraProvider returns a new default implementation of fabric primitives
@@ -0,0 +1,17 @@
+package dacidentity
+
+import (
+	"github.com/ckiere/test-network/auction-circuit/scopetag"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+	"time"
+)
+
+// ScopeTag computes the scope-exclusive pseudonym of the user for the given scope (e.g. an auction ID),
+// tag = H(scope)^sk, and a proof that the tag and the current nym use the same secret key. The tag is the
+// same for every nym of the user in a scope, but tags of different scopes are unlinkable.
+// The proof is bound to the message m
+func (u *User) ScopeTag(scope string, m []byte) (tag []byte, proof []byte) {
+	defer observeProof("scope_tag", time.Now())
+	return scopetag.Prove(NewRand(), u.H.(*FP256BN.ECP2), u.nymKey.PublicNymKey().(*FP256BN.ECP2), u.sk,
+		u.nymKey.PrivateNymKey(), scope, m)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/pkg/errors"
+	"time"
 )
 
 // User is a representation of a Fabric user
@@ -17,11 +18,15 @@ type User struct {
 	mspID     string
 	creds     dac.Credentials
 	sk        dac.SK
+	pk        dac.PK
 	tempProof dac.Proof
 	nymKey    NymKey
+	escrow    []byte
+	escrowProof []byte
 	H         interface{}
 	Ys        [][]interface{}
 	RootPk    interface{}
+	AuditorPk interface{}
 }
 
 // Create user from configuration
@@ -38,14 +43,24 @@ func CreateUser(dacConfig DacConfig, credConfig CredentialsConfig, id string, ms
 	if err != nil {
 		return nil, err
 	}
+	auditorPk, err := dacConfig.AuditorPk()
+	if err != nil {
+		return nil, err
+	}
+	pk, err := credConfig.Pk()
+	if err != nil {
+		return nil, err
+	}
 	user := &User{
 		id: id,
 		mspID: mspID,
 		creds: *credConfig.Credentials(),
 		sk: credConfig.Sk(),
+		pk: pk,
 		H: h,
 		Ys: ys,
 		RootPk: rootPk,
+		AuditorPk: auditorPk,
 	}
 	user.UpdateNymIdentity()
 	return user, nil
@@ -112,6 +127,7 @@ func (u *User) UpdateNymIdentity() {
 	skNym, pkNym := dac.GenerateNymKeys(prg, u.sk, u.H)
 	indices := dac.Indices{}
 
+	start := time.Now()
 	proof, e := u.creds.Prove(
 		prg,
 		u.sk,
@@ -126,12 +142,35 @@ func (u *User) UpdateNymIdentity() {
 	if e != nil {
 		panic("Failed to generate credential proof")
 	}
+	observeProof("credential", start)
 
 	u.nymKey = NymKey{privateKey: u.sk, privateNymKey: skNym, publicNymKey: pkNym, h: u.H}
 	u.tempProof = proof
+
+	// escrow the user public key for the auditor, the proof binds the escrow to the new nym
+	if u.AuditorPk != nil {
+		start = time.Now()
+		encryption, r := dac.AuditingEncrypt(prg, u.AuditorPk, u.pk)
+		auditingProof := dac.AuditingProve(prg, encryption, u.pk, u.sk, pkNym, skNym, u.AuditorPk, r, u.H)
+		u.escrow = encryption.ToBytes()
+		u.escrowProof = auditingProof.ToBytes()
+		observeProof("escrow", start)
+	}
+	nymRotations.Inc()
 	fmt.Println("Nym key updated")
 }
 
+// IdentityEscrow returns the encryption of the user public key for the auditor and the proof that it
+// is correct for the current nym. Both are nil if no auditor is configured
+func (u *User) IdentityEscrow() (escrow []byte, proof []byte) {
+	return u.escrow, u.escrowProof
+}
+
+// NymPublicKey returns the public key of the current nym, the identity the next transactions are sent with
+func (u *User) NymPublicKey() []byte {
+	return dac.PointToBytes(u.nymKey.publicNymKey)
+}
+
 type NymKey struct {
 	privateKey    dac.SK
 	privateNymKey dac.SK
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+)
+
+// bidOpening contains the opening values of a commitment, the winner needs them to pay the winning bid
+type bidOpening struct {
+	Price int    `json:"price"`
+	R     []byte `json:"r"`
+}
+
+// addDepositSecret adds the secret of the deposit voucher of the user to the transient map of a commitment.
+// The secret is read from the file username.deposit, written by the account that locked the deposit
+func addDepositSecret(transientMap map[string][]byte, username string) map[string][]byte {
+	secret, err := ioutil.ReadFile(username + ".deposit")
+	if err != nil {
+		panic(err)
+	}
+	if transientMap == nil {
+		transientMap = make(map[string][]byte)
+	}
+	transientMap["depositSecret"] = secret
+	return transientMap
+}
+
+// writeBidOpening stores the opening values of the bid of the user in the file username.opening
+func writeBidOpening(username string, price int, r *big.Int) {
+	openingBytes, _ := json.Marshal(bidOpening{Price: price, R: r.Bytes()})
+	err := ioutil.WriteFile(username+".opening", openingBytes, 0600)
+	if err != nil {
+		panic(err)
+	}
+}
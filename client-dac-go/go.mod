@@ -3,14 +3,29 @@ module github.com/ckiere/test-network/client-dac-go
 go 1.15
 
 require (
-	github.com/consensys/gnark-crypto v0.4.0
+	client-loadgen v0.0.0
+	github.com/ckiere/test-network/auction-circuit v0.0.0
 	github.com/dbogatov/dac-lib v1.0.0
 	github.com/dbogatov/fabric-amcl v0.0.0-20190731091901-c69f438d7884
-	github.com/golang/protobuf v1.3.3
+	github.com/golang/protobuf v1.5.3
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.15.2 // indirect
 	github.com/hyperledger/fabric-protos-go v0.0.0-20200707132912-fee30f3ccd23
 	github.com/hyperledger/fabric-sdk-go v1.0.0
-	github.com/pkg/errors v0.8.1
-	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
+	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.1.0
+	go.etcd.io/bbolt v1.3.5
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.14.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	golang.org/x/crypto v0.10.0
+	golang.org/x/net v0.11.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
 )
 
 replace github.com/hyperledger/fabric-sdk-go v1.0.0 => ./internal-fabric-sdk-go
+
+replace github.com/ckiere/test-network/auction-circuit => ../auction-circuit
+
+replace client-loadgen => ../client-loadgen
@@ -30,6 +30,7 @@ import (
 	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/metrics"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client enables access to a channel on a Fabric network.
@@ -43,11 +44,20 @@ type Client struct {
 	eventService fab.EventService
 	greylist     *greylist.Filter
 	metrics      *metrics.ClientMetrics
+	tracer       trace.Tracer
 }
 
 // ClientOption describes a functional parameter for the New constructor
 type ClientOption func(*Client) error
 
+// WithTracerProvider traces the queries and executions of the client with a tracer of the given provider
+func WithTracerProvider(provider trace.TracerProvider) ClientOption {
+	return func(cc *Client) error {
+		cc.tracer = provider.Tracer(invoke.TracerName)
+		return nil
+	}
+}
+
 // New returns a Client instance. Channel client can query chaincode, execute chaincode and register/unregister for chaincode events on specific channel.
 func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client, error) {
 
@@ -166,6 +176,9 @@ func (cc *Client) InvokeHandler(handler invoke.Handler, request Request, options
 				}
 
 				cc.greylist.Greylist(err)
+				if cc.metrics != nil && cc.metrics.Retries != nil {
+					cc.metrics.Retries.With("chaincode", request.ChaincodeID, "Fcn", request.Fcn).Add(1)
+				}
 
 				// Reset context parameters
 				requestContext.Opts.Targets = txnOpts.Targets
@@ -258,6 +271,7 @@ func (cc *Client) prepareHandlerContexts(reqCtx reqContext.Context, request Requ
 		Membership:   cc.membership,
 		Transactor:   transactor,
 		EventService: cc.eventService,
+		Metrics:      cc.metrics,
 	}
 
 	requestContext := &invoke.RequestContext{
@@ -7,7 +7,12 @@ SPDX-License-Identifier: Apache-2.0
 package channel
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery/greylist"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 )
@@ -22,3 +27,78 @@ func newClient(channelContext context.Channel, membership fab.ChannelMembership,
 	}
 	return channelClient
 }
+
+// queryHandler returns the handler chain of a query, it is traced if the client has a tracer
+func (cc *Client) queryHandler() invoke.Handler {
+	return cc.traced(invoke.NewQueryHandler())
+}
+
+// executeHandler returns the handler chain of an execution, it is traced if the client has a tracer
+func (cc *Client) executeHandler() invoke.Handler {
+	return cc.traced(invoke.NewExecuteHandler())
+}
+
+// traced starts a span for every run of the handler if the client has a tracer
+func (cc *Client) traced(handler invoke.Handler) invoke.Handler {
+	if cc.tracer != nil {
+		return invoke.NewTracingHandler(cc.tracer, handler)
+	}
+	return handler
+}
+
+// meteredQuery runs a query and records it in the client metrics
+func meteredQuery(cc *Client, request Request, options ...RequestOption) (Response, error) {
+	meterLabels := []string{
+		"chaincode", request.ChaincodeID,
+		"Fcn", request.Fcn,
+	}
+	cc.metrics.QueriesReceived.With(meterLabels...).Add(1)
+	startTime := time.Now()
+	r, err := cc.InvokeHandler(cc.queryHandler(), request, options...)
+	if err != nil {
+		if s, ok := err.(*status.Status); ok {
+			if s.Code == status.Timeout.ToInt32() {
+				meterLabels = append(meterLabels, "fail", "timeout")
+				cc.metrics.QueryTimeouts.With(meterLabels...).Add(1)
+				return r, err
+			}
+			meterLabels = append(meterLabels, "fail", fmt.Sprintf("Error - Group:%s - Code:%d", s.Group.String(), s.Code))
+			cc.metrics.QueriesFailed.With(meterLabels...).Add(1)
+			return r, err
+		}
+		meterLabels = append(meterLabels, "fail", fmt.Sprintf("Error - Generic: %s", err))
+		cc.metrics.QueriesFailed.With(meterLabels...).Add(1)
+		return r, err
+	}
+	cc.metrics.QueryDuration.With(meterLabels...).Observe(time.Since(startTime).Seconds())
+	return r, err
+}
+
+// meteredExecute runs an execution and records it in the client metrics
+func meteredExecute(cc *Client, request Request, options ...RequestOption) (Response, error) {
+	meterLabels := []string{
+		"chaincode", request.ChaincodeID,
+		"Fcn", request.Fcn,
+	}
+	cc.metrics.ExecutionsReceived.With(meterLabels...).Add(1)
+	startTime := time.Now()
+	r, err := cc.InvokeHandler(cc.executeHandler(), request, options...)
+	if err != nil {
+		if s, ok := err.(*status.Status); ok {
+			if s.Code == status.Timeout.ToInt32() {
+				meterLabels = append(meterLabels, "fail", "timeout")
+				cc.metrics.ExecutionTimeouts.With(meterLabels...).Add(1)
+				return r, err
+			}
+			meterLabels = append(meterLabels, "fail", fmt.Sprintf("Error - Group:%s - Code:%d", s.Group.String(), s.Code))
+			cc.metrics.ExecutionsFailed.With(meterLabels...).Add(1)
+			return r, err
+		}
+		meterLabels = append(meterLabels, "fail", fmt.Sprintf("Error - Generic: %s", err))
+		cc.metrics.ExecutionsFailed.With(meterLabels...).Add(1)
+		return r, err
+	}
+
+	cc.metrics.ExecutionDuration.With(meterLabels...).Observe(time.Since(startTime).Seconds())
+	return r, err
+}
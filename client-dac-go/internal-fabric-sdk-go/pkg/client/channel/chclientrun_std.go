@@ -8,14 +8,18 @@ SPDX-License-Identifier: Apache-2.0
 
 package channel
 
-import (
-	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
-)
-
+// callQuery only records the query if the SDK was created with metrics
 func callQuery(cc *Client, request Request, options ...RequestOption) (Response, error) {
-	return cc.InvokeHandler(invoke.NewQueryHandler(), request, options...)
+	if cc.metrics != nil && cc.metrics.QueriesReceived != nil {
+		return meteredQuery(cc, request, options...)
+	}
+	return cc.InvokeHandler(cc.queryHandler(), request, options...)
 }
 
+// callExecute only records the execution if the SDK was created with metrics
 func callExecute(cc *Client, request Request, options ...RequestOption) (Response, error) {
-	return cc.InvokeHandler(invoke.NewExecuteHandler(), request, options...)
+	if cc.metrics != nil && cc.metrics.ExecutionsReceived != nil {
+		return meteredExecute(cc, request, options...)
+	}
+	return cc.InvokeHandler(cc.executeHandler(), request, options...)
 }
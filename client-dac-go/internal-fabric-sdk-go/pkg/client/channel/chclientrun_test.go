@@ -0,0 +1,147 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	metricsApi "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/metrics"
+)
+
+func TestQueryMetrics(t *testing.T) {
+	provider := newFakeProvider()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.metrics = metrics.NewClientMetrics(provider)
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.count("queries_received", "chaincode=testCC,Fcn=invoke"))
+	assert.Equal(t, 1, provider.count("query_duration", "chaincode=testCC,Fcn=invoke"))
+	assert.Equal(t, 1, provider.count("endorsement_duration", "chaincode=testCC,Fcn=invoke,peer=http://peer1.com"))
+	assert.Equal(t, 0, provider.count("retries", "chaincode=testCC,Fcn=invoke"))
+}
+
+func TestRetryMetrics(t *testing.T) {
+	provider := newFakeProvider()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	testPeer1.Error = status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "test", nil)
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.metrics = metrics.NewClientMetrics(provider)
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(retry.DefaultChannelOpts))
+	require.Error(t, err)
+	assert.Equal(t, retry.DefaultChannelOpts.Attempts, provider.count("retries", "chaincode=testCC,Fcn=invoke"))
+	assert.Equal(t, retry.DefaultChannelOpts.Attempts+1, provider.count("endorsement_duration", "chaincode=testCC,Fcn=invoke,peer=http://peer1.com"))
+}
+
+func TestNoMetrics(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	chClient.metrics = &metrics.ClientMetrics{}
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	require.NoError(t, err)
+}
+
+// fakeProvider counts the updates of every metric by name and label values
+type fakeProvider struct {
+	mutex   sync.Mutex
+	updates map[string]int
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{updates: make(map[string]int)}
+}
+
+func (p *fakeProvider) count(name, labels string) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.updates[name+"{"+labels+"}"]
+}
+
+func (p *fakeProvider) update(name string, labels []string) {
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+"="+labels[i+1])
+	}
+	p.mutex.Lock()
+	p.updates[name+"{"+strings.Join(pairs, ",")+"}"]++
+	p.mutex.Unlock()
+}
+
+func (p *fakeProvider) NewCounter(o metricsApi.CounterOpts) metricsApi.Counter {
+	return fakeCounter{&fakeMetric{provider: p, name: o.Name}}
+}
+
+func (p *fakeProvider) NewGauge(o metricsApi.GaugeOpts) metricsApi.Gauge {
+	return nil
+}
+
+func (p *fakeProvider) NewHistogram(o metricsApi.HistogramOpts) metricsApi.Histogram {
+	return fakeHistogram{&fakeMetric{provider: p, name: o.Name}}
+}
+
+type fakeMetric struct {
+	provider *fakeProvider
+	name     string
+	labels   []string
+}
+
+func (m *fakeMetric) with(labelValues []string) *fakeMetric {
+	return &fakeMetric{provider: m.provider, name: m.name, labels: append(append([]string{}, m.labels...), labelValues...)}
+}
+
+func (m *fakeMetric) Add(delta float64) {
+	m.provider.update(m.name, m.labels)
+}
+
+func (m *fakeMetric) Observe(value float64) {
+	m.provider.update(m.name, m.labels)
+}
+
+// fakeCounter and fakeHistogram give the metric the With method of each interface
+type fakeCounter struct{ *fakeMetric }
+
+type fakeHistogram struct{ *fakeMetric }
+
+func (c fakeCounter) With(labelValues ...string) metricsApi.Counter {
+	return fakeCounter{c.with(labelValues)}
+}
+
+func (h fakeHistogram) With(labelValues ...string) metricsApi.Histogram {
+	return fakeHistogram{h.with(labelValues)}
+}
+
+func TestQueryTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	chClient := setupChannelClient([]fab.Peer{testPeer1}, t)
+	require.NoError(t, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))(chClient))
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	require.NoError(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "endorse", spans[0].Name())
+	assert.Equal(t, "testCC.invoke", spans[1].Name())
+	assert.Equal(t, invoke.TracerName, spans[1].InstrumentationScope().Name)
+}
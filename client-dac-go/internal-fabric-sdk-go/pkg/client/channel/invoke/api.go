@@ -16,6 +16,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/metrics"
 )
 
 // CCFilter returns true if the given chaincode should be included
@@ -76,6 +77,7 @@ type ClientContext struct {
 	Membership   fab.ChannelMembership
 	Transactor   fab.Transactor
 	EventService fab.EventService
+	Metrics      *metrics.ClientMetrics // records the endorsement time of every peer if set
 }
 
 //RequestContext contains request, opts, response parameters for handler execution
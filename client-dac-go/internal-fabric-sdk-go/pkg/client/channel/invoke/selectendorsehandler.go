@@ -72,7 +72,7 @@ func (e *SelectAndEndorseHandler) Handle(requestContext *RequestContext, clientC
 			if len(additionalEndorsers) > 0 {
 				requestContext.Opts.Targets = additionalEndorsers
 				logger.Debugf("...getting additional endorsements from %d target(s)", len(additionalEndorsers))
-				additionalResponses, err := clientContext.Transactor.SendTransactionProposal(requestContext.Response.Proposal, peer.PeersToTxnProcessors(additionalEndorsers))
+				additionalResponses, err := clientContext.Transactor.SendTransactionProposal(requestContext.Response.Proposal, peer.PeersToTxnProcessors(tracedPeers(requestContext, additionalEndorsers)))
 				if err != nil {
 					requestContext.Error = errors.WithMessage(err, "error sending transaction proposal")
 					return
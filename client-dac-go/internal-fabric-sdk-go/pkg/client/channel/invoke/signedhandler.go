@@ -0,0 +1,105 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+//SignedEndorsementHandler for endorsing a proposal that was signed outside of the SDK
+type SignedEndorsementHandler struct {
+	next     Handler
+	proposal *fab.TransactionProposal
+	signed   *pb.SignedProposal
+}
+
+//Handle sends the signed proposal to the targets, the transactor of the client context must be a fab.SignedSender
+func (e *SignedEndorsementHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+
+	if len(requestContext.Opts.Targets) == 0 {
+		requestContext.Error = status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "targets were not provided", nil)
+		return
+	}
+
+	sender, ok := clientContext.Transactor.(fab.SignedSender)
+	if !ok {
+		requestContext.Error = errors.New("transactor does not send signed proposals")
+		return
+	}
+
+	requestContext.Response.Proposal = e.proposal
+	requestContext.Response.TransactionID = e.proposal.TxnID
+
+	transactionProposalResponses, err := sender.SendSignedProposal(e.signed, endorsementTargets(requestContext, clientContext))
+	if err != nil {
+		requestContext.Error = checkEndorserServerError(err)
+		return
+	}
+
+	setEndorsements(requestContext, transactionProposalResponses)
+
+	//Delegate to next step if any
+	if e.next != nil {
+		e.next.Handle(requestContext, clientContext)
+	}
+}
+
+//SignedCommitTxHandler for committing a transaction envelope that was signed outside of the SDK
+type SignedCommitTxHandler struct {
+	next     Handler
+	txnID    fab.TransactionID
+	envelope *fab.SignedEnvelope
+}
+
+//Handle sends the signed envelope to the orderers and waits until the transaction is committed, the transactor of the client context must be a fab.SignedSender
+func (c *SignedCommitTxHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	sender, ok := clientContext.Transactor.(fab.SignedSender)
+	if !ok {
+		requestContext.Error = errors.New("transactor does not send signed transactions")
+		return
+	}
+
+	requestContext.Response.TransactionID = c.txnID
+	commitTx(requestContext, clientContext, func() error {
+		_, err := sender.SendSignedTransaction(c.envelope)
+		return err
+	})
+	if requestContext.Error != nil {
+		return
+	}
+
+	//Delegate to next step if any
+	if c.next != nil {
+		c.next.Handle(requestContext, clientContext)
+	}
+}
+
+//NewSignedEndorseHandler returns a handler for a proposal signed outside of the SDK with chain of
+//ProposalProcessorHandler, SignedEndorsementHandler, EndorsementValidationHandler and SignatureValidationHandler
+func NewSignedEndorseHandler(proposal *fab.TransactionProposal, signed *pb.SignedProposal, next ...Handler) Handler {
+	return NewProposalProcessorHandler(
+		NewSignedEndorsementHandler(proposal, signed,
+			NewEndorsementValidationHandler(
+				NewSignatureValidationHandler(next...),
+			),
+		),
+	)
+}
+
+//NewSignedEndorsementHandler returns a handler that endorses a proposal with the signature of its creator
+func NewSignedEndorsementHandler(proposal *fab.TransactionProposal, signed *pb.SignedProposal, next ...Handler) *SignedEndorsementHandler {
+	return &SignedEndorsementHandler{next: getNext(next), proposal: proposal, signed: signed}
+}
+
+//NewSignedCommitHandler returns a handler that commits the transaction of an envelope with the signature of its creator
+func NewSignedCommitHandler(txnID fab.TransactionID, envelope *fab.SignedEnvelope, next ...Handler) *SignedCommitTxHandler {
+	return &SignedCommitTxHandler{next: getNext(next), txnID: txnID, envelope: envelope}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	reqContext "context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+// TracerName is the name of the tracer that creates the spans of the invoke handlers
+const TracerName = "github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+
+//TracingHandler starts a span for every run of the handler chain
+type TracingHandler struct {
+	next   Handler
+	tracer trace.Tracer
+}
+
+//Handle starts a span and runs the next handler in a request context that carries it. The handlers that follow
+//create their spans as children of it, the span is a child of the span of the parent context if there is one
+func (t *TracingHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	ctx := requestContext.Ctx
+	spanCtx, span := t.tracer.Start(ctx, requestContext.Request.ChaincodeID+"."+requestContext.Request.Fcn,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("chaincode", requestContext.Request.ChaincodeID),
+			attribute.String("fcn", requestContext.Request.Fcn),
+		))
+	requestContext.Ctx = spanCtx
+	defer func() {
+		requestContext.Ctx = ctx
+		if requestContext.Response.TransactionID != "" {
+			span.SetAttributes(attribute.String("txID", string(requestContext.Response.TransactionID)))
+		}
+		endSpan(span, requestContext.Error)
+	}()
+
+	//Delegate to next step if any
+	if t.next != nil {
+		t.next.Handle(requestContext, clientContext)
+	}
+}
+
+//NewTracingHandler returns a handler that traces the handlers that follow it with spans of the given tracer
+func NewTracingHandler(tracer trace.Tracer, next ...Handler) *TracingHandler {
+	return &TracingHandler{next: getNext(next), tracer: tracer}
+}
+
+// startSpan starts a child span of the span in the request context, it does not record anything if the request
+// is not traced
+func startSpan(requestContext *RequestContext, name string, attributes ...attribute.KeyValue) (reqContext.Context, trace.Span) {
+	return spanTracer(requestContext.Ctx).Start(requestContext.Ctx, name, trace.WithAttributes(attributes...))
+}
+
+// spanTracer returns the tracer of the provider of the span in the context
+func spanTracer(ctx reqContext.Context) trace.Tracer {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(TracerName)
+}
+
+// endSpan records the error of a step in its span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracedPeer is a peer that traces the endorsement of a proposal
+type tracedPeer struct {
+	fab.Peer
+	ctx reqContext.Context
+}
+
+// ProcessTransactionProposal sends the proposal to the peer in a span of its own
+func (p *tracedPeer) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	_, span := spanTracer(p.ctx).Start(p.ctx, "endorse",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("peer", p.URL()), attribute.String("mspID", p.MSPID())))
+	resp, err := p.Peer.ProcessTransactionProposal(ctx, request)
+	if resp != nil {
+		span.SetAttributes(attribute.Int64("status", int64(resp.Status)))
+	}
+	endSpan(span, err)
+	return resp, err
+}
+
+// tracedPeers wraps the peers so that every endorsement gets a child span of the span in the request context,
+// the peers are returned as they are if the request is not traced
+func tracedPeers(requestContext *RequestContext, peers []fab.Peer) []fab.Peer {
+	if !trace.SpanFromContext(requestContext.Ctx).IsRecording() {
+		return peers
+	}
+	traced := make([]fab.Peer, len(peers))
+	for i, p := range peers {
+		traced[i] = &tracedPeer{Peer: p, ctx: requestContext.Ctx}
+	}
+	return traced
+}
@@ -0,0 +1,86 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestTracingHandlerExecute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{peer1, peer2}, t)
+	clientContext.EventService = fcmocks.NewMockEventService()
+
+	ctx := requestContext.Ctx
+	NewTracingHandler(tracer, NewExecuteHandler()).Handle(requestContext, clientContext)
+	require.NoError(t, requestContext.Error)
+	assert.Equal(t, ctx, requestContext.Ctx, "the request context is restored")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 5)
+	root := spans[len(spans)-1]
+	assert.Equal(t, "testCC.invoke", root.Name())
+	assert.Equal(t, codes.Unset, root.Status().Code)
+
+	var names []string
+	for _, span := range spans[:len(spans)-1] {
+		names = append(names, span.Name())
+		assert.Equal(t, root.SpanContext().SpanID(), span.Parent().SpanID(), "%v is a child of the request span", span.Name())
+	}
+	assert.Equal(t, []string{"endorse", "endorse", "broadcast", "commit"}, names)
+}
+
+func TestTracingHandlerInvalidTx(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	requestContext := prepareRequestContext(request, Opts{}, t)
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{peer1}, t)
+	mockEventService := fcmocks.NewMockEventService()
+	mockEventService.TxValidationCode = pb.TxValidationCode_MVCC_READ_CONFLICT
+	clientContext.EventService = mockEventService
+
+	NewTracingHandler(tracer, NewExecuteHandler()).Handle(requestContext, clientContext)
+	require.Error(t, requestContext.Error)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	commit := spans[2]
+	assert.Equal(t, "commit", commit.Name())
+	assert.Equal(t, codes.Error, commit.Status().Code)
+	assert.Equal(t, codes.Error, spans[3].Status().Code)
+}
+
+func TestUntracedRequest(t *testing.T) {
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1}}, t)
+
+	peers := tracedPeers(requestContext, requestContext.Opts.Targets)
+	assert.Equal(t, peer1, peers[0], "the peers are not wrapped without a span")
+
+	NewQueryHandler().Handle(requestContext, setupChannelClientContext(nil, nil, nil, t))
+	require.NoError(t, requestContext.Error)
+}
@@ -8,11 +8,14 @@ package invoke
 
 import (
 	"bytes"
+	reqContext "context"
 	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/options"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/hyperledger/fabric-protos-go/common"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
@@ -20,6 +23,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/metrics"
 )
 
 // TxnHeaderOptsProvider provides transaction header options which allow
@@ -49,7 +53,7 @@ func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContex
 	transactionProposalResponses, proposal, err := createAndSendTransactionProposal(
 		clientContext.Transactor,
 		&requestContext.Request,
-		peer.PeersToTxnProcessors(requestContext.Opts.Targets),
+		endorsementTargets(requestContext, clientContext),
 		TxnHeaderOpts...,
 	)
 
@@ -61,16 +65,60 @@ func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContex
 		return
 	}
 
+	setEndorsements(requestContext, transactionProposalResponses)
+
+	//Delegate to next step if any
+	if e.next != nil {
+		e.next.Handle(requestContext, clientContext)
+	}
+}
+
+// setEndorsements sets the proposal responses in the response, with the payload and status of the first one
+func setEndorsements(requestContext *RequestContext, transactionProposalResponses []*fab.TransactionProposalResponse) {
 	requestContext.Response.Responses = transactionProposalResponses
 	if len(transactionProposalResponses) > 0 {
 		requestContext.Response.Payload = transactionProposalResponses[0].ProposalResponse.GetResponse().Payload
 		requestContext.Response.ChaincodeStatus = transactionProposalResponses[0].ChaincodeStatus
 	}
+}
 
-	//Delegate to next step if any
-	if e.next != nil {
-		e.next.Handle(requestContext, clientContext)
+// endorsementTargets returns the proposal processors of the target peers, they are traced and timed if the request
+// is traced and the client has metrics
+func endorsementTargets(requestContext *RequestContext, clientContext *ClientContext) []fab.ProposalProcessor {
+	peers := tracedPeers(requestContext, requestContext.Opts.Targets)
+	if clientContext.Metrics != nil && clientContext.Metrics.EndorsementDuration != nil {
+		return timedProcessors(peers, clientContext.Metrics, &requestContext.Request)
 	}
+	return peer.PeersToTxnProcessors(peers)
+}
+
+// timedPeer is a peer that records the time it takes to endorse a proposal
+type timedPeer struct {
+	fab.Peer
+	metrics *metrics.ClientMetrics
+	labels  []string
+}
+
+// ProcessTransactionProposal sends the proposal to the peer and records the time until its response
+func (p *timedPeer) ProcessTransactionProposal(ctx reqContext.Context, request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	start := time.Now()
+	resp, err := p.Peer.ProcessTransactionProposal(ctx, request)
+	p.metrics.EndorsementDuration.With(p.labels...).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// timedProcessors converts the peers to proposal processors that record their endorsement time, they are still
+// peers so that duplicate targets are removed
+func timedProcessors(peers []fab.Peer, clientMetrics *metrics.ClientMetrics, request *Request) []fab.ProposalProcessor {
+	tpp := make([]fab.ProposalProcessor, len(peers))
+	for i, p := range peers {
+		tpp[i] = &timedPeer{
+			Peer:    p,
+			metrics: clientMetrics,
+			labels:  []string{"chaincode", request.ChaincodeID, "Fcn", request.Fcn, "peer", p.URL()},
+		}
+	}
+	return tpp
 }
 
 //ProposalProcessorHandler for selecting proposal processors
@@ -166,6 +214,23 @@ type CommitTxHandler struct {
 
 //Handle handles commit tx
 func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	commitTx(requestContext, clientContext, func() error {
+		_, err := createAndSendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses)
+		return err
+	})
+	if requestContext.Error != nil {
+		return
+	}
+
+	//Delegate to next step if any
+	if c.next != nil {
+		c.next.Handle(requestContext, clientContext)
+	}
+}
+
+// commitTx registers for the status of the transaction in the response, sends the transaction with the given
+// function and waits until it is committed. The request context has an error if the transaction is not valid
+func commitTx(requestContext *RequestContext, clientContext *ClientContext, send func() error) {
 	txnID := requestContext.Response.TransactionID
 
 	//Register Tx event
@@ -176,31 +241,29 @@ func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *
 	}
 	defer clientContext.EventService.Unregister(reg)
 
-	_, err = createAndSendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses)
+	_, span := startSpan(requestContext, "broadcast", attribute.String("txID", string(txnID)))
+	err = send()
+	endSpan(span, err)
 	if err != nil {
 		requestContext.Error = errors.Wrap(err, "CreateAndSendTransaction failed")
 		return
 	}
 
+	_, span = startSpan(requestContext, "commit", attribute.String("txID", string(txnID)))
 	select {
 	case txStatus := <-statusNotifier:
 		requestContext.Response.TxValidationCode = txStatus.TxValidationCode
+		span.SetAttributes(attribute.String("validationCode", txStatus.TxValidationCode.String()))
 
 		if txStatus.TxValidationCode != pb.TxValidationCode_VALID {
 			requestContext.Error = status.New(status.EventServerStatus, int32(txStatus.TxValidationCode),
 				"received invalid transaction", nil)
-			return
 		}
 	case <-requestContext.Ctx.Done():
 		requestContext.Error = status.New(status.ClientStatus, status.Timeout.ToInt32(),
 			"Execute didn't receive block event", nil)
-		return
-	}
-
-	//Delegate to next step if any
-	if c.next != nil {
-		c.next.Handle(requestContext, clientContext)
 	}
+	endSpan(span, requestContext.Error)
 }
 
 //NewQueryHandler returns query handler with chain of ProposalProcessorHandler, EndorsementHandler, EndorsementValidationHandler and SignatureValidationHandler
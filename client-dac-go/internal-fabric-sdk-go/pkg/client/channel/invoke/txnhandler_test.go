@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,11 +20,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	metricsApi "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/metrics"
 	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/metrics"
 	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
 )
 
@@ -205,6 +208,55 @@ func TestEndorsementHandler(t *testing.T) {
 	require.EqualError(t, requestContext.Error, errExpected.Error())
 }
 
+func TestEndorsementHandlerMetrics(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	durations := &fakeHistogram{observations: make(map[string]int)}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+	clientContext.Metrics = &metrics.ClientMetrics{EndorsementDuration: durations}
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	requestContext := prepareRequestContext(request, Opts{Targets: []fab.Peer{peer1, peer2, peer1}}, t)
+
+	handler := NewEndorsementHandler()
+	handler.Handle(requestContext, clientContext)
+	require.NoError(t, requestContext.Error)
+	assert.Len(t, requestContext.Response.Responses, 2, "duplicate targets are still removed")
+	assert.Equal(t, map[string]int{
+		"chaincode=test,Fcn=invoke,peer=http://peer1.com": 1,
+		"chaincode=test,Fcn=invoke,peer=http://peer2.com": 1,
+	}, durations.observations)
+
+	// without the histogram the targets are sent as they are
+	clientContext.Metrics = &metrics.ClientMetrics{}
+	handler.Handle(requestContext, clientContext)
+	require.NoError(t, requestContext.Error)
+	assert.Len(t, durations.observations, 2)
+}
+
+// fakeHistogram counts the observations of every label set
+type fakeHistogram struct {
+	mutex        sync.Mutex
+	labels       []string
+	observations map[string]int
+	parent       *fakeHistogram
+}
+
+func (h *fakeHistogram) With(labelValues ...string) metricsApi.Histogram {
+	return &fakeHistogram{labels: labelValues, parent: h}
+}
+
+func (h *fakeHistogram) Observe(value float64) {
+	var pairs []string
+	for i := 0; i+1 < len(h.labels); i += 2 {
+		pairs = append(pairs, h.labels[i]+"="+h.labels[i+1])
+	}
+	h.parent.mutex.Lock()
+	h.parent.observations[strings.Join(pairs, ",")]++
+	h.parent.mutex.Unlock()
+}
+
 // Target filter
 type filter struct {
 	peer fab.Peer
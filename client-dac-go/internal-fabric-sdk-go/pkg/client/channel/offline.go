@@ -0,0 +1,242 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protoutil"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/pkg/errors"
+)
+
+// CreateProposal creates the proposal of a request for the given creator and returns the bytes that the creator
+// signs, so that the signing identity does not have to be in this process. The creator is a serialized identity,
+// such as the identity of a DAC nym, the identity of the client context is used if it is nil.
+//  Parameters:
+//  request holds info about mandatory chaincode ID and function
+//  creator is the serialized identity of the signer
+//
+//  Returns:
+//  the bytes of the proposal, they are sent with their signature to EndorseSignedProposal
+func (cc *Client) CreateProposal(request Request, creator []byte) ([]byte, error) {
+	var opts []fab.TxnHeaderOpt
+	if creator != nil {
+		opts = append(opts, fab.WithCreator(creator))
+	}
+	txh, err := txn.NewHeader(cc.context, cc.context.ChannelID(), opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating transaction header failed")
+	}
+
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{
+		ChaincodeID:  request.ChaincodeID,
+		Fcn:          request.Fcn,
+		Args:         request.Args,
+		TransientMap: request.TransientMap,
+		IsInit:       request.IsInit,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating transaction proposal failed")
+	}
+
+	return proto.Marshal(proposal.Proposal)
+}
+
+// EndorseSignedProposal sends a proposal of CreateProposal with the signature of its creator to the endorsers.
+//  Parameters:
+//  proposal is the bytes returned by CreateProposal
+//  signature is the signature of the creator of the proposal over these bytes
+//  options holds optional request options, such as the targets
+//
+//  Returns:
+//  the endorsements of the proposal, the transaction is not sent to the orderer
+func (cc *Client) EndorseSignedProposal(proposal, signature []byte, options ...RequestOption) (Response, error) {
+	p, err := protoutil.UnmarshalProposal(proposal)
+	if err != nil {
+		return Response{}, err
+	}
+	hdr, err := protoutil.UnmarshalHeader(p.Header)
+	if err != nil {
+		return Response{}, err
+	}
+	chdr, err := protoutil.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return Response{}, err
+	}
+	request, err := invocationRequest(p.Payload)
+	if err != nil {
+		return Response{}, err
+	}
+
+	transactionProposal := &fab.TransactionProposal{TxnID: fab.TransactionID(chdr.TxId), Proposal: p}
+	signed := &pb.SignedProposal{ProposalBytes: proposal, Signature: signature}
+	return cc.InvokeHandler(cc.traced(invoke.NewSignedEndorseHandler(transactionProposal, signed)), request, options...)
+}
+
+// CreateTransactionPayload creates the transaction of the endorsements of EndorseSignedProposal and returns the bytes
+// of its payload, the creator of the proposal signs them.
+//  Parameters:
+//  response is the response of EndorseSignedProposal
+//
+//  Returns:
+//  the bytes of the payload, they are sent with their signature to SubmitSignedTransaction
+func (cc *Client) CreateTransactionPayload(response Response) ([]byte, error) {
+	tx, err := txn.New(fab.TransactionRequest{Proposal: response.Proposal, ProposalResponses: response.Responses})
+	if err != nil {
+		return nil, errors.WithMessage(err, "CreateTransaction failed")
+	}
+	payload, err := txn.NewPayload(tx)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(payload)
+}
+
+// SubmitSignedTransaction sends a payload of CreateTransactionPayload with the signature of its creator to the
+// orderers, and waits until the transaction is committed. The identity of the client context must be able to
+// receive the block events of the channel.
+//  Parameters:
+//  payload is the bytes returned by CreateTransactionPayload
+//  signature is the signature of the creator over these bytes
+//  options holds optional request options
+//
+//  Returns:
+//  the transaction ID and validation code
+func (cc *Client) SubmitSignedTransaction(payload, signature []byte, options ...RequestOption) (Response, error) {
+	txnID, request, err := transactionRequest(payload)
+	if err != nil {
+		return Response{}, err
+	}
+	envelope := &fab.SignedEnvelope{Payload: payload, Signature: signature}
+	return cc.InvokeHandler(cc.traced(invoke.NewSignedCommitHandler(txnID, envelope)), request, options...)
+}
+
+// OfflineObject is the content of a proposal or transaction payload of the offline flow, as its creator checks it
+// before signing
+type OfflineObject struct {
+	TxnID   fab.TransactionID
+	Creator []byte
+	Request Request
+}
+
+// DecodeProposal decodes a proposal of CreateProposal, so that the signer sees what it signs.
+//  Parameters:
+//  proposal is the bytes returned by CreateProposal
+//
+//  Returns:
+//  the transaction ID, the creator and the chaincode invocation of the proposal
+func DecodeProposal(proposal []byte) (*OfflineObject, error) {
+	p, err := protoutil.UnmarshalProposal(proposal)
+	if err != nil {
+		return nil, err
+	}
+	hdr, err := protoutil.UnmarshalHeader(p.Header)
+	if err != nil {
+		return nil, err
+	}
+	chdr, err := protoutil.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	if chdr.Type != int32(common.HeaderType_ENDORSER_TRANSACTION) {
+		return nil, errors.Errorf("proposal is not an endorser transaction: %v", common.HeaderType(chdr.Type))
+	}
+	shdr, err := protoutil.UnmarshalSignatureHeader(hdr.SignatureHeader)
+	if err != nil {
+		return nil, err
+	}
+	request, err := invocationRequest(p.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineObject{TxnID: fab.TransactionID(chdr.TxId), Creator: shdr.Creator, Request: request}, nil
+}
+
+// DecodeTransactionPayload decodes a transaction payload of CreateTransactionPayload, so that the signer sees
+// what it signs. The transient data of the proposal is not part of the transaction.
+//  Parameters:
+//  payload is the bytes returned by CreateTransactionPayload
+//
+//  Returns:
+//  the transaction ID, the creator and the chaincode invocation of the transaction
+func DecodeTransactionPayload(payload []byte) (*OfflineObject, error) {
+	txnID, request, err := transactionRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	p, err := protoutil.UnmarshalPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	shdr, err := protoutil.UnmarshalSignatureHeader(p.Header.SignatureHeader)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineObject{TxnID: txnID, Creator: shdr.Creator, Request: request}, nil
+}
+
+// transactionRequest returns the transaction ID and the request of the chaincode invocation in a transaction payload
+func transactionRequest(payload []byte) (fab.TransactionID, Request, error) {
+	p, err := protoutil.UnmarshalPayload(payload)
+	if err != nil {
+		return "", Request{}, err
+	}
+	if p.Header == nil {
+		return "", Request{}, errors.New("payload header is required")
+	}
+	chdr, err := protoutil.UnmarshalChannelHeader(p.Header.ChannelHeader)
+	if err != nil {
+		return "", Request{}, err
+	}
+	if chdr.Type != int32(common.HeaderType_ENDORSER_TRANSACTION) {
+		return "", Request{}, errors.Errorf("payload is not an endorser transaction: %v", common.HeaderType(chdr.Type))
+	}
+	tx, err := protoutil.UnmarshalTransaction(p.Data)
+	if err != nil {
+		return "", Request{}, err
+	}
+	if len(tx.Actions) == 0 {
+		return "", Request{}, errors.New("transaction has no actions")
+	}
+	cap, err := protoutil.UnmarshalChaincodeActionPayload(tx.Actions[0].Payload)
+	if err != nil {
+		return "", Request{}, err
+	}
+	request, err := invocationRequest(cap.ChaincodeProposalPayload)
+	if err != nil {
+		return "", Request{}, err
+	}
+	return fab.TransactionID(chdr.TxId), request, nil
+}
+
+// invocationRequest returns the request of the chaincode invocation in a proposal payload
+func invocationRequest(proposalPayload []byte) (Request, error) {
+	cpp, err := protoutil.UnmarshalChaincodeProposalPayload(proposalPayload)
+	if err != nil {
+		return Request{}, err
+	}
+	cis, err := protoutil.UnmarshalChaincodeInvocationSpec(cpp.Input)
+	if err != nil {
+		return Request{}, err
+	}
+	spec := cis.GetChaincodeSpec()
+	if spec.GetChaincodeId() == nil || len(spec.GetInput().GetArgs()) == 0 {
+		return Request{}, errors.New("proposal does not invoke a chaincode")
+	}
+	args := spec.Input.Args
+	return Request{
+		ChaincodeID:  spec.ChaincodeId.Name,
+		Fcn:          string(args[0]),
+		Args:         args[1:],
+		TransientMap: cpp.TransientMap,
+		IsInit:       spec.Input.IsInit,
+	}, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protoutil"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestSignedExecute(t *testing.T) {
+	testPeer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	broadcasts := make(chan *fab.SignedEnvelope, 1)
+	testOrderer1 := fcmocks.NewMockOrderer("", broadcasts)
+	chClient := setupChannelClientWithNodes([]fab.Peer{testPeer1}, []fab.Orderer{testOrderer1}, t)
+	chClient.eventService = fcmocks.NewMockEventService()
+
+	creator := []byte("external signer")
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a")},
+		TransientMap: map[string][]byte{"escrow": []byte("value")}}
+	proposal, err := chClient.CreateProposal(request, creator)
+	require.NoError(t, err)
+
+	p, err := protoutil.UnmarshalProposal(proposal)
+	require.NoError(t, err)
+	hdr, err := protoutil.UnmarshalHeader(p.Header)
+	require.NoError(t, err)
+	shdr, err := protoutil.UnmarshalSignatureHeader(hdr.SignatureHeader)
+	require.NoError(t, err)
+	assert.Equal(t, creator, shdr.Creator, "the proposal is created for the external signer")
+
+	response, err := chClient.EndorseSignedProposal(proposal, []byte("proposal signature"))
+	require.NoError(t, err)
+	require.Len(t, response.Responses, 1)
+	assert.NotEmpty(t, response.TransactionID)
+	assert.Equal(t, testPeer1.Payload, response.Payload)
+
+	payload, err := chClient.CreateTransactionPayload(response)
+	require.NoError(t, err)
+
+	// the signer decodes both objects before signing them
+	decoded, err := DecodeProposal(proposal)
+	require.NoError(t, err)
+	assert.Equal(t, creator, decoded.Creator)
+	assert.Equal(t, response.TransactionID, decoded.TxnID)
+	assert.Equal(t, request.Fcn, decoded.Request.Fcn)
+	assert.Equal(t, request.Args, decoded.Request.Args)
+	assert.Equal(t, request.TransientMap, decoded.Request.TransientMap)
+	decoded, err = DecodeTransactionPayload(payload)
+	require.NoError(t, err)
+	assert.Equal(t, creator, decoded.Creator)
+	assert.Equal(t, response.TransactionID, decoded.TxnID)
+	assert.Equal(t, "testCC", decoded.Request.ChaincodeID)
+	assert.Equal(t, request.Args, decoded.Request.Args)
+	_, err = DecodeTransactionPayload(proposal)
+	assert.Error(t, err, "a proposal is not a transaction payload")
+
+	committed, err := chClient.SubmitSignedTransaction(payload, []byte("payload signature"))
+	require.NoError(t, err)
+	assert.Equal(t, response.TransactionID, committed.TransactionID)
+	assert.Equal(t, pb.TxValidationCode_VALID, committed.TxValidationCode)
+
+	envelope := <-broadcasts
+	assert.Equal(t, payload, envelope.Payload)
+	assert.Equal(t, []byte("payload signature"), envelope.Signature, "the envelope has the external signature")
+}
+
+func TestSignedProposalRequest(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+
+	proposal, err := chClient.CreateProposal(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("a")}, IsInit: true}, nil)
+	require.NoError(t, err)
+	p, err := protoutil.UnmarshalProposal(proposal)
+	require.NoError(t, err)
+
+	request, err := invocationRequest(p.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, "testCC", request.ChaincodeID)
+	assert.Equal(t, "invoke", request.Fcn)
+	assert.Equal(t, [][]byte{[]byte("a")}, request.Args)
+	assert.True(t, request.IsInit)
+
+	_, err = chClient.CreateProposal(Request{ChaincodeID: "testCC"}, nil)
+	assert.Error(t, err, "the function is required")
+
+	_, err = chClient.EndorseSignedProposal([]byte("not a proposal"), nil)
+	assert.Error(t, err)
+
+	_, err = chClient.SubmitSignedTransaction(proposal, nil)
+	assert.Error(t, err, "a proposal is not a transaction payload")
+}
@@ -24,6 +24,13 @@ type Sender interface {
 	SendTransaction(tx *Transaction) (*TransactionResponse, error)
 }
 
+// SignedSender sends proposals and transactions that were signed outside of the SDK, such as by a signer
+// process that holds the key of the creator.
+type SignedSender interface {
+	SendSignedProposal(proposal *pb.SignedProposal, targets []ProposalProcessor) ([]*TransactionProposalResponse, error)
+	SendSignedTransaction(envelope *SignedEnvelope) (*TransactionResponse, error)
+}
+
 // The Transaction object created from an endorsed proposal.
 type Transaction struct {
 	Proposal    *TransactionProposal
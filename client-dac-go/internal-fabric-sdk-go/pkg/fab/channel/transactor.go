@@ -10,6 +10,7 @@ import (
 	reqContext "context"
 	"strings"
 
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
@@ -186,3 +187,21 @@ func (t *Transactor) CreateTransaction(request fab.TransactionRequest) (*fab.Tra
 func (t *Transactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
 	return txn.Send(t.reqCtx, tx, t.orderers)
 }
+
+// SendSignedProposal sends a proposal that was signed outside of the SDK to the target peers.
+func (t *Transactor) SendSignedProposal(proposal *pb.SignedProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+	ctx, ok := contextImpl.RequestClientContext(t.reqCtx)
+	if !ok {
+		return nil, errors.New("failed get client context from reqContext for SendSignedProposal")
+	}
+
+	reqCtx, cancel := contextImpl.NewRequest(ctx, contextImpl.WithTimeoutType(fab.PeerResponse), contextImpl.WithParent(t.reqCtx))
+	defer cancel()
+
+	return txn.SendSignedProposal(reqCtx, proposal, targets)
+}
+
+// SendSignedTransaction sends an envelope that was signed outside of the SDK to the orderer service.
+func (t *Transactor) SendSignedTransaction(envelope *fab.SignedEnvelope) (*fab.TransactionResponse, error) {
+	return txn.BroadcastEnvelope(t.reqCtx, envelope, t.orderers)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package remote signs with keys held by a signer daemon. The SDK computes the digest of an object and the
+// daemon signs it, over a local gRPC connection with mutual TLS. The messages are encoded in JSON with the json
+// codec of this package
+package remote
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// DefaultAddress is the unix socket the signer daemon listens on
+const DefaultAddress = "unix:///tmp/fabric-signer.sock"
+
+// Signature schemes of the keys of the daemon
+const (
+	// SchemeECDSA is an ECDSA signature of the digest in ASN.1 DER with a low S, the signature of X.509 identities
+	SchemeECDSA = "ecdsa"
+	// SchemeDACNym is a DAC signature of the digest with the keys of a nym
+	SchemeDACNym = "dac-nym"
+)
+
+// SignRequest asks for the signature of a digest with a key of the daemon, the scheme must be the scheme of the key.
+// SKI is the SKI of a KeyResponse, it selects the nym of a DAC key
+type SignRequest struct {
+	KeyID  string `json:"keyID"`
+	Scheme string `json:"scheme"`
+	SKI    []byte `json:"ski,omitempty"`
+	Digest []byte `json:"digest"`
+}
+
+// SignResponse is the signature of a digest
+type SignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+// KeyRequest asks for the public part of a key of the daemon
+type KeyRequest struct {
+	KeyID string `json:"keyID"`
+}
+
+// KeyResponse is the public part of a key. PublicKey is the PKIX encoding of an ECDSA key or the public key of a
+// nym, SKI is the subject key identifier of the key as the SDK computes it. The daemon creates a new nym for every
+// request of a DAC key, Creator is its serialized identity and Transient the transient data of its proposals
+type KeyResponse struct {
+	KeyID     string            `json:"keyID"`
+	Scheme    string            `json:"scheme"`
+	PublicKey []byte            `json:"publicKey"`
+	SKI       []byte            `json:"ski"`
+	Creator   []byte            `json:"creator,omitempty"`
+	Transient map[string][]byte `json:"transient,omitempty"`
+}
+
+// SignerServer is the service implemented by the signer daemon
+type SignerServer interface {
+	Sign(context.Context, *SignRequest) (*SignResponse, error)
+	PublicKey(context.Context, *KeyRequest) (*KeyResponse, error)
+}
+
+const serviceName = "fabric.signer.Signer"
+
+// codec encodes the messages in JSON
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// RegisterSignerServer registers the implementation of the service on a gRPC server
+func RegisterSignerServer(s *grpc.Server, srv SignerServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*SignerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Sign",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(SignRequest)
+				return handle(srv, ctx, dec, interceptor, "Sign", in, func(ctx context.Context) (interface{}, error) {
+					return srv.(SignerServer).Sign(ctx, in)
+				})
+			},
+		},
+		{
+			MethodName: "PublicKey",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(KeyRequest)
+				return handle(srv, ctx, dec, interceptor, "PublicKey", in, func(ctx context.Context) (interface{}, error) {
+					return srv.(SignerServer).PublicKey(ctx, in)
+				})
+			},
+		},
+	},
+	Metadata: "pkg/fab/signingmgr/remote",
+}
+
+// handle decodes the request of a method into in and calls it through the interceptor of the server
+func handle(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor,
+	method string, in interface{}, call func(context.Context) (interface{}, error)) (interface{}, error) {
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return call(ctx)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + method}
+	return interceptor(ctx, in, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return call(ctx)
+	})
+}
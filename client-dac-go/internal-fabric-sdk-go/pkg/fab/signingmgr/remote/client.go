@@ -0,0 +1,176 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Client is a client of the signer daemon
+type Client struct {
+	conn    *grpc.ClientConn
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+// Option configures a client
+type Option func(*Client)
+
+// WithTimeout bounds every attempt of a request, including the wait for a connection to the daemon
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets the number of times a request is sent again when the daemon is not available, such as while it
+// restarts, and the wait before each retry
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// Dial connects to the signer daemon at address, a unix socket such as DefaultAddress or a host and port. The
+// connection uses mutual TLS, see ClientTLSConfig
+func Dial(address string, tlsConfig *tls.Config, opts ...Option) (*Client, error) {
+	if tlsConfig == nil || len(tlsConfig.Certificates) == 0 {
+		return nil, errors.New("the signer daemon requires a client certificate")
+	}
+	c := &Client{timeout: 10 * time.Second, retries: 3, backoff: time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json"), grpc.WaitForReady(true)),
+		// reconnect soon after the daemon restarts
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.Config{BaseDelay: 100 * time.Millisecond,
+			Multiplier: 1.6, Jitter: 0.2, MaxDelay: c.backoff}, MinConnectTimeout: c.timeout}),
+	}
+	target := address
+	if strings.HasPrefix(address, "unix://") {
+		path := strings.TrimPrefix(address, "unix://")
+		dialOpts = append(dialOpts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		}))
+		target = "passthrough:///" + path
+	}
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial signer daemon failed")
+	}
+	c.conn = conn
+	return c, nil
+}
+
+// Close closes the connection to the daemon
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Sign returns the signature of a digest with a key of the daemon
+func (c *Client) Sign(keyID, scheme string, digest []byte) ([]byte, error) {
+	return c.sign(&SignRequest{KeyID: keyID, Scheme: scheme, Digest: digest})
+}
+
+func (c *Client) sign(req *SignRequest) ([]byte, error) {
+	resp := new(SignResponse)
+	err := c.invoke("Sign", req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+// Key returns the key of the daemon with the given ID, to sign with it through a SigningManager. For a DAC key the
+// daemon creates a new nym, call Key again for every transaction so that they are not linked by their nym
+func (c *Client) Key(keyID string) (*Key, error) {
+	resp := new(KeyResponse)
+	err := c.invoke("PublicKey", &KeyRequest{KeyID: keyID}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: resp.KeyID, Scheme: resp.Scheme, Creator: resp.Creator, Transient: resp.Transient,
+		publicKey: resp.PublicKey, ski: resp.SKI, private: true}, nil
+}
+
+// invoke calls a method of the daemon, it is retried if the daemon is not available or does not answer in time
+func (c *Client) invoke(method string, req, resp interface{}) error {
+	var err error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.backoff)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		err = c.conn.Invoke(ctx, "/"+serviceName+"/"+method, req, resp)
+		cancel()
+		code := status.Code(err)
+		if code != codes.Unavailable && code != codes.DeadlineExceeded {
+			break
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "signer daemon %v failed", method)
+	}
+	return nil
+}
+
+// ClientTLSConfig returns the TLS configuration of a client of the daemon, with the certificate and key of the
+// client and the CA that issued the certificate of the daemon. serverName is a name of the certificate of the daemon
+func ClientTLSConfig(certFile, keyFile, caFile, serverName string) (*tls.Config, error) {
+	cert, pool, err := loadTLSFiles(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: pool, ServerName: serverName,
+		MinVersion: tls.VersionTLS12}, nil
+}
+
+// ServerTLSConfig returns the TLS configuration of the daemon, it only accepts clients with a certificate issued by
+// the CA
+func ServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, pool, err := loadTLSFiles(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS12}, nil
+}
+
+func loadTLSFiles(certFile, keyFile, caFile string) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, errors.Wrap(err, "load TLS key pair failed")
+	}
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return tls.Certificate{}, nil, errors.Wrap(err, "read TLS CA failed")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, errors.Errorf("no certificate in %v", caFile)
+	}
+	return cert, pool, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"github.com/golang/protobuf/proto"
+	pb_msp "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+)
+
+// Identity is a signing identity whose key is in the signer daemon, it is passed to fabsdk.WithIdentity. The SDK
+// signs with it through a SigningManager
+type Identity struct {
+	mspID      string
+	serialized []byte
+	idBytes    []byte
+	key        *Key
+}
+
+// NewIdentity returns the identity of a serialized identity and the key of the daemon that signs for it, such as
+// an X.509 identity and its ECDSA key or the identity of a DAC nym and the keys of the nym
+func NewIdentity(serialized []byte, key *Key) (*Identity, error) {
+	sid := &pb_msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serialized, sid); err != nil {
+		return nil, errors.Wrap(err, "unmarshal serialized identity failed")
+	}
+	return &Identity{mspID: sid.Mspid, serialized: serialized, idBytes: sid.IdBytes, key: key}, nil
+}
+
+// Identifier returns the identifier of the identity, its ID is the ID of the key
+func (i *Identity) Identifier() *msp.IdentityIdentifier {
+	return &msp.IdentityIdentifier{MSPID: i.mspID, ID: i.key.ID}
+}
+
+// Verify a signature over some message using this identity as reference
+func (i *Identity) Verify(msg []byte, sig []byte) error {
+	return errors.New("not implemented")
+}
+
+// Serialize converts an identity to bytes
+func (i *Identity) Serialize() ([]byte, error) {
+	return i.serialized, nil
+}
+
+// EnrollmentCertificate returns the certificate of an X.509 identity, nil for a nym
+func (i *Identity) EnrollmentCertificate() []byte {
+	if i.key.Scheme != SchemeECDSA {
+		return nil
+	}
+	return i.idBytes
+}
+
+// Sign the message
+func (i *Identity) Sign(msg []byte) ([]byte, error) {
+	return nil, errors.New("Sign() function not implemented")
+}
+
+// PublicVersion returns the public parts of this identity
+func (i *Identity) PublicVersion() msp.Identity {
+	return i
+}
+
+// PrivateKey returns the key of the daemon
+func (i *Identity) PrivateKey() core.Key {
+	return i.key
+}
@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+)
+
+// Key is a key of the signer daemon, the private part never leaves the daemon. Creator and Transient are the
+// serialized identity of the nym of a DAC key and the transient data of its proposals, nil for an ECDSA key
+type Key struct {
+	ID        string
+	Scheme    string
+	Creator   []byte
+	Transient map[string][]byte
+	publicKey []byte
+	ski       []byte
+	private   bool
+}
+
+// Bytes returns the public key, the private key cannot be exported
+func (k *Key) Bytes() ([]byte, error) {
+	if k.private {
+		return nil, errors.New("not supported")
+	}
+	return k.publicKey, nil
+}
+
+// SKI returns the subject key identifier of the key
+func (k *Key) SKI() []byte {
+	return k.ski
+}
+
+// Symmetric returns false, the keys of the daemon are asymmetric
+func (k *Key) Symmetric() bool {
+	return false
+}
+
+// Private returns true if the key signs
+func (k *Key) Private() bool {
+	return k.private
+}
+
+// PublicKey returns the public part of the key
+func (k *Key) PublicKey() (core.Key, error) {
+	return &Key{ID: k.ID, Scheme: k.Scheme, publicKey: k.publicKey, ski: k.ski}, nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	bccspwrapper "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr"
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+)
+
+// testSigner signs with one ECDSA key, after a delay if set. ski is the SKI of the last signature request
+type testSigner struct {
+	key   *ecdsa.PrivateKey
+	delay time.Duration
+	ski   []byte
+}
+
+func (s *testSigner) Sign(ctx context.Context, req *SignRequest) (*SignResponse, error) {
+	time.Sleep(s.delay)
+	s.ski = req.SKI
+	signature, err := ecdsaSign(s.key, req.Digest)
+	return &SignResponse{Signature: signature}, err
+}
+
+func (s *testSigner) PublicKey(ctx context.Context, req *KeyRequest) (*KeyResponse, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	return &KeyResponse{KeyID: req.KeyID, Scheme: SchemeECDSA, PublicKey: publicKey, SKI: []byte("ski")}, err
+}
+
+func ecdsaSign(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	return ecdsa.SignASN1(rand.Reader, key, digest)
+}
+
+// testPKI writes a CA and the certificates of the daemon and of a client issued by it in dir
+func testPKI(t *testing.T, dir, name string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: name + " CA"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), IsCA: true,
+		KeyUsage: x509.KeyUsageCertSign, BasicConstraintsValid: true}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, name+"-ca.pem"), "CERTIFICATE", caDER)
+
+	for i, node := range []string{"server", "client"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{SerialNumber: big.NewInt(int64(i + 2)), Subject: pkix.Name{CommonName: node},
+			NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), DNSNames: []string{"signer"},
+			KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}}
+		der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		writePEM(t, filepath.Join(dir, name+"-"+node+".pem"), "CERTIFICATE", der)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		writePEM(t, filepath.Join(dir, name+"-"+node+"-key.pem"), "EC PRIVATE KEY", keyDER)
+	}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+}
+
+// startSigner serves the signer on a unix socket of dir with the TLS files of the test PKI
+func startSigner(t *testing.T, dir string, signer SignerServer) *grpc.Server {
+	tlsConfig, err := ServerTLSConfig(filepath.Join(dir, "test-server.pem"), filepath.Join(dir, "test-server-key.pem"),
+		filepath.Join(dir, "test-ca.pem"))
+	require.NoError(t, err)
+	path := filepath.Join(dir, "signer.sock")
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	RegisterSignerServer(server, signer)
+	go server.Serve(listener)
+	return server
+}
+
+func dialSigner(t *testing.T, dir, pki string, opts ...Option) *Client {
+	tlsConfig, err := ClientTLSConfig(filepath.Join(dir, pki+"-client.pem"), filepath.Join(dir, pki+"-client-key.pem"),
+		filepath.Join(dir, "test-ca.pem"), "signer")
+	require.NoError(t, err)
+	client, err := Dial("unix://"+filepath.Join(dir, "signer.sock"), tlsConfig, opts...)
+	require.NoError(t, err)
+	return client
+}
+
+func setupSigner(t *testing.T, delay time.Duration) (string, *testSigner, *grpc.Server) {
+	dir, err := ioutil.TempDir("", "signer")
+	require.NoError(t, err)
+	testPKI(t, dir, "test")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer := &testSigner{key: key, delay: delay}
+	return dir, signer, startSigner(t, dir, signer)
+}
+
+func TestSigningManager(t *testing.T) {
+	dir, signer, server := setupSigner(t, 0)
+	defer os.RemoveAll(dir)
+	defer server.Stop()
+
+	client := dialSigner(t, dir, "test")
+	defer client.Close()
+	cryptoSuite, err := sw.GetSuiteWithDefaultEphemeral()
+	require.NoError(t, err)
+	signingMgr, err := New(cryptoSuite, client, nil)
+	require.NoError(t, err)
+
+	key, err := client.Key("User1")
+	require.NoError(t, err)
+	assert.Equal(t, SchemeECDSA, key.Scheme)
+	assert.True(t, key.Private())
+	_, err = key.Bytes()
+	assert.Error(t, err, "the private key cannot be exported")
+	publicKey, err := key.PublicKey()
+	require.NoError(t, err)
+	publicKeyBytes, err := publicKey.Bytes()
+	require.NoError(t, err)
+	assert.NotEmpty(t, publicKeyBytes)
+
+	_, err = signingMgr.Sign(nil, key)
+	assert.Error(t, err, "Should have failed to sign nil object")
+	_, err = signingMgr.Sign([]byte("Hello"), nil)
+	assert.Error(t, err, "Should have failed to sign object with nil key")
+	_, err = signingMgr.Sign([]byte("Hello"), publicKey)
+	assert.Error(t, err, "Should have failed to sign with a public key")
+
+	signature, err := signingMgr.Sign([]byte("Hello"), key)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("Hello"))
+	assert.True(t, ecdsa.VerifyASN1(&signer.key.PublicKey, digest[:], signature), "the daemon signs the digest")
+	assert.Equal(t, key.SKI(), signer.ski, "the SKI selects the nym of a DAC key")
+}
+
+func TestSigningManagerNext(t *testing.T) {
+	dir, _, server := setupSigner(t, 0)
+	defer os.RemoveAll(dir)
+	defer server.Stop()
+
+	client := dialSigner(t, dir, "test")
+	defer client.Close()
+	next, err := signingmgr.New(&fcmocks.MockCryptoSuite{})
+	require.NoError(t, err)
+
+	signingMgr, err := New(&fcmocks.MockCryptoSuite{}, client, nil)
+	require.NoError(t, err)
+	_, err = signingMgr.Sign([]byte("Hello"), bccspwrapper.GetKey(&mockmsp.MockKey{}))
+	assert.Error(t, err, "other keys need a next signing manager")
+
+	signingMgr, err = New(&fcmocks.MockCryptoSuite{}, client, next)
+	require.NoError(t, err)
+	signature, err := signingMgr.Sign([]byte("Hello"), bccspwrapper.GetKey(&mockmsp.MockKey{}))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testSignature"), signature, "other keys are signed by the next signing manager")
+}
+
+func TestMutualAuthentication(t *testing.T) {
+	dir, _, server := setupSigner(t, 0)
+	defer os.RemoveAll(dir)
+	defer server.Stop()
+
+	_, err := Dial("unix://"+filepath.Join(dir, "signer.sock"), nil)
+	assert.Error(t, err, "a client needs a certificate")
+
+	// a client with a certificate of another CA is rejected by the daemon
+	testPKI(t, dir, "other")
+	client := dialSigner(t, dir, "other", WithTimeout(time.Second), WithRetries(0, 0))
+	defer client.Close()
+	_, err = client.Sign("User1", SchemeECDSA, []byte("digest"))
+	assert.Error(t, err)
+}
+
+func TestDaemonRestart(t *testing.T) {
+	dir, signer, server := setupSigner(t, 0)
+	defer os.RemoveAll(dir)
+
+	client := dialSigner(t, dir, "test", WithTimeout(500*time.Millisecond), WithRetries(10, 200*time.Millisecond))
+	defer client.Close()
+	_, err := client.Sign("User1", SchemeECDSA, []byte("digest"))
+	require.NoError(t, err)
+
+	server.Stop()
+	restarted := make(chan *grpc.Server, 1)
+	go func() {
+		time.Sleep(time.Second)
+		restarted <- startSigner(t, dir, signer)
+	}()
+	_, err = client.Sign("User1", SchemeECDSA, []byte("digest"))
+	assert.NoError(t, err, "the request is retried until the daemon is back")
+	(<-restarted).Stop()
+}
+
+func TestTimeout(t *testing.T) {
+	dir, _, server := setupSigner(t, time.Second)
+	defer os.RemoveAll(dir)
+	defer server.Stop()
+
+	client := dialSigner(t, dir, "test", WithTimeout(100*time.Millisecond), WithRetries(1, 0))
+	defer client.Close()
+	start := time.Now()
+	_, err := client.Sign("User1", SchemeECDSA, []byte("digest"))
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second, "the attempts time out")
+}
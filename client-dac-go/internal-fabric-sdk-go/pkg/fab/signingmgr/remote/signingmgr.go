@@ -0,0 +1,60 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+)
+
+// SigningManager signs objects with the keys of a signer daemon
+type SigningManager struct {
+	client         *Client
+	cryptoProvider core.CryptoSuite
+	hashOpts       core.HashOpts
+	next           core.SigningManager
+}
+
+// New returns a signing manager that hashes objects with the crypto provider and sends the digests to the daemon
+// of the client. Keys that are not keys of the daemon are signed by next if it is not nil
+func New(cryptoProvider core.CryptoSuite, client *Client, next core.SigningManager) (*SigningManager, error) {
+	if client == nil {
+		return nil, errors.New("signer client is required")
+	}
+	return &SigningManager{client: client, cryptoProvider: cryptoProvider, hashOpts: cryptosuite.GetSHAOpts(), next: next}, nil
+}
+
+// Sign will sign the given object using provided key
+func (mgr *SigningManager) Sign(object []byte, key core.Key) ([]byte, error) {
+
+	if len(object) == 0 {
+		return nil, errors.New("object (to sign) required")
+	}
+
+	if key == nil {
+		return nil, errors.New("key (for signing) required")
+	}
+
+	remoteKey, ok := key.(*Key)
+	if !ok {
+		if mgr.next != nil {
+			return mgr.next.Sign(object, key)
+		}
+		return nil, errors.New("key must be a key of the signer daemon")
+	}
+	if !remoteKey.Private() {
+		return nil, errors.New("key (for signing) must be private")
+	}
+
+	digest, err := mgr.cryptoProvider.Hash(object, mgr.hashOpts)
+	if err != nil {
+		return nil, err
+	}
+	return mgr.client.sign(&SignRequest{KeyID: remoteKey.ID, Scheme: remoteKey.Scheme, SKI: remoteKey.ski, Digest: digest})
+}
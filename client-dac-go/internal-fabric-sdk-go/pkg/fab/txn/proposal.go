@@ -84,6 +84,25 @@ func SendProposal(reqCtx reqContext.Context, proposal *fab.TransactionProposal,
 		return nil, errors.New("proposal is required")
 	}
 
+	ctx, ok := context.RequestClientContext(reqCtx)
+	if !ok {
+		return nil, errors.New("failed get client context from reqContext for signProposal")
+	}
+	signedProposal, err := signProposal(ctx, proposal.Proposal)
+	if err != nil {
+		return nil, errors.WithMessage(err, "sign proposal failed")
+	}
+
+	return SendSignedProposal(reqCtx, signedProposal, targets)
+}
+
+// SendSignedProposal sends a proposal that is already signed to ProposalProcessor.
+func SendSignedProposal(reqCtx reqContext.Context, signedProposal *pb.SignedProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+
+	if signedProposal == nil {
+		return nil, errors.New("proposal is required")
+	}
+
 	if len(targets) < 1 {
 		return nil, errors.New("targets is required")
 	}
@@ -96,15 +115,6 @@ func SendProposal(reqCtx reqContext.Context, proposal *fab.TransactionProposal,
 
 	targets = getTargetsWithoutDuplicates(targets)
 
-	ctx, ok := context.RequestClientContext(reqCtx)
-	if !ok {
-		return nil, errors.New("failed get client context from reqContext for signProposal")
-	}
-	signedProposal, err := signProposal(ctx, proposal.Proposal)
-	if err != nil {
-		return nil, errors.WithMessage(err, "sign proposal failed")
-	}
-
 	request := fab.ProcessProposalRequest{SignedProposal: signedProposal}
 
 	var responseMtx sync.Mutex
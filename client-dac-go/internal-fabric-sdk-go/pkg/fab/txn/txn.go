@@ -106,6 +106,21 @@ func Send(reqCtx reqContext.Context, tx *fab.Transaction, orderers []fab.Orderer
 	if len(orderers) == 0 {
 		return nil, errors.New("orderers is nil")
 	}
+	payload, err := NewPayload(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionResponse, err := BroadcastPayload(reqCtx, payload, orderers)
+	if err != nil {
+		return nil, err
+	}
+
+	return transactionResponse, nil
+}
+
+// NewPayload creates the payload of the envelope of a transaction, it has the header of the proposal.
+func NewPayload(tx *fab.Transaction) (*common.Payload, error) {
 	if tx == nil {
 		return nil, errors.New("transaction is nil")
 	}
@@ -124,15 +139,7 @@ func Send(reqCtx reqContext.Context, tx *fab.Transaction, orderers []fab.Orderer
 		return nil, err
 	}
 
-	// create the payload
-	payload := common.Payload{Header: hdr, Data: txBytes}
-
-	transactionResponse, err := BroadcastPayload(reqCtx, &payload, orderers)
-	if err != nil {
-		return nil, err
-	}
-
-	return transactionResponse, nil
+	return &common.Payload{Header: hdr, Data: txBytes}, nil
 }
 
 // BroadcastPayload will send the given payload to some orderer, picking random endpoints
@@ -152,12 +159,12 @@ func BroadcastPayload(reqCtx reqContext.Context, payload *common.Payload, ordere
 		return nil, err
 	}
 
-	return broadcastEnvelope(reqCtx, envelope, orderers)
+	return BroadcastEnvelope(reqCtx, envelope, orderers)
 }
 
-// broadcastEnvelope will send the given envelope to some orderer, picking random endpoints
+// BroadcastEnvelope will send the given signed envelope to some orderer, picking random endpoints
 // until all are exhausted
-func broadcastEnvelope(reqCtx reqContext.Context, envelope *fab.SignedEnvelope, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
+func BroadcastEnvelope(reqCtx reqContext.Context, envelope *fab.SignedEnvelope, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
 	// Check if orderers are defined
 	if len(orderers) == 0 {
 		return nil, errors.New("orderers not set")
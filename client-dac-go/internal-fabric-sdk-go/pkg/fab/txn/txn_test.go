@@ -144,7 +144,7 @@ func TestBroadcastEnvelope(t *testing.T) {
 	reqCtx, cancel := context.NewRequest(ctx, context.WithTimeout(10*time.Second))
 	defer cancel()
 
-	res, err := broadcastEnvelope(reqCtx, sigEnvelope, orderers)
+	res, err := BroadcastEnvelope(reqCtx, sigEnvelope, orderers)
 	require.NoErrorf(t, err, "Test Broadcast Envelope Failed, resp: %+v", res)
 
 	// Ensure only 1 orderer was selected for broadcast
@@ -168,7 +168,7 @@ func TestBroadcastEnvelope(t *testing.T) {
 	}
 	// It should always succeed even though one of them has failed
 	for i := 0; i < broadcastCount; i++ {
-		resp, err1 := broadcastEnvelope(reqCtx, sigEnvelope, orderers)
+		resp, err1 := BroadcastEnvelope(reqCtx, sigEnvelope, orderers)
 		require.NoErrorf(t, err1, "Test Broadcast Envelope Failed, resp: %+v", resp)
 	}
 
@@ -182,11 +182,11 @@ func checkBroadcastCount(broadcastCount int, orderer1 *mocks.MockOrderer, ordere
 		orderer2.EnqueueSendBroadcastError(errors.New("Service Unavailable"))
 	}
 	for i := 0; i < broadcastCount; i++ {
-		_, err1 := broadcastEnvelope(reqCtx, sigEnvelope, orderers)
+		_, err1 := BroadcastEnvelope(reqCtx, sigEnvelope, orderers)
 		require.Contains(t, err1.Error(), "Service Unavailable", "Test Broadcast failed but didn't return the correct reason")
 	}
 	emptyOrderers := []fab.Orderer{}
-	_, err := broadcastEnvelope(reqCtx, sigEnvelope, emptyOrderers)
+	_, err := BroadcastEnvelope(reqCtx, sigEnvelope, emptyOrderers)
 	require.Error(t, err, "Test empty orderers slice validation on broadcast envelope is not working as expected")
 	require.Equalf(t, "orderers not set", err.Error(), "Test empty orderers slice validation on broadcast envelope is not working as expected, got: \n \"%s\"", err.Error())
 }
@@ -212,17 +212,17 @@ func TestBroadcastPayloadWithOrdererDialFailure(t *testing.T) {
 	parentCtx, cancel := context.NewRequest(ctx, context.WithTimeout(5*time.Second)) // parentContext has 5 sec timeout
 	defer cancel()
 
-	_, err := broadcastEnvelope(parentCtx, sigEnvelope, orderers)
+	_, err := BroadcastEnvelope(parentCtx, sigEnvelope, orderers)
 	require.NoError(t, err, "BroadCastEnvelope to running orderers returned a connection error")
 
 	// stop orderer2 and try again (orderer1 and orderer3 should successfully connect)
 	orderer2.Stop()
-	_, err = broadcastEnvelope(parentCtx, sigEnvelope, orderers)
+	_, err = BroadcastEnvelope(parentCtx, sigEnvelope, orderers)
 	require.NoError(t, err, "BroadCastEnvelope to running orderer1 and orderer3 returned a connection error")
 
 	// stop orderer1 and try again (only orderer3 should successfully connect)
 	orderer1.Stop()
-	_, err = broadcastEnvelope(parentCtx, sigEnvelope, orderers)
+	_, err = BroadcastEnvelope(parentCtx, sigEnvelope, orderers)
 	require.NoError(t, err, "BroadCastEnvelope to running orderer3 returned a connection error")
 
 	// now try a new parent context using 1 nano second timeout to force 'context deadline exceeded'
@@ -230,7 +230,7 @@ func TestBroadcastPayloadWithOrdererDialFailure(t *testing.T) {
 	orderer2.Start()
 	parentCtx, cancel2 := context.NewRequest(ctx, context.WithTimeout(1*time.Nanosecond))
 	defer cancel2()
-	_, err = broadcastEnvelope(parentCtx, sigEnvelope, orderers)
+	_, err = BroadcastEnvelope(parentCtx, sigEnvelope, orderers)
 	require.Error(t, err, "BroadCastEnvelope to running orderers returned no error with 1 nano second context deadline")
 
 	orderer1.Stop()
@@ -59,6 +59,7 @@ type options struct {
 	ConfigBackend     []core.ConfigBackend
 	ProviderOpts      []coptions.Opt // Provider options are passed along to the various providers
 	metricsConfig     metricsCfg.MetricsConfig
+	prometheusMetrics bool
 }
 
 // Option configures the SDK.
@@ -207,6 +208,15 @@ func WithMetricsConfig(metricsConfigs ...interface{}) Option {
 	}
 }
 
+// WithPrometheusMetrics records the metrics of the channel clients in the default Prometheus registerer, for the
+// application to serve them. It only applies to the standard build, the pprof build uses the metrics configuration
+func WithPrometheusMetrics() Option {
+	return func(opts *options) error {
+		opts.prometheusMetrics = true
+		return nil
+	}
+}
+
 // WithProviderOpts adds options which are propagated to the various providers.
 func WithProviderOpts(sopts ...coptions.Opt) Option {
 	return func(opts *options) error {
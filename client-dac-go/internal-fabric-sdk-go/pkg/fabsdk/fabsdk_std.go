@@ -24,5 +24,9 @@ func (sdk *FabricSDK) initMetrics(config *configs) {
 	},
 	)
 
+	if sdk.opts.prometheusMetrics {
+		sdk.clientMetrics = metrics.PrometheusClientMetrics()
+		return
+	}
 	sdk.clientMetrics = &metrics.ClientMetrics{} // empty channel ClientMetrics for standard build.
 }
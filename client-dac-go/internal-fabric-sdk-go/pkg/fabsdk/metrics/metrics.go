@@ -6,7 +6,12 @@ SPDX-License-Identifier: Apache-2.0
 
 package metrics
 
-import "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/metrics"
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/metrics/prometheus"
+)
 
 var (
 	// for now, only channel clients require metrics tracking. TODO: update to generalize metrics for other client types if needed.
@@ -66,30 +71,62 @@ var (
 		LabelNames:   []string{"chaincode", "Fcn"},
 		StatsdFormat: "%{#fqname}.%{type}.%{channel}.%{execution}",
 	}
+	endorsementDuration = metrics.HistogramOpts{
+		Namespace:    "channel",
+		Name:         "endorsement_duration",
+		Help:         "The time for a peer to endorse a channel client proposal.",
+		LabelNames:   []string{"chaincode", "Fcn", "peer"},
+		StatsdFormat: "%{#fqname}.%{type}.%{channel}.%{endorsement}.%{peer}",
+	}
+	retries = metrics.CounterOpts{
+		Namespace:    "channel",
+		Name:         "retries",
+		Help:         "The number of times channel client queries and executions were retried.",
+		LabelNames:   []string{"chaincode", "Fcn"},
+		StatsdFormat: "%{#fqname}.%{type}.%{channel}.%{retry}",
+	}
 )
 
 // ClientMetrics contains the metrics used in the (channel) client
 type ClientMetrics struct {
-	QueriesReceived    metrics.Counter
-	QueriesFailed      metrics.Counter
-	QueryDuration      metrics.Histogram
-	QueryTimeouts      metrics.Counter
-	ExecutionsReceived metrics.Counter
-	ExecutionsFailed   metrics.Counter
-	ExecutionDuration  metrics.Histogram
-	ExecutionTimeouts  metrics.Counter
+	QueriesReceived     metrics.Counter
+	QueriesFailed       metrics.Counter
+	QueryDuration       metrics.Histogram
+	QueryTimeouts       metrics.Counter
+	ExecutionsReceived  metrics.Counter
+	ExecutionsFailed    metrics.Counter
+	ExecutionDuration   metrics.Histogram
+	ExecutionTimeouts   metrics.Counter
+	EndorsementDuration metrics.Histogram
+	Retries             metrics.Counter
 }
 
 // NewClientMetrics builds a new instance of ClientMetrics
 func NewClientMetrics(p metrics.Provider) *ClientMetrics {
 	return &ClientMetrics{
-		QueriesReceived:    p.NewCounter(queriesReceived),
-		QueriesFailed:      p.NewCounter(queriesFailed),
-		QueryDuration:      p.NewHistogram(queryDuration),
-		QueryTimeouts:      p.NewCounter(queryTimeouts),
-		ExecutionsReceived: p.NewCounter(executionsReceived),
-		ExecutionsFailed:   p.NewCounter(executionsFailed),
-		ExecutionDuration:  p.NewHistogram(executionDuration),
-		ExecutionTimeouts:  p.NewCounter(executionTimeouts),
+		QueriesReceived:     p.NewCounter(queriesReceived),
+		QueriesFailed:       p.NewCounter(queriesFailed),
+		QueryDuration:       p.NewHistogram(queryDuration),
+		QueryTimeouts:       p.NewCounter(queryTimeouts),
+		ExecutionsReceived:  p.NewCounter(executionsReceived),
+		ExecutionsFailed:    p.NewCounter(executionsFailed),
+		ExecutionDuration:   p.NewHistogram(executionDuration),
+		ExecutionTimeouts:   p.NewCounter(executionTimeouts),
+		EndorsementDuration: p.NewHistogram(endorsementDuration),
+		Retries:             p.NewCounter(retries),
 	}
 }
+
+var (
+	prometheusMetrics     *ClientMetrics
+	prometheusMetricsOnce sync.Once
+)
+
+// PrometheusClientMetrics returns client metrics registered with the default Prometheus registerer. A metric can
+// only be registered once, so every SDK instance of the process shares them
+func PrometheusClientMetrics() *ClientMetrics {
+	prometheusMetricsOnce.Do(func() {
+		prometheusMetrics = NewClientMetrics(&prometheus.Provider{})
+	})
+	return prometheusMetrics
+}
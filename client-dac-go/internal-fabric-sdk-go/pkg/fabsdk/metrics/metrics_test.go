@@ -0,0 +1,32 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusClientMetrics(t *testing.T) {
+	m := PrometheusClientMetrics()
+	assert.True(t, m == PrometheusClientMetrics(), "the metrics are registered once")
+
+	m.EndorsementDuration.With("chaincode", "cc", "Fcn", "invoke", "peer", "peer0.org1.example.com:7051").Observe(0.1)
+	m.Retries.With("chaincode", "cc", "Fcn", "invoke").Add(1)
+
+	families, err := prom.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	assert.True(t, names["channel_endorsement_duration"])
+	assert.True(t, names["channel_retries"])
+}
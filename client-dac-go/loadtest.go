@@ -0,0 +1,413 @@
+package main
+
+import (
+	"client-loadgen/loadstats"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/ckiere/test-network/client-dac-go/dacidentity"
+	"github.com/dbogatov/dac-lib/dac"
+	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The phases of a bidder of the load test. The identity is delegated by the authority, the credential proof
+// is the proof of the first nym, generated by UpdateNymIdentity
+const (
+	phaseIdentity   = "identity"
+	phaseCredential = "credential"
+	phaseCommit     = "commit"
+	phaseReveal     = "reveal"
+)
+
+var loadPhases = []string{phaseIdentity, phaseCredential, phaseCommit, phaseReveal}
+
+// loadBidder is a simulated bidder with an identity that only exists in memory
+type loadBidder struct {
+	id        int
+	user      *dacidentity.User
+	client    *channel.Client
+	price     int
+	r         *big.Int
+	com       []byte
+	txID      string
+	latencies map[string]time.Duration
+	errors    map[string]error
+}
+
+// phaseReport sums up one phase over the bidders. The latencies are those of the successful bidders, from
+// the proposal to the commit event for the commit and reveal phases
+type phaseReport struct {
+	Phase      string              `json:"phase"`
+	Started    int                 `json:"started"`
+	Succeeded  int                 `json:"succeeded"`
+	Failures   map[string]int      `json:"failures"`
+	ElapsedMs  float64             `json:"elapsedMs"`
+	Throughput float64             `json:"throughput"`
+	LatencyMs  loadstats.Latencies `json:"latencyMs"`
+}
+
+// loadTestReport is the result of a load test, the bidders are written to the CSV report one per line
+type loadTestReport struct {
+	AuctionID  string         `json:"auctionID"`
+	Bidders    int            `json:"bidders"`
+	CommitRate float64        `json:"commitRate"`
+	RevealRate float64        `json:"revealRate"`
+	Phases     []*phaseReport `json:"phases"`
+	bidders    []*loadBidder
+}
+
+// runLoadTest creates bidders with identities delegated by one authority and runs them through the commit
+// and reveal phases of a sealed-bid auction. The bidders arrive at a rate per second in each phase, the
+// reveal phase starts when the seller closes the auction
+func runLoadTest(args []string) {
+	flags := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	authName := flags.String("authority", "", "authority that delegates the identities of the bidders, as created by createauthority")
+	auctionID := flags.String("auction", "", "open sealed-bid auction the bidders bid in")
+	peers := flags.String("peers", "localhost:7051,localhost:9051", "comma separated endorsing peers")
+	bidders := flags.Int("bidders", 100, "number of bidders")
+	commitRate := flags.Float64("rate", 10, "bidders arriving per second in the commit phase, 0 starts them all at once")
+	revealRate := flags.Float64("reveal-rate", 0, "bidders revealing per second, 0 uses -rate")
+	minPrice := flags.Int("min-price", 1, "lowest bid price")
+	maxPrice := flags.Int("max-price", 1000, "highest bid price")
+	wait := flags.Duration("wait", 10*time.Minute, "time to wait for the seller to close the auction before the reveal phase")
+	retryTx := flags.Bool("retry", false, "retry failed transactions with the default options of the SDK, conflicts are then hidden")
+	jsonOut := flags.String("json", "", "write the report as JSON to this file")
+	csvOut := flags.String("csv", "", "write the phases of every bidder as CSV to this file")
+	flags.Parse(args)
+
+	if *authName == "" || *auctionID == "" {
+		log.Fatalf("the authority and the auction are required")
+	}
+	if *bidders < 1 || *commitRate < 0 || *revealRate < 0 || *minPrice < 1 || *maxPrice < *minPrice {
+		log.Fatalf("invalid load parameters")
+	}
+	if *revealRate == 0 {
+		*revealRate = *commitRate
+	}
+	endpoints := strings.Split(*peers, ",")
+	options := []channel.RequestOption{channel.WithTargetEndpoints(endpoints...)}
+	if *retryTx {
+		options = append(options, channel.WithRetry(retry.DefaultChannelOpts))
+	}
+
+	report := &loadTestReport{AuctionID: *auctionID, Bidders: *bidders, CommitRate: *commitRate, RevealRate: *revealRate}
+	fmt.Printf("Creating %v identities\n", *bidders)
+	report.bidders = createLoadBidders(*authName, *bidders, *minPrice, *maxPrice)
+
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer sdk.Close()
+	for _, bidder := range report.bidders {
+		bidder.client, err = channel.New(sdk.ChannelContext(channelName, fabsdk.WithIdentity(bidder.user)), channelOptions()...)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	query := report.bidders[0].client
+
+	auction := querySealedAuction(query, *auctionID, endpoints)
+	if auction.Status != "open" {
+		log.Fatalf("auction %v is not open", *auctionID)
+	}
+	if auction.Deposit > 0 {
+		log.Fatalf("auction %v requires a deposit, the load test has no deposit vouchers", *auctionID)
+	}
+
+	fmt.Printf("Committing %v bids\n", *bidders)
+	commitElapsed := arrive(report.bidders, *commitRate, func(bidder *loadBidder) bool {
+		return true
+	}, func(bidder *loadBidder) {
+		bidder.commit(*auctionID, auction.OneBidPerCredential, options)
+	})
+
+	fmt.Println("Waiting for the auction to be closed")
+	deadline := time.Now().Add(*wait)
+	for auction.Status == "open" && time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+		auction = querySealedAuction(query, *auctionID, endpoints)
+	}
+
+	var revealElapsed time.Duration
+	if auction.Status == "closed" {
+		auctioneerPk := queryAuctioneerPk(query, *auctionID, endpoints)
+		fmt.Println("Revealing the committed bids")
+		revealElapsed = arrive(report.bidders, *revealRate, func(bidder *loadBidder) bool {
+			return bidder.errors[phaseCommit] == nil
+		}, func(bidder *loadBidder) {
+			bidder.reveal(*auctionID, auctioneerPk, options)
+		})
+	} else {
+		fmt.Printf("Auction is %v, the bids are not revealed\n", auction.Status)
+	}
+
+	elapsed := map[string]time.Duration{phaseCommit: commitElapsed, phaseReveal: revealElapsed}
+	for _, phase := range loadPhases {
+		report.Phases = append(report.Phases, summarizePhase(phase, report.bidders, elapsed[phase]))
+	}
+	report.print()
+	if *jsonOut != "" {
+		reportBytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		err = ioutil.WriteFile(*jsonOut, reportBytes, 0644)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if *csvOut != "" {
+		report.writeCSV(*csvOut)
+	}
+}
+
+// createLoadBidders delegates an identity to every bidder from the authority and creates its first nym, on
+// every CPU in parallel. The times are recorded in the identity and credential phases
+func createLoadBidders(authName string, n, minPrice, maxPrice int) []*loadBidder {
+	configBytes, err := ioutil.ReadFile(configFileName)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	dacConfig, err := dacidentity.CreateConfigFromBytes(configBytes)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	ys, err := dacConfig.Ys()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	authConfigBytes, err := ioutil.ReadFile(authName + ".json")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	var authConfig dacidentity.CredentialsConfig
+	err = json.Unmarshal(authConfigBytes, &authConfig)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	bidders := make([]*loadBidder, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prg := dacidentity.NewRand()
+			for i := range jobs {
+				bidder := &loadBidder{id: i, price: minPrice + rand.Intn(maxPrice-minPrice+1),
+					latencies: make(map[string]time.Duration), errors: make(map[string]error)}
+
+				// Delegate extends the credentials it is called on, every identity starts from a fresh copy
+				start := time.Now()
+				authCreds := *dac.CredentialsFromBytes(authConfig.CredentialsBytes)
+				idConfig := createIdentity(authCreds, FP256BN.FromBytes(authConfig.SkBytes), prg, ys)
+				bidder.latencies[phaseIdentity] = time.Since(start)
+
+				start = time.Now()
+				user, err := dacidentity.CreateUser(*dacConfig, idConfig, "loadtest"+strconv.Itoa(i), "DacMSP")
+				if err != nil {
+					log.Fatalf("%v", err)
+				}
+				bidder.user = user
+				bidder.latencies[phaseCredential] = time.Since(start)
+				bidders[i] = bidder
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return bidders
+}
+
+// arrive starts f for the bidders selected by include, at most rate per second if rate is positive, and
+// returns when all of them are done
+func arrive(bidders []*loadBidder, rate float64, include func(bidder *loadBidder) bool, f func(bidder *loadBidder)) time.Duration {
+	var tick <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, bidder := range bidders {
+		if !include(bidder) {
+			continue
+		}
+		if tick != nil {
+			<-tick
+		}
+		wg.Add(1)
+		go func(bidder *loadBidder) {
+			defer wg.Done()
+			f(bidder)
+		}(bidder)
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// commit sends the commitment of the bid of the bidder, with the proof of uniqueness of the credential if
+// the auction accepts one bid per credential. The time of the proofs is part of the latency
+func (b *loadBidder) commit(auctionID string, oneBidPerCredential bool, options []channel.RequestOption) {
+	start := time.Now()
+	com, r, err := commitment.Commit(b.price)
+	if err != nil {
+		b.errors[phaseCommit] = err
+		return
+	}
+	comBytes := wire.MarshalCommitment(com)
+	proof, err := proveCommit("commit", b.price, r, comBytes, nil)
+	if err != nil {
+		b.errors[phaseCommit] = err
+		return
+	}
+	comBase64 := base64.StdEncoding.EncodeToString(comBytes)
+	proofBase64 := base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(proof))
+
+	request := channel.Request{ChaincodeID: chaincodeID, Fcn: "SendCommitment", Args: [][]byte{[]byte(auctionID),
+		[]byte(comBase64), []byte(proofBase64)}, TransientMap: escrowTransientMap(b.user)}
+	if oneBidPerCredential {
+		tag, tagProof := b.user.ScopeTag(auctionID, comBytes)
+		request.Fcn = "SendUniqueCommitment"
+		request.Args = append(request.Args, []byte(base64.StdEncoding.EncodeToString(tag)),
+			[]byte(base64.StdEncoding.EncodeToString(tagProof)))
+	}
+	response, err := b.client.Execute(request, options...)
+	b.latencies[phaseCommit] = time.Since(start)
+	if err != nil {
+		b.errors[phaseCommit] = err
+		return
+	}
+	b.r, b.com, b.txID = r, comBytes, string(response.Payload)
+}
+
+// reveal sends the bid of the bidder encrypted for the seller, the time of the encryption and of the proof
+// is part of the latency
+func (b *loadBidder) reveal(auctionID string, auctioneerPk *[32]byte, options []channel.RequestOption) {
+	start := time.Now()
+	encryptedBid, err := commitment.Encrypt(b.price, b.r, auctioneerPk)
+	if err != nil {
+		b.errors[phaseReveal] = err
+		return
+	}
+	proof, err := proveCommit("reveal", b.price, b.r, b.com, encryptedBid)
+	if err != nil {
+		b.errors[phaseReveal] = err
+		return
+	}
+	encryptedBidBase64 := base64.StdEncoding.EncodeToString(encryptedBid)
+	proofBase64 := base64.StdEncoding.EncodeToString(wire.MarshalOpeningProof(proof))
+	_, err = b.client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "RevealBid", Args: [][]byte{[]byte(auctionID),
+		[]byte(b.txID), []byte(""), []byte(encryptedBidBase64), []byte(proofBase64)}}, options...)
+	b.latencies[phaseReveal] = time.Since(start)
+	if err != nil {
+		b.errors[phaseReveal] = err
+	}
+}
+
+// summarizePhase sums up a phase over the bidders that started it. The identity and credential phases run
+// in parallel, their throughput is not reported
+func summarizePhase(phase string, bidders []*loadBidder, elapsed time.Duration) *phaseReport {
+	report := &phaseReport{Phase: phase, Failures: make(map[string]int), ElapsedMs: loadstats.ElapsedMs(elapsed)}
+	var samples []time.Duration
+	for _, bidder := range bidders {
+		latency, started := bidder.latencies[phase]
+		err := bidder.errors[phase]
+		if !started && err == nil {
+			continue
+		}
+		report.Started++
+		if err != nil {
+			report.Failures[loadstats.FailureClass(err)]++
+		} else {
+			report.Succeeded++
+			samples = append(samples, latency)
+		}
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Succeeded) / elapsed.Seconds()
+	}
+	report.LatencyMs = loadstats.Summarize(samples)
+	return report
+}
+
+// print prints the phases as a table, followed by the failures of each phase by class
+func (r *loadTestReport) print() {
+	fmt.Printf("%-12v %8v %8v %10v %10v %10v %10v %10v %10v\n", "phase", "started", "ok", "tx/s", "mean ms",
+		"p50 ms", "p90 ms", "p99 ms", "max ms")
+	for _, p := range r.Phases {
+		l := p.LatencyMs
+		fmt.Printf("%-12v %8v %8v %10.1f %10.1f %10.1f %10.1f %10.1f %10.1f\n", p.Phase, p.Started, p.Succeeded,
+			p.Throughput, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	}
+	for _, p := range r.Phases {
+		var classes []string
+		for class := range p.Failures {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Printf("%v failed, %v: %v\n", p.Phase, class, p.Failures[class])
+		}
+	}
+}
+
+// writeCSV writes a line per bidder with the latency in milliseconds and the failure class of every phase,
+// the latency is empty if the bidder did not reach the phase
+func (r *loadTestReport) writeCSV(fileName string) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	header := []string{"bidder", "price", "txID"}
+	for _, phase := range loadPhases {
+		header = append(header, phase+"Ms", phase+"Failure")
+	}
+	w.Write(header)
+	for _, bidder := range r.bidders {
+		record := []string{strconv.Itoa(bidder.id), strconv.Itoa(bidder.price), bidder.txID}
+		for _, phase := range loadPhases {
+			latency, started := bidder.latencies[phase]
+			ms, failure := "", ""
+			if started {
+				ms = strconv.FormatFloat(loadstats.ElapsedMs(latency), 'f', 3, 64)
+			}
+			if err := bidder.errors[phase]; err != nil {
+				failure = loadstats.FailureClass(err)
+			}
+			record = append(record, ms, failure)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
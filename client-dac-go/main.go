@@ -1,22 +1,15 @@
 package main
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"github.com/ckiere/test-network/client-dac-go/crypto"
 	"github.com/ckiere/test-network/client-dac-go/dacidentity"
 	"github.com/dbogatov/dac-lib/dac"
 	"github.com/dbogatov/fabric-amcl/amcl"
 	"github.com/dbogatov/fabric-amcl/amcl/FP256BN"
-	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
-	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
-	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
 	"io/ioutil"
 	"os"
 	"strconv"
-	"time"
 )
 
 const configFileName = "DacConfig.json"
@@ -25,6 +18,8 @@ const channelName = "auction"
 const chaincodeID = "blindauction"
 
 func main() {
+	os.Args = connectSigner(startTracing(serveMetrics(os.Args)))
+	defer stopTracing()
 	argc := len(os.Args)
 
 	if argc > 1 {
@@ -43,17 +38,94 @@ func main() {
 			} else {
 				fmt.Println("Wrong number of arguments")
 			}
-		} else if cmd == "client" {
+		} else if cmd == "bid" {
 			if argc > 5 {
 				price, err := strconv.Atoi(os.Args[4])
 				if err == nil && price > 0 {
-					launchClient(os.Args[2], os.Args[3], price, os.Args[5:])
+					placeBid(os.Args[2], os.Args[3], price, os.Args[5:])
 				} else {
 					fmt.Println("Invalid price")
 				}
 			} else {
 				fmt.Println("Wrong number of arguments")
 			}
+		} else if cmd == "reveal" || cmd == "claim" {
+			if argc > 4 {
+				if cmd == "reveal" {
+					revealBids(os.Args[2], os.Args[3], os.Args[4:])
+				} else {
+					claimBid(os.Args[2], os.Args[3], os.Args[4:])
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "status" {
+			if argc > 3 {
+				printBidStatus(os.Args[2], os.Args[3:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "createauditor" {
+			createAuditorFiles()
+		} else if cmd == "deanonymize" {
+			if argc > 5 {
+				deanonymize(os.Args[2], os.Args[3], os.Args[4], os.Args[5:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "accept" || cmd == "raise" {
+			if argc > 5 {
+				maxPrice, err := strconv.Atoi(os.Args[4])
+				if err == nil && maxPrice > 0 {
+					if cmd == "accept" {
+						launchDutchBidder(os.Args[2], os.Args[3], maxPrice, os.Args[5:])
+					} else {
+						launchEnglishBidder(os.Args[2], os.Args[3], maxPrice, os.Args[5:])
+					}
+				} else {
+					fmt.Println("Invalid price")
+				}
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "exportnym" {
+			if argc == 3 {
+				exportNym(os.Args[2])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "sign" {
+			if argc == 4 {
+				signFile(os.Args[2], os.Args[3])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "propose" {
+			if argc > 4 {
+				proposeOffline(os.Args[2], os.Args[3], os.Args[4], os.Args[5:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "endorse" {
+			if argc > 4 {
+				endorseOffline(os.Args[2], os.Args[3], os.Args[4:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "submit" {
+			if argc == 4 {
+				submitOffline(os.Args[2], os.Args[3])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "remoteinvoke" {
+			if argc >= 4 {
+				remoteInvoke(os.Args[2], os.Args[3], os.Args[4:])
+			} else {
+				fmt.Println("Wrong number of arguments")
+			}
+		} else if cmd == "loadtest" {
+			runLoadTest(os.Args[2:])
 		} else {
 			fmt.Println("Unknown command")
 		}
@@ -62,83 +134,10 @@ func main() {
 	}
 }
 
-func launchClient(username string, auctionID string, price int, endpoints []string) {
-	configBytes, _ := ioutil.ReadFile(configFileName)
-	dacConfig, err := dacidentity.CreateConfigFromBytes(configBytes)
-	userConfigBytes, _ := ioutil.ReadFile(username + ".json")
-	var userConfig dacidentity.CredentialsConfig
-	json.Unmarshal(userConfigBytes, &userConfig)
-	user, err := dacidentity.CreateUser(*dacConfig, userConfig, username, "DacMSP")
-
-	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), fabsdk.WithCorePkg(dacidentity.NewProviderFactory()))
-	if err != nil {
-		panic(err)
-	}
-
-	dacClientChannelContext := sdk.ChannelContext(channelName, fabsdk.WithIdentity(user))
-	client, err := channel.New(dacClientChannelContext)
-	if err != nil {
-		panic(err)
-	}
-
-	// get the auctioneer public key to encrypt the bid
-	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryAuctioneerPk", Args: [][]byte{[]byte(auctionID)}},
-		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
-	if err != nil {
-		panic(err)
-	}
-
-	var auctioneerPk [32]byte
-	auctioneerPkBytes := make([]byte, base64.StdEncoding.DecodedLen(len(response.Payload)))
-	_, err = base64.StdEncoding.Decode(auctioneerPkBytes, response.Payload)
-	if err != nil {
-		panic(err)
-	}
-	copy(auctioneerPk[:], auctioneerPkBytes)
-
-	// commit to a bid and prove knowledge of opening values
-	com, r, err := crypto.Commit(price)
-	if err != nil {
-		panic(err)
-	}
-	comBytes := com.Marshal()
-	t, s1, s2, err := crypto.ProveCommit(price, r, comBytes, nil)
-	if err != nil {
-		panic(err)
-	}
-	proofBytes := crypto.CommitProofToBytes(t, s1, s2)
-
-	comBase64 := base64.StdEncoding.EncodeToString(comBytes)
-	proofBase64 := base64.StdEncoding.EncodeToString(proofBytes)
-	response, _ = client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "SendCommitment", Args: [][]byte{[]byte(auctionID), []byte(comBase64), []byte(proofBase64)}},
-		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
-	txID := response.Payload
-
-	// renew the nym identity
-	//user.UpdateNymIdentity()
-
-	// pause to wait for the second phase of the auction
-	time.Sleep(time.Duration(30) * time.Second)
-
-	// encrypt the bid
-	encryptedBid, err := crypto.Encrypt(price, r, &auctioneerPk)
-	if err != nil {
-		panic(err)
-	}
-
-	// generate proof of knowledge of opening values
-	t, s1, s2, err = crypto.ProveCommit(price, r, comBytes, encryptedBid)
-	if err != nil {
-		panic(err)
-	}
-	proof2Bytes := crypto.CommitProofToBytes(t, s1, s2)
-
-	// reveal the encrypted bid
-	encryptedBidBase64 := base64.StdEncoding.EncodeToString(encryptedBid)
-	proof2Base64 := base64.StdEncoding.EncodeToString(proof2Bytes)
-	client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "RevealBid", Args: [][]byte{[]byte(auctionID), txID,
-		[]byte(""), []byte(encryptedBidBase64), []byte(proof2Base64)}},
-		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+// auctionRules are the optional rules of a sealed-bid auction that change how bids are sent
+type auctionRules struct {
+	OneBidPerCredential bool `json:"oneBidPerCredential"`
+	Deposit             int  `json:"deposit"`
 }
 
 func createConfigFiles() {
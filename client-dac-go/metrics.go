@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+)
+
+// metricsEnabled is set by the --metrics-addr option, the SDK then records the metrics of the channel clients
+var metricsEnabled bool
+
+// commitmentProofDuration is the time to prove the knowledge of the opening values of a commitment, when the bid
+// is committed and when it is revealed
+var commitmentProofDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "bidder",
+	Name:      "commitment_proof_duration_seconds",
+	Help:      "The time to generate a proof of knowledge of the opening values of a commitment.",
+	Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+}, []string{"phase"})
+
+func init() {
+	prometheus.MustRegister(commitmentProofDuration)
+}
+
+// serveMetrics serves the metrics over HTTP at /metrics if the arguments before the command contain
+// --metrics-addr, and returns the arguments without the option. The address is the one to listen on, such as :9443
+func serveMetrics(args []string) []string {
+	addr, args := cutOption(args, "--metrics-addr")
+	if addr == "" {
+		return args
+	}
+
+	// listen before the command runs so that a wrong address fails at once
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		log.Fatal(http.Serve(listener, mux))
+	}()
+	metricsEnabled = true
+	return args
+}
+
+// sdkOptions returns the options of the SDK of the DAC identities
+func sdkOptions() []fabsdk.Option {
+	options := []fabsdk.Option{fabsdk.WithCorePkg(providerFactory())}
+	if metricsEnabled {
+		options = append(options, fabsdk.WithPrometheusMetrics())
+	}
+	return options
+}
+
+// proveCommit proves the knowledge of the opening values of a commitment and records the time of the proof
+// in the phase of the bid, commit or reveal
+func proveCommit(phase string, price int, r *big.Int, comBytes, encryptedBid []byte) (*wire.OpeningProof, error) {
+	start := time.Now()
+	proof, err := commitment.ProveCommit(price, r, comBytes, encryptedBid)
+	if err == nil {
+		commitmentProofDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+	}
+	return proof, err
+}
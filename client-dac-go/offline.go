@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/dacidentity"
+	"github.com/ckiere/test-network/client-dac-go/offlinesign"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"io/ioutil"
+	"os"
+)
+
+// The files of an offline signer are named after the signing user, the nyms file is secret and stays with the
+// signer while the other files are carried between the signer and the connected machine
+const nymsFileSuffix = ".nyms.json"
+const creatorFileSuffix = ".creator.json"
+const proposalFileSuffix = offlinesign.ProposalSuffix
+const payloadFileSuffix = offlinesign.PayloadSuffix
+const signatureFileSuffix = offlinesign.SignatureSuffix
+
+// creatorConfig is the public identity transactions are created for, with the transient data of its proposals
+type creatorConfig struct {
+	Creator   []byte            `json:"creator"`
+	Transient map[string][]byte `json:"transient,omitempty"`
+}
+
+// offlineNym is a nym exported for one offline transaction, TxID is the transaction whose proposal it signed
+type offlineNym struct {
+	Nym  dacidentity.NymConfig `json:"nym"`
+	TxID string                `json:"txId,omitempty"`
+}
+
+// exportNym creates a new nym of the DAC identity of the user on the signer, each nym is used for one transaction
+// so that the transactions of the user cannot be linked. The keys of the nym are added to the nyms file to sign
+// later, and the serialized nym with its identity escrow is written to the creator file
+func exportNym(username string) {
+	user := newDacUser(username)
+	creator, err := user.Serialize()
+	if err != nil {
+		panic(err)
+	}
+	nyms := readNyms(username)
+	nyms[creatorHash(creator)] = &offlineNym{Nym: user.NymConfig()}
+	writeNyms(username, nyms)
+
+	creatorBytes, err := json.Marshal(creatorConfig{Creator: creator, Transient: escrowTransientMap(user)})
+	if err != nil {
+		panic(err)
+	}
+	writeFile(username+creatorFileSuffix, creatorBytes, 0644)
+}
+
+// signFile signs a proposal or transaction payload with the nym of the user it was created for and writes the
+// signature next to it. The object is decoded and shown before signing, a nym signs the proposal of a single
+// transaction and then its payload, after which it is deleted
+func signFile(username, fileName string) {
+	decoded, isProposal, err := offlinesign.Decode(fileName, readFile(fileName))
+	if err != nil {
+		panic(err)
+	}
+	nyms := readNyms(username)
+	hash := creatorHash(decoded.Creator)
+	nym, ok := nyms[hash]
+	if !ok {
+		panic(fmt.Errorf("%v is not created for an unused nym of %v, run exportnym for a new nym", fileName, username))
+	}
+	txID := string(decoded.TxnID)
+	if isProposal && nym.TxID != "" && nym.TxID != txID {
+		panic(fmt.Errorf("the nym already signed the proposal of transaction %v, run exportnym for a new nym", nym.TxID))
+	}
+	if !isProposal && nym.TxID != txID {
+		panic(fmt.Errorf("the proposal of transaction %v was not signed with this nym", txID))
+	}
+	if err := offlinesign.Confirm(decoded, os.Stdin, os.Stdout); err != nil {
+		panic(err)
+	}
+
+	user := newDacUser(username)
+	if err := user.RestoreNym(nym.Nym); err != nil {
+		panic(err)
+	}
+	cryptoSuite, err := sw.GetSuiteWithDefaultEphemeral()
+	if err != nil {
+		panic(err)
+	}
+	signingManager, err := dacidentity.New(cryptoSuite)
+	if err != nil {
+		panic(err)
+	}
+	if isProposal {
+		// record the transaction before signing so that the nym never signs two proposals
+		nym.TxID = txID
+	} else {
+		delete(nyms, hash)
+	}
+	writeNyms(username, nyms)
+	signature, err := signingManager.Sign(readFile(fileName), user.PrivateKey())
+	if err != nil {
+		panic(err)
+	}
+	writeFile(fileName+signatureFileSuffix, signature, 0644)
+}
+
+// readNyms reads the unused nyms of the user, by hash of their serialized identity
+func readNyms(username string) map[string]*offlineNym {
+	nyms := make(map[string]*offlineNym)
+	content, err := ioutil.ReadFile(username + nymsFileSuffix)
+	if os.IsNotExist(err) {
+		return nyms
+	} else if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(content, &nyms); err != nil {
+		panic(err)
+	}
+	return nyms
+}
+
+func writeNyms(username string, nyms map[string]*offlineNym) {
+	nymsBytes, err := json.Marshal(nyms)
+	if err != nil {
+		panic(err)
+	}
+	writeFile(username+nymsFileSuffix, nymsBytes, 0600)
+}
+
+func creatorHash(creator []byte) string {
+	hash := sha256.Sum256(creator)
+	return hex.EncodeToString(hash[:])
+}
+
+// proposeOffline creates the proposal of a chaincode function for the creator of the user, the client only
+// connects to the network
+func proposeOffline(clientName, username, fcn string, args []string) {
+	var creator creatorConfig
+	if err := json.Unmarshal(readFile(username+creatorFileSuffix), &creator); err != nil {
+		panic(err)
+	}
+	argsBytes := make([][]byte, len(args))
+	for i, arg := range args {
+		argsBytes[i] = []byte(arg)
+	}
+
+	client, _ := newDacChannelClient(clientName)
+	proposal, err := client.CreateProposal(channel.Request{ChaincodeID: chaincodeID, Fcn: fcn, Args: argsBytes,
+		TransientMap: creator.Transient}, creator.Creator)
+	if err != nil {
+		panic(err)
+	}
+	writeFile(username+proposalFileSuffix, proposal, 0644)
+}
+
+// endorseOffline sends the signed proposal of the user to the endorsers and writes the payload of the transaction
+// for the user to sign
+func endorseOffline(clientName, username string, endpoints []string) {
+	proposal := readFile(username + proposalFileSuffix)
+	signature := readFile(username + proposalFileSuffix + signatureFileSuffix)
+
+	client, _ := newDacChannelClient(clientName)
+	response, err := client.EndorseSignedProposal(proposal, signature,
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	payload, err := client.CreateTransactionPayload(response)
+	if err != nil {
+		panic(err)
+	}
+	writeFile(username+payloadFileSuffix, payload, 0644)
+	fmt.Printf("Endorsed transaction %v: %s\n", response.TransactionID, response.Payload)
+}
+
+// submitOffline sends the signed transaction of the user to the orderer and waits until it is committed
+func submitOffline(clientName, username string) {
+	payload := readFile(username + payloadFileSuffix)
+	signature := readFile(username + payloadFileSuffix + signatureFileSuffix)
+
+	client, _ := newDacChannelClient(clientName)
+	response, err := client.SubmitSignedTransaction(payload, signature)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Committed transaction %v\n", response.TransactionID)
+}
+
+func readFile(fileName string) []byte {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		panic(err)
+	}
+	return content
+}
+
+func writeFile(fileName string, content []byte, perm os.FileMode) {
+	if err := ioutil.WriteFile(fileName, content, perm); err != nil {
+		panic(err)
+	}
+}
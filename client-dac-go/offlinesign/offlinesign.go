@@ -0,0 +1,68 @@
+package offlinesign
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The files of the offline flow are named after the signing user, the signature is written next to the signed file
+const (
+	ProposalSuffix  = ".proposal"
+	PayloadSuffix   = ".payload"
+	SignatureSuffix = ".sig"
+)
+
+// ErrDeclined is returned when the operator of the signer does not confirm the signature
+var ErrDeclined = errors.New("signature declined")
+
+// Decode decodes a proposal or transaction payload of the offline flow, the kind of object is given by the suffix
+// of its file. isProposal tells which one was decoded
+func Decode(fileName string, object []byte) (decoded *channel.OfflineObject, isProposal bool, err error) {
+	switch {
+	case strings.HasSuffix(fileName, ProposalSuffix):
+		decoded, err = channel.DecodeProposal(object)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid proposal %v: %v", fileName, err)
+		}
+		return decoded, true, nil
+	case strings.HasSuffix(fileName, PayloadSuffix):
+		decoded, err = channel.DecodeTransactionPayload(object)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid transaction payload %v: %v", fileName, err)
+		}
+		return decoded, false, nil
+	}
+	return nil, false, fmt.Errorf("%v is neither a %v nor a %v file", fileName, ProposalSuffix, PayloadSuffix)
+}
+
+// Confirm prints the chaincode invocation of a decoded object and asks the operator of the signer to confirm it
+func Confirm(decoded *channel.OfflineObject, in io.Reader, out io.Writer) error {
+	request := decoded.Request
+	fmt.Fprintf(out, "Transaction %v\nChaincode: %v\nFunction: %v\n", decoded.TxnID, request.ChaincodeID, request.Fcn)
+	for i, arg := range request.Args {
+		fmt.Fprintf(out, "Arg %v: %q\n", i, arg)
+	}
+	keys := make([]string, 0, len(request.TransientMap))
+	for key := range request.TransientMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "Transient %v: %v bytes\n", key, len(request.TransientMap[key]))
+	}
+
+	fmt.Fprint(out, "Sign? [y/N] ")
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return ErrDeclined
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/dacidentity"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"io/ioutil"
+	"strconv"
+	"time"
+)
+
+// openAuction contains the public fields of an open-outcry auction
+type openAuction struct {
+	AuctionType string `json:"auctionType"`
+	WinningBid  string `json:"winningBid"`
+	Price       int    `json:"price"`
+	Status      string `json:"status"`
+}
+
+// launchDutchBidder waits until the price of a Dutch auction drops to maxPrice and accepts it
+func launchDutchBidder(username, auctionID string, maxPrice int, endpoints []string) {
+	client, user := newDacChannelClient(username)
+
+	for {
+		auction := queryOpenAuction(client, auctionID, endpoints)
+		if auction.Status != "open" {
+			fmt.Println("Auction is not open anymore")
+			return
+		}
+		price := queryCurrentPrice(client, auctionID, endpoints)
+		if price <= maxPrice {
+			response, err := client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "AcceptPrice",
+				Args: [][]byte{[]byte(auctionID), []byte(strconv.Itoa(maxPrice))}, TransientMap: escrowTransientMap(user)},
+				channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+			if err != nil {
+				fmt.Printf("failed to accept price: %v\n", err)
+				return
+			}
+			fmt.Printf("Accepted price %v, bid %s\n", price, response.Payload)
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// launchEnglishBidder places the minimum bid in an English auction every time it is outbid, until the next
+// bid would be higher than maxPrice or the auction ends
+func launchEnglishBidder(username, auctionID string, maxPrice int, endpoints []string) {
+	client, user := newDacChannelClient(username)
+
+	lastBid := ""
+	for {
+		auction := queryOpenAuction(client, auctionID, endpoints)
+		if auction.Status != "open" {
+			if auction.WinningBid != "" && auction.WinningBid == lastBid {
+				fmt.Printf("Won the auction at price %v\n", auction.Price)
+			} else {
+				fmt.Println("Auction ended")
+			}
+			return
+		}
+		if auction.WinningBid == "" || auction.WinningBid != lastBid {
+			price := queryCurrentPrice(client, auctionID, endpoints)
+			if price > maxPrice {
+				fmt.Printf("Next bid %v is higher than the maximum price\n", price)
+				return
+			}
+			response, err := client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: "PlaceBid",
+				Args: [][]byte{[]byte(auctionID), []byte(strconv.Itoa(price))}, TransientMap: escrowTransientMap(user)},
+				channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+			if err != nil {
+				// another bidder was faster, try again with the new price
+				fmt.Printf("failed to place bid: %v\n", err)
+			} else {
+				lastBid = string(response.Payload)
+				fmt.Printf("Placed bid %v at price %v\n", lastBid, price)
+			}
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// newDacChannelClient creates a channel client using the DAC identity of the user
+func newDacChannelClient(username string) (*channel.Client, *dacidentity.User) {
+	user := newDacUser(username)
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+
+	dacClientChannelContext := sdk.ChannelContext(channelName, fabsdk.WithIdentity(user))
+	client, err := channel.New(dacClientChannelContext, channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	return client, user
+}
+
+// newDacUser loads the DAC identity of the user with a new nym
+func newDacUser(username string) *dacidentity.User {
+	configBytes, _ := ioutil.ReadFile(configFileName)
+	dacConfig, err := dacidentity.CreateConfigFromBytes(configBytes)
+	if err != nil {
+		panic(err)
+	}
+	userConfigBytes, _ := ioutil.ReadFile(username + ".json")
+	var userConfig dacidentity.CredentialsConfig
+	json.Unmarshal(userConfigBytes, &userConfig)
+	user, err := dacidentity.CreateUser(*dacConfig, userConfig, username, "DacMSP")
+	if err != nil {
+		panic(err)
+	}
+	return user
+}
+
+func queryOpenAuction(client *channel.Client, auctionID string, endpoints []string) openAuction {
+	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryAuction", Args: [][]byte{[]byte(auctionID)}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	var auction openAuction
+	err = json.Unmarshal(response.Payload, &auction)
+	if err != nil {
+		panic(err)
+	}
+	return auction
+}
+
+func queryCurrentPrice(client *channel.Client, auctionID string, endpoints []string) int {
+	response, err := client.Query(channel.Request{ChaincodeID: chaincodeID, Fcn: "QueryCurrentPrice", Args: [][]byte{[]byte(auctionID)}},
+		channel.WithRetry(retry.DefaultChannelOpts), channel.WithTargetEndpoints(endpoints...))
+	if err != nil {
+		panic(err)
+	}
+	price, err := strconv.Atoi(string(response.Payload))
+	if err != nil {
+		panic(err)
+	}
+	return price
+}
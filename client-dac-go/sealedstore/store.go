@@ -0,0 +1,124 @@
+package sealedstore
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"time"
+)
+
+var (
+	metaBucket = []byte("meta")
+	saltKey    = []byte("salt")
+	checkKey   = []byte("check")
+	formatKey  = []byte("format")
+)
+
+// format is the version of the sealing of the values, stores sealed without the key of their record cannot
+// be opened
+const format = "2"
+
+// ErrInvalidSealed is returned when a value was not sealed with the key of the store
+var ErrInvalidSealed = errors.New("invalid sealed value")
+
+// DB is a bbolt database with a key derived from a passphrase, the values the clients seal with it cannot be
+// read from the file alone. Each value is bound to the key of its record, a sealed value copied under another
+// key does not open. The salt and a check value sealed with the key are kept in the meta bucket
+type DB struct {
+	*bolt.DB
+	key [chacha20poly1305.KeySize]byte
+}
+
+// Open opens or creates the database at path and the given buckets, waiting at most timeout for another
+// process to release the file. check is the value sealed when the database is created, it tells a wrong
+// passphrase from a corrupted key when the database is opened again
+func Open(path, passphrase string, check []byte, timeout time.Duration, buckets ...[]byte) (*DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("opening %v: %v", path, err)
+	}
+	store := &DB{DB: db}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		salt := meta.Get(saltKey)
+		if salt != nil && string(meta.Get(formatKey)) != format {
+			return fmt.Errorf("%v was created by an older version of the client, its values are not bound to their keys", path)
+		}
+		if salt == nil {
+			salt = make([]byte, 16)
+			if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+				return err
+			}
+			if err := meta.Put(saltKey, salt); err != nil {
+				return err
+			}
+			if err := meta.Put(formatKey, []byte(format)); err != nil {
+				return err
+			}
+		}
+		key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+		if err != nil {
+			return err
+		}
+		copy(store.key[:], key)
+		sealedCheck := meta.Get(checkKey)
+		if sealedCheck == nil {
+			sealed, err := store.Seal(checkKey, check)
+			if err != nil {
+				return err
+			}
+			return meta.Put(checkKey, sealed)
+		}
+		if _, err := store.Unseal(checkKey, sealedCheck); err != nil {
+			return fmt.Errorf("wrong passphrase for %v", path)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Seal encrypts and authenticates a value with the key of the store, the nonce is prepended. key is the key
+// of the record the value is stored under, it is authenticated with the value
+func (s *DB) Seal(key, value []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(s.key[:])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, value, key), nil
+}
+
+// Unseal decrypts a value sealed with the key of the store under the key of its record
+func (s *DB) Unseal(key, sealed []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(s.key[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidSealed
+	}
+	value, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], key)
+	if err != nil {
+		return nil, ErrInvalidSealed
+	}
+	return value, nil
+}
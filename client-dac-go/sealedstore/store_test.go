@@ -0,0 +1,108 @@
+package sealedstore
+
+import (
+	"bytes"
+	bolt "go.etcd.io/bbolt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testBucket = []byte("records")
+
+func openTestDB(t *testing.T, path, passphrase string) *DB {
+	t.Helper()
+	db, err := Open(path, passphrase, []byte("test"), time.Second, testBucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSeal(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.db"), "passphrase")
+	defer db.Close()
+	sealed, err := db.Seal([]byte("record1"), []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Error("the value is in the clear")
+	}
+	value, err := db.Unseal([]byte("record1"), sealed)
+	if err != nil || string(value) != "secret" {
+		t.Errorf("unsealed %q, %v", value, err)
+	}
+	again, err := db.Seal([]byte("record1"), []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again, sealed) {
+		t.Error("the nonce was reused")
+	}
+
+	// a sealed value copied under another record does not open
+	if _, err := db.Unseal([]byte("record2"), sealed); err != ErrInvalidSealed {
+		t.Errorf("value of record1 unsealed under record2, %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := db.Unseal([]byte("record1"), sealed); err != ErrInvalidSealed {
+		t.Errorf("tampered value unsealed, %v", err)
+	}
+	if _, err := db.Unseal([]byte("record1"), sealed[:10]); err != ErrInvalidSealed {
+		t.Errorf("truncated value unsealed, %v", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openTestDB(t, path, "passphrase")
+	sealed, err := db.Seal([]byte("record1"), []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(testBucket).Put([]byte("record1"), sealed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the file is locked while it is open
+	if _, err := Open(path, "passphrase", []byte("test"), 100*time.Millisecond, testBucket); err == nil {
+		t.Fatal("the database was opened twice")
+	}
+	db.Close()
+
+	if _, err := Open(path, "wrong", []byte("test"), time.Second, testBucket); err == nil {
+		t.Fatal("the database was opened with a wrong passphrase")
+	}
+	db = openTestDB(t, path, "passphrase")
+	defer db.Close()
+	err = db.View(func(tx *bolt.Tx) error {
+		value, err := db.Unseal([]byte("record1"), tx.Bucket(testBucket).Get([]byte("record1")))
+		if err == nil && string(value) != "secret" {
+			t.Errorf("unsealed %q", value)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenOlderFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openTestDB(t, path, "passphrase")
+	err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Delete(formatKey)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if _, err := Open(path, "passphrase", []byte("test"), time.Second, testBucket); err == nil {
+		t.Error("a database of an older format was opened")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/dacidentity"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr/remote"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"log"
+	"path/filepath"
+)
+
+// signerClient is the connection to the signer daemon, nil if the commands sign locally
+var signerClient *remote.Client
+
+// connectSigner connects to the signer daemon of the --signer option, with the client certificate client.crt, its
+// key client.key and the CA of the daemon ca.crt in the directory of the --signer-tls option
+func connectSigner(args []string) []string {
+	address, args := cutOption(args, "--signer")
+	tlsDir, args := cutOption(args, "--signer-tls")
+	if address == "" {
+		return args
+	}
+
+	tlsConfig, err := remote.ClientTLSConfig(filepath.Join(tlsDir, "client.crt"), filepath.Join(tlsDir, "client.key"),
+		filepath.Join(tlsDir, "ca.crt"), "signer")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	signerClient, err = remote.Dial(address, tlsConfig)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return args
+}
+
+// remoteInvoke invokes a chaincode function as a new nym of the user, the signer daemon keeps the credentials of
+// the user and creates the nym
+func remoteInvoke(username, fcn string, args []string) {
+	if signerClient == nil {
+		log.Fatalf("remoteinvoke needs the --signer option")
+	}
+	key, err := signerClient.Key(username)
+	if err != nil {
+		panic(err)
+	}
+	if key.Creator == nil {
+		log.Fatalf("key %v of the signer daemon is not a DAC key", username)
+	}
+	identity, err := remote.NewIdentity(key.Creator, key)
+	if err != nil {
+		panic(err)
+	}
+	argsBytes := make([][]byte, len(args))
+	for i, arg := range args {
+		argsBytes[i] = []byte(arg)
+	}
+
+	sdk, err := fabsdk.New(config.FromFile("connection-org1.yaml"), sdkOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	client, err := channel.New(sdk.ChannelContext(channelName, fabsdk.WithIdentity(identity)), channelOptions()...)
+	if err != nil {
+		panic(err)
+	}
+	response, err := client.Execute(channel.Request{ChaincodeID: chaincodeID, Fcn: fcn, Args: argsBytes,
+		TransientMap: key.Transient}, channel.WithRetry(retry.DefaultChannelOpts))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Committed transaction %v: %s\n", response.TransactionID, response.Payload)
+}
+
+// providerFactory returns the provider factory of the DAC identities, it signs with the daemon if there is one
+func providerFactory() *dacidentity.ProviderFactory {
+	if signerClient != nil {
+		return dacidentity.NewRemoteProviderFactory(signerClient)
+	}
+	return dacidentity.NewProviderFactory()
+}
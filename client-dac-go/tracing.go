@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"log"
+	"strings"
+	"time"
+)
+
+// tracerProvider is set by the --trace option, the channel clients then trace their requests
+var tracerProvider *sdktrace.TracerProvider
+
+// startTracing traces the requests of the channel clients if the arguments before the command contain --trace,
+// and returns the arguments without the option. The exporter is stdout to print the spans, or the address of
+// an OTLP collector such as localhost:4317
+func startTracing(args []string) []string {
+	exporter, args := cutOption(args, "--trace")
+	if exporter == "" {
+		return args
+	}
+
+	var processor sdktrace.SpanProcessor
+	if exporter == "stdout" {
+		spanExporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		processor = sdktrace.NewSimpleSpanProcessor(spanExporter)
+	} else {
+		// the collector is not contacted before the first batch so that a stopped collector does not block commands
+		spanExporter, err := otlptracegrpc.New(context.Background(), otlptracegrpc.WithEndpoint(exporter),
+			otlptracegrpc.WithInsecure())
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		processor = sdktrace.NewBatchSpanProcessor(spanExporter)
+	}
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceNameKey.String("dac-bidder"))))
+	return args
+}
+
+// stopTracing exports the spans that are not exported yet
+func stopTracing() {
+	if tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		log.Printf("failed to export spans: %v", err)
+	}
+}
+
+// channelOptions returns the options of the channel clients
+func channelOptions() []channel.ClientOption {
+	if tracerProvider == nil {
+		return nil
+	}
+	return []channel.ClientOption{channel.WithTracerProvider(tracerProvider)}
+}
+
+// cutOption removes an option with a value from the options before the command, given as --name value or
+// --name=value, and returns its value
+func cutOption(args []string, name string) (string, []string) {
+	for i := 1; i < len(args) && strings.HasPrefix(args[i], "--"); i++ {
+		if strings.HasPrefix(args[i], name+"=") {
+			return strings.TrimPrefix(args[i], name+"="), append(args[:i:i], args[i+1:]...)
+		} else if args[i] == name && i+1 < len(args) {
+			return args[i+1], append(args[:i:i], args[i+2:]...)
+		} else if !strings.Contains(args[i], "=") {
+			// skip the value of another option
+			i++
+		}
+	}
+	return "", args
+}
@@ -0,0 +1,8 @@
+module client-loadgen
+
+go 1.15
+
+require (
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200707132912-fee30f3ccd23
+	github.com/hyperledger/fabric-sdk-go v1.0.0
+)
@@ -0,0 +1,71 @@
+package loadstats
+
+import (
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"sort"
+	"time"
+)
+
+// Latencies are the mean and the percentiles of the latencies of a run, in milliseconds
+type Latencies struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// FailureClass sorts an error of the SDK: the validation code of a transaction that was committed invalid, such
+// as MVCC_READ_CONFLICT, an endorsement failure, a timeout, or another error
+func FailureClass(err error) string {
+	s, ok := status.FromError(err)
+	if !ok {
+		return "other"
+	}
+	switch s.Group {
+	case status.EventServerStatus:
+		if name, known := peer.TxValidationCode_name[s.Code]; known {
+			return name
+		}
+		return "invalid transaction"
+	case status.EndorserServerStatus, status.EndorserClientStatus, status.ChaincodeStatus:
+		return "endorsement"
+	case status.OrdererServerStatus, status.OrdererClientStatus:
+		return "ordering"
+	case status.ClientStatus:
+		if s.Code == status.Timeout.ToInt32() {
+			return "timeout"
+		}
+	}
+	return "other"
+}
+
+// Summarize returns the mean and the percentiles of the latencies, samples is sorted in place
+func Summarize(samples []time.Duration) Latencies {
+	if len(samples) == 0 {
+		return Latencies{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	var total time.Duration
+	for _, sample := range samples {
+		total += sample
+	}
+	percentile := func(q float64) float64 {
+		return ElapsedMs(samples[int(q*float64(len(samples)-1))])
+	}
+	return Latencies{
+		Mean: ElapsedMs(total / time.Duration(len(samples))),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+		Max:  ElapsedMs(samples[len(samples)-1]),
+	}
+}
+
+// ElapsedMs returns a duration in milliseconds, the unit of the reports
+func ElapsedMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
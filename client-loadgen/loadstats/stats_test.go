@@ -0,0 +1,60 @@
+package loadstats
+
+import (
+	"errors"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	if latencies := Summarize(nil); latencies != (Latencies{}) {
+		t.Errorf("latencies without samples %+v", latencies)
+	}
+	if latencies := Summarize([]time.Duration{3 * time.Millisecond}); latencies != (Latencies{3, 3, 3, 3, 3, 3}) {
+		t.Errorf("latencies of one sample %+v", latencies)
+	}
+
+	// 1 to 101 ms in random order, the percentiles are the samples at their rank
+	samples := make([]time.Duration, 101)
+	for i, j := range rand.Perm(len(samples)) {
+		samples[i] = time.Duration(j+1) * time.Millisecond
+	}
+	latencies := Summarize(samples)
+	expected := Latencies{Mean: 51, P50: 51, P90: 91, P95: 96, P99: 100, Max: 101}
+	if latencies != expected {
+		t.Errorf("latencies %+v, expected %+v", latencies, expected)
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i] < samples[i-1] {
+			t.Fatal("the samples were not sorted")
+		}
+	}
+
+	if latencies := Summarize([]time.Duration{time.Millisecond, 2 * time.Millisecond}); latencies.Mean != 1.5 || latencies.P99 != 1 {
+		t.Errorf("latencies %+v", latencies)
+	}
+}
+
+func TestFailureClass(t *testing.T) {
+	classes := map[error]string{
+		status.New(status.EventServerStatus, int32(peer.TxValidationCode_MVCC_READ_CONFLICT), "invalid", nil):         "MVCC_READ_CONFLICT",
+		status.New(status.EventServerStatus, int32(peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE), "invalid", nil): "ENDORSEMENT_POLICY_FAILURE",
+		status.New(status.EventServerStatus, 9999, "invalid", nil):                                                    "invalid transaction",
+		status.New(status.EndorserServerStatus, 500, "endorsement failed", nil):                                       "endorsement",
+		status.New(status.EndorserClientStatus, 0, "no endorsers", nil):                                               "endorsement",
+		status.New(status.ChaincodeStatus, 500, "chaincode error", nil):                                               "endorsement",
+		status.New(status.OrdererServerStatus, 400, "bad request", nil):                                               "ordering",
+		status.New(status.OrdererClientStatus, 0, "broadcast failed", nil):                                            "ordering",
+		status.New(status.ClientStatus, status.Timeout.ToInt32(), "request timed out", nil):                           "timeout",
+		status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "no peers", nil):                               "other",
+		errors.New("connection refused"):                                                                              "other",
+	}
+	for err, class := range classes {
+		if c := FailureClass(err); c != class {
+			t.Errorf("%v is %v, expected %v", err, c, class)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	argc := len(os.Args)
+	if argc > 1 {
+		cmd := os.Args[1]
+		args := os.Args[2:]
+		if cmd == "run" {
+			run(args)
+		} else if cmd == "workloads" {
+			for _, name := range workloadNames() {
+				fmt.Printf("%-16v %v\n", name, workloads[name].description)
+			}
+		} else {
+			fmt.Println("Unknown command")
+		}
+	} else {
+		fmt.Println("No command given as argument")
+	}
+}
+
+// run drives one workload of the benchmark contract and prints its report
+func run(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	configFile := flags.String("config", "connection-org1.yaml", "connection profile of the SDK")
+	username := flags.String("user", "User1", "user of the organization that sends the transactions")
+	org := flags.String("org", "org1", "organization of the user")
+	channelID := flags.String("channel", "auction", "channel of the contract")
+	chaincode := flags.String("chaincode", "datacontract", "name of the benchmark contract")
+	peers := flags.String("peers", "localhost:7051,localhost:9051", "comma separated endorsing peers")
+	workloadName := flags.String("workload", "read", "workload to run, see the workloads command")
+	txs := flags.Int("tx", 1000, "number of transactions, ignored if -duration is set")
+	duration := flags.Duration("duration", 0, "run for a duration instead of a number of transactions")
+	concurrency := flags.Int("concurrency", 10, "number of transactions in flight")
+	rate := flags.Float64("rate", 0, "transactions sent per second, 0 sends as fast as the workers allow")
+	keys := flags.Int("keys", 1000, "number of distinct keys, or of owners for the composite key workloads")
+	hot := flags.Int("hot", 4, "number of counters of the counter and history workloads")
+	batch := flags.Int("batch", 10, "keys written by a batch and read by a scan")
+	payloadSize := flags.Int("payload", 256, "size of the written values in bytes")
+	prefix := flags.String("prefix", "bench", "prefix of the keys")
+	collection := flags.String("collection", "", "private data collection, the implicit collection of the organization if empty")
+	retry := flags.Bool("retry", false, "retry failed transactions with the default options of the SDK, conflicts are then hidden")
+	jsonOut := flags.String("json", "", "write the report as JSON to this file")
+	flags.Parse(args)
+
+	w, known := workloads[*workloadName]
+	if !known {
+		log.Fatalf("unknown workload %q", *workloadName)
+	}
+	if *concurrency < 1 || *keys < 1 || *hot < 1 || *batch < 1 || *payloadSize < 0 || (*duration == 0 && *txs < 1) {
+		log.Fatalf("invalid load parameters")
+	}
+
+	sdk, err := fabsdk.New(config.FromFile(*configFile))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer sdk.Close()
+	client, err := channel.New(sdk.ChannelContext(*channelID, fabsdk.WithUser(*username), fabsdk.WithOrg(*org)))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	params := &loadParams{
+		chaincode:   *chaincode,
+		endpoints:   strings.Split(*peers, ","),
+		keys:        *keys,
+		hot:         *hot,
+		batch:       *batch,
+		payloadSize: *payloadSize,
+		prefix:      *prefix,
+		collection:  *collection,
+		retry:       *retry,
+	}
+	report := drive(client, w, params, *txs, *duration, *concurrency, *rate)
+	report.Workload = *workloadName
+	report.print()
+	if *jsonOut != "" {
+		reportBytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		err = ioutil.WriteFile(*jsonOut, reportBytes, 0644)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"client-loadgen/loadstats"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"sort"
+	"sync"
+	"time"
+)
+
+// loadReport is the result of a run. The latencies are those of the successful requests, from the proposal to
+// the commit event for the transactions of a submitting workload
+type loadReport struct {
+	Workload    string              `json:"workload"`
+	Sent        int                 `json:"sent"`
+	Succeeded   int                 `json:"succeeded"`
+	Failures    map[string]int      `json:"failures"`
+	ElapsedMs   float64             `json:"elapsedMs"`
+	Throughput  float64             `json:"throughput"`
+	LatencyMs   loadstats.Latencies `json:"latencyMs"`
+	FirstErrors []string            `json:"firstErrors,omitempty"`
+}
+
+// maxFirstErrors is the number of error messages kept in the report
+const maxFirstErrors = 5
+
+// drive sends the requests of a workload with concurrency requests in flight, at most rate per second if rate is
+// positive, until txs requests were sent or for duration if it is not zero
+func drive(client *channel.Client, w workload, p *loadParams, txs int, duration time.Duration, concurrency int, rate float64) *loadReport {
+	jobs := make(chan int, concurrency)
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		deadline := time.Now().Add(duration)
+		for i := 0; duration > 0 || i < txs; i++ {
+			if duration > 0 && time.Now().After(deadline) {
+				return
+			}
+			if tick != nil {
+				<-tick
+			}
+			jobs <- i
+		}
+	}()
+
+	report := &loadReport{Failures: make(map[string]int)}
+	var samples []time.Duration
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+	for n := 0; n < concurrency; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				sent := time.Now()
+				err := w.send(client, p, i)
+				latency := time.Since(sent)
+				mu.Lock()
+				report.Sent++
+				if err == nil {
+					report.Succeeded++
+					samples = append(samples, latency)
+				} else {
+					report.Failures[loadstats.FailureClass(err)]++
+					if len(report.FirstErrors) < maxFirstErrors {
+						report.FirstErrors = append(report.FirstErrors, err.Error())
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report.ElapsedMs = loadstats.ElapsedMs(elapsed)
+	report.Throughput = float64(report.Succeeded) / elapsed.Seconds()
+	report.LatencyMs = loadstats.Summarize(samples)
+	return report
+}
+
+// print prints the report as a table, followed by the failures of each class
+func (r *loadReport) print() {
+	fmt.Printf("%-16v %8v %8v %10v %10v %10v %10v %10v %10v\n", "workload", "sent", "ok", "tx/s", "mean ms",
+		"p50 ms", "p90 ms", "p99 ms", "max ms")
+	l := r.LatencyMs
+	fmt.Printf("%-16v %8v %8v %10.1f %10.1f %10.1f %10.1f %10.1f %10.1f\n", r.Workload, r.Sent, r.Succeeded,
+		r.Throughput, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	var classes []string
+	for class := range r.Failures {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		fmt.Printf("failed, %v: %v\n", class, r.Failures[class])
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// loadParams are the parameters of a run shared by the workloads
+type loadParams struct {
+	chaincode   string
+	endpoints   []string
+	keys        int
+	hot         int
+	batch       int
+	payloadSize int
+	prefix      string
+	collection  string
+	retry       bool
+}
+
+// workload is an operation of the benchmark contract. request returns the i-th request of a run, submit is
+// true if the request is a transaction that is ordered and committed, false if it is only endorsed
+type workload struct {
+	description string
+	submit      bool
+	request     func(p *loadParams, i int) channel.Request
+}
+
+var workloads = map[string]workload{
+	"store": {"writes one key with Store, the value is a string of -payload bytes", true,
+		func(p *loadParams, i int) channel.Request {
+			value := make([]byte, p.payloadSize)
+			for j := range value {
+				value[j] = 'a' + byte(j%26)
+			}
+			return request(p, "Store", key(p, i%p.keys), string(value))
+		}},
+	"read": {"reads one key with Read, the keys are written by the store workload", false,
+		func(p *loadParams, i int) channel.Request {
+			return request(p, "Read", key(p, rand.Intn(p.keys)))
+		}},
+	"batch": {"writes -batch keys of -payload bytes in one transaction", true,
+		func(p *loadParams, i int) channel.Request {
+			return request(p, "StoreBatch", p.prefix, strconv.Itoa(p.batch), strconv.Itoa(p.payloadSize))
+		}},
+	"scan": {"reads the -batch keys written by the batch workload with a range scan", false,
+		func(p *loadParams, i int) channel.Request {
+			return request(p, "ScanRange", key(p, 0), key(p, p.batch))
+		}},
+	"composite-write": {"writes one value under a composite key of one of -keys owners", true,
+		func(p *loadParams, i int) channel.Request {
+			return request(p, "StoreIndexed", p.prefix, owner(i%p.keys), strconv.Itoa(i), strconv.Itoa(p.payloadSize))
+		}},
+	"composite-query": {"reads the values of one owner with a partial composite key query", false,
+		func(p *loadParams, i int) channel.Request {
+			return request(p, "QueryIndexed", p.prefix, owner(rand.Intn(p.keys)))
+		}},
+	"counter": {"increments one of -hot counters, concurrent increments of a counter conflict", true,
+		func(p *loadParams, i int) channel.Request {
+			return request(p, "Increment", counter(p, rand.Intn(p.hot)))
+		}},
+	"private": {"writes one key of -payload bytes to a private data collection, the peers must be of the organization", true,
+		func(p *loadParams, i int) channel.Request {
+			r := request(p, "StorePrivate", p.collection, key(p, i%p.keys), strconv.Itoa(p.payloadSize))
+			r.TransientMap = map[string][]byte{"payload": make([]byte, p.payloadSize)}
+			return r
+		}},
+	"history": {"reads the history of one of the counters of the counter workload", false,
+		func(p *loadParams, i int) channel.Request {
+			return request(p, "KeyHistory", counter(p, rand.Intn(p.hot)))
+		}},
+}
+
+// workloadNames returns the names of the workloads in alphabetical order
+func workloadNames() []string {
+	var names []string
+	for name := range workloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func request(p *loadParams, fcn string, args ...string) channel.Request {
+	r := channel.Request{ChaincodeID: p.chaincode, Fcn: fcn}
+	for _, arg := range args {
+		r.Args = append(r.Args, []byte(arg))
+	}
+	return r
+}
+
+// key returns the i-th key, the same as the i-th key of a batch of the contract
+func key(p *loadParams, i int) string {
+	return fmt.Sprintf("%s-%06d", p.prefix, i)
+}
+
+func owner(i int) string {
+	return fmt.Sprintf("owner-%d", i)
+}
+
+func counter(p *loadParams, i int) string {
+	return fmt.Sprintf("%s-counter-%d", p.prefix, i)
+}
+
+// send sends a request of the workload to the endorsing peers of the run
+func (w *workload) send(client *channel.Client, p *loadParams, i int) error {
+	options := []channel.RequestOption{channel.WithTargetEndpoints(p.endpoints...)}
+	if p.retry {
+		options = append(options, channel.WithRetry(retry.DefaultChannelOpts))
+	}
+	var err error
+	if w.submit {
+		_, err = client.Execute(w.request(p, i), options...)
+	} else {
+		_, err = client.Query(w.request(p, i), options...)
+	}
+	return err
+}
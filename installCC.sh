@@ -4,6 +4,8 @@ PEERS="--peerAddresses localhost:7051 --peerAddresses localhost:9051"
 VER="$1"
 SEQ="$2"
 
+# the chaincode imports ../auction-circuit, which the package only contains once vendored
+(cd auction-chaincode && go mod vendor)
 peer lifecycle chaincode package blindauction.tar.gz --path auction-chaincode --lang golang --label blindauction
 PKGID=blindauction:$(sha256sum blindauction.tar.gz | cut -d " " -f 1)
 
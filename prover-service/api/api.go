@@ -0,0 +1,214 @@
+// Package api is the local gRPC API of the prover service. The messages are encoded in JSON with the json codec
+// of this package, clients select it with grpc.CallContentSubtype, see Dial
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"time"
+)
+
+// DefaultAddress is the unix socket the prover service listens on, the witnesses contain the decrypted bids
+// and must not leave the host of the auctioneer
+const DefaultAddress = "unix:///tmp/auction-prover.sock"
+
+// Status of a job
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Bid is a bid of the witness of a job, in the commitment order of the auction. Excluded bids hold no opening
+type Bid struct {
+	Commitment []byte `json:"commitment"`
+	Value      int    `json:"value"`
+	R          []byte `json:"r"`
+	Excluded   bool   `json:"excluded"`
+}
+
+// JobRequest asks for the proof of the winner of an auction. KeyID is the ID of the verifying key pinned by
+// the auction, the SHA-256 of vk
+type JobRequest struct {
+	AuctionID string `json:"auctionID"`
+	KeyID     string `json:"keyID"`
+	Bids      []Bid  `json:"bids"`
+	Winner    int    `json:"winner"`
+}
+
+// Job is the state of a proving job. Proof is the proof of the winner encoded for the chaincode, once the job
+// is done. The durations are in milliseconds, LoadingMs and ProvingMs are the ones of the last attempt, the
+// keys are only loaded by the first job that uses them
+type Job struct {
+	ID          string    `json:"id"`
+	AuctionID   string    `json:"auctionID"`
+	KeyID       string    `json:"keyID"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error,omitempty"`
+	Proof       []byte    `json:"proof,omitempty"`
+	SubmittedAt time.Time `json:"submittedAt"`
+	StartedAt   time.Time `json:"startedAt,omitempty"`
+	FinishedAt  time.Time `json:"finishedAt,omitempty"`
+	QueuedMs    int64     `json:"queuedMs"`
+	LoadingMs   int64     `json:"loadingMs"`
+	ProvingMs   int64     `json:"provingMs"`
+}
+
+// JobID identifies a job
+type JobID struct {
+	ID string `json:"id"`
+}
+
+// JobList is the list of the jobs known to the service, the oldest first
+type JobList struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// Empty is the request of the methods without argument
+type Empty struct{}
+
+// ProverServer is the service implemented by the prover daemon. Submit returns the job already queued or
+// running for the auction, if any, so that an auctioneer can resubmit after a restart
+type ProverServer interface {
+	Submit(context.Context, *JobRequest) (*Job, error)
+	Status(context.Context, *JobID) (*Job, error)
+	List(context.Context, *Empty) (*JobList, error)
+}
+
+const serviceName = "auction.prover.Prover"
+
+// codec encodes the messages in JSON
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// RegisterProverServer registers the implementation of the service on a gRPC server
+func RegisterProverServer(s *grpc.Server, srv ProverServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ProverServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Submit",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(JobRequest)
+				return handle(srv, ctx, dec, interceptor, "Submit", in, func(ctx context.Context) (interface{}, error) {
+					return srv.(ProverServer).Submit(ctx, in)
+				})
+			},
+		},
+		{
+			MethodName: "Status",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(JobID)
+				return handle(srv, ctx, dec, interceptor, "Status", in, func(ctx context.Context) (interface{}, error) {
+					return srv.(ProverServer).Status(ctx, in)
+				})
+			},
+		},
+		{
+			MethodName: "List",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(Empty)
+				return handle(srv, ctx, dec, interceptor, "List", in, func(ctx context.Context) (interface{}, error) {
+					return srv.(ProverServer).List(ctx, in)
+				})
+			},
+		},
+	},
+	Metadata: "prover-service/api",
+}
+
+// handle decodes the request of a method into in and calls it through the interceptor of the server
+func handle(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor,
+	method string, in interface{}, call func(context.Context) (interface{}, error)) (interface{}, error) {
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return call(ctx)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + method}
+	return interceptor(ctx, in, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return call(ctx)
+	})
+}
+
+// Client is a client of the prover service
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// Dial connects to the prover service at address, e.g. DefaultAddress
+func Dial(address string) (*Client, error) {
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Close closes the connection to the service
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Submit queues a proving job
+func (c *Client) Submit(ctx context.Context, req *JobRequest) (*Job, error) {
+	job := new(Job)
+	err := c.conn.Invoke(ctx, "/"+serviceName+"/Submit", req, job)
+	return job, err
+}
+
+// Status returns the state of a job
+func (c *Client) Status(ctx context.Context, id string) (*Job, error) {
+	job := new(Job)
+	err := c.conn.Invoke(ctx, "/"+serviceName+"/Status", &JobID{ID: id}, job)
+	return job, err
+}
+
+// List returns the jobs known to the service
+func (c *Client) List(ctx context.Context) (*JobList, error) {
+	jobs := new(JobList)
+	err := c.conn.Invoke(ctx, "/"+serviceName+"/List", &Empty{}, jobs)
+	return jobs, err
+}
+
+// Wait polls the state of a job until it is done or failed
+func (c *Client) Wait(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	for {
+		job, err := c.Status(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status == JobDone || job.Status == JobFailed {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
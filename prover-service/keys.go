@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// keyManifest is the part of the manifest of zk-generator read by the service
+type keyManifest struct {
+	MaxBids int    `json:"maxBids"`
+	Backend string `json:"backend"`
+}
+
+// provingKey is a key directory written by zk-generator. The circuit and the proving key are loaded on the
+// first job and kept in memory, a failed load is tried again by the next job
+type provingKey struct {
+	dir      string
+	manifest keyManifest
+
+	mu     sync.Mutex
+	loaded bool
+	r1cs   frontend.CompiledConstraintSystem
+	pk     groth16.ProvingKey
+}
+
+// keyStore holds the key directories found under the keys directory, by the ID of their verifying key in the
+// registry of the chaincode, the SHA-256 of vk
+type keyStore struct {
+	keys map[string]*provingKey
+}
+
+// openKeyStore indexes every directory of keysDir holding a manifest and a verifying key
+func openKeyStore(keysDir string) (*keyStore, error) {
+	entries, err := ioutil.ReadDir(keysDir)
+	if err != nil {
+		return nil, err
+	}
+	store := &keyStore{keys: make(map[string]*provingKey)}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(keysDir, entry.Name())
+		vkBytes, err := ioutil.ReadFile(filepath.Join(dir, "vk"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		manifestBytes, err := ioutil.ReadFile(filepath.Join(dir, "manifest.json"))
+		if err != nil {
+			return nil, fmt.Errorf("keys in %v: %v", dir, err)
+		}
+		var manifest keyManifest
+		err = json.Unmarshal(manifestBytes, &manifest)
+		if err != nil {
+			return nil, fmt.Errorf("manifest of %v: %v", dir, err)
+		}
+		digest := sha256.Sum256(vkBytes)
+		keyID := hex.EncodeToString(digest[:])
+		store.keys[keyID] = &provingKey{dir: dir, manifest: manifest}
+		log.Printf("key %v: %v, %v bids, in %v", keyID, manifest.Backend, manifest.MaxBids, dir)
+	}
+	return store, nil
+}
+
+// get returns the key of the given ID, nil if the service does not have it
+func (s *keyStore) get(keyID string) *provingKey {
+	return s.keys[keyID]
+}
+
+// load reads the circuit and the proving key if they are not in memory yet
+func (k *provingKey) load() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.loaded {
+		return nil
+	}
+	var err error
+	switch k.manifest.Backend {
+	case wire.ProofSystemGroth16:
+		r1cs := groth16.NewCS(ecc.BLS12_381)
+		pk := groth16.NewProvingKey(ecc.BLS12_381)
+		err = readKeyFiles(k.dir, map[string]io.ReaderFrom{"circuit": r1cs, "pk": pk})
+		k.r1cs, k.pk = r1cs, pk
+	default:
+		err = fmt.Errorf("unknown backend %q", k.manifest.Backend)
+	}
+	if err != nil {
+		return fmt.Errorf("loading the keys of %v: %v", k.dir, err)
+	}
+	log.Printf("loaded the keys of %v", k.dir)
+	k.loaded = true
+	return nil
+}
+
+// prove proves a witness with the loaded keys and encodes the proof with its proof system for the chaincode
+func (k *provingKey) prove(witness *circuit.AuctionCircuit) ([]byte, error) {
+	proof, err := groth16.Prove(k.r1cs, k.pk, witness)
+	if err != nil {
+		return nil, err
+	}
+	var proofBuf bytes.Buffer
+	_, err = proof.WriteTo(&proofBuf)
+	if err != nil {
+		return nil, err
+	}
+	return wire.MarshalWinnerProof(k.manifest.Backend, proofBuf.Bytes())
+}
+
+func readKeyFiles(dir string, objects map[string]io.ReaderFrom) error {
+	for name, object := range objects {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		_, err = object.ReadFrom(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/ckiere/test-network/prover-service/api"
+	"google.golang.org/grpc"
+	"log"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	argc := len(os.Args)
+	if argc > 1 {
+		cmd := os.Args[1]
+		args := os.Args[2:]
+		if cmd == "serve" {
+			serve(args)
+		} else if cmd == "jobs" {
+			listJobs(args)
+		} else {
+			fmt.Println("Unknown command")
+		}
+	} else {
+		fmt.Println("No command given as argument")
+	}
+}
+
+// serve runs the prover daemon on a unix socket, with the keys of every directory written by zk-generator
+// under the keys directory
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	address := flags.String("address", api.DefaultAddress, "unix socket to listen on")
+	keysDir := flags.String("keys", "keys", "directory of the key directories")
+	// the provers of gnark already use every CPU, running a few proofs at once mostly overlaps the parts
+	// of a proof that are not parallel
+	workers := flags.Int("workers", (runtime.NumCPU()+3)/4, "number of proofs computed at once")
+	queueSize := flags.Int("queue", 64, "number of jobs waiting for a worker")
+	retries := flags.Int("retries", 2, "number of times a failed proof is tried again")
+	flags.Parse(args)
+	if *workers < 1 || *queueSize < 1 || *retries < 0 {
+		log.Fatalf("invalid number of workers, queue size or retries")
+	}
+
+	keys, err := openKeyStore(*keysDir)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(keys.keys) == 0 {
+		log.Fatalf("no keys in %v", *keysDir)
+	}
+	path := strings.TrimPrefix(*address, "unix://")
+	listener, err := listenUnix(path)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	server := grpc.NewServer()
+	api.RegisterProverServer(server, newService(keys, *workers, *queueSize, *retries))
+	log.Printf("listening on %v with %v workers", path, *workers)
+	err = server.Serve(listener)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// listenUnix listens on a unix socket that is only open to the user of the service. The socket is created with
+// the umask set, changing its mode after Listen would leave it open to other users until then
+func listenUnix(path string) (net.Listener, error) {
+	// remove the socket left by a previous run
+	os.Remove(path)
+	mask := syscall.Umask(0177)
+	defer syscall.Umask(mask)
+	return net.Listen("unix", path)
+}
+
+// listJobs prints the jobs of the service, their status and timings
+func listJobs(args []string) {
+	flags := flag.NewFlagSet("jobs", flag.ExitOnError)
+	address := flags.String("address", api.DefaultAddress, "address of the service")
+	flags.Parse(args)
+
+	client, err := api.Dial(*address)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	list, err := client.List(ctx)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for _, job := range list.Jobs {
+		fmt.Printf("%v auction %v: %v, %v attempts, queued %v ms, keys loaded in %v ms, proved in %v ms %v\n", job.ID,
+			job.AuctionID, job.Status, job.Attempts, job.QueuedMs, job.LoadingMs, job.ProvingMs, job.Error)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/ckiere/test-network/prover-service/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// jobRetention is how long finished jobs are kept for their auctioneer to fetch the proof
+const jobRetention = time.Hour
+
+// job is a queued proving job, its witness is dropped once the job is finished. witnessHash identifies the bids
+// and the winner of the request, a proof is only shared with a request for the same witness
+type job struct {
+	api.Job
+	key         *provingKey
+	witness     *circuit.AuctionCircuit
+	witnessHash [sha256.Size]byte
+}
+
+// service queues the proving jobs and runs them on a bounded number of workers
+type service struct {
+	keys       *keyStore
+	maxRetries int
+	retryDelay time.Duration
+	queue      chan *job
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// newService starts the workers of the service, the queue holds at most queueSize jobs waiting for a worker
+func newService(keys *keyStore, workers, queueSize, maxRetries int) *service {
+	s := &service{
+		keys:       keys,
+		maxRetries: maxRetries,
+		retryDelay: time.Second,
+		queue:      make(chan *job, queueSize),
+		jobs:       make(map[string]*job),
+	}
+	for i := 0; i < workers; i++ {
+		go s.work()
+	}
+	return s
+}
+
+// Submit checks the witness of a job and queues it. A request for the same auction, key and witness as a job
+// that did not fail returns that job
+func (s *service) Submit(ctx context.Context, req *api.JobRequest) (*api.Job, error) {
+	key := s.keys.get(req.KeyID)
+	if key == nil {
+		return nil, status.Errorf(codes.NotFound, "no proving key for verifying key %v", req.KeyID)
+	}
+	witness, err := buildWitness(key.manifest.MaxBids, req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	hash, err := hashWitness(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	for _, j := range s.jobs {
+		if j.AuctionID == req.AuctionID && j.KeyID == req.KeyID && j.witnessHash == hash && j.Status != api.JobFailed {
+			return j.snapshot(), nil
+		}
+	}
+	j := &job{key: key, witness: witness, witnessHash: hash}
+	j.ID = newJobID()
+	j.AuctionID = req.AuctionID
+	j.KeyID = req.KeyID
+	j.Status = api.JobQueued
+	j.SubmittedAt = time.Now()
+	select {
+	case s.queue <- j:
+	default:
+		return nil, status.Errorf(codes.ResourceExhausted, "the queue of the prover is full")
+	}
+	s.jobs[j.ID] = j
+	log.Printf("job %v: queued for auction %v", j.ID, j.AuctionID)
+	return j.snapshot(), nil
+}
+
+// Status returns the state of a job
+func (s *service) Status(ctx context.Context, id *api.JobID) (*api.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id.ID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no job %v", id.ID)
+	}
+	return j.snapshot(), nil
+}
+
+// List returns the jobs without their proofs
+func (s *service) List(ctx context.Context, _ *api.Empty) (*api.JobList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	list := &api.JobList{Jobs: make([]api.Job, 0, len(s.jobs))}
+	for _, j := range s.jobs {
+		snapshot := j.snapshot()
+		snapshot.Proof = nil
+		list.Jobs = append(list.Jobs, *snapshot)
+	}
+	sort.Slice(list.Jobs, func(a, b int) bool {
+		return list.Jobs[a].SubmittedAt.Before(list.Jobs[b].SubmittedAt)
+	})
+	return list, nil
+}
+
+// work runs the jobs of the queue, a failed proof is tried again maxRetries times, after a delay that grows
+// with every attempt
+func (s *service) work() {
+	for j := range s.queue {
+		var proof []byte
+		var err error
+		for attempt := 0; attempt <= s.maxRetries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(time.Duration(attempt) * s.retryDelay)
+			}
+			s.mu.Lock()
+			j.Status = api.JobRunning
+			j.Attempts++
+			j.StartedAt = time.Now()
+			if attempt == 0 {
+				j.QueuedMs = j.StartedAt.Sub(j.SubmittedAt).Milliseconds()
+			}
+			s.mu.Unlock()
+
+			// the keys are loaded by the first job, or again after a failed load
+			err = j.key.load()
+			loaded := time.Now()
+			if err == nil {
+				proof, err = j.key.prove(j.witness)
+			}
+			s.mu.Lock()
+			j.LoadingMs = loaded.Sub(j.StartedAt).Milliseconds()
+			j.ProvingMs = time.Since(loaded).Milliseconds()
+			s.mu.Unlock()
+			if err == nil {
+				break
+			}
+			log.Printf("job %v: attempt %v failed: %v", j.ID, attempt+1, err)
+		}
+
+		s.mu.Lock()
+		j.FinishedAt = time.Now()
+		j.witness = nil
+		if err != nil {
+			j.Status = api.JobFailed
+			j.Error = err.Error()
+		} else {
+			j.Status = api.JobDone
+			j.Proof = proof
+		}
+		log.Printf("job %v: %v after %v attempts, queued %v ms, keys loaded in %v ms, proved in %v ms", j.ID, j.Status,
+			j.Attempts, j.QueuedMs, j.LoadingMs, j.ProvingMs)
+		s.mu.Unlock()
+	}
+}
+
+// prune forgets the jobs finished for longer than the retention, s.mu must be held
+func (s *service) prune() {
+	for id, j := range s.jobs {
+		if !j.FinishedAt.IsZero() && time.Since(j.FinishedAt) > jobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// snapshot copies the state of a job, s.mu must be held
+func (j *job) snapshot() *api.Job {
+	snapshot := j.Job
+	return &snapshot
+}
+
+// buildWitness decodes the bids of a request into the witness of a circuit for maxBids bids. The witness is
+// checked here so that a failed proof is worth another attempt: the bids that are not excluded must open their
+// commitment and the winner must be the highest of them
+func buildWitness(maxBids int, req *api.JobRequest) (*circuit.AuctionCircuit, error) {
+	bids := make([]circuit.Bid, len(req.Bids))
+	for i, b := range req.Bids {
+		com, err := wire.UnmarshalCommitment(b.Commitment)
+		if err != nil {
+			return nil, fmt.Errorf("bid %v: %v", i, err)
+		}
+		bids[i] = circuit.Bid{Commitment: *com, Value: b.Value, R: new(big.Int).SetBytes(b.R), Excluded: b.Excluded}
+		if !b.Excluded && !commitment.CheckCommit(b.Value, bids[i].R, com) {
+			return nil, fmt.Errorf("bid %v does not open its commitment", i)
+		}
+	}
+	if req.Winner != circuit.Winner(bids) {
+		return nil, fmt.Errorf("bid %v is not the winner", req.Winner)
+	}
+	return circuit.NewWitness(maxBids, bids, req.Winner)
+}
+
+// hashWitness returns the hash of the bids and the winner of a request
+func hashWitness(req *api.JobRequest) ([sha256.Size]byte, error) {
+	witnessBytes, err := json.Marshal(struct {
+		Bids   []api.Bid `json:"bids"`
+		Winner int       `json:"winner"`
+	}{req.Bids, req.Winner})
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(witnessBytes), nil
+}
+
+func newJobID() string {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/ckiere/test-network/auction-circuit/wire"
+	"github.com/ckiere/test-network/prover-service/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testKeyID = "key1"
+
+// newTestService returns a service with a key whose backend is unknown, so that every proof fails to load
+// the key. The service has no worker if workers is 0, its jobs stay queued
+func newTestService(t *testing.T, workers, queueSize, retries int) *service {
+	keys := &keyStore{keys: map[string]*provingKey{
+		testKeyID: {dir: t.TempDir(), manifest: keyManifest{MaxBids: 3, Backend: "unknown"}},
+	}}
+	s := newService(keys, workers, queueSize, retries)
+	s.retryDelay = time.Millisecond
+	return s
+}
+
+// testRequest returns a request for the proof of the winner of bids with the given values
+func testRequest(t *testing.T, auctionID string, values ...int) *api.JobRequest {
+	req := &api.JobRequest{AuctionID: auctionID, KeyID: testKeyID}
+	for i, value := range values {
+		com, r, err := commitment.Commit(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Bids = append(req.Bids, api.Bid{Commitment: wire.MarshalCommitment(com), Value: value, R: r.Bytes()})
+		if value > values[req.Winner] {
+			req.Winner = i
+		}
+	}
+	return req
+}
+
+// waitJob waits for a job to be done or failed
+func waitJob(t *testing.T, s *service, id string) *api.Job {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		job, err := s.Status(context.Background(), &api.JobID{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == api.JobDone || job.Status == api.JobFailed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %v did not finish", id)
+	return nil
+}
+
+func TestSubmitDedupe(t *testing.T) {
+	s := newTestService(t, 0, 8, 0)
+	ctx := context.Background()
+	req := testRequest(t, "auction1", 10, 30, 20)
+	job, err := s.Submit(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != api.JobQueued || job.AuctionID != "auction1" || job.KeyID != testKeyID {
+		t.Errorf("submitted job %+v", job)
+	}
+	again, err := s.Submit(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != job.ID {
+		t.Error("the same witness was queued twice")
+	}
+
+	// the proof of a witness is not shared with another witness of the same auction
+	other := testRequest(t, "auction1", 10, 30, 20)
+	otherJob, err := s.Submit(ctx, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherJob.ID == job.ID {
+		t.Error("a job was shared by two witnesses with other openings")
+	}
+	excluded := *req
+	excluded.Bids = append([]api.Bid{}, req.Bids...)
+	excluded.Bids[0] = api.Bid{Commitment: req.Bids[0].Commitment, Excluded: true}
+	excludedJob, err := s.Submit(ctx, &excluded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if excludedJob.ID == job.ID {
+		t.Error("a job was shared by two witnesses excluding other bids")
+	}
+	otherAuction := *req
+	otherAuction.AuctionID = "auction2"
+	otherAuctionJob, err := s.Submit(ctx, &otherAuction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherAuctionJob.ID == job.ID {
+		t.Error("a job was shared by two auctions")
+	}
+
+	list, err := s.List(ctx, &api.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Jobs) != 4 || list.Jobs[0].ID != job.ID {
+		t.Errorf("listed %+v", list.Jobs)
+	}
+}
+
+func TestSubmitInvalid(t *testing.T) {
+	s := newTestService(t, 0, 1, 0)
+	ctx := context.Background()
+	invalid := map[string]*api.JobRequest{}
+	unknownKey := testRequest(t, "auction1", 10, 20)
+	unknownKey.KeyID = "key2"
+	invalid["a request for an unknown key"] = unknownKey
+	loser := testRequest(t, "auction1", 10, 20)
+	loser.Winner = 0
+	invalid["a request for a bid that does not win"] = loser
+	wrongOpening := testRequest(t, "auction1", 10, 20)
+	wrongOpening.Bids[0].Value = 11
+	invalid["a bid that does not open its commitment"] = wrongOpening
+	invalid["too many bids"] = testRequest(t, "auction1", 10, 20, 30, 40)
+	for name, req := range invalid {
+		if _, err := s.Submit(ctx, req); err == nil {
+			t.Errorf("%v was queued", name)
+		}
+	}
+
+	if _, err := s.Submit(ctx, testRequest(t, "auction1", 10, 20)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.Submit(ctx, testRequest(t, "auction2", 10, 20))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("a job was queued in a full queue, %v", err)
+	}
+	if _, err := s.Status(ctx, &api.JobID{ID: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("status of an unknown job, %v", err)
+	}
+}
+
+func TestRetryExhaustion(t *testing.T) {
+	s := newTestService(t, 1, 8, 2)
+	ctx := context.Background()
+	req := testRequest(t, "auction1", 10, 20)
+	job, err := s.Submit(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = waitJob(t, s, job.ID)
+	if job.Status != api.JobFailed || job.Attempts != 3 || job.Error == "" || job.Proof != nil {
+		t.Errorf("job %+v after the retries", job)
+	}
+
+	// a failed job is not shared, the same witness is tried again
+	again, err := s.Submit(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID == job.ID {
+		t.Error("a failed job was returned for the same witness")
+	}
+	if again = waitJob(t, s, again.ID); again.Attempts != 3 {
+		t.Errorf("job %+v after the retries", again)
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	s := newTestService(t, 0, 8, 0)
+	path := filepath.Join(t.TempDir(), "prover.sock")
+	listener, err := listenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode %v of the socket", info.Mode())
+	}
+	server := grpc.NewServer()
+	api.RegisterProverServer(server, s)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	client, err := api.Dial("unix://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	req := testRequest(t, "auction1", 10, 20)
+	job, err := client.Submit(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the job stays queued, the auctioneer stops waiting for it
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.Wait(ctx, job.ID, 10*time.Millisecond); err == nil {
+		t.Fatal("the wait for a queued job returned")
+	}
+	// the job is kept, the auctioneer finds it again when it submits the same witness
+	again, err := client.Submit(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != job.ID || again.Status != api.JobQueued {
+		t.Errorf("job %+v submitted again, expected %v", again, job.ID)
+	}
+}
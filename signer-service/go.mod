@@ -0,0 +1,18 @@
+module github.com/ckiere/test-network/signer-service
+
+go 1.15
+
+require (
+	github.com/ckiere/test-network/client-dac-go v0.0.0
+	github.com/dbogatov/dac-lib v1.0.0
+	github.com/hyperledger/fabric-sdk-go v1.0.0
+	google.golang.org/grpc v1.54.0
+)
+
+replace github.com/hyperledger/fabric-sdk-go v1.0.0 => ../client-dac-go/internal-fabric-sdk-go
+
+replace github.com/ckiere/test-network/client-dac-go => ../client-dac-go
+
+replace github.com/ckiere/test-network/auction-circuit => ../auction-circuit
+
+replace client-loadgen => ../client-loadgen
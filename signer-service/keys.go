@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ckiere/test-network/client-dac-go/dacidentity"
+	"github.com/dbogatov/dac-lib/dac"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr/remote"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The daemon keeps the nyms it created for a client for a limited time, long enough to sign a transaction
+const nymLifetime = 10 * time.Minute
+const maxNyms = 256
+
+// errUnknownSKI is returned when a signature is requested for a nym the key did not create or no longer keeps
+var errUnknownSKI = errors.New("unknown or expired SKI")
+
+// keyConfig is a key of the keys file. An ECDSA key is the key of the keystore of an MSP directory written by
+// cryptogen or the CA, a DAC key is the DAC configuration and the credentials of the user. Clients lists the common
+// names or hex SKIs of the client certificates that may use the key
+type keyConfig struct {
+	ID          string   `json:"id"`
+	Scheme      string   `json:"scheme"`
+	MSP         string   `json:"msp,omitempty"`
+	DacConfig   string   `json:"dacConfig,omitempty"`
+	Credentials string   `json:"credentials,omitempty"`
+	Clients     []string `json:"clients"`
+}
+
+// signingKey is a key loaded by the daemon, ski selects the nym of a DAC key
+type signingKey interface {
+	scheme() string
+	sign(ski, digest []byte) ([]byte, error)
+	publicKey() (*remote.KeyResponse, error)
+}
+
+// daemonKey is a key of the daemon with the clients allowed to use it
+type daemonKey struct {
+	signingKey
+	clients map[string]bool
+}
+
+// allows tells if the client of a certificate may use the key, by the common name or SKI of the certificate
+func (k *daemonKey) allows(cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+	if k.clients[cert.Subject.CommonName] {
+		return true
+	}
+	return len(cert.SubjectKeyId) > 0 && k.clients[hex.EncodeToString(cert.SubjectKeyId)]
+}
+
+// loadKeys loads the keys of the keys file, the paths of the file are relative to its directory
+func loadKeys(fileName string) (map[string]*daemonKey, error) {
+	configBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var configs struct {
+		Keys []keyConfig `json:"keys"`
+	}
+	if err := json.Unmarshal(configBytes, &configs); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(fileName)
+	keys := make(map[string]*daemonKey)
+	for _, config := range configs.Keys {
+		if _, ok := keys[config.ID]; ok {
+			return nil, fmt.Errorf("key %v is defined twice", config.ID)
+		}
+		if len(config.Clients) == 0 {
+			return nil, fmt.Errorf("key %v allows no clients", config.ID)
+		}
+		var key signingKey
+		if config.Scheme == remote.SchemeECDSA {
+			key, err = loadECDSAKey(config.ID, filepath.Join(dir, config.MSP))
+		} else if config.Scheme == remote.SchemeDACNym {
+			key, err = loadNymKey(config.ID, filepath.Join(dir, config.DacConfig), filepath.Join(dir, config.Credentials))
+		} else {
+			err = fmt.Errorf("unknown scheme %v", config.Scheme)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %v", config.ID, err)
+		}
+		clients := make(map[string]bool)
+		for _, client := range config.Clients {
+			clients[client] = true
+		}
+		keys[config.ID] = &daemonKey{signingKey: key, clients: clients}
+	}
+	return keys, nil
+}
+
+// ecdsaKey is the key of an X.509 identity
+type ecdsaKey struct {
+	id         string
+	privateKey *ecdsa.PrivateKey
+}
+
+// loadECDSAKey loads the only key of the keystore of an MSP directory
+func loadECDSAKey(id, mspDir string) (*ecdsaKey, error) {
+	files, err := filepath.Glob(filepath.Join(mspDir, "keystore", "*_sk"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("expected one key in the keystore of %v, found %v", mspDir, len(files))
+	}
+	keyPEM, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %v", files[0])
+	}
+	// cryptogen writes PKCS8 keys and older tools SEC 1 keys
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		privateKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%v is not an ECDSA key", files[0])
+		}
+		return &ecdsaKey{id: id, privateKey: privateKey}, nil
+	}
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsaKey{id: id, privateKey: privateKey}, nil
+}
+
+func (k *ecdsaKey) scheme() string {
+	return remote.SchemeECDSA
+}
+
+// sign signs the digest with a low S, as Fabric rejects the other half of the signatures
+func (k *ecdsaKey) sign(ski, digest []byte) ([]byte, error) {
+	if len(ski) > 0 && !bytes.Equal(ski, k.ski()) {
+		return nil, errUnknownSKI
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, k.privateKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	halfOrder := new(big.Int).Rsh(k.privateKey.Params().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(k.privateKey.Params().N, s)
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+// publicKey returns the PKIX encoding of the key
+func (k *ecdsaKey) publicKey() (*remote.KeyResponse, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&k.privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &remote.KeyResponse{KeyID: k.id, Scheme: remote.SchemeECDSA, PublicKey: publicKey, SKI: k.ski()}, nil
+}
+
+// ski returns the SHA-256 of the point as the SDK computes it
+func (k *ecdsaKey) ski() []byte {
+	ski := sha256.Sum256(elliptic.Marshal(k.privateKey.Curve, k.privateKey.X, k.privateKey.Y))
+	return ski[:]
+}
+
+// nymKey is a DAC identity, it creates a new nym for every key request so that the transactions of the user are
+// not linked by their nym
+type nymKey struct {
+	id    string
+	mutex sync.Mutex
+	user  *dacidentity.User
+	nyms  map[string]*nym
+}
+
+// nym is a nym created by a nymKey, by hex SKI
+type nym struct {
+	key     dacidentity.NymKey
+	created time.Time
+}
+
+// loadNymKey loads the DAC identity of the user
+func loadNymKey(id, dacConfigFile, credentialsFile string) (*nymKey, error) {
+	configBytes, err := ioutil.ReadFile(dacConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	dacConfig, err := dacidentity.CreateConfigFromBytes(configBytes)
+	if err != nil {
+		return nil, err
+	}
+	credentialsBytes, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var credentials dacidentity.CredentialsConfig
+	if err := json.Unmarshal(credentialsBytes, &credentials); err != nil {
+		return nil, err
+	}
+	user, err := dacidentity.CreateUser(*dacConfig, credentials, id, "DacMSP")
+	if err != nil {
+		return nil, err
+	}
+	return &nymKey{id: id, user: user, nyms: make(map[string]*nym)}, nil
+}
+
+func (k *nymKey) scheme() string {
+	return remote.SchemeDACNym
+}
+
+// sign signs the digest with the nym of the SKI
+func (k *nymKey) sign(ski, digest []byte) ([]byte, error) {
+	k.mutex.Lock()
+	k.expireNyms()
+	n, ok := k.nyms[hex.EncodeToString(ski)]
+	k.mutex.Unlock()
+	if !ok {
+		return nil, errUnknownSKI
+	}
+	signature := dac.SignNym(dacidentity.NewRand(), n.key.PublicNymKey(), n.key.PrivateNymKey(), n.key.PrivateKey(),
+		n.key.H(), digest)
+	return signature.ToBytes(), nil
+}
+
+// publicKey creates a new nym and returns its public key, serialized identity and escrow. Its SKI is the SHA-256
+// of the public key
+func (k *nymKey) publicKey() (*remote.KeyResponse, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.user.UpdateNymIdentity()
+	creator, err := k.user.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	pk := k.user.NymPublicKey()
+	ski := sha256.Sum256(pk)
+	k.expireNyms()
+	if len(k.nyms) >= maxNyms {
+		k.removeOldestNym()
+	}
+	k.nyms[hex.EncodeToString(ski[:])] = &nym{key: k.user.PrivateKey().(dacidentity.NymKey), created: time.Now()}
+
+	response := &remote.KeyResponse{KeyID: k.id, Scheme: remote.SchemeDACNym, PublicKey: pk, SKI: ski[:],
+		Creator: creator}
+	if escrow, proof := k.user.IdentityEscrow(); escrow != nil {
+		response.Transient = map[string][]byte{"escrow": escrow, "escrowProof": proof}
+	}
+	return response, nil
+}
+
+// expireNyms removes the nyms older than nymLifetime, the mutex must be held
+func (k *nymKey) expireNyms() {
+	for ski, n := range k.nyms {
+		if time.Since(n.created) > nymLifetime {
+			delete(k.nyms, ski)
+		}
+	}
+}
+
+// removeOldestNym removes the oldest nym, the mutex must be held
+func (k *nymKey) removeOldestNym() {
+	oldest := ""
+	for ski, n := range k.nyms {
+		if oldest == "" || n.created.Before(k.nyms[oldest].created) {
+			oldest = ski
+		}
+	}
+	delete(k.nyms, oldest)
+}
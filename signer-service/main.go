@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr/remote"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	argc := len(os.Args)
+	if argc > 1 {
+		cmd := os.Args[1]
+		args := os.Args[2:]
+		if cmd == "serve" {
+			serve(args)
+		} else {
+			fmt.Println("Unknown command")
+		}
+	} else {
+		fmt.Println("No command given as argument")
+	}
+}
+
+// serve runs the signer daemon with the keys of the keys file, on a unix socket or a TCP address. Clients
+// authenticate with a certificate issued by the TLS CA
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	address := flags.String("address", remote.DefaultAddress, "unix socket or host:port to listen on")
+	keysFile := flags.String("keys", "signer.json", "file of the keys of the daemon")
+	certFile := flags.String("tls-cert", "tls/server.crt", "TLS certificate of the daemon")
+	keyFile := flags.String("tls-key", "tls/server.key", "TLS key of the daemon")
+	caFile := flags.String("tls-ca", "tls/ca.crt", "CA of the certificates of the clients")
+	flags.Parse(args)
+
+	keys, err := loadKeys(*keysFile)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(keys) == 0 {
+		log.Fatalf("no keys in %v", *keysFile)
+	}
+	tlsConfig, err := remote.ServerTLSConfig(*certFile, *keyFile, *caFile)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	var listener net.Listener
+	if strings.HasPrefix(*address, "unix://") {
+		listener, err = listenUnix(strings.TrimPrefix(*address, "unix://"))
+	} else {
+		listener, err = net.Listen("tcp", *address)
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	remote.RegisterSignerServer(server, &signerService{keys: keys})
+	log.Printf("listening on %v with %v keys", *address, len(keys))
+	err = server.Serve(listener)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// listenUnix listens on a unix socket only the user of the daemon can connect to, the umask is set before Listen
+// creates the socket so that it is never open to other users
+func listenUnix(path string) (net.Listener, error) {
+	// remove the socket left by a previous run
+	os.Remove(path)
+	mask := syscall.Umask(0177)
+	defer syscall.Umask(mask)
+	return net.Listen("unix", path)
+}
+
+// signerService signs digests with the keys of the daemon
+type signerService struct {
+	keys map[string]*daemonKey
+}
+
+// key returns the key of a request if the client of the request may use it
+func (s *signerService) key(ctx context.Context, keyID string) (*daemonKey, error) {
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown key %v", keyID)
+	}
+	if !key.allows(clientCertificate(ctx)) {
+		return nil, status.Errorf(codes.PermissionDenied, "client %v may not use key %v", clientName(ctx), keyID)
+	}
+	return key, nil
+}
+
+func (s *signerService) Sign(ctx context.Context, req *remote.SignRequest) (*remote.SignResponse, error) {
+	key, err := s.key(ctx, req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	// a client must know the kind of signature it gets
+	if scheme := key.scheme(); scheme != req.Scheme {
+		return nil, status.Errorf(codes.InvalidArgument, "key %v signs with %v, not %v", req.KeyID, scheme, req.Scheme)
+	}
+	if len(req.Digest) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "digest required")
+	}
+	signature, err := key.sign(req.SKI, req.Digest)
+	if err == errUnknownSKI {
+		return nil, status.Errorf(codes.NotFound, "key %v: %v", req.KeyID, err)
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	log.Printf("signed a digest with %v for %v", req.KeyID, clientName(ctx))
+	return &remote.SignResponse{Signature: signature}, nil
+}
+
+func (s *signerService) PublicKey(ctx context.Context, req *remote.KeyRequest) (*remote.KeyResponse, error) {
+	key, err := s.key(ctx, req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	response, err := key.publicKey()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return response, nil
+}
+
+// clientCertificate returns the certificate of the client of a request
+func clientCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil
+	}
+	return tlsInfo.State.PeerCertificates[0]
+}
+
+// clientName returns the common name of the certificate of the client of a request
+func clientName(ctx context.Context) string {
+	cert := clientCertificate(ctx)
+	if cert == nil {
+		return ""
+	}
+	return cert.Subject.CommonName
+}
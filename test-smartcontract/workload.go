@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxBatch bounds the number of keys a single transaction writes, so that a misconfigured load
+// generator does not build blocks the orderer rejects
+const maxBatch = 1000
+
+// maxPayload bounds the size of a written value and maxBatchBytes the size of the values of a batch,
+// below the preferred and absolute block sizes of the orderer
+const (
+	maxPayload    = 512 * 1024
+	maxBatchBytes = 64 * 1024 * 1024
+)
+
+// ScanResult is the number of keys a query read and the size of their values
+type ScanResult struct {
+	Keys  int `json:"keys"`
+	Bytes int `json:"bytes"`
+}
+
+// HistoryEntry is a version of a key returned by KeyHistory
+type HistoryEntry struct {
+	TxID      string `json:"txID"`
+	Timestamp int64  `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Size      int    `json:"size"`
+}
+
+// StoreBatch writes count keys prefix-000000 to prefix-<count-1> with values of payloadSize bytes
+func (dc *DataContract) StoreBatch(ctx contractapi.TransactionContextInterface, prefix string, count int, payloadSize int) error {
+	if count < 1 || count > maxBatch || payloadSize < 0 || payloadSize > maxPayload || count*payloadSize > maxBatchBytes {
+		return fmt.Errorf("invalid batch of %v keys of %v bytes", count, payloadSize)
+	}
+	value := payload(ctx, payloadSize)
+	for i := 0; i < count; i++ {
+		err := ctx.GetStub().PutState(batchKey(prefix, i), value)
+		if err != nil {
+			return errors.New("Unable to interact with world state")
+		}
+	}
+	return nil
+}
+
+// ScanRange reads the keys from startKey included to endKey excluded, the keys of a batch are
+// prefix-000000 to prefix-<count-1>
+func (dc *DataContract) ScanRange(ctx contractapi.TransactionContextInterface, startKey string, endKey string) (*ScanResult, error) {
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, errors.New("Unable to interact with world state")
+	}
+	defer iterator.Close()
+
+	result := &ScanResult{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, errors.New("Unable to interact with world state")
+		}
+		result.Keys++
+		result.Bytes += len(kv.Value)
+	}
+	return result, nil
+}
+
+// StoreIndexed writes a value of payloadSize bytes under the composite key objectType~owner~id
+func (dc *DataContract) StoreIndexed(ctx contractapi.TransactionContextInterface, objectType string, owner string, id string, payloadSize int) error {
+	if payloadSize < 0 || payloadSize > maxPayload {
+		return fmt.Errorf("invalid payload size %v", payloadSize)
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, []string{owner, id})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, payload(ctx, payloadSize))
+	if err != nil {
+		return errors.New("Unable to interact with world state")
+	}
+	return nil
+}
+
+// QueryIndexed reads every value of an owner written with StoreIndexed
+func (dc *DataContract) QueryIndexed(ctx contractapi.TransactionContextInterface, objectType string, owner string) (*ScanResult, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, []string{owner})
+	if err != nil {
+		return nil, errors.New("Unable to interact with world state")
+	}
+	defer iterator.Close()
+
+	result := &ScanResult{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, errors.New("Unable to interact with world state")
+		}
+		result.Keys++
+		result.Bytes += len(kv.Value)
+	}
+	return result, nil
+}
+
+// Increment adds one to a counter and returns its new value. Concurrent increments of the same
+// counter read the same version, all but one of the transactions of a block fail validation with
+// MVCC_READ_CONFLICT, which is what a few hot counters are used to measure
+func (dc *DataContract) Increment(ctx contractapi.TransactionContextInterface, key string) (int, error) {
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, errors.New("Unable to interact with world state")
+	}
+	counter := 0
+	if existing != nil {
+		counter, err = strconv.Atoi(string(existing))
+		if err != nil {
+			return 0, fmt.Errorf("key %s does not hold a counter", key)
+		}
+	}
+	counter++
+	err = ctx.GetStub().PutState(key, []byte(strconv.Itoa(counter)))
+	if err != nil {
+		return 0, errors.New("Unable to interact with world state")
+	}
+	return counter, nil
+}
+
+// StorePrivate writes a value of payloadSize bytes to a private data collection, the implicit
+// collection of the client's organization if collection is empty. The value is taken from the
+// transient field "payload" if it is set, so that it does not appear in the transaction
+func (dc *DataContract) StorePrivate(ctx contractapi.TransactionContextInterface, collection string, key string, payloadSize int) error {
+	if collection == "" {
+		mspID, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return fmt.Errorf("failed to get client MSP ID: %v", err)
+		}
+		collection = "_implicit_org_" + mspID
+	}
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("error getting transient: %v", err)
+	}
+	value, ok := transientMap["payload"]
+	if !ok {
+		if payloadSize < 0 || payloadSize > maxPayload {
+			return fmt.Errorf("invalid payload size %v", payloadSize)
+		}
+		value = payload(ctx, payloadSize)
+	} else if len(value) > maxPayload {
+		return fmt.Errorf("invalid payload size %v", len(value))
+	}
+	err = ctx.GetStub().PutPrivateData(collection, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to put private data: %v", err)
+	}
+	return nil
+}
+
+// KeyHistory returns the versions of a key, the peers must keep the history database enabled
+func (dc *DataContract) KeyHistory(ctx contractapi.TransactionContextInterface, key string) ([]HistoryEntry, error) {
+	iterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history of key %s: %v", key, err)
+	}
+	defer iterator.Close()
+
+	history := []HistoryEntry{}
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get history of key %s: %v", key, err)
+		}
+		history = append(history, HistoryEntry{
+			TxID:      modification.TxId,
+			Timestamp: modification.Timestamp.GetSeconds(),
+			IsDelete:  modification.IsDelete,
+			Size:      len(modification.Value),
+		})
+	}
+	return history, nil
+}
+
+// batchKey returns the key of the i-th value of a batch, the index is padded so that the keys of a
+// batch are in the order of a range scan
+func batchKey(prefix string, i int) string {
+	return fmt.Sprintf("%s-%06d", prefix, i)
+}
+
+// payload returns a value of size bytes, at most maxPayload. It is built from the transaction ID,
+// so that every endorsing peer writes the same value
+func payload(ctx contractapi.TransactionContextInterface, size int) []byte {
+	if size > maxPayload {
+		size = maxPayload
+	}
+	seed := []byte(ctx.GetStub().GetTxID())
+	if len(seed) == 0 {
+		seed = []byte{0}
+	}
+	return bytes.Repeat(seed, size/len(seed)+1)[:size]
+}
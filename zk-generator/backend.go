@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"io"
+)
+
+// backendGroth16 is the backend of the manifest, the proof system of the proofs of the winner
+const backendGroth16 = "groth16"
+
+// groth16Keys proves and verifies with the circuit and keys of a directory
+type groth16Keys struct {
+	r1cs frontend.CompiledConstraintSystem
+	pk   groth16.ProvingKey
+	vk   groth16.VerifyingKey
+}
+
+func (k *groth16Keys) prove(witness *circuit.AuctionCircuit) (io.WriterTo, error) {
+	return groth16.Prove(k.r1cs, k.pk, witness)
+}
+
+func (k *groth16Keys) verify(proofBytes []byte, publicWitness *circuit.AuctionCircuit) error {
+	proof := groth16.NewProof(ecc.BLS12_381)
+	_, err := proof.ReadFrom(bytes.NewReader(proofBytes))
+	if err != nil {
+		return fmt.Errorf("invalid proof: %v", err)
+	}
+	return groth16.Verify(proof, k.vk, publicWitness)
+}
+
+// readKeys reads the keys of dir, the circuit and the proving key are only read if withProver is set
+func readKeys(dir string, manifest *Manifest, withProver bool) (*groth16Keys, error) {
+	if manifest.Backend != backendGroth16 {
+		return nil, fmt.Errorf("unknown backend %q", manifest.Backend)
+	}
+	keys := new(groth16Keys)
+	var err error
+	if withProver {
+		if keys.r1cs, err = readCircuit(dir); err != nil {
+			return nil, err
+		}
+		if keys.pk, err = readProvingKey(dir); err != nil {
+			return nil, err
+		}
+	}
+	keys.vk, err = readVerifyingKey(dir)
+	return keys, err
+}
+
+// encodeObject returns the encoding of a proof or a key
+func encodeObject(object io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := object.WriteTo(&buf)
+	return buf.Bytes(), err
+}
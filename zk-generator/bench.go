@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+)
+
+// bench measures the auction circuit: the time of the setup, of a proof and of a verification, and the size of
+// the proof and of the verifying key, which the chaincode stores and checks
+func bench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	maxBids := flags.Int("max-bids", MaxBids, "maximum number of bids in a proof")
+	runs := flags.Int("runs", 3, "number of proofs and verifications")
+	flags.Parse(args)
+	if *maxBids < 1 || *runs < 1 {
+		log.Fatalf("the maximum number of bids and the number of runs must be positive")
+	}
+
+	values := make([]int, *maxBids)
+	for i := range values {
+		values[i] = 100 + i
+	}
+	winner := *maxBids - 1
+	bids := commitValues(values)
+
+	fmt.Printf("Benchmarking %v\n", backendGroth16)
+	start := time.Now()
+	keys := newGroth16Keys(*maxBids)
+	vkBytes, err := encodeObject(keys.vk)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	setup := time.Since(start)
+
+	var prove, verify time.Duration
+	var proofSize int
+	for i := 0; i < *runs; i++ {
+		start = time.Now()
+		proof, err := keys.prove(buildWitness(bids, winner))
+		if err != nil {
+			log.Fatalf("prove failed: %v", err)
+		}
+		prove += time.Since(start)
+		encoded, err := encodeObject(proof)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		proofSize = len(encoded)
+
+		start = time.Now()
+		err = keys.verify(encoded, buildPublicWitness(bids, winner))
+		if err != nil {
+			log.Fatalf("verify failed: %v", err)
+		}
+		verify += time.Since(start)
+	}
+	prove /= time.Duration(*runs)
+	verify /= time.Duration(*runs)
+
+	fmt.Printf("\n%v bids, average of %v runs\n", *maxBids, *runs)
+	fmt.Printf("%-8v %12v %12v %12v %12v %12v\n", "backend", "setup", "prove", "verify", "proof (B)", "vk (B)")
+	fmt.Printf("%-8v %12v %12v %12v %12v %12v\n", backendGroth16, setup.Round(time.Millisecond),
+		prove.Round(time.Millisecond), verify.Round(time.Microsecond), proofSize, len(vkBytes))
+}
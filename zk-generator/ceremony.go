@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// The ceremony replaces the single party setup. Phase 1 computes powers of tau that do not depend on the
+// circuit, phase 2 derives the parameters of the auction circuit from them. The coordinator creates each
+// phase, the participants contribute in turn by passing the file to the next one, and anyone can verify
+// the final keys against the transcript of contributions. The keys are safe as long as one participant
+// of each phase destroyed their secrets
+
+// phase1New creates an empty phase 1 accumulator, large enough for the circuit of maxBids bids
+func phase1New(args []string) {
+	flags := flag.NewFlagSet("phase1-new", flag.ExitOnError)
+	power := flags.Int("power", 0, "log2 of the size of the accumulator, derived from the circuit if zero")
+	maxBids := flags.Int("max-bids", MaxBids, "maximum number of bids in a proof, used if no power is given")
+	out := flags.String("out", "phase1", "output accumulator file")
+	flags.Parse(args)
+
+	if *power == 0 {
+		r1cs := compileAuctionCircuit(*maxBids)
+		*power = powerFor(r1cs.GetNbConstraints())
+	}
+	if *power < 1 || *power > 28 {
+		log.Fatalf("invalid power %v", *power)
+	}
+	err := writeObject(*out, NewPhase1(*power))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Printf("Phase 1 accumulator of power %v written to %v\n", *power, *out)
+}
+
+// phase1Contribute adds a contribution to a phase 1 accumulator
+func phase1Contribute(args []string) {
+	flags := flag.NewFlagSet("phase1-contribute", flag.ExitOnError)
+	in := flags.String("in", "phase1", "input accumulator file")
+	out := flags.String("out", "phase1.next", "output accumulator file")
+	flags.Parse(args)
+
+	phase1 := new(Phase1)
+	err := readObject(*in, phase1)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = phase1.Contribute()
+	if err != nil {
+		log.Fatalf("contribution failed: %v", err)
+	}
+	err = writeObject(*out, phase1)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	hash, err := phase1.challenge()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Printf("Contribution %v written to %v\n", len(phase1.Contributions), *out)
+	fmt.Printf("Contribution hash: %x\n", hash)
+}
+
+// phase1Verify checks a phase 1 accumulator and prints its contributions
+func phase1Verify(args []string) {
+	flags := flag.NewFlagSet("phase1-verify", flag.ExitOnError)
+	in := flags.String("in", "phase1", "accumulator file")
+	flags.Parse(args)
+
+	phase1 := new(Phase1)
+	err := readObject(*in, phase1)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = phase1.Verify()
+	if err != nil {
+		log.Fatalf("invalid phase 1: %v", err)
+	}
+	fmt.Printf("Phase 1 of power %v is valid\n", phase1.Power)
+	for i := range phase1.Contributions {
+		hash, err := phase1.Contributions[i].hash()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("  contribution %v: %x\n", i+1, hash)
+	}
+}
+
+// phase2New derives the initial phase 2 parameters of the circuit from a verified phase 1 accumulator, and
+// writes the compiled circuit into the key directory
+func phase2New(args []string) {
+	flags := flag.NewFlagSet("phase2-new", flag.ExitOnError)
+	phase1Path := flags.String("phase1", "phase1", "final phase 1 accumulator file")
+	maxBids := flags.Int("max-bids", MaxBids, "maximum number of bids in a proof")
+	dir := flags.String("dir", ".", "directory of the circuit and keys")
+	out := flags.String("out", "phase2", "output parameters file")
+	flags.Parse(args)
+
+	phase1 := readVerifiedPhase1(*phase1Path)
+	r1cs := compileAuctionCircuit(*maxBids)
+	phase2, err := NewPhase2(phase1, r1cs, *maxBids)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = os.MkdirAll(*dir, 0755)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = writeObject(filepath.Join(*dir, "circuit"), r1cs)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = writeObject(*out, phase2)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Printf("Phase 2 parameters for %v bids written to %v\n", *maxBids, *out)
+}
+
+// phase2Contribute adds a contribution to the phase 2 parameters
+func phase2Contribute(args []string) {
+	flags := flag.NewFlagSet("phase2-contribute", flag.ExitOnError)
+	in := flags.String("in", "phase2", "input parameters file")
+	out := flags.String("out", "phase2.next", "output parameters file")
+	flags.Parse(args)
+
+	phase2 := new(Phase2)
+	err := readObject(*in, phase2)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = phase2.Contribute()
+	if err != nil {
+		log.Fatalf("contribution failed: %v", err)
+	}
+	err = writeObject(*out, phase2)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	hash, err := phase2.challenge()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Printf("Contribution %v written to %v\n", len(phase2.Contributions), *out)
+	fmt.Printf("Contribution hash: %x\n", hash)
+}
+
+// ceremonyFinalize writes the keys of the final phase 2 parameters and their manifest into the key directory
+func ceremonyFinalize(args []string) {
+	flags := flag.NewFlagSet("ceremony-finalize", flag.ExitOnError)
+	phase2Path := flags.String("phase2", "phase2", "final phase 2 parameters file")
+	dir := flags.String("dir", ".", "directory of the circuit and keys")
+	flags.Parse(args)
+
+	phase2 := new(Phase2)
+	err := readObject(*phase2Path, phase2)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(phase2.Contributions) == 0 {
+		log.Fatalf("phase 2 has no contribution")
+	}
+	r1cs, err := readCircuit(*dir)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	pk, vk, err := phase2.Keys()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	manifest, err := writeKeys(*dir, int(phase2.MaxBids), backendGroth16, r1cs, pk, vk)
+	if err != nil {
+		log.Fatalf("failed to write keys: %v", err)
+	}
+	for _, name := range keyFileNames {
+		fmt.Printf("%v: %v\n", name, manifest.Files[name])
+	}
+}
+
+// ceremonyVerify checks the keys of a directory against the transcripts of both phases: the circuit of the
+// directory is the auction circuit, the phase 2 parameters derive from phase 1 and that circuit through valid
+// contributions, and the keys are the ones of the final parameters. The circuit hash of the key in the registry
+// of the chaincode, returned by QueryVerifyingKey, must be the one of the circuit
+func ceremonyVerify(args []string) {
+	flags := flag.NewFlagSet("ceremony-verify", flag.ExitOnError)
+	phase1Path := flags.String("phase1", "phase1", "final phase 1 accumulator file")
+	phase2Path := flags.String("phase2", "phase2", "final phase 2 parameters file")
+	dir := flags.String("dir", ".", "directory of the circuit and keys")
+	registryHash := flags.String("registry-circuit-hash", "", "circuit hash of the key in the registry of the chaincode")
+	flags.Parse(args)
+	if *registryHash == "" {
+		log.Fatalf("the circuit hash of the key in the registry is required")
+	}
+
+	phase1 := readVerifiedPhase1(*phase1Path)
+	if len(phase1.Contributions) == 0 {
+		log.Fatalf("phase 1 has no contribution")
+	}
+	phase2 := new(Phase2)
+	err := readObject(*phase2Path, phase2)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(phase2.Contributions) == 0 {
+		log.Fatalf("phase 2 has no contribution")
+	}
+	manifest, err := readManifest(*dir)
+	if err != nil {
+		log.Fatalf("failed to read manifest: %v", err)
+	}
+	err = manifest.checkFingerprints(*dir)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if manifest.Backend != backendGroth16 {
+		log.Fatalf("the ceremony only produces %v keys, manifest is for %v", backendGroth16, manifest.Backend)
+	}
+	if manifest.MaxBids != int(phase2.MaxBids) {
+		log.Fatalf("manifest is for %v bids, phase 2 for %v", manifest.MaxBids, phase2.MaxBids)
+	}
+
+	// gnark does not write the same circuit file twice, the circuit of the directory is compared with a fresh
+	// compilation by their hash. The phase 2 parameters are bound to the hash from the first contribution
+	expected, err := circuitHash(compileAuctionCircuit(manifest.MaxBids))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	r1cs, err := readCircuit(*dir)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	actual, err := circuitHash(r1cs)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if actual != expected {
+		log.Fatalf("circuit of %v is not the auction circuit for %v bids", *dir, manifest.MaxBids)
+	}
+	if phase2.CircuitHash != expected {
+		log.Fatalf("phase 2 is not for the auction circuit for %v bids", manifest.MaxBids)
+	}
+	hash := hex.EncodeToString(expected[:])
+	if manifest.CircuitHash != hash {
+		log.Fatalf("manifest has circuit hash %v, the circuit %v", manifest.CircuitHash, hash)
+	}
+	if *registryHash != hash {
+		log.Fatalf("the key in the registry has circuit hash %v, the circuit %v", *registryHash, hash)
+	}
+
+	initial, err := NewPhase2(phase1, r1cs, manifest.MaxBids)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = phase2.Verify(initial)
+	if err != nil {
+		log.Fatalf("invalid phase 2: %v", err)
+	}
+
+	pk, vk, err := phase2.Keys()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	var pkBuf, vkBuf bytes.Buffer
+	if _, err = pk.WriteTo(&pkBuf); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if _, err = vk.WriteTo(&vkBuf); err != nil {
+		log.Fatalf("%v", err)
+	}
+	checkFileContent(filepath.Join(*dir, "pk"), pkBuf.Bytes())
+	checkFileContent(filepath.Join(*dir, "vk"), vkBuf.Bytes())
+
+	fmt.Printf("Keys for %v bids match the ceremony\n", manifest.MaxBids)
+	fmt.Printf("  circuit hash: %v\n", hash)
+	for i := range phase1.Contributions {
+		hash, err := phase1.Contributions[i].hash()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("  phase 1 contribution %v: %x\n", i+1, hash)
+	}
+	for i := range phase2.Contributions {
+		hash, err := phase2.Contributions[i].hash()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("  phase 2 contribution %v: %x\n", i+1, hash)
+	}
+}
+
+func compileAuctionCircuit(maxBids int) frontend.CompiledConstraintSystem {
+	if maxBids < 1 {
+		log.Fatalf("the maximum number of bids must be positive")
+	}
+	r1cs, err := frontend.Compile(ecc.BLS12_381, backend.GROTH16, circuit.NewAuctionCircuit(maxBids))
+	if err != nil {
+		log.Fatalf("compilation of the circuit failed: %v", err)
+	}
+	return r1cs
+}
+
+func readVerifiedPhase1(path string) *Phase1 {
+	phase1 := new(Phase1)
+	err := readObject(path, phase1)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = phase1.Verify()
+	if err != nil {
+		log.Fatalf("invalid phase 1: %v", err)
+	}
+	return phase1
+}
+
+// checkFileContent exits if a file does not hold the expected bytes
+func checkFileContent(path string, expected []byte) {
+	actual, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if !bytes.Equal(actual, expected) {
+		log.Fatalf("%v does not match the ceremony", path)
+	}
+}
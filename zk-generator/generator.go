@@ -2,153 +2,91 @@ package main
 
 import (
 	"fmt"
-	"github.com/consensys/gnark-crypto/ecc"
-	"github.com/consensys/gnark/backend"
-	"github.com/consensys/gnark/backend/groth16"
-	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/std/algebra/twistededwards"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/ckiere/test-network/auction-circuit/commitment"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/twistededwards"
 	"log"
-	"os"
 )
 
+// MaxBids is the default number of bids in a proof
 const MaxBids = 10
 
-type AuctionCircuit struct {
-	// struct tags on a variable is optional
-	// default uses variable name and secret visibility.
-	Values [MaxBids]frontend.Variable
-	Rs [MaxBids]frontend.Variable
-	ComsX [MaxBids]frontend.Variable `gnark:",public"`
-	ComsY [MaxBids]frontend.Variable `gnark:",public"`
-	WinningValue frontend.Variable
-	WinningR frontend.Variable
-	WinningComX frontend.Variable `gnark:",public"`
-	WinningComY frontend.Variable `gnark:",public"`
-}
-
-// Define declares the circuit constraints
-func (circuit *AuctionCircuit) Define(curveID ecc.ID, cs *frontend.ConstraintSystem) error {
-	// constants
-	curve, _ := twistededwards.NewEdCurve(ecc.BLS12_381)
-	// check winning commitment
-	circuit.CheckCommitment(curve, circuit.WinningValue, circuit.WinningR, circuit.WinningComX, circuit.WinningComY, cs)
-	// check all other bids (valid commitment and value lower than winning bid)
-	for i := 0; i < MaxBids; i++ {
-		circuit.CheckCommitment(curve, circuit.Values[i], circuit.Rs[i], circuit.ComsX[i], circuit.ComsY[i], cs)
-		cs.AssertIsLessOrEqual(circuit.Values[i], circuit.WinningValue)
-	}
-	return nil
-}
-
-func (circuit *AuctionCircuit) CheckCommitment(curve twistededwards.EdCurve, value, r, comX, comY frontend.Variable, cs *frontend.ConstraintSystem) {
-	// com = g^value h^r
-	com := twistededwards.Point{}
-	com.ScalarMulFixedBase(cs, curve.BaseX, curve.BaseY, value, curve)
-	temp := twistededwards.Point{}
-	temp.ScalarMulFixedBase(cs, hx, hy, r, curve)
-	com.AddGeneric(cs, &com, &temp, curve)
-	cs.AssertIsEqual(com.X, comX)
-	cs.AssertIsEqual(com.Y, comY)
-}
+// testProof proves and verifies an auction with two equal highest bids, and checks that only the earliest
+// one can be declared the winner
+func testProof(keys *groth16Keys, maxBids int) {
+	fmt.Println("Building proof")
 
-func main() {
-	var circuit AuctionCircuit
+	// two equal highest bids, the earliest one wins
+	winningValue := 500
+	winningIndex := maxBids / 3
+	tieIndex := maxBids - 1
 
-	// compiles our circuit into a R1CS
-	r1cs, err := frontend.Compile(ecc.BLS12_381, backend.GROTH16, &circuit)
-	if err != nil {
-		log.Fatalf("compilation of the circuit failed: %v", err)
+	values := make([]int, maxBids)
+	for i := 0; i < maxBids; i++ {
+		values[i] = 100 + i
+		if i == winningIndex || i == tieIndex {
+			values[i] = winningValue
+		}
 	}
-	fmt.Printf("Nb constraints: %v", r1cs.GetNbConstraints())
-	pk, vk, err := groth16.Setup(r1cs)
-	if err != nil {
-		log.Fatalf("setup failed: %v", err)
-	}
-	file, err := os.OpenFile("circuit", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0700)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-	_, err = r1cs.WriteTo(file)
-	if err != nil {
-		panic(err)
-	}
-	pkFile, err := os.OpenFile("pk", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0700)
-	if err != nil {
-		panic(err)
-	}
-	defer pkFile.Close()
-	_, err = pk.WriteTo(pkFile)
+	bids := commitValues(values)
+
+	proof, err := keys.prove(buildWitness(bids, winningIndex))
 	if err != nil {
-		panic(err)
+		log.Fatalf("prove failed: %v", err)
 	}
-	vkFile, err := os.OpenFile("vk", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0700)
+	encoded, err := encodeObject(proof)
 	if err != nil {
-		panic(err)
+		log.Fatalf("%v", err)
 	}
-	defer vkFile.Close()
-	_, err = vk.WriteTo(vkFile)
+	fmt.Println("Verifying")
+	err = keys.verify(encoded, buildPublicWitness(bids, winningIndex))
 	if err != nil {
-		panic(err)
+		log.Fatalf("verify failed :%v", err)
 	}
 
-	testProof(r1cs, pk, vk)
-
-	/*com, r, _ := Commit(100)
-	fmt.Println(CheckCommit(100, r, com))
-	c, s1, s2, err := ProveCommit(100, r, com.Marshal(), nil)
-	if err != nil {
-		panic(err)
+	// declaring the later of the two equal bids as the winner must fail
+	if winningIndex != tieIndex {
+		_, err = keys.prove(buildWitness(bids, tieIndex))
+		if err == nil {
+			log.Fatalf("prove succeeded for a winner that does not respect the tie-break rule")
+		}
+		fmt.Println("Tie-break rule enforced")
 	}
-	fmt.Println(CheckCommitProof(c, s1, s2, com.Marshal(), nil))*/
-
 }
 
-func testProof(r1cs frontend.CompiledConstraintSystem, pk groth16.ProvingKey, vk groth16.VerifyingKey) {
-	fmt.Println("Building proof")
-	var witness AuctionCircuit
-	var solution AuctionCircuit
-	n := 10
-
-	winningValue := 500
-
-	witness.WinningValue.Assign(winningValue)
-	winningCom, winningR, _ := Commit(winningValue)
-	witness.WinningR.Assign(winningR)
-	witness.WinningComX.Assign(winningCom.X)
-	witness.WinningComY.Assign(winningCom.Y)
-	solution.WinningComX.Assign(winningCom.X)
-	solution.WinningComY.Assign(winningCom.Y)
-
-	for i := 0; i < n ; i++ {
-		value := 100 + i
-		witness.Values[i].Assign(value)
-		com, r, _ := Commit(value)
-		witness.Rs[i].Assign(r)
-		witness.ComsX[i].Assign(com.X)
-		witness.ComsY[i].Assign(com.Y)
-		solution.ComsX[i].Assign(com.X)
-		solution.ComsY[i].Assign(com.Y)
+// commitValues commits to each value
+func commitValues(values []int) []circuit.Bid {
+	bids := make([]circuit.Bid, len(values))
+	for i := range values {
+		com, r, err := commitment.Commit(values[i])
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		bids[i] = circuit.Bid{Commitment: *com, Value: values[i], R: r}
 	}
-	// fill non used bids with a value of 0
-	for i := n; i < MaxBids ; i++ {
-		value := 0
-		com, r, _ := Commit(value)
-		witness.Values[i].Assign(value)
-		witness.Rs[i].Assign(r)
+	return bids
+}
 
-		witness.ComsX[i].Assign(com.X)
-		witness.ComsY[i].Assign(com.Y)
-		solution.ComsX[i].Assign(com.X)
-		solution.ComsY[i].Assign(com.Y)
-	}
-	proof, err := groth16.Prove(r1cs, pk, &witness)
+// buildWitness creates the full witness of an auction in which the bid at index winner wins
+func buildWitness(bids []circuit.Bid, winner int) *circuit.AuctionCircuit {
+	witness, err := circuit.NewWitness(len(bids), bids, winner)
 	if err != nil {
-		log.Fatalf("prove failed: %v", err)
+		log.Fatalf("%v", err)
 	}
-	fmt.Println("Verifying")
-	err = groth16.Verify(proof, vk, &solution)
+	return witness
+}
+
+// buildPublicWitness creates the public part of the witness, used to verify a proof
+func buildPublicWitness(bids []circuit.Bid, winner int) *circuit.AuctionCircuit {
+	coms := make([]twistededwards.PointAffine, len(bids))
+	excluded := make([]bool, len(bids))
+	for i := range bids {
+		coms[i] = bids[i].Commitment
+		excluded[i] = bids[i].Excluded
+	}
+	public, err := circuit.NewPublicWitness(len(bids), coms, excluded, winner)
 	if err != nil {
-		log.Fatalf("verify failed :%v", err)
+		log.Fatalf("%v", err)
 	}
-}
\ No newline at end of file
+	return public
+}
@@ -3,6 +3,9 @@ module github.com/ckiere/test-network/zk-generator
 go 1.15
 
 require (
+	github.com/ckiere/test-network/auction-circuit v0.0.0
 	github.com/consensys/gnark v0.4.0
 	github.com/consensys/gnark-crypto v0.4.1-0.20210428083642-6bd055b79906
 )
+
+replace github.com/ckiere/test-network/auction-circuit => ../auction-circuit
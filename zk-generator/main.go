@@ -0,0 +1,257 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+func main() {
+	argc := len(os.Args)
+	if argc > 1 {
+		cmd := os.Args[1]
+		args := os.Args[2:]
+		if cmd == "setup" {
+			setup(args)
+		} else if cmd == "verify-keys" {
+			verifyKeys(args)
+		} else if cmd == "prove" {
+			prove(args)
+		} else if cmd == "verify" {
+			verify(args)
+		} else if cmd == "inspect" {
+			inspect(args)
+		} else if cmd == "bench" {
+			bench(args)
+		} else if cmd == "phase1-new" {
+			phase1New(args)
+		} else if cmd == "phase1-contribute" {
+			phase1Contribute(args)
+		} else if cmd == "phase1-verify" {
+			phase1Verify(args)
+		} else if cmd == "phase2-new" {
+			phase2New(args)
+		} else if cmd == "phase2-contribute" {
+			phase2Contribute(args)
+		} else if cmd == "ceremony-finalize" {
+			ceremonyFinalize(args)
+		} else if cmd == "ceremony-verify" {
+			ceremonyVerify(args)
+		} else {
+			fmt.Println("Unknown command")
+		}
+	} else {
+		fmt.Println("No command given as argument")
+	}
+}
+
+// setup compiles the circuit for a maximum number of bids, runs the Groth16 setup and writes the circuit, the
+// keys and their manifest into the output directory
+func setup(args []string) {
+	flags := flag.NewFlagSet("setup", flag.ExitOnError)
+	maxBids := flags.Int("max-bids", MaxBids, "maximum number of bids in a proof")
+	out := flags.String("out", ".", "output directory")
+	test := flags.Bool("test", false, "prove and verify a sample auction with the new keys")
+	flags.Parse(args)
+	if *maxBids < 1 {
+		log.Fatalf("the maximum number of bids must be positive")
+	}
+
+	keys := newGroth16Keys(*maxBids)
+	manifest, err := writeKeys(*out, *maxBids, backendGroth16, keys.r1cs, keys.pk, keys.vk)
+	if err != nil {
+		log.Fatalf("failed to write keys: %v", err)
+	}
+	for _, name := range keyFileNames {
+		fmt.Printf("%v: %v\n", name, manifest.Files[name])
+	}
+
+	if *test {
+		testProof(keys, *maxBids)
+	}
+}
+
+func newGroth16Keys(maxBids int) *groth16Keys {
+	// compiles our circuit into a R1CS
+	r1cs, err := frontend.Compile(ecc.BLS12_381, backend.GROTH16, circuit.NewAuctionCircuit(maxBids))
+	if err != nil {
+		log.Fatalf("compilation of the circuit failed: %v", err)
+	}
+	fmt.Printf("Nb constraints: %v\n", r1cs.GetNbConstraints())
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		log.Fatalf("setup failed: %v", err)
+	}
+	return &groth16Keys{r1cs: r1cs, pk: pk, vk: vk}
+}
+
+// verifyKeys checks that the files of a directory match their manifest, and that the proving and verifying
+// keys belong to the circuit, by proving and verifying a sample auction
+func verifyKeys(args []string) {
+	flags := flag.NewFlagSet("verify-keys", flag.ExitOnError)
+	dir := flags.String("dir", ".", "directory of the circuit and keys")
+	flags.Parse(args)
+
+	manifest, err := readManifest(*dir)
+	if err != nil {
+		log.Fatalf("failed to read manifest: %v", err)
+	}
+	err = manifest.checkFingerprints(*dir)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	keys, err := readKeys(*dir, manifest, true)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	// the public inputs of the circuit, Groth16 adds the constant wire
+	nbPublic := len(circuit.PublicInputLayout(circuit.NewAuctionCircuit(manifest.MaxBids)))
+	_, _, nbCircuitPublic := keys.r1cs.GetNbVariables()
+	nbCircuitPublic--
+	if nbCircuitPublic != nbPublic {
+		log.Fatalf("circuit is not an auction circuit for %v bids", manifest.MaxBids)
+	}
+
+	values := make([]int, manifest.MaxBids)
+	for i := range values {
+		values[i] = i + 1
+	}
+	winner := manifest.MaxBids - 1
+	bids := commitValues(values)
+	proof, err := keys.prove(buildWitness(bids, winner))
+	if err != nil {
+		log.Fatalf("proving key does not match the circuit: %v", err)
+	}
+	encoded, err := encodeObject(proof)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = keys.verify(encoded, buildPublicWitness(bids, winner))
+	if err != nil {
+		log.Fatalf("verifying key does not match the proving key: %v", err)
+	}
+	fmt.Println("Keys match the circuit")
+}
+
+// prove computes a proof from a JSON witness file
+func prove(args []string) {
+	flags := flag.NewFlagSet("prove", flag.ExitOnError)
+	dir := flags.String("dir", ".", "directory of the circuit and keys")
+	witnessPath := flags.String("witness", "witness.json", "JSON witness file")
+	out := flags.String("out", "proof", "output proof file")
+	flags.Parse(args)
+
+	manifest, err := readManifest(*dir)
+	if err != nil {
+		log.Fatalf("failed to read manifest: %v", err)
+	}
+	witnessJSON, err := readWitnessFile(*witnessPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	witness, err := witnessJSON.fullAssignment(manifest.MaxBids)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	keys, err := readKeys(*dir, manifest, true)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	proof, err := keys.prove(witness)
+	if err != nil {
+		log.Fatalf("prove failed: %v", err)
+	}
+	encoded, err := encodeObject(proof)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	err = ioutil.WriteFile(*out, encoded, 0644)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Printf("%v proof written to %v\n", manifest.Backend, *out)
+}
+
+// verify checks a proof against the public part of a JSON witness file
+func verify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	dir := flags.String("dir", ".", "directory of the circuit and keys")
+	witnessPath := flags.String("witness", "witness.json", "JSON witness file, only the public fields are used")
+	proofPath := flags.String("proof", "proof", "proof file")
+	flags.Parse(args)
+
+	manifest, err := readManifest(*dir)
+	if err != nil {
+		log.Fatalf("failed to read manifest: %v", err)
+	}
+	witnessJSON, err := readWitnessFile(*witnessPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	publicWitness, err := witnessJSON.publicAssignment(manifest.MaxBids)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	keys, err := readKeys(*dir, manifest, false)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	proof, err := ioutil.ReadFile(*proofPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	err = keys.verify(proof, publicWitness)
+	if err != nil {
+		log.Fatalf("verify failed: %v", err)
+	}
+	fmt.Println("Proof is valid")
+}
+
+// inspect prints the size of a circuit and the layout of its public inputs. The circuit is read from a
+// directory, or compiled for the given number of bids
+func inspect(args []string) {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory of the circuit, the circuit is compiled if empty")
+	maxBids := flags.Int("max-bids", MaxBids, "maximum number of bids in a proof, if the circuit is compiled")
+	flags.Parse(args)
+
+	var r1cs frontend.CompiledConstraintSystem
+	var err error
+	if *dir != "" {
+		manifest, err := readManifest(*dir)
+		if err != nil {
+			log.Fatalf("failed to read manifest: %v", err)
+		}
+		*maxBids = manifest.MaxBids
+		r1cs, err = readCircuit(*dir)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	} else {
+		r1cs, err = frontend.Compile(ecc.BLS12_381, backend.GROTH16, circuit.NewAuctionCircuit(*maxBids))
+		if err != nil {
+			log.Fatalf("compilation of the circuit failed: %v", err)
+		}
+	}
+
+	internal, secret, public := r1cs.GetNbVariables()
+	fmt.Printf("Max bids: %v\n", *maxBids)
+	fmt.Printf("Curve: %v\n", r1cs.CurveID())
+	fmt.Printf("Nb constraints: %v\n", r1cs.GetNbConstraints())
+	fmt.Printf("Nb variables: %v internal, %v secret, %v public\n", internal, secret, public)
+	fmt.Println("Public inputs:")
+	fmt.Println("  0: one (constant)")
+	for i, name := range circuit.PublicInputLayout(circuit.NewAuctionCircuit(*maxBids)) {
+		fmt.Printf("  %v: %v\n", i+1, name)
+	}
+}
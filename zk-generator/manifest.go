@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const manifestFileName = "manifest.json"
+
+// names of the files written by the setup, relative to the output directory
+var keyFileNames = []string{"circuit", "pk", "vk"}
+
+// Manifest describes the files written by the setup. Files maps each file name to the hex encoded
+// SHA-256 fingerprint of its content, so that the auctioneer and the chaincode can agree on a key version.
+// CircuitHash is the hash of the circuit that does not depend on its compilation, registered with the key
+type Manifest struct {
+	MaxBids       int               `json:"maxBids"`
+	Curve         string            `json:"curve"`
+	Backend       string            `json:"backend"`
+	NbConstraints int               `json:"nbConstraints"`
+	CircuitHash   string            `json:"circuitHash"`
+	Files         map[string]string `json:"files"`
+}
+
+// writeKeys writes the circuit, the keys of a backend and the manifest into dir
+func writeKeys(dir string, maxBids int, backend string, r1cs frontend.CompiledConstraintSystem, pk, vk io.WriterTo) (*Manifest, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := circuitHash(r1cs)
+	if err != nil {
+		return nil, err
+	}
+	objects := []io.WriterTo{r1cs, pk, vk}
+	manifest := &Manifest{
+		MaxBids:       maxBids,
+		Curve:         r1cs.CurveID().String(),
+		Backend:       backend,
+		NbConstraints: r1cs.GetNbConstraints(),
+		CircuitHash:   hex.EncodeToString(hash[:]),
+		Files:         make(map[string]string),
+	}
+	for i, name := range keyFileNames {
+		path := filepath.Join(dir, name)
+		err = writeObject(path, objects[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %v: %v", path, err)
+		}
+		manifest.Files[name], err = fingerprint(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, manifestFileName), manifestBytes, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// readManifest reads the manifest of dir
+func readManifest(dir string) (*Manifest, error) {
+	manifestBytes, err := ioutil.ReadFile(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	err = json.Unmarshal(manifestBytes, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// checkFingerprints checks that the files of dir have the fingerprints of the manifest
+func (m *Manifest) checkFingerprints(dir string) error {
+	for _, name := range keyFileNames {
+		expected, ok := m.Files[name]
+		if !ok {
+			return fmt.Errorf("manifest has no fingerprint for %v", name)
+		}
+		actual, err := fingerprint(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if actual != expected {
+			return fmt.Errorf("fingerprint of %v is %v, manifest has %v", name, actual, expected)
+		}
+	}
+	return nil
+}
+
+// readCircuit reads the compiled Groth16 circuit of dir
+func readCircuit(dir string) (frontend.CompiledConstraintSystem, error) {
+	r1cs := groth16.NewCS(ecc.BLS12_381)
+	err := readObject(filepath.Join(dir, "circuit"), r1cs)
+	return r1cs, err
+}
+
+// readProvingKey reads the proving key of dir
+func readProvingKey(dir string) (groth16.ProvingKey, error) {
+	pk := groth16.NewProvingKey(ecc.BLS12_381)
+	err := readObject(filepath.Join(dir, "pk"), pk)
+	return pk, err
+}
+
+// readVerifyingKey reads the verifying key of dir
+func readVerifyingKey(dir string) (groth16.VerifyingKey, error) {
+	vk := groth16.NewVerifyingKey(ecc.BLS12_381)
+	err := readObject(filepath.Join(dir, "vk"), vk)
+	return vk, err
+}
+
+// fingerprint returns the hex encoded SHA-256 hash of a file
+func fingerprint(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeObject(path string, object io.WriterTo) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0700)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = object.WriteTo(file)
+	return err
+}
+
+func readObject(path string, object io.ReaderFrom) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = object.ReadFrom(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %v: %v", path, err)
+	}
+	return nil
+}
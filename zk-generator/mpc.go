@@ -0,0 +1,310 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+	"math/big"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// domain separation tag of the points derived from the transcript
+const mpcDST = "AUCTION-GROTH16-MPC-V1"
+
+// contributionProof proves the knowledge of the secret x of a contribution, SX = S^x in G1 and RX = R^x in G2,
+// where R is derived from the challenge, S and SX. The challenge is the hash of the previous contribution,
+// so that a proof cannot be replayed in another transcript
+type contributionProof struct {
+	S, SX bls12381.G1Affine
+	RX    bls12381.G2Affine
+}
+
+func proveContribution(x *fr.Element, challenge []byte) (contributionProof, error) {
+	var proof contributionProof
+	var s fr.Element
+	if _, err := s.SetRandom(); err != nil {
+		return proof, err
+	}
+	_, _, g1, _ := bls12381.Generators()
+	var b big.Int
+	proof.S.ScalarMultiplication(&g1, s.ToBigIntRegular(&b))
+	proof.SX.ScalarMultiplication(&proof.S, x.ToBigIntRegular(&b))
+	r, err := challengePoint(challenge, &proof.S, &proof.SX)
+	if err != nil {
+		return proof, err
+	}
+	proof.RX.ScalarMultiplication(&r, &b)
+	return proof, nil
+}
+
+// verify checks the proof and returns the point R, so that the caller can check that the contribution
+// was made with the same x
+func (p *contributionProof) verify(challenge []byte) (bls12381.G2Affine, error) {
+	r, err := challengePoint(challenge, &p.S, &p.SX)
+	if err != nil {
+		return r, err
+	}
+	if p.S.IsInfinity() || p.SX.IsInfinity() || !sameRatio(&p.S, &p.SX, &r, &p.RX) {
+		return r, errors.New("invalid proof of knowledge")
+	}
+	return r, nil
+}
+
+func (p *contributionProof) encode(enc *bls12381.Encoder) error {
+	for _, v := range []interface{}{&p.S, &p.SX, &p.RX} {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *contributionProof) decode(dec *bls12381.Decoder) error {
+	for _, v := range []interface{}{&p.S, &p.SX, &p.RX} {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func challengePoint(challenge []byte, s, sx *bls12381.G1Affine) (bls12381.G2Affine, error) {
+	hash := sha256.New()
+	hash.Write(challenge)
+	sBytes := s.Bytes()
+	hash.Write(sBytes[:])
+	sxBytes := sx.Bytes()
+	hash.Write(sxBytes[:])
+	return bls12381.HashToCurveG2Svdw(hash.Sum(nil), []byte(mpcDST))
+}
+
+// sameRatio checks that b1 = a1^x and b2 = a2^x for the same x, that is e(a1, b2) = e(b1, a2)
+func sameRatio(a1, b1 *bls12381.G1Affine, a2, b2 *bls12381.G2Affine) bool {
+	var negB1 bls12381.G1Affine
+	negB1.Neg(b1)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{*a1, negB1}, []bls12381.G2Affine{*b2, *a2})
+	return err == nil && ok
+}
+
+// randomScalars returns n random scalars in regular form, as expected by MultiExp
+func randomScalars(n int) ([]fr.Element, error) {
+	scalars := make([]fr.Element, n)
+	for i := range scalars {
+		if _, err := scalars[i].SetRandom(); err != nil {
+			return nil, err
+		}
+		scalars[i].FromMont()
+	}
+	return scalars, nil
+}
+
+// powerPairsG1 returns random linear combinations of points[:n-1] and points[1:] with the same scalars. If the
+// points are successive powers, the second combination is the first one to the power of their ratio
+func powerPairsG1(points []bls12381.G1Affine) (lc, lcShifted bls12381.G1Affine, err error) {
+	scalars, err := randomScalars(len(points) - 1)
+	if err != nil {
+		return
+	}
+	lc.MultiExp(points[:len(points)-1], scalars)
+	lcShifted.MultiExp(points[1:], scalars)
+	return
+}
+
+// powerPairsG2 is powerPairsG1 in G2
+func powerPairsG2(points []bls12381.G2Affine) (lc, lcShifted bls12381.G2Affine, err error) {
+	scalars, err := randomScalars(len(points) - 1)
+	if err != nil {
+		return
+	}
+	lc.MultiExp(points[:len(points)-1], scalars)
+	lcShifted.MultiExp(points[1:], scalars)
+	return
+}
+
+// combinationsG1 returns the same random linear combination of two lists of points
+func combinationsG1(a, b []bls12381.G1Affine) (lcA, lcB bls12381.G1Affine, err error) {
+	if len(a) != len(b) {
+		err = errors.New("lists of points have different lengths")
+		return
+	}
+	scalars, err := randomScalars(len(a))
+	if err != nil {
+		return
+	}
+	lcA.MultiExp(a, scalars)
+	lcB.MultiExp(b, scalars)
+	return
+}
+
+// powers returns 1, x, ..., x^(n-1)
+func powers(x *fr.Element, n int) []fr.Element {
+	result := make([]fr.Element, n)
+	result[0].SetOne()
+	for i := 1; i < n; i++ {
+		result[i].Mul(&result[i-1], x)
+	}
+	return result
+}
+
+// scaleG1 multiplies each point by the matching scalar, in place
+func scaleG1(points []bls12381.G1Affine, scalars []fr.Element) {
+	parallelize(len(points), func(start, end int) {
+		var b big.Int
+		for i := start; i < end; i++ {
+			points[i].ScalarMultiplication(&points[i], scalars[i].ToBigIntRegular(&b))
+		}
+	})
+}
+
+// scaleG2 multiplies each point by the matching scalar, in place
+func scaleG2(points []bls12381.G2Affine, scalars []fr.Element) {
+	parallelize(len(points), func(start, end int) {
+		var b big.Int
+		for i := start; i < end; i++ {
+			points[i].ScalarMultiplication(&points[i], scalars[i].ToBigIntRegular(&b))
+		}
+	})
+}
+
+// lagrangeG1 converts [τ^i] for i < n into the Lagrange basis [L_i(τ)] of the domain, with an inverse FFT
+// in the group. L_i(τ) = 1/n Σ_j ω^(-ij) τ^j, as in the setup of gnark
+func lagrangeG1(points []bls12381.G1Affine, domain *fft.Domain) []bls12381.G1Affine {
+	n := len(points)
+	a := make([]bls12381.G1Jac, n)
+	for i := range points {
+		a[i].FromAffine(&points[i])
+	}
+	bitReverseIndexes(n, func(i, j int) { a[i], a[j] = a[j], a[i] })
+
+	twiddles := inverseTwiddles(domain, n)
+	for m := 2; m <= n; m <<= 1 {
+		half := m / 2
+		step := n / m
+		parallelize(n/2, func(start, end int) {
+			var t bls12381.G1Jac
+			for b := start; b < end; b++ {
+				k := (b/half)*m + b%half
+				t.Set(&a[k+half])
+				if j := b % half; j != 0 {
+					t.ScalarMultiplication(&t, &twiddles[j*step])
+				}
+				a[k+half].Set(&a[k]).SubAssign(&t)
+				a[k].AddAssign(&t)
+			}
+		})
+	}
+
+	var nInv big.Int
+	domain.CardinalityInv.ToBigIntRegular(&nInv)
+	parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			a[i].ScalarMultiplication(&a[i], &nInv)
+		}
+	})
+	result := make([]bls12381.G1Affine, n)
+	bls12381.BatchJacobianToAffineG1(a, result)
+	return result
+}
+
+// lagrangeG2 is lagrangeG1 in G2
+func lagrangeG2(points []bls12381.G2Affine, domain *fft.Domain) []bls12381.G2Affine {
+	n := len(points)
+	a := make([]bls12381.G2Jac, n)
+	for i := range points {
+		a[i].FromAffine(&points[i])
+	}
+	bitReverseIndexes(n, func(i, j int) { a[i], a[j] = a[j], a[i] })
+
+	twiddles := inverseTwiddles(domain, n)
+	for m := 2; m <= n; m <<= 1 {
+		half := m / 2
+		step := n / m
+		parallelize(n/2, func(start, end int) {
+			var t bls12381.G2Jac
+			for b := start; b < end; b++ {
+				k := (b/half)*m + b%half
+				t.Set(&a[k+half])
+				if j := b % half; j != 0 {
+					t.ScalarMultiplication(&t, &twiddles[j*step])
+				}
+				a[k+half].Set(&a[k]).SubAssign(&t)
+				a[k].AddAssign(&t)
+			}
+		})
+	}
+
+	var nInv big.Int
+	domain.CardinalityInv.ToBigIntRegular(&nInv)
+	result := make([]bls12381.G2Affine, n)
+	parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			a[i].ScalarMultiplication(&a[i], &nInv)
+			result[i].FromJacobian(&a[i])
+		}
+	})
+	return result
+}
+
+// inverseTwiddles returns ω^(-i) for i < n/2
+func inverseTwiddles(domain *fft.Domain, n int) []big.Int {
+	twiddles := make([]big.Int, n/2)
+	var w fr.Element
+	w.SetOne()
+	for i := range twiddles {
+		w.ToBigIntRegular(&twiddles[i])
+		w.Mul(&w, &domain.GeneratorInv)
+	}
+	return twiddles
+}
+
+// bitReverseIndexes calls swap for every pair of indexes that are exchanged by the bit reversal permutation
+func bitReverseIndexes(n int, swap func(i, j int)) {
+	nn := uint(bits.UintSize - bits.TrailingZeros(uint(n)))
+	for i := uint(0); i < uint(n); i++ {
+		irev := bits.Reverse(i) >> nn
+		if irev > i {
+			swap(int(i), int(irev))
+		}
+	}
+}
+
+// parallelize splits the iterations between the CPUs
+func parallelize(n int, work func(start, end int)) {
+	nbTasks := runtime.NumCPU()
+	if nbTasks > n {
+		nbTasks = n
+	}
+	if nbTasks <= 1 {
+		work(0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	chunk := (n + nbTasks - 1) / nbTasks
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			work(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// hashEncoded hashes the encoding of a value
+func hashEncoded(encode func(enc *bls12381.Encoder) error) ([]byte, error) {
+	hash := sha256.New()
+	err := encode(bls12381.NewEncoder(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash: %v", err)
+	}
+	return hash.Sum(nil), nil
+}
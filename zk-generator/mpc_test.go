@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+func TestContributionProof(t *testing.T) {
+	var x fr.Element
+	if _, err := x.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	_, _, g1, _ := bls12381.Generators()
+	var gx bls12381.G1Affine
+	gx.ScalarMultiplication(&g1, x.ToBigIntRegular(new(big.Int)))
+
+	proof, err := proveContribution(&x, []byte("challenge"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := proof.verify([]byte("challenge"))
+	if err != nil {
+		t.Fatalf("valid proof rejected: %v", err)
+	}
+	if !sameRatio(&g1, &gx, &r, &proof.RX) {
+		t.Error("the proof is not for the secret of the contribution")
+	}
+
+	// a proof cannot be replayed in another transcript
+	if _, err := proof.verify([]byte("other challenge")); err == nil {
+		t.Error("proof verified with another challenge")
+	}
+	tampered := proof
+	tampered.SX = g1
+	if _, err := tampered.verify([]byte("challenge")); err == nil {
+		t.Error("tampered proof verified")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"io"
+	"math/big"
+)
+
+// Phase1 is the powers of tau accumulator, it does not depend on the circuit. With n = 2^Power, it holds
+// [τ^i]1 for i < 2n, [ατ^i]1, [βτ^i]1 and [τ^i]2 for i < n, and [β]2. Every contribution multiplies
+// τ, α and β by secrets that are destroyed afterwards
+type Phase1 struct {
+	Power         uint64
+	TauG1         []bls12381.G1Affine
+	AlphaTauG1    []bls12381.G1Affine
+	BetaTauG1     []bls12381.G1Affine
+	TauG2         []bls12381.G2Affine
+	BetaG2        bls12381.G2Affine
+	Contributions []phase1Contribution
+}
+
+// phase1Contribution records the values after a contribution and the proofs of knowledge of its secrets
+type phase1Contribution struct {
+	TauG1, AlphaG1, BetaG1 bls12381.G1Affine
+	TauG2, BetaG2          bls12381.G2Affine
+	TauProof               contributionProof
+	AlphaProof             contributionProof
+	BetaProof              contributionProof
+}
+
+// NewPhase1 returns an accumulator of size 2^power with τ = α = β = 1
+func NewPhase1(power int) *Phase1 {
+	n := 1 << power
+	_, _, g1, g2 := bls12381.Generators()
+	p := &Phase1{
+		Power:      uint64(power),
+		TauG1:      make([]bls12381.G1Affine, 2*n),
+		AlphaTauG1: make([]bls12381.G1Affine, n),
+		BetaTauG1:  make([]bls12381.G1Affine, n),
+		TauG2:      make([]bls12381.G2Affine, n),
+		BetaG2:     g2,
+	}
+	for i := range p.TauG1 {
+		p.TauG1[i] = g1
+	}
+	for i := 0; i < n; i++ {
+		p.AlphaTauG1[i] = g1
+		p.BetaTauG1[i] = g1
+		p.TauG2[i] = g2
+	}
+	return p
+}
+
+// Contribute multiplies τ, α and β by fresh random secrets and appends the proofs of knowledge
+func (p *Phase1) Contribute() error {
+	var tau, alpha, beta fr.Element
+	for _, x := range []*fr.Element{&tau, &alpha, &beta} {
+		if _, err := x.SetRandom(); err != nil {
+			return err
+		}
+	}
+	challenge, err := p.challenge()
+	if err != nil {
+		return err
+	}
+
+	tauPowers := powers(&tau, len(p.TauG1))
+	scaleG1(p.TauG1, tauPowers)
+	scaleG2(p.TauG2, tauPowers[:len(p.TauG2)])
+	scaled := make([]fr.Element, len(p.AlphaTauG1))
+	for i := range scaled {
+		scaled[i].Mul(&tauPowers[i], &alpha)
+	}
+	scaleG1(p.AlphaTauG1, scaled)
+	for i := range scaled {
+		scaled[i].Mul(&tauPowers[i], &beta)
+	}
+	scaleG1(p.BetaTauG1, scaled)
+	p.BetaG2.ScalarMultiplication(&p.BetaG2, beta.ToBigIntRegular(new(big.Int)))
+
+	c := phase1Contribution{
+		TauG1:   p.TauG1[1],
+		AlphaG1: p.AlphaTauG1[0],
+		BetaG1:  p.BetaTauG1[0],
+		TauG2:   p.TauG2[1],
+		BetaG2:  p.BetaG2,
+	}
+	if c.TauProof, err = proveContribution(&tau, challenge); err != nil {
+		return err
+	}
+	if c.AlphaProof, err = proveContribution(&alpha, challenge); err != nil {
+		return err
+	}
+	if c.BetaProof, err = proveContribution(&beta, challenge); err != nil {
+		return err
+	}
+	p.Contributions = append(p.Contributions, c)
+	return nil
+}
+
+// Verify checks the chain of contributions and that the accumulator holds well formed powers of the
+// secrets of the last contribution
+func (p *Phase1) Verify() error {
+	n := 1 << p.Power
+	if len(p.TauG1) != 2*n || len(p.AlphaTauG1) != n || len(p.BetaTauG1) != n || len(p.TauG2) != n {
+		return errors.New("accumulator does not have the size of its power")
+	}
+	_, _, g1, g2 := bls12381.Generators()
+
+	// the secrets start at 1
+	last := phase1Contribution{TauG1: g1, AlphaG1: g1, BetaG1: g1, TauG2: g2, BetaG2: g2}
+	challenge := phase1InitialChallenge(p.Power)
+	for i := range p.Contributions {
+		c := &p.Contributions[i]
+		if c.TauG1.IsInfinity() || c.AlphaG1.IsInfinity() || c.BetaG1.IsInfinity() {
+			return fmt.Errorf("contribution %v has a zero secret", i)
+		}
+		steps := []struct {
+			name      string
+			prev, new *bls12381.G1Affine
+			proof     *contributionProof
+		}{
+			{"tau", &last.TauG1, &c.TauG1, &c.TauProof},
+			{"alpha", &last.AlphaG1, &c.AlphaG1, &c.AlphaProof},
+			{"beta", &last.BetaG1, &c.BetaG1, &c.BetaProof},
+		}
+		for _, step := range steps {
+			r, err := step.proof.verify(challenge)
+			if err != nil {
+				return fmt.Errorf("contribution %v: %v of %v", i, err, step.name)
+			}
+			if !sameRatio(step.prev, step.new, &r, &step.proof.RX) {
+				return fmt.Errorf("contribution %v: %v is not updated with the proven secret", i, step.name)
+			}
+		}
+		if !sameRatio(&g1, &c.TauG1, &g2, &c.TauG2) || !sameRatio(&g1, &c.BetaG1, &g2, &c.BetaG2) {
+			return fmt.Errorf("contribution %v: G1 and G2 values are inconsistent", i)
+		}
+		var err error
+		if challenge, err = c.hash(); err != nil {
+			return err
+		}
+		last = *c
+	}
+
+	if !p.TauG1[0].Equal(&g1) || !p.TauG2[0].Equal(&g2) {
+		return errors.New("accumulator does not start with the generators")
+	}
+	if !p.TauG1[1].Equal(&last.TauG1) || !p.AlphaTauG1[0].Equal(&last.AlphaG1) || !p.BetaTauG1[0].Equal(&last.BetaG1) ||
+		!p.TauG2[1].Equal(&last.TauG2) || !p.BetaG2.Equal(&last.BetaG2) {
+		return errors.New("accumulator does not match the last contribution")
+	}
+
+	// each list holds successive powers of τ
+	for _, points := range [][]bls12381.G1Affine{p.TauG1, p.AlphaTauG1, p.BetaTauG1} {
+		lc, lcShifted, err := powerPairsG1(points)
+		if err != nil {
+			return err
+		}
+		if !sameRatio(&lc, &lcShifted, &g2, &p.TauG2[1]) {
+			return errors.New("accumulator does not hold powers of tau in G1")
+		}
+	}
+	lc, lcShifted, err := powerPairsG2(p.TauG2)
+	if err != nil {
+		return err
+	}
+	if !sameRatio(&g1, &p.TauG1[1], &lc, &lcShifted) {
+		return errors.New("accumulator does not hold powers of tau in G2")
+	}
+	return nil
+}
+
+// challenge returns the challenge of the next contribution
+func (p *Phase1) challenge() ([]byte, error) {
+	if len(p.Contributions) == 0 {
+		return phase1InitialChallenge(p.Power), nil
+	}
+	return p.Contributions[len(p.Contributions)-1].hash()
+}
+
+func phase1InitialChallenge(power uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], power)
+	hash := sha256.Sum256(append([]byte("phase1"), buf[:]...))
+	return hash[:]
+}
+
+func (c *phase1Contribution) hash() ([]byte, error) {
+	return hashEncoded(c.encode)
+}
+
+func (c *phase1Contribution) encode(enc *bls12381.Encoder) error {
+	for _, v := range []interface{}{&c.TauG1, &c.AlphaG1, &c.BetaG1, &c.TauG2, &c.BetaG2} {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	for _, proof := range []*contributionProof{&c.TauProof, &c.AlphaProof, &c.BetaProof} {
+		if err := proof.encode(enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *phase1Contribution) decode(dec *bls12381.Decoder) error {
+	for _, v := range []interface{}{&c.TauG1, &c.AlphaG1, &c.BetaG1, &c.TauG2, &c.BetaG2} {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+	for _, proof := range []*contributionProof{&c.TauProof, &c.AlphaProof, &c.BetaProof} {
+		if err := proof.decode(dec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTo implements io.WriterTo
+func (p *Phase1) WriteTo(w io.Writer) (int64, error) {
+	enc := bls12381.NewEncoder(w)
+	for _, v := range []interface{}{p.Power, p.TauG1, p.AlphaTauG1, p.BetaTauG1, p.TauG2, &p.BetaG2, uint64(len(p.Contributions))} {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	for i := range p.Contributions {
+		if err := p.Contributions[i].encode(enc); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom implements io.ReaderFrom
+func (p *Phase1) ReadFrom(r io.Reader) (int64, error) {
+	dec := bls12381.NewDecoder(r)
+	var nbContributions uint64
+	for _, v := range []interface{}{&p.Power, &p.TauG1, &p.AlphaTauG1, &p.BetaTauG1, &p.TauG2, &p.BetaG2, &nbContributions} {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	if p.Power > 28 || nbContributions > 1<<16 {
+		return dec.BytesRead(), errors.New("invalid accumulator header")
+	}
+	p.Contributions = make([]phase1Contribution, nbContributions)
+	for i := range p.Contributions {
+		if err := p.Contributions[i].decode(dec); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	return dec.BytesRead(), nil
+}
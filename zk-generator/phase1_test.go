@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testPower is the power of the phase 1 of the tests, large enough for testCircuit
+const testPower = 3
+
+func TestPhase1(t *testing.T) {
+	phase1 := NewPhase1(testPower)
+	if err := phase1.Verify(); err != nil {
+		t.Fatalf("empty accumulator: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := phase1.Contribute(); err != nil {
+			t.Fatal(err)
+		}
+		if err := phase1.Verify(); err != nil {
+			t.Fatalf("contribution %v: %v", i, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := phase1.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read := new(Phase1)
+	if _, err := read.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := read.Verify(); err != nil || len(read.Contributions) != 2 {
+		t.Fatalf("accumulator read back: %v", err)
+	}
+}
+
+func TestPhase1Tampered(t *testing.T) {
+	phase1 := NewPhase1(testPower)
+	for i := 0; i < 2; i++ {
+		if err := phase1.Contribute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a power that is not the next one
+	tampered := *phase1
+	tampered.TauG1 = append(tampered.TauG1[:0:0], phase1.TauG1...)
+	tampered.TauG1[3] = tampered.TauG1[2]
+	if err := tampered.Verify(); err == nil {
+		t.Error("accumulator with a wrong power verified")
+	}
+	// a contribution whose proof is for another secret
+	tampered = *phase1
+	tampered.Contributions = append(tampered.Contributions[:0:0], phase1.Contributions...)
+	tampered.Contributions[1].TauProof = tampered.Contributions[0].TauProof
+	if err := tampered.Verify(); err == nil {
+		t.Error("contribution with the proof of another contribution verified")
+	}
+	// a contribution removed from the transcript
+	tampered = *phase1
+	tampered.Contributions = phase1.Contributions[1:]
+	if err := tampered.Verify(); err == nil {
+		t.Error("transcript with a missing contribution verified")
+	}
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+)
+
+// Phase2 holds the parameters of the auction circuit. The fixed part is derived from the phase 1 accumulator
+// and the circuit with γ = 1, the contributions multiply δ by their secret and divide K and Z by it. The hash of
+// the circuit is part of the fixed part, so the first contribution and all the next ones are bound to it
+type Phase2 struct {
+	MaxBids         uint64
+	NbConstraints   uint64
+	CircuitHash     [sha256.Size]byte
+	AlphaG1, BetaG1 bls12381.G1Affine
+	BetaG2          bls12381.G2Affine
+	A, B1           []bls12381.G1Affine
+	B2              []bls12381.G2Affine
+	VkK             []bls12381.G1Affine
+	DeltaG1         bls12381.G1Affine
+	DeltaG2         bls12381.G2Affine
+	K, Z            []bls12381.G1Affine
+	Contributions   []phase2Contribution
+}
+
+// phase2Contribution records δ after a contribution and the proof of knowledge of its secret
+type phase2Contribution struct {
+	DeltaG1 bls12381.G1Affine
+	Proof   contributionProof
+}
+
+// r1csTerm is a term of a constraint, coeff * wire
+type r1csTerm struct {
+	wire  int
+	coeff fr.Element
+}
+
+// r1csConstraint is a constraint L * R = O
+type r1csConstraint struct {
+	L, R, O []r1csTerm
+}
+
+// NewPhase2 derives the initial parameters of the circuit from the phase 1 accumulator, with δ = 1. The Lagrange
+// basis at τ is computed from the powers of τ, so nobody learns the secrets of phase 1
+func NewPhase2(phase1 *Phase1, r1cs frontend.CompiledConstraintSystem, maxBids int) (*Phase2, error) {
+	constraints, err := readConstraints(r1cs)
+	if err != nil {
+		return nil, err
+	}
+	nbInternal, nbSecret, nbPublic := r1cs.GetNbVariables()
+	nbWires := nbInternal + nbSecret + nbPublic
+	domain := fft.NewDomain(uint64(r1cs.GetNbConstraints()), 1, true)
+	n := int(domain.Cardinality)
+	if n > len(phase1.TauG2) {
+		return nil, fmt.Errorf("phase 1 of power %v is too small for %v constraints, power %v is needed",
+			phase1.Power, r1cs.GetNbConstraints(), powerFor(r1cs.GetNbConstraints()))
+	}
+
+	tauL1 := lagrangeG1(phase1.TauG1[:n], domain)
+	alphaTauL1 := lagrangeG1(phase1.AlphaTauG1[:n], domain)
+	betaTauL1 := lagrangeG1(phase1.BetaTauG1[:n], domain)
+	tauL2 := lagrangeG2(phase1.TauG2[:n], domain)
+
+	// A(τ), B(τ) and βA(τ) + αB(τ) + C(τ) of every wire
+	a := make([]bls12381.G1Jac, nbWires)
+	b1 := make([]bls12381.G1Jac, nbWires)
+	b2 := make([]bls12381.G2Jac, nbWires)
+	k := make([]bls12381.G1Jac, nbWires)
+	for i, c := range constraints {
+		for _, t := range c.L {
+			addTermG1(&a[t.wire], &tauL1[i], &t.coeff)
+			addTermG1(&k[t.wire], &betaTauL1[i], &t.coeff)
+		}
+		for _, t := range c.R {
+			addTermG1(&b1[t.wire], &tauL1[i], &t.coeff)
+			addTermG2(&b2[t.wire], &tauL2[i], &t.coeff)
+			addTermG1(&k[t.wire], &alphaTauL1[i], &t.coeff)
+		}
+		for _, t := range c.O {
+			addTermG1(&k[t.wire], &tauL1[i], &t.coeff)
+		}
+	}
+
+	_, _, g1, g2 := bls12381.Generators()
+	p := &Phase2{
+		MaxBids:       uint64(maxBids),
+		NbConstraints: uint64(r1cs.GetNbConstraints()),
+		CircuitHash:   hashConstraints(nbInternal, nbSecret, nbPublic, constraints),
+		AlphaG1:       phase1.AlphaTauG1[0],
+		BetaG1:        phase1.BetaTauG1[0],
+		BetaG2:        phase1.BetaG2,
+		A:             make([]bls12381.G1Affine, nbWires),
+		B1:            make([]bls12381.G1Affine, nbWires),
+		B2:            make([]bls12381.G2Affine, nbWires),
+		DeltaG1:       g1,
+		DeltaG2:       g2,
+		Z:             make([]bls12381.G1Affine, n),
+	}
+	kAffine := make([]bls12381.G1Affine, nbWires)
+	bls12381.BatchJacobianToAffineG1(a, p.A)
+	bls12381.BatchJacobianToAffineG1(b1, p.B1)
+	bls12381.BatchJacobianToAffineG1(k, kAffine)
+	for i := range b2 {
+		p.B2[i].FromJacobian(&b2[i])
+	}
+	p.VkK = kAffine[:nbPublic]
+	p.K = kAffine[nbPublic:]
+
+	// Z(τ)τ^i = τ^(n+i) - τ^i
+	for i := range p.Z {
+		var z bls12381.G1Jac
+		z.FromAffine(&phase1.TauG1[n+i])
+		var tau bls12381.G1Jac
+		tau.FromAffine(&phase1.TauG1[i])
+		z.SubAssign(&tau)
+		p.Z[i].FromJacobian(&z)
+	}
+	return p, nil
+}
+
+// Contribute multiplies δ by a fresh random secret, divides K and Z by it and appends the proof of knowledge
+func (p *Phase2) Contribute() error {
+	var delta, deltaInv fr.Element
+	if _, err := delta.SetRandom(); err != nil {
+		return err
+	}
+	deltaInv.Inverse(&delta)
+	challenge, err := p.challenge()
+	if err != nil {
+		return err
+	}
+
+	var b big.Int
+	p.DeltaG1.ScalarMultiplication(&p.DeltaG1, delta.ToBigIntRegular(&b))
+	p.DeltaG2.ScalarMultiplication(&p.DeltaG2, &b)
+	scaleG1(p.K, constants(&deltaInv, len(p.K)))
+	scaleG1(p.Z, constants(&deltaInv, len(p.Z)))
+
+	c := phase2Contribution{DeltaG1: p.DeltaG1}
+	if c.Proof, err = proveContribution(&delta, challenge); err != nil {
+		return err
+	}
+	p.Contributions = append(p.Contributions, c)
+	return nil
+}
+
+// Verify checks that the parameters are the initial ones updated by the chain of contributions
+func (p *Phase2) Verify(initial *Phase2) error {
+	fixed, err := hashEncoded(p.encodeFixed)
+	if err != nil {
+		return err
+	}
+	initialFixed, err := hashEncoded(initial.encodeFixed)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(fixed, initialFixed) {
+		return errors.New("parameters do not derive from the phase 1 accumulator and the circuit")
+	}
+	_, _, g1, g2 := bls12381.Generators()
+
+	last := g1
+	challenge := fixed
+	for i := range p.Contributions {
+		c := &p.Contributions[i]
+		if c.DeltaG1.IsInfinity() {
+			return fmt.Errorf("contribution %v has a zero secret", i)
+		}
+		r, err := c.Proof.verify(challenge)
+		if err != nil {
+			return fmt.Errorf("contribution %v: %v", i, err)
+		}
+		if !sameRatio(&last, &c.DeltaG1, &r, &c.Proof.RX) {
+			return fmt.Errorf("contribution %v: delta is not updated with the proven secret", i)
+		}
+		if challenge, err = c.hash(); err != nil {
+			return err
+		}
+		last = c.DeltaG1
+	}
+
+	if !p.DeltaG1.Equal(&last) {
+		return errors.New("parameters do not match the last contribution")
+	}
+	if !sameRatio(&g1, &p.DeltaG1, &g2, &p.DeltaG2) {
+		return errors.New("delta in G1 and G2 are inconsistent")
+	}
+	// K and Z are the initial ones divided by δ
+	for _, pair := range [][2][]bls12381.G1Affine{{p.K, initial.K}, {p.Z, initial.Z}} {
+		lc, lcInitial, err := combinationsG1(pair[0], pair[1])
+		if err != nil {
+			return err
+		}
+		if !sameRatio(&lc, &lcInitial, &g2, &p.DeltaG2) {
+			return errors.New("parameters are not divided by delta")
+		}
+	}
+	return nil
+}
+
+// Keys returns the Groth16 keys of the parameters. gnark does not export the types of its keys, their fields
+// are set by reflection
+func (p *Phase2) Keys() (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	_, _, _, g2 := bls12381.Generators()
+	domain := fft.NewDomain(p.NbConstraints, 1, true)
+	if int(domain.Cardinality) != len(p.Z) {
+		return nil, nil, errors.New("parameters do not match the number of constraints")
+	}
+	z := make([]bls12381.G1Affine, len(p.Z))
+	copy(z, p.Z)
+	bitReverseIndexes(len(z), func(i, j int) { z[i], z[j] = z[j], z[i] })
+
+	pk := groth16.NewProvingKey(ecc.BLS12_381)
+	vk := groth16.NewVerifyingKey(ecc.BLS12_381)
+	pkValue := reflect.ValueOf(pk).Elem()
+	vkValue := reflect.ValueOf(vk).Elem()
+	fields := []struct {
+		value reflect.Value
+		path  []string
+		x     interface{}
+	}{
+		{pkValue, []string{"Domain"}, *domain},
+		{pkValue, []string{"G1", "Alpha"}, p.AlphaG1},
+		{pkValue, []string{"G1", "Beta"}, p.BetaG1},
+		{pkValue, []string{"G1", "Delta"}, p.DeltaG1},
+		{pkValue, []string{"G1", "A"}, p.A},
+		{pkValue, []string{"G1", "B"}, p.B1},
+		{pkValue, []string{"G1", "Z"}, z},
+		{pkValue, []string{"G1", "K"}, p.K},
+		{pkValue, []string{"G2", "Beta"}, p.BetaG2},
+		{pkValue, []string{"G2", "Delta"}, p.DeltaG2},
+		{pkValue, []string{"G2", "B"}, p.B2},
+		{vkValue, []string{"G1", "Alpha"}, p.AlphaG1},
+		{vkValue, []string{"G1", "Beta"}, p.BetaG1},
+		{vkValue, []string{"G1", "Delta"}, p.DeltaG1},
+		{vkValue, []string{"G1", "K"}, p.VkK},
+		{vkValue, []string{"G2", "Beta"}, p.BetaG2},
+		{vkValue, []string{"G2", "Delta"}, p.DeltaG2},
+		{vkValue, []string{"G2", "Gamma"}, g2},
+	}
+	for _, f := range fields {
+		v := f.value
+		for _, name := range f.path {
+			v = v.FieldByName(name)
+		}
+		x := reflect.ValueOf(f.x)
+		if !v.IsValid() || !v.CanSet() || v.Type() != x.Type() {
+			return nil, nil, fmt.Errorf("unsupported key layout at %v", f.path)
+		}
+		v.Set(x)
+	}
+	// the verifier uses e(alpha, beta) and the negations of gamma and delta, gnark computes them when
+	// the key is read
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		return nil, nil, err
+	}
+	vk = groth16.NewVerifyingKey(ecc.BLS12_381)
+	if _, err := vk.ReadFrom(&buf); err != nil {
+		return nil, nil, err
+	}
+	return pk, vk, nil
+}
+
+// readConstraints reads the constraints of a compiled circuit. gnark does not export the types of its
+// constraint systems, the coefficients of the terms are read with the AddTerm method of the R1CS
+func readConstraints(r1cs frontend.CompiledConstraintSystem) ([]r1csConstraint, error) {
+	value := reflect.ValueOf(r1cs)
+	addTerm := value.MethodByName("AddTerm")
+	if value.Kind() != reflect.Ptr || !addTerm.IsValid() {
+		return nil, errors.New("unsupported constraint system")
+	}
+	constraints := value.Elem().FieldByName("Constraints")
+	if !constraints.IsValid() || constraints.Kind() != reflect.Slice {
+		return nil, errors.New("unsupported constraint system")
+	}
+
+	var one fr.Element
+	one.SetOne()
+	readTerms := func(expression reflect.Value) []r1csTerm {
+		terms := make([]r1csTerm, expression.Len())
+		for i := range terms {
+			term := expression.Index(i)
+			terms[i].wire = int(term.MethodByName("VariableID").Call(nil)[0].Int())
+			// coeff = 0 + 1 * coefficient of the term
+			addTerm.Call([]reflect.Value{reflect.ValueOf(&terms[i].coeff), term, reflect.ValueOf(one)})
+		}
+		return terms
+	}
+	result := make([]r1csConstraint, constraints.Len())
+	for i := range result {
+		c := constraints.Index(i)
+		result[i] = r1csConstraint{
+			L: readTerms(c.FieldByName("L")),
+			R: readTerms(c.FieldByName("R")),
+			O: readTerms(c.FieldByName("O")),
+		}
+	}
+	return result, nil
+}
+
+// circuitHash returns the hash of a compiled circuit, see hashConstraints
+func circuitHash(r1cs frontend.CompiledConstraintSystem) ([sha256.Size]byte, error) {
+	constraints, err := readConstraints(r1cs)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	nbInternal, nbSecret, nbPublic := r1cs.GetNbVariables()
+	return hashConstraints(nbInternal, nbSecret, nbPublic, constraints), nil
+}
+
+// hashConstraints returns the SHA-256 of the number of wires and of the constraints of a circuit. gnark orders
+// the terms of a linear expression and the table of coefficients differently at every compilation, so the
+// terms are hashed sorted by wire with the value of their coefficient. Two compilations of a circuit have the
+// same hash, which is not the fingerprint of the circuit file
+func hashConstraints(nbInternal, nbSecret, nbPublic int, constraints []r1csConstraint) [sha256.Size]byte {
+	hash := sha256.New()
+	writeUint64 := func(x int) {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(x))
+		hash.Write(b[:])
+	}
+	writeUint64(nbPublic)
+	writeUint64(nbSecret)
+	writeUint64(nbInternal)
+	writeUint64(len(constraints))
+	for _, c := range constraints {
+		for _, expression := range [][]r1csTerm{c.L, c.R, c.O} {
+			terms := append([]r1csTerm{}, expression...)
+			sort.Slice(terms, func(i, j int) bool { return terms[i].wire < terms[j].wire })
+			writeUint64(len(terms))
+			for i := range terms {
+				writeUint64(terms[i].wire)
+				coeff := terms[i].coeff.Bytes()
+				hash.Write(coeff[:])
+			}
+		}
+	}
+	var result [sha256.Size]byte
+	copy(result[:], hash.Sum(nil))
+	return result
+}
+
+// addTermG1 adds coeff * point to acc
+func addTermG1(acc *bls12381.G1Jac, point *bls12381.G1Affine, coeff *fr.Element) {
+	if coeff.IsZero() {
+		return
+	}
+	var t bls12381.G1Affine
+	if one := fr.One(); coeff.Equal(&one) {
+		t = *point
+	} else {
+		t.ScalarMultiplication(point, coeff.ToBigIntRegular(new(big.Int)))
+	}
+	acc.AddMixed(&t)
+}
+
+// addTermG2 adds coeff * point to acc
+func addTermG2(acc *bls12381.G2Jac, point *bls12381.G2Affine, coeff *fr.Element) {
+	if coeff.IsZero() {
+		return
+	}
+	var t bls12381.G2Affine
+	if one := fr.One(); coeff.Equal(&one) {
+		t = *point
+	} else {
+		t.ScalarMultiplication(point, coeff.ToBigIntRegular(new(big.Int)))
+	}
+	acc.AddMixed(&t)
+}
+
+// constants returns n copies of x
+func constants(x *fr.Element, n int) []fr.Element {
+	result := make([]fr.Element, n)
+	for i := range result {
+		result[i] = *x
+	}
+	return result
+}
+
+// powerFor returns the smallest phase 1 power for a number of constraints
+func powerFor(nbConstraints int) int {
+	domain := fft.NewDomain(uint64(nbConstraints), 1, true)
+	power := 0
+	for 1<<power < domain.Cardinality {
+		power++
+	}
+	return power
+}
+
+// challenge returns the challenge of the next contribution, the first one is the hash of the fixed part
+func (p *Phase2) challenge() ([]byte, error) {
+	if len(p.Contributions) == 0 {
+		return hashEncoded(p.encodeFixed)
+	}
+	return p.Contributions[len(p.Contributions)-1].hash()
+}
+
+func (c *phase2Contribution) hash() ([]byte, error) {
+	return hashEncoded(func(enc *bls12381.Encoder) error {
+		if err := enc.Encode(&c.DeltaG1); err != nil {
+			return err
+		}
+		return c.Proof.encode(enc)
+	})
+}
+
+// encodeFixed encodes the part of the parameters that the contributions do not change, the circuit hash is
+// encoded as four big-endian words
+func (p *Phase2) encodeFixed(enc *bls12381.Encoder) error {
+	fixed := []interface{}{p.MaxBids, p.NbConstraints}
+	for i := 0; i < sha256.Size; i += 8 {
+		fixed = append(fixed, binary.BigEndian.Uint64(p.CircuitHash[i:]))
+	}
+	fixed = append(fixed, &p.AlphaG1, &p.BetaG1, &p.BetaG2, p.A, p.B1, p.B2, p.VkK)
+	for _, v := range fixed {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTo implements io.WriterTo
+func (p *Phase2) WriteTo(w io.Writer) (int64, error) {
+	enc := bls12381.NewEncoder(w)
+	if err := p.encodeFixed(enc); err != nil {
+		return enc.BytesWritten(), err
+	}
+	for _, v := range []interface{}{&p.DeltaG1, &p.DeltaG2, p.K, p.Z, uint64(len(p.Contributions))} {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	for i := range p.Contributions {
+		if err := enc.Encode(&p.Contributions[i].DeltaG1); err != nil {
+			return enc.BytesWritten(), err
+		}
+		if err := p.Contributions[i].Proof.encode(enc); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom implements io.ReaderFrom
+func (p *Phase2) ReadFrom(r io.Reader) (int64, error) {
+	dec := bls12381.NewDecoder(r)
+	var nbContributions uint64
+	var hashWords [sha256.Size / 8]uint64
+	for _, v := range []interface{}{&p.MaxBids, &p.NbConstraints, &hashWords[0], &hashWords[1], &hashWords[2], &hashWords[3],
+		&p.AlphaG1, &p.BetaG1, &p.BetaG2, &p.A, &p.B1, &p.B2, &p.VkK, &p.DeltaG1, &p.DeltaG2, &p.K, &p.Z, &nbContributions} {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	for i, word := range hashWords {
+		binary.BigEndian.PutUint64(p.CircuitHash[8*i:], word)
+	}
+	if nbContributions > 1<<16 {
+		return dec.BytesRead(), errors.New("invalid parameters header")
+	}
+	p.Contributions = make([]phase2Contribution, nbContributions)
+	for i := range p.Contributions {
+		if err := dec.Decode(&p.Contributions[i].DeltaG1); err != nil {
+			return dec.BytesRead(), err
+		}
+		if err := p.Contributions[i].Proof.decode(dec); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	return dec.BytesRead(), nil
+}
+
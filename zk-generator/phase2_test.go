@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// testCircuit is x^3 + x + c = y, with c = 5 unless set
+type testCircuit struct {
+	c int
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *testCircuit) Define(curveID ecc.ID, cs *frontend.ConstraintSystem) error {
+	c := circuit.c
+	if c == 0 {
+		c = 5
+	}
+	x3 := cs.Mul(circuit.X, circuit.X, circuit.X)
+	cs.AssertIsEqual(circuit.Y, cs.Add(x3, circuit.X, c))
+	return nil
+}
+
+func compileTestCircuit(t *testing.T, c int) frontend.CompiledConstraintSystem {
+	r1cs, err := frontend.Compile(ecc.BLS12_381, backend.GROTH16, &testCircuit{c: c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r1cs
+}
+
+// testPhase2 returns the initial phase 2 parameters of the test circuit and the parameters after two
+// contributions
+func testPhase2(t *testing.T, r1cs frontend.CompiledConstraintSystem) (*Phase2, *Phase2) {
+	phase1 := NewPhase1(testPower)
+	if err := phase1.Contribute(); err != nil {
+		t.Fatal(err)
+	}
+	initial, err := NewPhase2(phase1, r1cs, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := initial.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	phase2 := new(Phase2)
+	if _, err := phase2.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := phase2.Contribute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return initial, phase2
+}
+
+func TestPhase2Keys(t *testing.T) {
+	r1cs := compileTestCircuit(t, 0)
+	initial, phase2 := testPhase2(t, r1cs)
+	if err := phase2.Verify(initial); err != nil {
+		t.Fatalf("valid contributions rejected: %v", err)
+	}
+
+	pk, vk, err := phase2.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var witness testCircuit
+	witness.X.Assign(3)
+	witness.Y.Assign(35)
+	proof, err := groth16.Prove(r1cs, pk, &witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var public testCircuit
+	public.Y.Assign(35)
+	if err := groth16.Verify(proof, vk, &public); err != nil {
+		t.Fatalf("proof with the keys of the ceremony rejected: %v", err)
+	}
+	public.Y = frontend.Variable{}
+	public.Y.Assign(36)
+	if err := groth16.Verify(proof, vk, &public); err == nil {
+		t.Error("proof verified with another public input")
+	}
+}
+
+func TestPhase2Tampered(t *testing.T) {
+	initial, phase2 := testPhase2(t, compileTestCircuit(t, 0))
+
+	// K not divided by the secret of the contributions
+	tampered := *phase2
+	tampered.K = append(tampered.K[:0:0], phase2.K...)
+	tampered.K[0] = initial.K[0]
+	if err := tampered.Verify(initial); err == nil {
+		t.Error("parameters with K not divided by delta verified")
+	}
+	// delta that is not the one of the last contribution
+	tampered = *phase2
+	tampered.DeltaG1, tampered.DeltaG2 = initial.DeltaG1, initial.DeltaG2
+	if err := tampered.Verify(initial); err == nil {
+		t.Error("parameters with another delta verified")
+	}
+	// a contribution whose proof is for another secret
+	tampered = *phase2
+	tampered.Contributions = append(tampered.Contributions[:0:0], phase2.Contributions...)
+	tampered.Contributions[1].Proof = tampered.Contributions[0].Proof
+	if err := tampered.Verify(initial); err == nil {
+		t.Error("contribution with the proof of another contribution verified")
+	}
+}
+
+func TestPhase2CircuitBinding(t *testing.T) {
+	r1cs := compileTestCircuit(t, 0)
+	other := compileTestCircuit(t, 6)
+	hash, err := circuitHash(r1cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherHash, err := circuitHash(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == otherHash {
+		t.Fatal("two circuits have the same hash")
+	}
+
+	initial, phase2 := testPhase2(t, r1cs)
+	if phase2.CircuitHash != hash {
+		t.Error("phase 2 is not bound to the hash of its circuit")
+	}
+	phase1 := NewPhase1(testPower)
+	if err := phase1.Contribute(); err != nil {
+		t.Fatal(err)
+	}
+	otherInitial, err := NewPhase2(phase1, other, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := phase2.Verify(otherInitial); err == nil {
+		t.Error("parameters verified against another circuit")
+	}
+	// the hash is part of the challenge of the first contribution
+	tampered := *phase2
+	tampered.CircuitHash = otherHash
+	initial.CircuitHash = otherHash
+	if err := tampered.Verify(initial); err == nil {
+		t.Error("contributions verified for another circuit hash")
+	}
+}
+
+// gnark orders the terms of the constraints differently at every compilation, the hash does not depend on it
+func TestCircuitHash(t *testing.T) {
+	hash, err := circuitHash(compileAuctionCircuit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		again, err := circuitHash(compileAuctionCircuit(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again != hash {
+			t.Fatal("two compilations of the auction circuit have different hashes")
+		}
+	}
+	other, err := circuitHash(compileAuctionCircuit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == hash {
+		t.Error("the circuits for 1 and 2 bids have the same hash")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ckiere/test-network/auction-circuit/circuit"
+	"io/ioutil"
+	"math/big"
+)
+
+// witnessFile is the JSON encoding of a witness. Field elements are decimal strings, the secret fields are
+// only needed to prove
+type witnessFile struct {
+	Values       []string `json:"values,omitempty"`
+	Rs           []string `json:"rs,omitempty"`
+	ComsX        []string `json:"comsX"`
+	ComsY        []string `json:"comsY"`
+	// commitments left out of the comparison, none if absent
+	Excluded     []bool   `json:"excluded,omitempty"`
+	WinningValue string   `json:"winningValue,omitempty"`
+	WinningR     string   `json:"winningR,omitempty"`
+	WinningComX  string   `json:"winningComX"`
+	WinningComY  string   `json:"winningComY"`
+	WinningIndex int      `json:"winningIndex"`
+}
+
+func readWitnessFile(path string) (*witnessFile, error) {
+	witnessBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var witness witnessFile
+	err = json.Unmarshal(witnessBytes, &witness)
+	if err != nil {
+		return nil, fmt.Errorf("invalid witness file: %v", err)
+	}
+	return &witness, nil
+}
+
+// publicAssignment returns the public part of the witness for a circuit with maxBids bids
+func (w *witnessFile) publicAssignment(maxBids int) (*circuit.AuctionCircuit, error) {
+	if len(w.ComsX) != maxBids || len(w.ComsY) != maxBids {
+		return nil, fmt.Errorf("witness has %v commitments, circuit has %v", len(w.ComsX), maxBids)
+	}
+	if w.WinningIndex < 0 || w.WinningIndex >= maxBids {
+		return nil, fmt.Errorf("invalid winning index %v", w.WinningIndex)
+	}
+	if w.Excluded != nil && len(w.Excluded) != maxBids {
+		return nil, fmt.Errorf("witness has %v exclusion flags, circuit has %v bids", len(w.Excluded), maxBids)
+	}
+	assignment := circuit.NewAuctionCircuit(maxBids)
+	for i := 0; i < maxBids; i++ {
+		if err := assignDecimal(&assignment.ComsX[i], w.ComsX[i]); err != nil {
+			return nil, err
+		}
+		if err := assignDecimal(&assignment.ComsY[i], w.ComsY[i]); err != nil {
+			return nil, err
+		}
+		if w.Excluded != nil && w.Excluded[i] {
+			assignment.Excluded[i].Assign(1)
+		} else {
+			assignment.Excluded[i].Assign(0)
+		}
+	}
+	if err := assignDecimal(&assignment.WinningComX, w.WinningComX); err != nil {
+		return nil, err
+	}
+	if err := assignDecimal(&assignment.WinningComY, w.WinningComY); err != nil {
+		return nil, err
+	}
+	assignment.WinningIndex.Assign(w.WinningIndex)
+	return assignment, nil
+}
+
+// fullAssignment returns the full witness for a circuit with maxBids bids, the selector of the winning
+// bid is derived from the winning index
+func (w *witnessFile) fullAssignment(maxBids int) (*circuit.AuctionCircuit, error) {
+	assignment, err := w.publicAssignment(maxBids)
+	if err != nil {
+		return nil, err
+	}
+	if len(w.Values) != maxBids || len(w.Rs) != maxBids {
+		return nil, fmt.Errorf("witness has %v bids, circuit has %v", len(w.Values), maxBids)
+	}
+	for i := 0; i < maxBids; i++ {
+		if err := assignDecimal(&assignment.Values[i], w.Values[i]); err != nil {
+			return nil, err
+		}
+		if err := assignDecimal(&assignment.Rs[i], w.Rs[i]); err != nil {
+			return nil, err
+		}
+		if i == w.WinningIndex {
+			assignment.WinningSelector[i].Assign(1)
+		} else {
+			assignment.WinningSelector[i].Assign(0)
+		}
+	}
+	if err := assignDecimal(&assignment.WinningValue, w.WinningValue); err != nil {
+		return nil, err
+	}
+	if err := assignDecimal(&assignment.WinningR, w.WinningR); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// assignDecimal assigns a decimal string to a variable
+func assignDecimal(v interface{ Assign(interface{}) }, value string) error {
+	i, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return fmt.Errorf("invalid field element %q", value)
+	}
+	v.Assign(i)
+	return nil
+}